@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultAccessibilityLabel es la etiqueta con la que la vista de triage de
+// accesibilidad filtra los issues; ACCESSIBILITY_LABEL la cambia.
+const defaultAccessibilityLabel = "a11y"
+
+var accessibilityLabel = envOrDefault("ACCESSIBILITY_LABEL", defaultAccessibilityLabel)
+
+// wcagCriterionRegex acepta el número de un criterio de conformidad de WCAG
+// 2.x, por ejemplo 1.4.3 o 2.4.11.
+var wcagCriterionRegex = regexp.MustCompile(`^[1-4]\.\d{1,2}\.\d{1,2}$`)
+
+// accessibilitySeverities son las severidades que entiende triage, de mayor
+// a menor impacto.
+var accessibilitySeverities = []string{"bloqueante", "alta", "media", "baja"}
+
+// accessibilityInfo es la sección opcional de accesibilidad del formulario.
+// Cualquier dato presente marca el envío como un problema de accesibilidad.
+type accessibilityInfo struct {
+	Criterion     string `json:"criterion,omitempty"`
+	AssistiveTech string `json:"assistiveTech,omitempty"`
+	Severity      string `json:"severity,omitempty"`
+}
+
+// sanitizeAccessibilityInfo valida el criterio y la severidad, que tienen
+// valores conocidos, y limpia la tecnología de apoyo como el resto de los
+// textos libres del cliente. Una sección vacía equivale a no enviarla.
+func sanitizeAccessibilityInfo(info *accessibilityInfo) (*accessibilityInfo, error) {
+	if info == nil {
+		return nil, nil
+	}
+	clean := &accessibilityInfo{AssistiveTech: sanitizeClientText(info.AssistiveTech)}
+	if criterion := strings.TrimPrefix(strings.TrimSpace(info.Criterion), "WCAG "); criterion != "" {
+		if !wcagCriterionRegex.MatchString(criterion) {
+			return nil, fmt.Errorf("accessibility.criterion debe ser un criterio WCAG como 1.4.3")
+		}
+		clean.Criterion = criterion
+	}
+	if severity := strings.ToLower(strings.TrimSpace(info.Severity)); severity != "" {
+		known := false
+		for _, candidate := range accessibilitySeverities {
+			known = known || severity == candidate
+		}
+		if !known {
+			return nil, fmt.Errorf("accessibility.severity debe ser %s", strings.Join(accessibilitySeverities, ", "))
+		}
+		clean.Severity = severity
+	}
+	if clean.Criterion == "" && clean.AssistiveTech == "" && clean.Severity == "" {
+		return nil, nil
+	}
+	return clean, nil
+}
+
+// renderAccessibilityInfo arma el bloque estructurado que se agrega al
+// issue. A diferencia del entorno del cliente va a la vista: es lo primero
+// que triage de accesibilidad necesita leer.
+func renderAccessibilityInfo(info *accessibilityInfo) string {
+	if info == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("### Accesibilidad\n")
+	rows := []struct{ label, value string }{
+		{"Criterio WCAG", info.Criterion},
+		{"Tecnología de apoyo", info.AssistiveTech},
+		{"Severidad", info.Severity},
+	}
+	for _, row := range rows {
+		if row.value != "" {
+			fmt.Fprintf(&b, "- **%s:** %s\n", row.label, row.value)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// issueLabels son las etiquetas de la plantilla más las que agrega el envío.
+// Copiamos el slice para no modificar el catálogo compartido.
+func (p *preparedSubmission) issueLabels() []string {
+	labels := append([]string{}, p.Template.Labels...)
+	if p.Accessibility != nil && accessibilityLabel != "" {
+		labels = append(labels, accessibilityLabel)
+	}
+	return labels
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizeAccessibilityInfo(t *testing.T) {
+	clean, err := sanitizeAccessibilityInfo(&accessibilityInfo{Criterion: " WCAG 1.4.3 ", AssistiveTech: "NVDA <2024> | Firefox", Severity: "Alta"})
+	if err != nil {
+		t.Fatalf("sanitizeAccessibilityInfo: %v", err)
+	}
+	if clean.Criterion != "1.4.3" || clean.Severity != "alta" || clean.AssistiveTech != `NVDA &lt;2024&gt; \| Firefox` {
+		t.Fatalf("sección inesperada: %+v", clean)
+	}
+	if _, err := sanitizeAccessibilityInfo(&accessibilityInfo{Criterion: "contraste"}); err == nil {
+		t.Fatal("un criterio que no es WCAG debe rechazarse")
+	}
+	if _, err := sanitizeAccessibilityInfo(&accessibilityInfo{Severity: "urgente"}); err == nil {
+		t.Fatal("una severidad desconocida debe rechazarse")
+	}
+	if clean, err := sanitizeAccessibilityInfo(&accessibilityInfo{AssistiveTech: "  "}); err != nil || clean != nil {
+		t.Fatalf("una sección vacía equivale a no enviarla, got %+v / %v", clean, err)
+	}
+}
+
+func TestSubmitPreparedAgregaBloqueYEtiquetaDeAccesibilidad(t *testing.T) {
+	var gotLabels, projectLabels []string
+	var gotBody string
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(_ context.Context, _ string, labels []string, body string) (*githubIssueResponse, error) {
+			gotLabels, gotBody = labels, body
+			return &githubIssueResponse{Number: 3, HTMLURL: "https://example.com/issues/3", NodeID: "node-3"}, nil
+		}
+		deps.ProjectAdder = func(_ context.Context, _, _ string, labels []string) error {
+			projectLabels = labels
+			return nil
+		}
+	})
+
+	req := issueRequest{
+		TemplateID:    "bug",
+		Title:         "El lector de pantalla no anuncia el total",
+		Fields:        map[string]string{"summary": "s", "steps": "1", "expected": "a", "actual": "b"},
+		Consent:       validConsent(),
+		Accessibility: &accessibilityInfo{Criterion: "4.1.3", AssistiveTech: "VoiceOver", Severity: "bloqueante"},
+	}
+	prepared, subErr := prepareSubmission(context.Background(), req)
+	if subErr != nil {
+		t.Fatalf("prepareSubmission: %v", subErr)
+	}
+	if _, subErr := submitPrepared(context.Background(), prepared); subErr != nil {
+		t.Fatalf("submitPrepared: %v", subErr)
+	}
+	if !strings.Contains(gotBody, "### Accesibilidad\n- **Criterio WCAG:** 4.1.3\n- **Tecnología de apoyo:** VoiceOver\n- **Severidad:** bloqueante") {
+		t.Fatalf("falta el bloque de accesibilidad:\n%s", gotBody)
+	}
+	want := strings.Join(append(append([]string{}, templates["bug"].Labels...), accessibilityLabel), ",")
+	if strings.Join(gotLabels, ",") != want || strings.Join(projectLabels, ",") != want {
+		t.Fatalf("etiquetas = %v / %v; se esperaba %s", gotLabels, projectLabels, want)
+	}
+	if strings.Contains(strings.Join(templates["bug"].Labels, ","), accessibilityLabel) {
+		t.Fatal("no debe modificarse la plantilla compartida")
+	}
+
+	req.Accessibility.Severity = "urgente"
+	if _, subErr := prepareSubmission(context.Background(), req); subErr == nil || subErr.Code != "invalid_request" {
+		t.Fatalf("se esperaba invalid_request, got %+v", subErr)
+	}
+}
+
+func TestIssueRequestFromFormLeeAccesibilidad(t *testing.T) {
+	form := url.Values{"templateId": {"bug"}, "title": {"x"}, "a11yCriterion": {"1.1.1"}, "a11ySeverity": {"media"}}
+	req := issueRequestFromForm(form, time.Now())
+	if req.Accessibility == nil || req.Accessibility.Criterion != "1.1.1" || req.Accessibility.Severity != "media" {
+		t.Fatalf("sección inesperada: %+v", req.Accessibility)
+	}
+	if _, ok := req.Fields["a11yCriterion"]; ok {
+		t.Fatal("los campos de accesibilidad no son campos de la plantilla")
+	}
+	if req := issueRequestFromForm(url.Values{"templateId": {"bug"}}, time.Now()); req.Accessibility != nil {
+		t.Fatal("sin campos de accesibilidad no hay sección")
+	}
+}
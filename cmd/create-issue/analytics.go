@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const bigQueryInsertScope = "https://www.googleapis.com/auth/bigquery.insertdata"
+
+// analyticsBatchSize y analyticsFlushInterval acotan cuánto espera un evento
+// antes de llegar a BigQuery; analyticsBufferSize, cuántos pueden esperar.
+const (
+	analyticsBatchSize     = 50
+	analyticsFlushInterval = 5 * time.Second
+	analyticsBufferSize    = 1000
+)
+
+// countryHeaders son los encabezados con el país del cliente que ponen los
+// proxies más comunes delante del servicio (Cloudflare, App Engine y un
+// balanceador de Google con encabezado personalizado).
+var countryHeaders = []string{"CF-IPCountry", "X-AppEngine-Country", "X-Client-Geo-Country"}
+
+// submissionEvent es un envío del formulario sin datos personales: ni IP, ni
+// origen exacto, ni contenido. Sirve para el embudo por plantilla y país.
+type submissionEvent struct {
+	Timestamp     time.Time `json:"timestamp"`
+	TemplateID    string    `json:"template_id"`
+	Channel       string    `json:"channel"`
+	Outcome       string    `json:"outcome"`
+	Status        int       `json:"status"`
+	LatencyMillis int64     `json:"latency_ms"`
+	Country       string    `json:"country,omitempty"`
+
+	// insertID deduplica reintentos en BigQuery; no se guarda en la tabla.
+	insertID string
+}
+
+// analyticsExporter recibe los eventos de envío. Export no debe bloquear la
+// respuesta al usuario.
+type analyticsExporter interface {
+	Export(event submissionEvent)
+}
+
+// submissionChannel clasifica la ruta como canal del embudo, o "" si la
+// petición no es un envío (pasos de sesión, sonda, enlaces cortos).
+func submissionChannel(method, path string) string {
+	if method != http.MethodPost {
+		return ""
+	}
+	switch {
+	case path == formPostPath:
+		return "form"
+	case strings.HasPrefix(path, sessionPrefix+"/") && strings.HasSuffix(path, "/submit"):
+		return "session"
+	case path == sessionPrefix || strings.HasPrefix(path, sessionPrefix+"/") || path == probePath:
+		return ""
+	default:
+		return "json"
+	}
+}
+
+// requestCountry devuelve el código ISO de dos letras del país, si algún
+// proxy lo informó.
+func requestCountry(r *http.Request) string {
+	for _, header := range countryHeaders {
+		value := strings.ToUpper(strings.TrimSpace(r.Header.Get(header)))
+		if len(value) == 2 && value[0] >= 'A' && value[0] <= 'Z' && value[1] >= 'A' && value[1] <= 'Z' {
+			return value
+		}
+	}
+	return ""
+}
+
+// exportSubmissionEvent arma el evento al cerrar la petición. El resultado
+// es el código de error lógico, "encolado" o "ok".
+func exportSubmissionEvent(exporter analyticsExporter, logger *requestLogger, r *http.Request) {
+	if exporter == nil || logger == nil {
+		return
+	}
+	channel := submissionChannel(r.Method, r.URL.Path)
+	if channel == "" {
+		return
+	}
+	outcome := "ok"
+	switch {
+	case logger.errorCode != "":
+		outcome = logger.errorCode
+	case logger.status == http.StatusAccepted:
+		outcome = "encolado"
+	case logger.status >= http.StatusBadRequest:
+		outcome = "error"
+	}
+	exporter.Export(submissionEvent{
+		Timestamp:     logger.startedAt,
+		TemplateID:    logger.templateID,
+		Channel:       channel,
+		Outcome:       outcome,
+		Status:        logger.status,
+		LatencyMillis: time.Since(logger.startedAt).Milliseconds(),
+		Country:       requestCountry(r),
+		insertID:      logger.requestID,
+	})
+}
+
+// bigQueryExporter acumula eventos y los manda en lotes con la API de
+// streaming insert (tabledata.insertAll). Si el búfer se llena se descartan
+// eventos nuevos: la analítica nunca debe frenar un envío.
+type bigQueryExporter struct {
+	endpoint string
+	client   *http.Client
+	tokens   *googleTokenCache
+	events   chan submissionEvent
+}
+
+// newBigQueryExporterFromEnv lee ANALYTICS_BQ_TABLE ("proyecto.dataset.tabla").
+// Sin la variable la exportación queda desactivada.
+func newBigQueryExporterFromEnv(getenv func(string) string) (*bigQueryExporter, error) {
+	raw := strings.TrimSpace(getenv("ANALYTICS_BQ_TABLE"))
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("ANALYTICS_BQ_TABLE inválido: %q (se espera proyecto.dataset.tabla)", raw)
+	}
+	endpoint := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/datasets/%s/tables/%s/insertAll",
+		url.PathEscape(parts[0]), url.PathEscape(parts[1]), url.PathEscape(parts[2]))
+	return &bigQueryExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		tokens:   &googleTokenCache{scope: bigQueryInsertScope},
+		events:   make(chan submissionEvent, analyticsBufferSize),
+	}, nil
+}
+
+func (b *bigQueryExporter) Export(event submissionEvent) {
+	select {
+	case b.events <- event:
+	default:
+		log.Print("analítica: búfer lleno, se descarta un evento")
+	}
+}
+
+// Run envía lotes hasta que el contexto se cancela y entonces manda lo que
+// quede pendiente.
+func (b *bigQueryExporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(analyticsFlushInterval)
+	defer ticker.Stop()
+	var batch []submissionEvent
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.insert(ctx, batch); err != nil {
+			log.Printf("analítica: no se pudieron enviar %d eventos a BigQuery: %v", len(batch), err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-b.events:
+					batch = append(batch, event)
+				default:
+					shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					flush(shutdownCtx)
+					cancel()
+					return
+				}
+			}
+		case event := <-b.events:
+			batch = append(batch, event)
+			if len(batch) >= analyticsBatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+func (b *bigQueryExporter) insert(ctx context.Context, events []submissionEvent) error {
+	token, err := b.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener token para BigQuery: %w", err)
+	}
+	rows := make([]map[string]any, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, map[string]any{"insertId": event.insertID, "json": event})
+	}
+	body, err := json.Marshal(map[string]any{"skipInvalidRows": true, "rows": rows})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("BigQuery devolvió %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	// insertAll responde 200 aunque rechace filas sueltas.
+	var result struct {
+		InsertErrors []json.RawMessage `json:"insertErrors"`
+	}
+	if json.Unmarshal(respBody, &result) == nil && len(result.InsertErrors) > 0 {
+		return fmt.Errorf("BigQuery rechazó %d filas", len(result.InsertErrors))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryExporter struct{ events []submissionEvent }
+
+func (m *memoryExporter) Export(event submissionEvent) { m.events = append(m.events, event) }
+
+func TestSubmissionChannel(t *testing.T) {
+	cases := map[string]string{
+		"/":                    "json",
+		formPostPath:           "form",
+		"/sessions":            "",
+		"/sessions/abc":        "",
+		"/sessions/abc/submit": "session",
+		probePath:              "",
+	}
+	for path, want := range cases {
+		if got := submissionChannel(http.MethodPost, path); got != want {
+			t.Errorf("submissionChannel(%q) = %q, se esperaba %q", path, got, want)
+		}
+	}
+	if got := submissionChannel(http.MethodOptions, "/"); got != "" {
+		t.Errorf("un preflight no es un envío, llegó %q", got)
+	}
+}
+
+func TestHandleRequestExportaEventoAnonimo(t *testing.T) {
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	exporter := &memoryExporter{}
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Analytics = exporter
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			t.Fatal("sin consentimiento no se debe llamar a GitHub")
+			return nil, nil
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(`{"templateId":"blank","title":"x","fields":{"descripcion":"y"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://allowed.example")
+	req.Header.Set("CF-IPCountry", "mx")
+	handleRequest(httptest.NewRecorder(), req)
+
+	if len(exporter.events) != 1 {
+		t.Fatalf("se esperaba un evento, llegaron %+v", exporter.events)
+	}
+	event := exporter.events[0]
+	if event.TemplateID != "blank" || event.Channel != "json" || event.Outcome != "consent_required" || event.Country != "MX" || event.insertID == "" {
+		t.Fatalf("evento inesperado: %+v", event)
+	}
+}
+
+func TestNewBigQueryExporterFromEnv(t *testing.T) {
+	exporter, err := newBigQueryExporterFromEnv(func(string) string { return "" })
+	if err != nil || exporter != nil {
+		t.Fatalf("sin ANALYTICS_BQ_TABLE la exportación debe quedar apagada: %v, %v", exporter, err)
+	}
+	if _, err := newBigQueryExporterFromEnv(func(string) string { return "proyecto.tabla" }); err == nil {
+		t.Fatal("una tabla sin dataset debe rechazarse")
+	}
+	exporter, err = newBigQueryExporterFromEnv(func(string) string { return "eos-prod.formularios.envios" })
+	if err != nil || !strings.HasSuffix(exporter.endpoint, "/projects/eos-prod/datasets/formularios/tables/envios/insertAll") {
+		t.Fatalf("endpoint inesperado: %v, %v", exporter, err)
+	}
+}
+
+func TestBigQueryExporterEnviaLoPendienteAlDetenerse(t *testing.T) {
+	var rows int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Rows []json.RawMessage `json:"rows"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		rows += len(body.Rows)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	exporter := &bigQueryExporter{
+		endpoint: server.URL,
+		client:   server.Client(),
+		tokens:   &googleTokenCache{token: "t", expiry: time.Now().Add(time.Hour)},
+		events:   make(chan submissionEvent, analyticsBufferSize),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		exporter.Run(ctx)
+	}()
+	for i := 0; i < 3; i++ {
+		exporter.Export(submissionEvent{TemplateID: "blank", insertID: strconv.Itoa(i)})
+	}
+	cancel()
+	<-done
+	if rows != 3 {
+		t.Fatalf("al cancelar deben enviarse los 3 eventos pendientes, llegaron %d", rows)
+	}
+}
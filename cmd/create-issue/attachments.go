@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Límites por defecto de los adjuntos. Con tres imágenes de 1 MiB el trabajo
+// encolado sigue por debajo del máximo de 10 MB de un mensaje de Pub/Sub.
+const (
+	defaultAttachmentMaxFiles = 3
+	defaultAttachmentMaxBytes = 1 << 20
+)
+
+// attachmentFormField es el input type=file del formulario clásico.
+const attachmentFormField = "attachments"
+
+// attachmentPrefix agrupa los archivos subidos en la rama o el bucket.
+const attachmentPrefix = "adjuntos/"
+
+const (
+	defaultAttachmentBranch = "adjuntos"
+	gcsUploadEndpoint       = "https://storage.googleapis.com/upload/storage/v1"
+	gcsPublicEndpoint       = "https://storage.googleapis.com"
+	gcsWriteScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+// attachmentTypes son los formatos aceptados y su extensión. El tipo se
+// detecta por el contenido, no por lo que declara el cliente.
+var attachmentTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// attachmentInput es una imagen tal como llega en la solicitud: Data va en
+// base64 (se acepta también una data URL completa).
+type attachmentInput struct {
+	Name string `json:"name,omitempty"`
+	Data string `json:"data"`
+}
+
+// attachment es un adjunto ya decodificado y validado.
+type attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// objectName usa el hash del contenido: reintentar el mismo envío (cola,
+// cuarentena) no duplica archivos y la URL no revela el nombre original.
+func (a attachment) objectName() string {
+	sum := sha256.Sum256(a.Data)
+	return attachmentPrefix + hex.EncodeToString(sum[:]) + attachmentTypes[a.ContentType]
+}
+
+// attachmentStore sube un archivo y devuelve una URL pública para
+// incrustarlo en el issue.
+type attachmentStore interface {
+	Put(ctx context.Context, a attachment) (string, error)
+}
+
+// attachmentUploader valida y sube los adjuntos de un envío.
+type attachmentUploader struct {
+	store    attachmentStore
+	maxFiles int
+	maxBytes int
+}
+
+// newAttachmentUploaderFromEnv lee ATTACHMENTS_STORE (github o gcs),
+// ATTACHMENTS_MAX_FILES y ATTACHMENTS_MAX_BYTES. Sin almacenamiento devuelve
+// nil y los envíos con adjuntos se rechazan.
+func newAttachmentUploaderFromEnv(getenv func(string) string) (*attachmentUploader, error) {
+	var store attachmentStore
+	switch kind := strings.ToLower(strings.TrimSpace(getenv("ATTACHMENTS_STORE"))); kind {
+	case "", "off", "none":
+		return nil, nil
+	case "github":
+		owner, name := githubRepoOwner, githubRepoName
+		if raw := strings.TrimSpace(getenv("ATTACHMENTS_REPO")); raw != "" {
+			parts := strings.Split(raw, "/")
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return nil, fmt.Errorf("ATTACHMENTS_REPO inválido: %q (usa dueño/nombre)", raw)
+			}
+			owner, name = parts[0], parts[1]
+		}
+		branch := strings.TrimSpace(getenv("ATTACHMENTS_BRANCH"))
+		if branch == "" {
+			branch = defaultAttachmentBranch
+		}
+		store = &githubAttachmentStore{
+			endpoint: "https://api.github.com",
+			owner:    owner,
+			repo:     name,
+			branch:   branch,
+			client:   &http.Client{Timeout: 30 * time.Second, Transport: &githubAuthTransport{base: &outboundLoggingTransport{}}},
+		}
+	case "gcs":
+		bucket := strings.TrimSpace(getenv("ATTACHMENTS_BUCKET"))
+		if bucket == "" {
+			return nil, fmt.Errorf("ATTACHMENTS_STORE=gcs requiere ATTACHMENTS_BUCKET")
+		}
+		store = &gcsAttachmentStore{
+			bucket:   bucket,
+			endpoint: gcsUploadEndpoint,
+			public:   gcsPublicEndpoint,
+			client:   &http.Client{Timeout: 30 * time.Second, Transport: &outboundLoggingTransport{}},
+			tokens:   &googleTokenCache{scope: gcsWriteScope},
+		}
+	default:
+		return nil, fmt.Errorf("ATTACHMENTS_STORE desconocido: %q (usa github o gcs)", kind)
+	}
+
+	uploader := &attachmentUploader{store: store, maxFiles: defaultAttachmentMaxFiles, maxBytes: defaultAttachmentMaxBytes}
+	for key, dst := range map[string]*int{"ATTACHMENTS_MAX_FILES": &uploader.maxFiles, "ATTACHMENTS_MAX_BYTES": &uploader.maxBytes} {
+		if raw := strings.TrimSpace(getenv(key)); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value <= 0 {
+				return nil, fmt.Errorf("%s inválido: %q", key, raw)
+			}
+			*dst = value
+		}
+	}
+	return uploader, nil
+}
+
+// requestBodyLimit es el tamaño máximo de un envío: el JSON de siempre más
+// los adjuntos en base64 si están habilitados.
+func requestBodyLimit() int64 {
+	limit := int64(maxRequestBodyBytes)
+	if uploader := loadServiceDeps().Attachments; uploader != nil {
+		limit += int64(uploader.maxFiles) * int64(base64.StdEncoding.EncodedLen(uploader.maxBytes))
+	}
+	return limit
+}
+
+// decodeAttachments valida cantidad, tamaño y formato antes de tocar GitHub,
+// como el resto de prepareSubmission.
+func decodeAttachments(inputs []attachmentInput) ([]attachment, *submissionError) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	invalid := func(message string, cause error) *submissionError {
+		return &submissionError{Status: http.StatusBadRequest, Code: "invalid_attachment", Message: message, Cause: cause}
+	}
+	uploader := loadServiceDeps().Attachments
+	if uploader == nil {
+		return nil, invalid("Este servicio no acepta adjuntos", nil)
+	}
+	if len(inputs) > uploader.maxFiles {
+		return nil, invalid(fmt.Sprintf("Se admiten hasta %d adjuntos", uploader.maxFiles), nil)
+	}
+
+	attachments := make([]attachment, 0, len(inputs))
+	for i, input := range inputs {
+		raw := strings.TrimSpace(input.Data)
+		if strings.HasPrefix(raw, "data:") {
+			if comma := strings.IndexByte(raw, ','); comma >= 0 {
+				raw = raw[comma+1:]
+			}
+		}
+		data, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return nil, invalid(fmt.Sprintf("El adjunto %d no es base64 válido", i+1), err)
+		}
+		if len(data) == 0 {
+			return nil, invalid(fmt.Sprintf("El adjunto %d está vacío", i+1), nil)
+		}
+		if len(data) > uploader.maxBytes {
+			return nil, invalid(fmt.Sprintf("El adjunto %d supera el máximo de %d bytes", i+1, uploader.maxBytes), nil)
+		}
+		contentType := http.DetectContentType(data)
+		if _, ok := attachmentTypes[contentType]; !ok {
+			return nil, invalid(fmt.Sprintf("El adjunto %d no es una imagen PNG, JPEG, GIF o WebP", i+1), fmt.Errorf("tipo detectado %s", contentType))
+		}
+		name := sanitizeAttachmentName(input.Name)
+		if name == "" {
+			name = fmt.Sprintf("captura-%d", i+1)
+		}
+		attachments = append(attachments, attachment{Name: name, ContentType: contentType, Data: data})
+	}
+	return attachments, nil
+}
+
+// sanitizeAttachmentName deja el nombre apto para el texto alternativo de
+// una imagen en Markdown.
+func sanitizeAttachmentName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '[', ']', '(', ')', '<', '>', '`', '\\', '\n', '\r':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if runes := []rune(name); len(runes) > 80 {
+		name = string(runes[:80])
+	}
+	return strings.TrimSpace(name)
+}
+
+// attachmentsFromMultipart convierte los archivos del formulario clásico al
+// mismo formato que envía el frontend con JSON.
+func attachmentsFromMultipart(files []*multipart.FileHeader) ([]attachmentInput, error) {
+	var inputs []attachmentInput
+	for _, header := range files {
+		if header.Size == 0 {
+			// Un input type=file sin elegir llega como parte vacía.
+			continue
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, attachmentInput{Name: header.Filename, Data: base64.StdEncoding.EncodeToString(data)})
+	}
+	return inputs, nil
+}
+
+// uploadAttachments sube los adjuntos y agrega al cuerpo la sección con las
+// imágenes. Un fallo no impide crear el issue: el reporte vale más que la
+// captura, así que se anota cuántas faltaron y se deja registro.
+func uploadAttachments(ctx context.Context, uploader *attachmentUploader, body string, attachments []attachment) string {
+	if len(attachments) == 0 || uploader == nil {
+		return body
+	}
+	var lines []string
+	failed := 0
+	for _, a := range attachments {
+		link, err := uploader.store.Put(ctx, a)
+		if err != nil {
+			failed++
+			logErrorWithFallback(ctx, "attachment_upload_error", fmt.Sprintf("no se pudo subir el adjunto %q", a.Name), err)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("![%s](%s)", a.Name, link))
+	}
+	if failed > 0 {
+		lines = append(lines, fmt.Sprintf("_No se pudieron subir %d de %d adjuntos._", failed, len(attachments)))
+	}
+	return strings.TrimSpace(body + "\n\n### Capturas\n" + strings.Join(lines, "\n"))
+}
+
+// githubAttachmentStore guarda los archivos en una rama del repositorio con
+// la API de contenidos y los enlaza por raw.githubusercontent.com. La rama
+// debe existir; conviene una huérfana para no mezclar las capturas con el
+// código.
+type githubAttachmentStore struct {
+	endpoint string
+	owner    string
+	repo     string
+	branch   string
+	client   *http.Client
+}
+
+func (g *githubAttachmentStore) Put(ctx context.Context, a attachment) (string, error) {
+	name := a.objectName()
+	payload, err := json.Marshal(map[string]string{
+		"message": "Adjunto de un issue: " + name,
+		"content": base64.StdEncoding.EncodeToString(a.Data),
+		"branch":  g.branch,
+	})
+	if err != nil {
+		return "", err
+	}
+	target := fmt.Sprintf("%s/repos/%s/%s/contents/%s", g.endpoint, g.owner, g.repo, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	switch {
+	case resp.StatusCode == http.StatusCreated:
+	case resp.StatusCode == http.StatusUnprocessableEntity && strings.Contains(string(raw), "sha"):
+		// Sin sha GitHub no reemplaza un archivo existente; como el nombre
+		// es el hash del contenido, ya está subido.
+	default:
+		return "", fmt.Errorf("GitHub devolvió %d al subir %s: %s", resp.StatusCode, name, strings.TrimSpace(string(raw)))
+	}
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", g.owner, g.repo, url.PathEscape(g.branch), name), nil
+}
+
+// gcsAttachmentStore sube los archivos a un bucket de Cloud Storage. El
+// bucket debe permitir lectura pública (allUsers con Storage Object Viewer)
+// para que GitHub muestre las imágenes.
+type gcsAttachmentStore struct {
+	bucket   string
+	endpoint string
+	public   string
+	client   *http.Client
+	tokens   *googleTokenCache
+}
+
+func (g *gcsAttachmentStore) Put(ctx context.Context, a attachment) (string, error) {
+	name := a.objectName()
+	token, err := g.tokens.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{"uploadType": {"media"}, "name": {name}, "ifGenerationMatch": {"0"}}
+	target := fmt.Sprintf("%s/b/%s/o?%s", g.endpoint, url.PathEscape(g.bucket), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(a.Data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", a.ContentType)
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	// 412 significa que el objeto ya existe: mismo hash, mismo contenido.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPreconditionFailed {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("Cloud Storage devolvió %d al subir %s: %s", resp.StatusCode, name, strings.TrimSpace(string(raw)))
+	}
+	return fmt.Sprintf("%s/%s/%s", g.public, g.bucket, name), nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pngHeader alcanza para que http.DetectContentType reconozca un PNG.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+type fakeAttachmentStore struct {
+	uploaded []attachment
+	fail     bool
+}
+
+func (f *fakeAttachmentStore) Put(_ context.Context, a attachment) (string, error) {
+	if f.fail {
+		return "", errors.New("almacenamiento caído")
+	}
+	f.uploaded = append(f.uploaded, a)
+	return "https://cdn.example/" + a.objectName(), nil
+}
+
+func TestDecodeAttachmentsValida(t *testing.T) {
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Attachments = &attachmentUploader{store: &fakeAttachmentStore{}, maxFiles: 2, maxBytes: 64}
+	})
+	png := base64.StdEncoding.EncodeToString(pngHeader)
+
+	got, subErr := decodeAttachments([]attachmentInput{{Name: "pantalla [1].png", Data: "data:image/png;base64," + png}})
+	if subErr != nil || len(got) != 1 || got[0].ContentType != "image/png" || got[0].Name != "pantalla 1.png" {
+		t.Fatalf("una captura válida se acepta: %+v / %+v", got, subErr)
+	}
+
+	cases := map[string][]attachmentInput{
+		"demasiados": {{Data: png}, {Data: png}, {Data: png}},
+		"base64":     {{Data: "no es base64!"}},
+		"tamaño":     {{Data: base64.StdEncoding.EncodeToString(append(pngHeader, make([]byte, 64)...))}},
+		"tipo":       {{Data: base64.StdEncoding.EncodeToString([]byte("<html><script>alert(1)</script>"))}},
+	}
+	for name, inputs := range cases {
+		if _, subErr := decodeAttachments(inputs); subErr == nil || subErr.Code != "invalid_attachment" {
+			t.Errorf("%s: se esperaba invalid_attachment, got %+v", name, subErr)
+		}
+	}
+}
+
+func TestDecodeAttachmentsSinAlmacenamiento(t *testing.T) {
+	useServiceDeps(t, func(deps *serviceDeps) { deps.Attachments = nil })
+	if _, subErr := decodeAttachments([]attachmentInput{{Data: "eA=="}}); subErr == nil || subErr.Code != "invalid_attachment" {
+		t.Fatalf("sin ATTACHMENTS_STORE los adjuntos se rechazan: %+v", subErr)
+	}
+}
+
+func TestAdjuntosSeIncrustanEnElIssue(t *testing.T) {
+	store := &fakeAttachmentStore{}
+	var body string
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Attachments = &attachmentUploader{store: store, maxFiles: 3, maxBytes: 1 << 10}
+		deps.IssueCreator = func(_ context.Context, _ string, _ []string, b string) (*githubIssueResponse, error) {
+			body = b
+			return &githubIssueResponse{Number: 1, HTMLURL: "https://example.com/issues/1", NodeID: "node"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	payload := `{"templateId":"blank","title":"t","fields":{"descripcion":"y"},"attachments":[{"name":"error.png","data":"` +
+		base64.StdEncoding.EncodeToString(pngHeader) + `"}],` + consentJSON() + `}`
+	rr := httptest.NewRecorder()
+	handleRequest(rr, httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(payload)))
+	if rr.Code != http.StatusOK || len(store.uploaded) != 1 {
+		t.Fatalf("se esperaba el issue con la captura subida: %d %s", rr.Code, rr.Body.String())
+	}
+	want := "### Capturas\n![error.png](https://cdn.example/" + store.uploaded[0].objectName() + ")"
+	if !strings.Contains(body, want) {
+		t.Fatalf("el cuerpo debe incrustar la captura:\n%s", body)
+	}
+}
+
+func TestAdjuntosFallidosNoFrenanElIssue(t *testing.T) {
+	useServiceDeps(t, func(deps *serviceDeps) { deps.LogBackend = &memoryLogBackend{} })
+	uploader := &attachmentUploader{store: &fakeAttachmentStore{fail: true}}
+	body := uploadAttachments(context.Background(), uploader, "cuerpo", []attachment{{Name: "a", ContentType: "image/png", Data: pngHeader}})
+	if !strings.Contains(body, "No se pudieron subir 1 de 1 adjuntos") {
+		t.Fatalf("el issue debe anotar las capturas perdidas:\n%s", body)
+	}
+}
+
+func TestFormPostMultipartConAdjunto(t *testing.T) {
+	store := &fakeAttachmentStore{}
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Attachments = &attachmentUploader{store: store, maxFiles: 3, maxBytes: 1 << 10}
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			return &githubIssueResponse{Number: 7, HTMLURL: "https://example.com/issues/7", NodeID: "node"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	for key, value := range map[string]string{"templateId": "blank", "title": "t", "descripcion": "y", "consent": validConsent().PolicyVersion} {
+		_ = form.WriteField(key, value)
+	}
+	file, _ := form.CreateFormFile(attachmentFormField, "captura.png")
+	_, _ = file.Write(pngHeader)
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://service.local"+formPostPath, &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	if rr.Code != http.StatusSeeOther || !strings.Contains(rr.Header().Get("Location"), "estado=ok") || len(store.uploaded) != 1 {
+		t.Fatalf("el formulario multipart debe crear el issue con la captura: %d %s", rr.Code, rr.Header().Get("Location"))
+	}
+}
+
+func TestGitHubAttachmentStore(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.URL.Path, "/repos/org/capturas/contents/adjuntos/") || payload["branch"] != "adjuntos" {
+			t.Errorf("solicitud inesperada: %s %s %v", r.Method, r.URL.Path, payload)
+		}
+		if calls > 1 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = io.WriteString(w, `{"message":"Invalid request.\n\n\"sha\" wasn't supplied."}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	store := &githubAttachmentStore{endpoint: server.URL, owner: "org", repo: "capturas", branch: "adjuntos", client: server.Client()}
+	a := attachment{Name: "a", ContentType: "image/png", Data: pngHeader}
+	for i := 0; i < 2; i++ {
+		link, err := store.Put(context.Background(), a)
+		if err != nil || link != "https://raw.githubusercontent.com/org/capturas/adjuntos/"+a.objectName() {
+			t.Fatalf("subida %d: %q / %v", i+1, link, err)
+		}
+	}
+}
+
+func TestGCSAttachmentStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.URL.Query().Get("ifGenerationMatch") != "0" || r.Header.Get("Content-Type") != "image/png" {
+			t.Errorf("solicitud inesperada: %v %v", r.URL, r.Header)
+		}
+		w.WriteHeader(http.StatusPreconditionFailed)
+	}))
+	defer server.Close()
+
+	store := &gcsAttachmentStore{
+		bucket:   "capturas",
+		endpoint: server.URL,
+		public:   "https://storage.example",
+		client:   server.Client(),
+		tokens:   &googleTokenCache{token: "token", expiry: time.Now().Add(time.Hour)},
+	}
+	a := attachment{Name: "a", ContentType: "image/png", Data: pngHeader}
+	if link, err := store.Put(context.Background(), a); err != nil || link != "https://storage.example/capturas/"+a.objectName() {
+		t.Fatalf("un objeto existente cuenta como subido: %q / %v", link, err)
+	}
+}
+
+func TestNewAttachmentUploaderFromEnv(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(key string) string { return values[key] }
+	}
+	if uploader, err := newAttachmentUploaderFromEnv(env(nil)); uploader != nil || err != nil {
+		t.Fatalf("sin ATTACHMENTS_STORE no hay adjuntos: %+v / %v", uploader, err)
+	}
+	uploader, err := newAttachmentUploaderFromEnv(env(map[string]string{"ATTACHMENTS_STORE": "github", "ATTACHMENTS_REPO": "org/capturas", "ATTACHMENTS_MAX_FILES": "5"}))
+	if err != nil || uploader.maxFiles != 5 || uploader.maxBytes != defaultAttachmentMaxBytes {
+		t.Fatalf("github: %+v / %v", uploader, err)
+	}
+	if store := uploader.store.(*githubAttachmentStore); store.owner != "org" || store.repo != "capturas" || store.branch != defaultAttachmentBranch {
+		t.Fatalf("repositorio y rama: %+v", store)
+	}
+	for _, bad := range []map[string]string{
+		{"ATTACHMENTS_STORE": "gcs"},
+		{"ATTACHMENTS_STORE": "s3"},
+		{"ATTACHMENTS_STORE": "github", "ATTACHMENTS_REPO": "sin-barra"},
+		{"ATTACHMENTS_STORE": "github", "ATTACHMENTS_MAX_BYTES": "0"},
+	} {
+		if _, err := newAttachmentUploaderFromEnv(env(bad)); err == nil {
+			t.Errorf("%v debe ser un error de arranque", bad)
+		}
+	}
+}
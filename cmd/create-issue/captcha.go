@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Endpoints de verificación de cada proveedor. CAPTCHA_VERIFY_URL los
+// reemplaza (por ejemplo, para apuntar a un proxy o a un doble de pruebas).
+var captchaVerifyURLs = map[string]string{
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+}
+
+// captchaFormFields son los campos que agregan los widgets en un formulario
+// HTML clásico; el frontend con JavaScript manda captchaToken.
+var captchaFormFields = []string{"captchaToken", "cf-turnstile-response", "h-captcha-response"}
+
+// defaultCaptchaTimeout acota la espera del proveedor.
+const defaultCaptchaTimeout = 5 * time.Second
+
+// captchaVerifier valida del lado del servidor el token que emite el widget
+// de Turnstile o hCaptcha. Ambos exponen la misma API siteverify.
+type captchaVerifier struct {
+	provider string
+	endpoint string
+	secret   string
+	client   *http.Client
+}
+
+// newCaptchaVerifierFromEnv lee CAPTCHA_PROVIDER (turnstile o hcaptcha),
+// CAPTCHA_SECRET y CAPTCHA_VERIFY_URL. Sin proveedor devuelve nil y no se
+// exige captcha.
+func newCaptchaVerifierFromEnv(getenv func(string) string) (*captchaVerifier, error) {
+	provider := strings.ToLower(strings.TrimSpace(getenv("CAPTCHA_PROVIDER")))
+	if provider == "" || provider == "off" {
+		return nil, nil
+	}
+	endpoint, ok := captchaVerifyURLs[provider]
+	if !ok {
+		return nil, fmt.Errorf("CAPTCHA_PROVIDER desconocido: %q (usa turnstile o hcaptcha)", provider)
+	}
+	secret := strings.TrimSpace(getenv("CAPTCHA_SECRET"))
+	if secret == "" {
+		return nil, fmt.Errorf("CAPTCHA_SECRET es obligatorio con CAPTCHA_PROVIDER=%s", provider)
+	}
+	if override := strings.TrimSpace(getenv("CAPTCHA_VERIFY_URL")); override != "" {
+		endpoint = override
+	}
+	return &captchaVerifier{
+		provider: provider,
+		endpoint: endpoint,
+		secret:   secret,
+		client:   &http.Client{Timeout: defaultCaptchaTimeout, Transport: &outboundLoggingTransport{}},
+	}, nil
+}
+
+// Verify consulta siteverify. Devuelve los códigos de error del proveedor si
+// el token no es válido, o un error si no se pudo preguntar.
+func (v *captchaVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, []string, error) {
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("User-Agent", userAgent)
+	resp, err := v.client.Do(httpReq)
+	if err != nil {
+		return false, nil, fmt.Errorf("%s no responde: %w", v.provider, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, nil, fmt.Errorf("%s devolvió %d: %s", v.provider, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&result); err != nil {
+		return false, nil, fmt.Errorf("respuesta ilegible de %s: %w", v.provider, err)
+	}
+	return result.Success, result.ErrorCodes, nil
+}
+
+// checkCaptcha exige un token válido antes de crear el issue, salvo que la
+// bandera captcha esté apagada para la petición. Si el proveedor está caído
+// se registra y se deja pasar, como el webhook de moderación: el límite por
+// IP y el filtro de abuso siguen activos.
+func checkCaptcha(ctx context.Context, r *http.Request, req issueRequest) *submissionError {
+	verifier := loadServiceDeps().Captcha
+	if verifier == nil || !flagEnabled(ctx, flagCaptcha) {
+		return nil
+	}
+	token := strings.TrimSpace(req.CaptchaToken)
+	if token == "" {
+		return &submissionError{Status: http.StatusBadRequest, Code: "invalid_captcha", Message: "Falta completar la verificación anti-bots"}
+	}
+	ok, codes, err := verifier.Verify(ctx, token, requestClientIP(r))
+	if err != nil {
+		logErrorWithFallback(ctx, "captcha_verify_error", "no se pudo verificar el captcha; el envío sigue", err)
+		return nil
+	}
+	if ok {
+		return nil
+	}
+	return &submissionError{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_captcha",
+		Message: "La verificación anti-bots no es válida o ya se usó",
+		Cause:   fmt.Errorf("%s rechazó el token: %s", verifier.provider, strings.Join(codes, ",")),
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptchaVerificaAntesDeCrear(t *testing.T) {
+	var received url.Values
+	siteverify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		received = r.PostForm
+		if r.PostForm.Get("response") == "valido" {
+			_, _ = w.Write([]byte(`{"success":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":false,"error-codes":["timeout-or-duplicate"]}`))
+	}))
+	defer siteverify.Close()
+
+	verifier, err := newCaptchaVerifierFromEnv(func(key string) string {
+		return map[string]string{"CAPTCHA_PROVIDER": "turnstile", "CAPTCHA_SECRET": "secreto", "CAPTCHA_VERIFY_URL": siteverify.URL}[key]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := 0
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Captcha = verifier
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			created++
+			return &githubIssueResponse{Number: 1, HTMLURL: "https://example.com/issues/1", NodeID: "node"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	post := func(token string) *httptest.ResponseRecorder {
+		body := `{"templateId":"blank","title":"t","fields":{"descripcion":"y"},"captchaToken":"` + token + `",` + consentJSON() + `}`
+		rr := httptest.NewRecorder()
+		handleRequest(rr, httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body)))
+		return rr
+	}
+
+	for _, token := range []string{"", "usado"} {
+		if rr := post(token); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"invalid_captcha"`) {
+			t.Fatalf("token %q: se esperaba 400 invalid_captcha: %d %s", token, rr.Code, rr.Body.String())
+		}
+	}
+	if created != 0 {
+		t.Fatal("sin captcha válido no se crea el issue")
+	}
+	if rr := post("valido"); rr.Code != http.StatusOK || created != 1 {
+		t.Fatalf("un token válido crea el issue: %d %s", rr.Code, rr.Body.String())
+	}
+	if received.Get("secret") != "secreto" || received.Get("response") != "valido" {
+		t.Fatalf("siteverify debe recibir secreto y token: %v", received)
+	}
+}
+
+func TestCaptchaProveedorCaidoDejaPasar(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	logs := &memoryLogBackend{}
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = logs
+		deps.Captcha = &captchaVerifier{provider: "hcaptcha", endpoint: down.URL, secret: "s", client: http.DefaultClient}
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	if subErr := checkCaptcha(context.Background(), req, issueRequest{CaptchaToken: "x"}); subErr != nil {
+		t.Fatalf("un proveedor caído no debe bloquear los envíos: %+v", subErr)
+	}
+}
+
+func TestCaptchaEnviaLaIPSinLimiteYRespetaLaBandera(t *testing.T) {
+	var calls int
+	var remoteIP string
+	siteverify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		calls++
+		remoteIP = r.PostForm.Get("remoteip")
+		_, _ = w.Write([]byte(`{"success":false}`))
+	}))
+	defer siteverify.Close()
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Captcha = &captchaVerifier{provider: "turnstile", endpoint: siteverify.URL, secret: "s", client: http.DefaultClient}
+		deps.RateLimiter = nil
+		deps.ProxyHops = 1
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+
+	if subErr := checkCaptcha(context.Background(), req, issueRequest{CaptchaToken: "x"}); subErr == nil || remoteIP != "198.51.100.7" {
+		t.Fatalf("siteverify debe recibir la IP aunque no haya límite de envíos: %q / %+v", remoteIP, subErr)
+	}
+	flags, err := parseFeatureFlags(`{"captcha": {"percent": 0}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := withFeatureFlags(context.Background(), flags, "req", "")
+	if subErr := checkCaptcha(ctx, req, issueRequest{}); subErr != nil || calls != 1 {
+		t.Fatalf("con la bandera apagada no se exige captcha: %+v (%d llamadas)", subErr, calls)
+	}
+}
+
+func TestCaptchaDesdeFormulario(t *testing.T) {
+	form := url.Values{"templateId": {"blank"}, "h-captcha-response": {"tok"}, "descripcion": {"y"}}
+	req := issueRequestFromForm(form, time.Now())
+	if req.CaptchaToken != "tok" {
+		t.Fatalf("el token del widget debe leerse del formulario: %q", req.CaptchaToken)
+	}
+	if _, ok := req.Fields["h-captcha-response"]; ok {
+		t.Fatal("el token no es un campo de la plantilla")
+	}
+}
+
+func TestNewCaptchaVerifierFromEnv(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(key string) string { return values[key] }
+	}
+	if v, err := newCaptchaVerifierFromEnv(env(nil)); v != nil || err != nil {
+		t.Fatalf("sin proveedor no se exige captcha: %+v / %v", v, err)
+	}
+	if v, err := newCaptchaVerifierFromEnv(env(map[string]string{"CAPTCHA_PROVIDER": "hcaptcha", "CAPTCHA_SECRET": "s"})); err != nil || v.endpoint != captchaVerifyURLs["hcaptcha"] {
+		t.Fatalf("hcaptcha usa su endpoint por defecto: %+v / %v", v, err)
+	}
+	if _, err := newCaptchaVerifierFromEnv(env(map[string]string{"CAPTCHA_PROVIDER": "turnstile"})); err == nil {
+		t.Fatal("sin CAPTCHA_SECRET es un error de arranque")
+	}
+	if _, err := newCaptchaVerifierFromEnv(env(map[string]string{"CAPTCHA_PROVIDER": "recaptcha", "CAPTCHA_SECRET": "s"})); err == nil {
+		t.Fatal("un proveedor desconocido es un error de arranque")
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// templateCatalogPath expone el catálogo de plantillas para el frontend.
+const templateCatalogPath = "/templates"
+
+// templateCatalogMaxAge es cuánto puede reutilizar el navegador o la CDN el
+// catálogo sin preguntar. Pasado ese tiempo revalida con If-None-Match y,
+// si nada cambió, recibe un 304 sin cuerpo.
+const templateCatalogMaxAge = 5 * time.Minute
+
+// templateCatalogOut usa los mismos nombres que issueTemplates en
+// docs/index.html para que el frontend pueda consumirlo tal cual.
+type templateCatalogOut struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Title       string             `json:"title"`
+	Labels      []string           `json:"labels"`
+	Body        []templateFieldOut `json:"body"`
+}
+
+type templateFieldOut struct {
+	Type        fieldType `json:"type"`
+	ID          string    `json:"id,omitempty"`
+	Label       string    `json:"label,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Placeholder string    `json:"placeholder,omitempty"`
+	Value       string    `json:"value,omitempty"`
+	Required    bool      `json:"required,omitempty"`
+}
+
+// catalogSnapshot es el catálogo ya serializado. El ETag sale del contenido,
+// así que solo cambia cuando cambian las plantillas; LastModified registra
+// cuándo se publicó ese contenido por primera vez.
+type catalogSnapshot struct {
+	Body         []byte
+	ETag         string
+	LastModified time.Time
+}
+
+var currentCatalog atomic.Pointer[catalogSnapshot]
+
+func init() {
+	if err := publishTemplateCatalog(templates, time.Now()); err != nil {
+		panic(err)
+	}
+}
+
+// publishTemplateCatalog serializa el catálogo y lo publica para
+// handleTemplateCatalog. Hay que llamarlo cada vez que se recargan las
+// plantillas; si el contenido no cambió se conservan ETag y fecha, para no
+// invalidar las copias en caché sin motivo.
+func publishTemplateCatalog(catalog map[string]issueTemplate, now time.Time) error {
+	out := make([]templateCatalogOut, 0, len(catalog))
+	for _, id := range sortedTemplateIDs(catalog) {
+		tmpl := catalog[id]
+		entry := templateCatalogOut{ID: id, Name: tmpl.Name, Description: tmpl.Description, Title: tmpl.Title, Labels: tmpl.Labels, Body: []templateFieldOut{}}
+		for _, field := range tmpl.Body {
+			entry.Body = append(entry.Body, templateFieldOut{
+				Type:        field.Type,
+				ID:          field.ID,
+				Label:       field.Label,
+				Description: field.Description,
+				Placeholder: field.Placeholder,
+				Value:       field.Value,
+				Required:    field.Required,
+			})
+		}
+		out = append(out, entry)
+	}
+	body, err := json.Marshal(out)
+	if err != nil {
+		return fmt.Errorf("serializar catálogo de plantillas: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	if previous := currentCatalog.Load(); previous != nil && previous.ETag == etag {
+		return nil
+	}
+	currentCatalog.Store(&catalogSnapshot{Body: body, ETag: etag, LastModified: now.UTC().Truncate(time.Second)})
+	return nil
+}
+
+// handleTemplateCatalog responde GET y HEAD /templates con validadores de
+// caché. If-None-Match tiene prioridad sobre If-Modified-Since, como indica
+// RFC 9110.
+func handleTemplateCatalog(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	snapshot := currentCatalog.Load()
+	header := w.Header()
+	header.Set("ETag", snapshot.ETag)
+	header.Set("Last-Modified", snapshot.LastModified.Format(http.TimeFormat))
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(templateCatalogMaxAge.Seconds())))
+
+	status := http.StatusOK
+	if catalogNotModified(r, snapshot) {
+		status = http.StatusNotModified
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.RecordStatus(status)
+	}
+	if status == http.StatusNotModified {
+		w.WriteHeader(status)
+		return
+	}
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Length", fmt.Sprint(len(snapshot.Body)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(snapshot.Body); err != nil {
+		logErrorWithFallback(ctx, "write_response_error", "error al escribir el catálogo de plantillas", err)
+	}
+}
+
+func catalogNotModified(r *http.Request, snapshot *catalogSnapshot) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == snapshot.ETag {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !snapshot.LastModified.After(since)
+	}
+	return false
+}
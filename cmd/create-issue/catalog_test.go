@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func catalogRequest(method string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "http://service.local"+templateCatalogPath, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	return rr
+}
+
+func TestTemplateCatalogDevuelvePlantillas(t *testing.T) {
+	rr := catalogRequest(http.MethodGet, nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") == "" || rr.Header().Get("Cache-Control") == "" {
+		t.Fatalf("respuesta inesperada %d: %v", rr.Code, rr.Header())
+	}
+	var catalog []templateCatalogOut
+	if err := json.Unmarshal(rr.Body.Bytes(), &catalog); err != nil {
+		t.Fatalf("catálogo ilegible: %v", err)
+	}
+	if len(catalog) != len(templates) || catalog[0].ID != "blank" || len(catalog[0].Body) == 0 {
+		t.Fatalf("catálogo inesperado: %+v", catalog)
+	}
+
+	head := catalogRequest(http.MethodHead, nil)
+	if head.Code != http.StatusOK || head.Body.Len() != 0 {
+		t.Fatalf("HEAD no debe traer cuerpo: %d %q", head.Code, head.Body.String())
+	}
+}
+
+func TestTemplateCatalogConservaOrdenYObligatorios(t *testing.T) {
+	var catalog []templateCatalogOut
+	if err := json.Unmarshal(catalogRequest(http.MethodGet, nil).Body.Bytes(), &catalog); err != nil {
+		t.Fatalf("catálogo ilegible: %v", err)
+	}
+	for _, entry := range catalog {
+		tmpl := templates[entry.ID]
+		if len(entry.Body) != len(tmpl.Body) {
+			t.Fatalf("%s: %d campos; se esperaban %d", entry.ID, len(entry.Body), len(tmpl.Body))
+		}
+		// El frontend dibuja los campos en el orden del arreglo.
+		for i, field := range tmpl.Body {
+			got := entry.Body[i]
+			if got.ID != field.ID || got.Type != field.Type || got.Required != field.Required || got.Label != field.Label {
+				t.Fatalf("%s campo %d = %+v; se esperaba %+v", entry.ID, i, got, field)
+			}
+		}
+	}
+}
+
+func TestTemplateCatalogGetCondicional(t *testing.T) {
+	first := catalogRequest(http.MethodGet, nil)
+	etag := first.Header().Get("ETag")
+
+	if rr := catalogRequest(http.MethodGet, map[string]string{"If-None-Match": `"otro", W/` + etag}); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatalf("con el ETag vigente se esperaba 304 sin cuerpo, llegó %d", rr.Code)
+	}
+	if rr := catalogRequest(http.MethodGet, map[string]string{"If-None-Match": `"viejo"`}); rr.Code != http.StatusOK {
+		t.Fatalf("con un ETag viejo se esperaba 200, llegó %d", rr.Code)
+	}
+	since := first.Header().Get("Last-Modified")
+	if rr := catalogRequest(http.MethodGet, map[string]string{"If-Modified-Since": since}); rr.Code != http.StatusNotModified {
+		t.Fatalf("con If-Modified-Since vigente se esperaba 304, llegó %d", rr.Code)
+	}
+}
+
+func TestPublishTemplateCatalogRegeneraETag(t *testing.T) {
+	original := currentCatalog.Load()
+	t.Cleanup(func() { currentCatalog.Store(original) })
+
+	later := original.LastModified.Add(time.Hour)
+	if err := publishTemplateCatalog(templates, later); err != nil {
+		t.Fatalf("publishTemplateCatalog: %v", err)
+	}
+	if current := currentCatalog.Load(); current != original {
+		t.Fatal("recargar el mismo catálogo no debe cambiar ETag ni fecha")
+	}
+
+	reloaded := map[string]issueTemplate{"blank": templates["blank"]}
+	if err := publishTemplateCatalog(reloaded, later); err != nil {
+		t.Fatalf("publishTemplateCatalog: %v", err)
+	}
+	current := currentCatalog.Load()
+	if current.ETag == original.ETag || !current.LastModified.Equal(later.UTC().Truncate(time.Second)) {
+		t.Fatalf("un catálogo distinto debe publicar ETag y fecha nuevos: %+v", current)
+	}
+	if rr := catalogRequest(http.MethodGet, map[string]string{"If-None-Match": original.ETag}); rr.Code != http.StatusOK {
+		t.Fatalf("tras la recarga el ETag anterior ya no vale, llegó %d", rr.Code)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Límites de la telemetría del cliente. Son generosos para datos reales de un
+// navegador y suficientemente cortos para que nadie use el bloque como canal
+// para pegar texto arbitrario en el issue.
+const (
+	maxClientTextRunes   = 120
+	maxClientFeatureFlag = 20
+)
+
+var (
+	viewportRegex    = regexp.MustCompile(`^\d{2,5}x\d{2,5}$`)
+	featureFlagRegex = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+)
+
+// clientInfo es el entorno que el formulario web reporta de forma
+// automática. Reemplaza al campo libre "Entorno", que casi siempre llegaba
+// vacío justo cuando más falta hacía para reproducir un bug.
+type clientInfo struct {
+	Browser      string   `json:"browser,omitempty"`
+	OS           string   `json:"os,omitempty"`
+	Viewport     string   `json:"viewport,omitempty"`
+	AppVersion   string   `json:"appVersion,omitempty"`
+	FeatureFlags []string `json:"featureFlags,omitempty"`
+}
+
+// sanitizeClientInfo normaliza los textos libres y valida los campos con
+// formato conocido. Los textos se recortan en lugar de rechazarse: un user
+// agent largo no debe impedir que se cree el issue. Viewport y flags sí se
+// validan porque un formato inesperado indica un cliente que no es el nuestro.
+func sanitizeClientInfo(info *clientInfo) (*clientInfo, error) {
+	if info == nil {
+		return nil, nil
+	}
+
+	clean := &clientInfo{
+		Browser:    sanitizeClientText(info.Browser),
+		OS:         sanitizeClientText(info.OS),
+		AppVersion: sanitizeClientText(info.AppVersion),
+	}
+
+	if viewport := strings.ToLower(strings.TrimSpace(info.Viewport)); viewport != "" {
+		if !viewportRegex.MatchString(viewport) {
+			return nil, fmt.Errorf("client.viewport debe tener el formato ANCHOxALTO")
+		}
+		clean.Viewport = viewport
+	}
+
+	if len(info.FeatureFlags) > maxClientFeatureFlag {
+		return nil, fmt.Errorf("client.featureFlags admite como máximo %d elementos", maxClientFeatureFlag)
+	}
+	for _, flag := range info.FeatureFlags {
+		flag = strings.TrimSpace(flag)
+		if flag == "" {
+			continue
+		}
+		if !featureFlagRegex.MatchString(flag) {
+			return nil, fmt.Errorf("client.featureFlags contiene un valor inválido: %q", truncateRunes(flag, 32))
+		}
+		clean.FeatureFlags = append(clean.FeatureFlags, flag)
+	}
+
+	if clean.Browser == "" && clean.OS == "" && clean.Viewport == "" && clean.AppVersion == "" && len(clean.FeatureFlags) == 0 {
+		return nil, nil
+	}
+	return clean, nil
+}
+
+// sanitizeClientText elimina caracteres de control, colapsa espacios y escapa
+// lo que podría romper la tabla Markdown o inyectar HTML en el issue.
+func sanitizeClientText(raw string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, raw)
+	cleaned = truncateRunes(strings.Join(strings.Fields(cleaned), " "), maxClientTextRunes)
+	cleaned = html.EscapeString(cleaned)
+	return strings.NewReplacer("|", `\|`, "`", "'").Replace(cleaned)
+}
+
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
+
+// renderClientInfo arma el bloque plegado que se agrega al final del issue.
+// Va colapsado para no distraer de la descripción que escribió la persona.
+func renderClientInfo(info *clientInfo) string {
+	if info == nil {
+		return ""
+	}
+	rows := []struct{ label, value string }{
+		{"Navegador", info.Browser},
+		{"Sistema operativo", info.OS},
+		{"Viewport", info.Viewport},
+		{"Versión de la app", info.AppVersion},
+	}
+	if len(info.FeatureFlags) > 0 {
+		quoted := make([]string, len(info.FeatureFlags))
+		for i, flag := range info.FeatureFlags {
+			quoted[i] = "`" + flag + "`"
+		}
+		rows = append(rows, struct{ label, value string }{"Feature flags", strings.Join(quoted, ", ")})
+	}
+
+	var b strings.Builder
+	b.WriteString("<details>\n<summary>Entorno del cliente</summary>\n\n| Dato | Valor |\n| --- | --- |\n")
+	for _, row := range rows {
+		if row.value == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", row.label, row.value)
+	}
+	b.WriteString("\n</details>")
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeClientInfo(t *testing.T) {
+	info, err := sanitizeClientInfo(&clientInfo{
+		Browser:      "Firefox 128 | <script>\n",
+		OS:           "  Linux\tx86_64 ",
+		Viewport:     "1280X720",
+		AppVersion:   "`v1.2.3`",
+		FeatureFlags: []string{"nuevo-form", " "},
+	})
+	if err != nil {
+		t.Fatalf("sanitizeClientInfo: %v", err)
+	}
+	if info.Browser != `Firefox 128 \| &lt;script&gt;` {
+		t.Fatalf("Browser = %q", info.Browser)
+	}
+	if info.OS != "Linux x86_64" || info.Viewport != "1280x720" || info.AppVersion != "'v1.2.3'" {
+		t.Fatalf("valores normalizados inesperados: %+v", info)
+	}
+	if len(info.FeatureFlags) != 1 || info.FeatureFlags[0] != "nuevo-form" {
+		t.Fatalf("FeatureFlags = %v", info.FeatureFlags)
+	}
+}
+
+func TestSanitizeClientInfoRechazaFormatos(t *testing.T) {
+	cases := []*clientInfo{
+		{Viewport: "grande"},
+		{FeatureFlags: []string{"con espacio"}},
+		{FeatureFlags: make([]string, maxClientFeatureFlag+1)},
+	}
+	for _, tc := range cases {
+		if _, err := sanitizeClientInfo(tc); err == nil {
+			t.Errorf("sanitizeClientInfo(%+v) debería fallar", tc)
+		}
+	}
+}
+
+func TestSanitizeClientInfoVacio(t *testing.T) {
+	info, err := sanitizeClientInfo(&clientInfo{Browser: "  "})
+	if err != nil || info != nil {
+		t.Fatalf("un cliente sin datos no debe generar bloque, got (%+v, %v)", info, err)
+	}
+}
+
+func TestPrepareSubmissionAgregaEntornoDelCliente(t *testing.T) {
+	req := issueRequest{
+		TemplateID: "blank",
+		Title:      "Con entorno",
+		Fields:     map[string]string{"descripcion": "algo falla"},
+		Client:     &clientInfo{Browser: "Chrome 126", FeatureFlags: []string{"beta"}},
+		Consent:    validConsent(),
+	}
+	prepared, subErr := prepareSubmission(context.Background(), req)
+	if subErr != nil {
+		t.Fatalf("prepareSubmission: %v", subErr)
+	}
+	if !strings.HasSuffix(prepared.Body, "</details>") {
+		t.Fatalf("el bloque del cliente debe ir al final:\n%s", prepared.Body)
+	}
+	for _, want := range []string{"<summary>Entorno del cliente</summary>", "| Navegador | Chrome 126 |", "| Feature flags | `beta` |"} {
+		if !strings.Contains(prepared.Body, want) {
+			t.Fatalf("falta %q en el cuerpo:\n%s", want, prepared.Body)
+		}
+	}
+
+	req.Client = &clientInfo{Viewport: "x"}
+	if _, subErr := prepareSubmission(context.Background(), req); subErr == nil || subErr.Status != 400 {
+		t.Fatalf("un viewport inválido debería responder 400, got %+v", subErr)
+	}
+}
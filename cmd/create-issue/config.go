@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// serviceConfig agrupa la configuración que puede cambiar mientras el
+// servicio corre. Se publica completa mediante un puntero atómico, de modo que
+// cada petición lee una instantánea coherente aunque otra goroutine la
+// reemplace a mitad del camino.
+type serviceConfig struct {
+	AllowAnyOrigin       bool
+	AllowedOrigin        string
+	AllowedOriginEntries []originEntry
+
+	// Flags recorta comportamientos nuevos a una parte del tráfico.
+	Flags *featureFlags
+
+	// ProjectFields completa o agrega campos del Project por plantilla (por
+	// ejemplo el Area de los bugs); sale de PROJECT_FIELDS.
+	ProjectFields map[string]map[string]string
+
+	// TemplateTargets cambia el repositorio o el Project de ciertas
+	// plantillas; sale de TEMPLATE_TARGETS.
+	TemplateTargets map[string]issueTarget
+}
+
+// serviceDeps agrupa las dependencias intercambiables del servicio. Las
+// pruebas sustituyen la instantánea completa en lugar de pisar variables
+// sueltas, lo que elimina las carreras entre handlers y restauraciones.
+type serviceDeps struct {
+	LogBackend   logBackend
+	IssueCreator func(ctx context.Context, title string, labels []string, body string) (*githubIssueResponse, error)
+	// SingleCallCreator crea el issue y lo agrega al Project en una sola
+	// mutación GraphQL; nil usa siempre IssueCreator.
+	SingleCallCreator func(ctx context.Context, title string, labels []string, body string) (*githubIssueResponse, error)
+	ProjectAdder      func(ctx context.Context, nodeID string, templateID string, labels []string) error
+	SubmissionQueue   submissionQueue
+
+	// Sessions guarda los borradores del flujo por pasos; nil lo desactiva.
+	Sessions sessionStore
+
+	// Idempotency recuerda las claves Idempotency-Key recientes para no
+	// crear dos veces el mismo issue; nil lo desactiva.
+	Idempotency idempotencyStore
+
+	// ShortLinks emite y resuelve enlaces /i/{token}; nil los desactiva.
+	ShortLinks *shortLinker
+
+	// GitHubTokens reparte las llamadas a GitHub entre GITHUB_TOKEN y
+	// GITHUB_TOKENS, con failover si uno se revoca o se agota.
+	GitHubTokens *tokenPool
+
+	// Probe y ProbeSecret habilitan la sonda sintética en /probe; sin
+	// secreto la ruta no existe.
+	Probe       *probeBackend
+	ProbeSecret string
+
+	// ExternalTrackers replica los issues de ciertas plantillas en Jira o
+	// Linear; nil lo desactiva. IssueBodyUpdater anota la referencia cruzada.
+	ExternalTrackers *trackerRouter
+	IssueBodyUpdater func(ctx context.Context, number int, body string) error
+
+	// Analytics recibe un evento anónimo por envío; nil lo desactiva.
+	Analytics analyticsExporter
+
+	// Attachments sube las capturas de los envíos; nil las rechaza.
+	Attachments *attachmentUploader
+
+	// Captcha verifica el token del widget anti-bots; nil no lo exige.
+	Captcha *captchaVerifier
+
+	// Screener puntúa los envíos: descarta los abusivos y retiene los dudosos
+	// para revisión humana en Quarantine; AdminToken protege
+	// /admin/quarantine. Sin filtro todo se crea directo.
+	Screener   submissionScreener
+	Quarantine quarantineStore
+	AdminToken string
+
+	// LoadShedder descarta los envíos menos prioritarios cuando el servicio
+	// se satura; nil lo desactiva.
+	LoadShedder *loadShedder
+
+	// RateLimiter limita los POST por IP y por origen; nil lo desactiva.
+	RateLimiter *rateLimiter
+
+	// ProxyHops es RATE_LIMIT_PROXY_HOPS: cuántas entradas de
+	// X-Forwarded-For agregan nuestros proxies. Sirve para la IP del cliente
+	// aunque RateLimiter esté apagado.
+	ProxyHops int
+
+	// Cooldowns pausa un origen y plantilla tras un rechazo de contenido.
+	Cooldowns *cooldownTracker
+
+	// ModuleResolver y ModuleAreaLinker enlazan el envío con el módulo del
+	// roadmap desde el que se reportó (moduleId).
+	ModuleResolver   func(ctx context.Context, moduleID string) (*moduleRef, error)
+	ModuleAreaLinker func(ctx context.Context, issueNodeID string, moduleNumber int) error
+
+	// Incidents lee la página de estado para asociar los bugs con un
+	// incidente activo; nil lo desactiva.
+	Incidents *incidentFeed
+
+	// DuplicateLinker cruza comentarios y etiqueta ambos issues cuando el
+	// envío confirma un posible duplicado (duplicateOf).
+	DuplicateLinker func(ctx context.Context, issueNumber, originalNumber int) error
+	// DuplicateSearcher busca issues abiertos con títulos parecidos antes
+	// de crear uno nuevo; nil no busca.
+	DuplicateSearcher func(ctx context.Context, title string) ([]duplicateCandidate, error)
+
+	// Readiness verifica GitHub y Cloud Logging para /readyz; nil responde
+	// siempre listo.
+	Readiness *readinessProbe
+}
+
+var (
+	currentConfig atomic.Pointer[serviceConfig]
+	currentDeps   atomic.Pointer[serviceDeps]
+)
+
+func init() {
+	currentConfig.Store(newOriginConfig(allowedOrigin, buildDefaultAllowedOrigins))
+	currentDeps.Store(&serviceDeps{
+		LogBackend:       &noopLogBackend{},
+		IssueCreator:     createIssue,
+		ProjectAdder:     addToProjectAndSetType,
+		IssueBodyUpdater: updateIssueBody,
+		Cooldowns:        newCooldownTracker(contentRejectionCooldown),
+		ShortLinks:       newShortLinkerFromEnv(os.Getenv),
+		GitHubTokens:     newTokenPoolFromEnv(os.Getenv),
+		Probe:            newProbeBackendFromEnv(os.Getenv),
+		ProbeSecret:      os.Getenv("PROBE_SECRET"),
+		Quarantine:       newMemoryQuarantine(maxQuarantined),
+		AdminToken:       os.Getenv("ADMIN_TOKEN"),
+		Incidents:        newIncidentFeedFromEnv(os.Getenv),
+
+		ModuleResolver:   newModuleDirectory(modulesURL).Resolve,
+		ModuleAreaLinker: copyModuleArea,
+		DuplicateLinker:  linkPossibleDuplicate,
+		Readiness:        newReadinessProbe(readinessTTL, defaultReadinessChecks()...),
+	})
+}
+
+func loadServiceConfig() *serviceConfig { return currentConfig.Load() }
+
+func storeServiceConfig(cfg *serviceConfig) { currentConfig.Store(cfg) }
+
+func loadServiceDeps() *serviceDeps { return currentDeps.Load() }
+
+func storeServiceDeps(deps *serviceDeps) { currentDeps.Store(deps) }
+
+// reloadServiceConfig vuelve a leer los orígenes permitidos y publica la nueva
+// configuración. Si ALLOWED_ORIGIN_FILE apunta a un archivo, su contenido
+// tiene prioridad sobre la variable de entorno, ya que es lo único que una
+// operadora puede cambiar sin reiniciar el proceso.
+func reloadServiceConfig(getenv func(string) string, readFile func(string) ([]byte, error)) *serviceConfig {
+	origins := strings.TrimSpace(getenv("ALLOWED_ORIGIN"))
+	if path := strings.TrimSpace(getenv("ALLOWED_ORIGIN_FILE")); path != "" {
+		data, err := readFile(path)
+		if err != nil {
+			log.Printf("no se pudo leer ALLOWED_ORIGIN_FILE %q, se conserva ALLOWED_ORIGIN: %v", path, err)
+		} else {
+			origins = strings.TrimSpace(string(data))
+		}
+	}
+
+	cfg := newOriginConfig(origins, buildDefaultAllowedOrigins)
+	flags, err := loadFeatureFlags(getenv, readFile)
+	if err != nil {
+		log.Printf("banderas inválidas, se conservan las anteriores: %v", err)
+		flags = loadServiceConfig().Flags
+	}
+	cfg.Flags = flags
+	if err := loadTemplateSettings(cfg, getenv, readFile); err != nil {
+		log.Printf("configuración por plantilla inválida, se conserva la anterior: %v", err)
+		previous := loadServiceConfig()
+		cfg.ProjectFields, cfg.TemplateTargets = previous.ProjectFields, previous.TemplateTargets
+	}
+	storeServiceConfig(cfg)
+	return cfg
+}
+
+// loadTemplateSettings lee en cfg la configuración por plantilla. Una
+// plantilla que el catálogo vigente no conoce solo genera un aviso: puede
+// llegar con la próxima recarga de .github/ISSUE_TEMPLATE.
+func loadTemplateSettings(cfg *serviceConfig, getenv func(string) string, readFile func(string) ([]byte, error)) error {
+	raw, err := envOrFile(getenv, readFile, "PROJECT_FIELDS")
+	if err != nil {
+		return err
+	}
+	fields, err := parseProjectFields(raw)
+	if err != nil {
+		return err
+	}
+	warnUnknownTemplates("PROJECT_FIELDS", fields)
+
+	if raw, err = envOrFile(getenv, readFile, "TEMPLATE_TARGETS"); err != nil {
+		return err
+	}
+	targets, err := parseTemplateTargets(raw, defaultIssueTarget())
+	if err != nil {
+		return err
+	}
+	warnUnknownTemplates("TEMPLATE_TARGETS", targets)
+
+	cfg.ProjectFields, cfg.TemplateTargets = fields, targets
+	return nil
+}
+
+// watchReloadSignal recarga la configuración cada vez que el proceso recibe
+// SIGHUP, sin cortar las peticiones en curso.
+func watchReloadSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				log.Print("SIGHUP recibido: recargando configuración")
+				logOriginConfig(reloadServiceConfig(os.Getenv, os.ReadFile))
+			}
+		}
+	}()
+}
+
+// envOrFile devuelve el contenido de <key>_FILE si está definido (se puede
+// editar y recargar con SIGHUP como FEATURE_FLAGS_FILE) o, si no, key.
+func envOrFile(getenv func(string) string, readFile func(string) ([]byte, error), key string) (string, error) {
+	path := strings.TrimSpace(getenv(key + "_FILE"))
+	if path == "" {
+		return getenv(key), nil
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo leer %s_FILE %q: %w", key, path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func logOriginConfig(cfg *serviceConfig) {
+	switch {
+	case cfg.AllowAnyOrigin:
+		log.Print("CORS abierto: se permiten todos los orígenes (ALLOWED_ORIGIN=*)")
+	case len(cfg.AllowedOriginEntries) == 0:
+		log.Print("ADVERTENCIA: ALLOWED_ORIGIN vacío o sin valores válidos, se rechazarán solicitudes con origen")
+	default:
+		log.Printf("Orígenes permitidos: %s", cfg.AllowedOrigin)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReloadServiceConfigPrefiereArchivo(t *testing.T) {
+	useServiceConfig(t, loadServiceConfig())
+
+	env := map[string]string{
+		"ALLOWED_ORIGIN":      "https://env.example",
+		"ALLOWED_ORIGIN_FILE": "/etc/origins",
+	}
+	readFile := func(path string) ([]byte, error) {
+		if path != "/etc/origins" {
+			t.Fatalf("ruta inesperada: %s", path)
+		}
+		return []byte("https://archivo.example\n"), nil
+	}
+
+	cfg := reloadServiceConfig(func(key string) string { return env[key] }, readFile)
+	if !cfg.isOriginAllowed("https://archivo.example") || cfg.isOriginAllowed("https://env.example") {
+		t.Fatalf("el archivo debería reemplazar a ALLOWED_ORIGIN: %+v", cfg.AllowedOriginEntries)
+	}
+	if loadServiceConfig() != cfg {
+		t.Fatal("la configuración recargada debe quedar publicada")
+	}
+}
+
+func TestReloadServiceConfigConservaEntornoSiFallaArchivo(t *testing.T) {
+	useServiceConfig(t, loadServiceConfig())
+
+	env := map[string]string{
+		"ALLOWED_ORIGIN":      "https://env.example",
+		"ALLOWED_ORIGIN_FILE": "/no/existe",
+	}
+	readFile := func(string) ([]byte, error) { return nil, errors.New("no existe") }
+
+	cfg := reloadServiceConfig(func(key string) string { return env[key] }, readFile)
+	if !cfg.isOriginAllowed("https://env.example") {
+		t.Fatalf("un archivo ilegible no debe dejar el servicio sin orígenes: %+v", cfg.AllowedOriginEntries)
+	}
+}
+
+func TestReloadServiceConfigReleeConfiguracionPorPlantilla(t *testing.T) {
+	useServiceConfig(t, loadServiceConfig())
+	env := map[string]string{"PROJECT_FIELDS_FILE": "/etc/campos", "TEMPLATE_TARGETS": "feature=org/ideas"}
+	content := "bug.Area=Plataforma"
+	getenv := func(key string) string { return env[key] }
+	readFile := func(path string) ([]byte, error) {
+		if path != "/etc/campos" {
+			return nil, errors.New("sin archivo")
+		}
+		return []byte(content), nil
+	}
+
+	cfg := reloadServiceConfig(getenv, readFile)
+	if cfg.ProjectFields["bug"]["Area"] != "Plataforma" {
+		t.Fatalf("SIGHUP debe releer PROJECT_FIELDS_FILE: %+v", cfg.ProjectFields)
+	}
+	if cfg.TemplateTargets["feature"].Repo != "ideas" {
+		t.Fatalf("TEMPLATE_TARGETS vive en la misma instantánea: %+v", cfg.TemplateTargets)
+	}
+
+	content = "bug=sin-campo"
+	if cfg := reloadServiceConfig(getenv, readFile); cfg.ProjectFields["bug"]["Area"] != "Plataforma" || cfg.TemplateTargets["feature"].Repo != "ideas" {
+		t.Fatalf("un valor inválido conserva el anterior: %+v", cfg.ProjectFields)
+	}
+}
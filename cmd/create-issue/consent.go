@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultPrivacyPolicyVersion es la versión del aviso de privacidad que
+// muestra docs/index.html. Al publicar un aviso nuevo se actualizan ambos
+// lados (o PRIVACY_POLICY_VERSION en el despliegue) para que los formularios
+// viejos en caché no sigan enviando datos bajo un aviso anterior.
+const defaultPrivacyPolicyVersion = "2026-10"
+
+// consentClockSkew tolera relojes de navegador adelantados sin aceptar fechas
+// claramente inventadas.
+const consentClockSkew = 5 * time.Minute
+
+var privacyPolicyVersion = envOrDefault("PRIVACY_POLICY_VERSION", defaultPrivacyPolicyVersion)
+
+// consentRecord es la constancia de que la persona aceptó el aviso de
+// privacidad antes de enviar sus datos. AcceptedAt lo informa el navegador;
+// ReceivedAt lo fija el servicio y es la fecha que vale para auditoría.
+type consentRecord struct {
+	PolicyVersion string    `json:"policyVersion"`
+	AcceptedAt    time.Time `json:"acceptedAt"`
+	ReceivedAt    time.Time `json:"receivedAt"`
+}
+
+// validateConsent exige el consentimiento para la versión vigente del aviso.
+// Devuelve una copia con ReceivedAt fijado por el servidor. Si el registro ya
+// lo traía es un envío que aceptamos antes y ahora sale de la cola: no lo
+// rechazamos porque el aviso haya cambiado mientras esperaba. handlePost
+// descarta el ReceivedAt que mande el cliente, así que no se puede falsificar.
+func validateConsent(consent *consentRecord, now time.Time) (*consentRecord, *submissionError) {
+	if consent == nil || strings.TrimSpace(consent.PolicyVersion) == "" {
+		return nil, &submissionError{Status: http.StatusBadRequest, Code: "consent_required", Message: "Debes aceptar el aviso de privacidad para enviar el formulario"}
+	}
+	version := strings.TrimSpace(consent.PolicyVersion)
+	if consent.ReceivedAt.IsZero() && version != privacyPolicyVersion {
+		return nil, &submissionError{
+			Status:  http.StatusBadRequest,
+			Code:    "consent_outdated",
+			Message: fmt.Sprintf("El aviso de privacidad cambió (versión %s); recarga la página y acéptalo de nuevo", privacyPolicyVersion),
+		}
+	}
+	if consent.AcceptedAt.IsZero() || consent.AcceptedAt.After(now.Add(consentClockSkew)) {
+		return nil, &submissionError{Status: http.StatusBadRequest, Code: "consent_required", Message: "consent.acceptedAt debe ser una fecha válida"}
+	}
+
+	record := &consentRecord{
+		PolicyVersion: version,
+		AcceptedAt:    consent.AcceptedAt.UTC(),
+		ReceivedAt:    consent.ReceivedAt.UTC(),
+	}
+	if consent.ReceivedAt.IsZero() {
+		record.ReceivedAt = now.UTC()
+	}
+	return record, nil
+}
+
+// logConsent deja la constancia en el log de auditoría junto con el issue
+// creado, que es la prueba que Legal pide conservar.
+func logConsent(ctx context.Context, issueNumber int, consent *consentRecord) {
+	logger := loggerFromContext(ctx)
+	if logger == nil || consent == nil {
+		return
+	}
+	logger.logWithEntry(ctx, "consent", severityInfo, fmt.Sprintf("issue #%d creado con consentimiento al aviso %s", issueNumber, consent.PolicyVersion), logEntry{Consent: consent})
+}
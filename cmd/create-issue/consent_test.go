@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eos-roadmap-tools/internal/errcodes"
+)
+
+// validConsent devuelve el consentimiento que enviaría el formulario web con
+// el aviso vigente.
+func validConsent() *consentRecord {
+	return &consentRecord{PolicyVersion: privacyPolicyVersion, AcceptedAt: time.Now().Add(-time.Minute)}
+}
+
+// consentJSON es validConsent serializado para las pruebas que arman el
+// cuerpo HTTP a mano.
+func consentJSON() string {
+	return fmt.Sprintf(`"consent":{"policyVersion":%q,"acceptedAt":%q}`, privacyPolicyVersion, time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+}
+
+func TestValidateConsent(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		consent  *consentRecord
+		wantCode errcodes.Code
+	}{
+		{"sin consentimiento", nil, "consent_required"},
+		{"sin versión", &consentRecord{AcceptedAt: now}, "consent_required"},
+		{"versión vieja", &consentRecord{PolicyVersion: "2020-01", AcceptedAt: now}, "consent_outdated"},
+		{"sin fecha", &consentRecord{PolicyVersion: privacyPolicyVersion}, "consent_required"},
+		{"fecha futura", &consentRecord{PolicyVersion: privacyPolicyVersion, AcceptedAt: now.Add(time.Hour)}, "consent_required"},
+		{"válido", &consentRecord{PolicyVersion: privacyPolicyVersion, AcceptedAt: now.Add(-time.Minute)}, ""},
+		{"versión vieja ya encolada", &consentRecord{PolicyVersion: "2020-01", AcceptedAt: now.Add(-time.Hour), ReceivedAt: now.Add(-time.Hour)}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, subErr := validateConsent(tc.consent, now)
+			if tc.wantCode != "" {
+				if subErr == nil || subErr.Code != tc.wantCode || subErr.Status != http.StatusBadRequest {
+					t.Fatalf("se esperaba %s, llegó %v", tc.wantCode, subErr)
+				}
+				return
+			}
+			if subErr != nil {
+				t.Fatalf("error inesperado: %v", subErr)
+			}
+			if got.ReceivedAt.IsZero() {
+				t.Fatalf("ReceivedAt debe quedar fijado")
+			}
+			if !tc.consent.ReceivedAt.IsZero() && !got.ReceivedAt.Equal(tc.consent.ReceivedAt) {
+				t.Fatalf("se debe conservar el ReceivedAt original, llegó %v", got.ReceivedAt)
+			}
+		})
+	}
+}
+
+func TestHandlePostRegistraConsentimiento(t *testing.T) {
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+
+	fakeBackend := &memoryLogBackend{}
+	created := 0
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = fakeBackend
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			created++
+			return &githubIssueResponse{Number: 7, HTMLURL: "https://example.com/issue/7", NodeID: "node-7"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handleRequest(rr, req)
+		return rr.Code
+	}
+
+	if status := post(`{"templateId":"blank","title":"Sin aviso","fields":{"descripcion":"x"}}`); status != http.StatusBadRequest {
+		t.Fatalf("sin consentimiento se esperaba 400, llegó %d", status)
+	}
+	if created != 0 {
+		t.Fatalf("no se debe crear el issue sin consentimiento")
+	}
+
+	// El cliente no puede fijar la fecha de recepción para saltarse la versión.
+	forged := `{"templateId":"blank","title":"Forjado","fields":{"descripcion":"x"},"consent":{"policyVersion":"2020-01","acceptedAt":"2026-01-01T00:00:00Z","receivedAt":"2026-01-01T00:00:00Z"}}`
+	if status := post(forged); status != http.StatusBadRequest {
+		t.Fatalf("con versión vieja se esperaba 400, llegó %d", status)
+	}
+
+	if status := post(`{"templateId":"blank","title":"Con aviso","fields":{"descripcion":"x"},` + consentJSON() + `}`); status != http.StatusOK {
+		t.Fatalf("se esperaba 200, llegó %d", status)
+	}
+	for _, entry := range fakeBackend.Entries() {
+		if entry.Stage == "consent" {
+			if entry.Consent == nil || entry.Consent.PolicyVersion != privacyPolicyVersion || entry.Consent.ReceivedAt.IsZero() {
+				t.Fatalf("constancia incompleta: %+v", entry.Consent)
+			}
+			return
+		}
+	}
+	t.Fatalf("no se registró la constancia de consentimiento")
+}
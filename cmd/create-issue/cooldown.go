@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// contentRejectionCooldown es cuánto esperamos antes de aceptar otro envío
+// del mismo origen y plantilla después de que GitHub rechazara el contenido.
+// Un 422 no se arregla reintentando: el mismo formulario fallará igual y solo
+// gasta cuota de la API.
+const contentRejectionCooldown = 2 * time.Minute
+
+// cooldownTracker recuerda, por origen y plantilla, hasta cuándo rechazamos
+// envíos tras un rechazo de contenido. Vive en memoria: tras un reinicio el
+// peor caso es un intento extra contra GitHub.
+type cooldownTracker struct {
+	duration time.Duration
+
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func newCooldownTracker(duration time.Duration) *cooldownTracker {
+	return &cooldownTracker{duration: duration, until: map[string]time.Time{}}
+}
+
+// cooldownKey agrupa por origen. Sin Origin (curl, un formulario HTML sin
+// CORS) usa la IP del cliente: si no, un solo cliente pausaría a todos los
+// que llegan sin encabezado.
+func cooldownKey(origin, clientIP, templateID string) string {
+	if origin == "" {
+		return "ip:" + clientIP + "\x00" + templateID
+	}
+	return "origin:" + origin + "\x00" + templateID
+}
+
+// Start abre (o extiende) la pausa para la clave indicada.
+func (c *cooldownTracker) Start(key string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.until[key] = now.Add(c.duration)
+	// Limpiamos las pausas vencidas aquí para que el mapa no crezca sin
+	// límite con orígenes que nunca vuelven.
+	for k, until := range c.until {
+		if !now.Before(until) {
+			delete(c.until, k)
+		}
+	}
+}
+
+// Remaining devuelve cuánto falta para que termine la pausa, o cero si no hay
+// una activa.
+func (c *cooldownTracker) Remaining(key string, now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until, ok := c.until[key]
+	if !ok || !now.Before(until) {
+		return 0
+	}
+	return until.Sub(now)
+}
+
+// checkCooldown rechaza con 429 y una guía para corregir el formulario si el
+// origen (o la IP, sin origen) tiene una pausa activa para la plantilla.
+func checkCooldown(w http.ResponseWriter, origin, clientIP, templateID string) *submissionError {
+	cooldowns := loadServiceDeps().Cooldowns
+	if cooldowns == nil {
+		return nil
+	}
+	remaining := cooldowns.Remaining(cooldownKey(origin, clientIP, templateID), time.Now())
+	if remaining <= 0 {
+		return nil
+	}
+	seconds := int(math.Ceil(remaining.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	return &submissionError{
+		Status:  http.StatusTooManyRequests,
+		Code:    "cooldown_active",
+		Message: fmt.Sprintf("GitHub rechazó hace poco un envío similar. Revisa el título, la longitud del texto y los campos antes de reintentar en %d segundos", seconds),
+	}
+}
+
+// startCooldown abre la pausa para el origen (o la IP) de la petición en
+// curso.
+func startCooldown(ctx context.Context, templateID string) {
+	cooldowns := loadServiceDeps().Cooldowns
+	if cooldowns == nil {
+		return
+	}
+	var origin, clientIP string
+	if logger := loggerFromContext(ctx); logger != nil {
+		origin, clientIP = logger.origin, logger.clientIP
+	}
+	cooldowns.Start(cooldownKey(origin, clientIP, templateID), time.Now())
+}
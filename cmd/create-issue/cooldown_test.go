@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eos-roadmap-tools/internal/errcodes"
+)
+
+func TestCooldownTrackerVence(t *testing.T) {
+	tracker := newCooldownTracker(time.Minute)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	key := cooldownKey("https://a.example", "203.0.113.1", "bug")
+
+	if got := tracker.Remaining(key, now); got != 0 {
+		t.Fatalf("sin rechazo no debe haber pausa, llegó %v", got)
+	}
+	tracker.Start(key, now)
+	if got := tracker.Remaining(key, now.Add(20*time.Second)); got != 40*time.Second {
+		t.Fatalf("pausa restante = %v, se esperaban 40s", got)
+	}
+	if got := tracker.Remaining(cooldownKey("https://a.example", "203.0.113.1", "feature"), now); got != 0 {
+		t.Fatalf("la pausa es por plantilla, llegó %v", got)
+	}
+	if got := tracker.Remaining(key, now.Add(time.Minute)); got != 0 {
+		t.Fatalf("la pausa debe vencer, llegó %v", got)
+	}
+}
+
+func TestHandlePostPausaTrasRechazoDeContenido(t *testing.T) {
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+
+	calls := 0
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Cooldowns = newCooldownTracker(time.Minute)
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			calls++
+			return nil, &errcodes.GitHubError{Status: http.StatusUnprocessableEntity, Detail: map[string]any{"message": "Validation Failed"}}
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	post := func(templateID, fields string) *httptest.ResponseRecorder {
+		body := `{"templateId":"` + templateID + `","title":"Largo","fields":` + fields + `,` + consentJSON() + `}`
+		req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "https://allowed.example")
+		rr := httptest.NewRecorder()
+		handleRequest(rr, req)
+		return rr
+	}
+
+	if rr := post("blank", `{"descripcion":"x"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("el rechazo de GitHub debe responder 422, llegó %d", rr.Code)
+	}
+
+	rr := post("blank", `{"descripcion":"x"}`)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("durante la pausa se esperaba 429, llegó %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("la pausa debe indicar Retry-After")
+	}
+	if !strings.Contains(rr.Body.String(), "cooldown_active") {
+		t.Fatalf("respuesta sin código cooldown_active: %s", rr.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("durante la pausa no se debe llamar a GitHub, hubo %d llamadas", calls)
+	}
+
+	// Otra plantilla del mismo origen no queda bloqueada.
+	post("feature", `{"descripcion":"x","criterio":"y"}`)
+	if calls != 2 {
+		t.Fatalf("la pausa no debe afectar otras plantillas, hubo %d llamadas", calls)
+	}
+}
+
+func TestCooldownSinOrigenPausaSoloLaIP(t *testing.T) {
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	calls := 0
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Cooldowns = newCooldownTracker(time.Minute)
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			calls++
+			return nil, &errcodes.GitHubError{Status: http.StatusUnprocessableEntity}
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	post := func(remoteAddr string) *httptest.ResponseRecorder {
+		body := `{"templateId":"blank","title":"x","fields":{"descripcion":"x"},` + consentJSON() + `}`
+		req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handleRequest(rr, req)
+		return rr
+	}
+
+	post("198.51.100.1:1234")
+	if rr := post("198.51.100.1:1234"); rr.Code != http.StatusTooManyRequests || calls != 1 {
+		t.Fatalf("la misma IP sin origen debe quedar en pausa: %d (%d llamadas)", rr.Code, calls)
+	}
+	if post("198.51.100.2:1234"); calls != 2 {
+		t.Fatalf("otra IP sin origen no debe quedar en pausa, hubo %d llamadas", calls)
+	}
+}
+
+func TestProcessQueuedSubmissionNoReintentaRechazoDeContenido(t *testing.T) {
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Cooldowns = newCooldownTracker(time.Minute)
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			return nil, &errcodes.GitHubError{Status: http.StatusUnprocessableEntity}
+		}
+	})
+
+	queue := newMemorySubmissionQueue(1)
+	job := submissionJob{ID: "job-422", Origin: "https://allowed.example", ClientIP: "203.0.113.1", TemplateID: "blank", Title: "x", Fields: map[string]string{"descripcion": "x"}, Consent: validConsent()}
+	if err := queue.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	item, err := queue.Receive(context.Background())
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	processQueuedSubmission(context.Background(), item, &memoryLogBackend{})
+
+	if len(queue.items) != 0 {
+		t.Fatal("un rechazo de contenido no debe volver a la cola")
+	}
+	if loadServiceDeps().Cooldowns.Remaining(cooldownKey(job.Origin, job.ClientIP, job.TemplateID), time.Now()) == 0 {
+		t.Fatal("el rechazo desde la cola también debe abrir la pausa del origen")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// processCPUTime no está disponible fuera de unix; el descarte por CPU queda
+// sin señal y solo vigila los demás límites.
+func processCPUTime() (time.Duration, error) {
+	return 0, errors.New("uso de CPU no disponible en esta plataforma")
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"eos-roadmap-tools/internal/errcodes"
+)
+
+// possibleDuplicateLabel marca ambos issues para que triage los encuentre
+// con un solo filtro y decida cuál conservar.
+const possibleDuplicateLabel = "possible-duplicate"
+
+// duplicateCommentMarker permite reconocer los comentarios del servicio si
+// más adelante hay que actualizarlos o contarlos.
+const duplicateCommentMarker = "<!-- create-issue:possible-duplicate -->"
+
+// errDuplicateIsPullRequest indica que duplicateOf apunta a un pull request;
+// no tiene sentido sugerir fusionar un reporte con un PR.
+var errDuplicateIsPullRequest = errors.New("duplicateOf apunta a un pull request")
+
+// validateDuplicateOf revisa el número que manda el cliente cuando la persona
+// vio un posible duplicado y decidió enviar de todos modos. Cero significa que
+// no hubo aviso.
+func validateDuplicateOf(number int) *submissionError {
+	if number < 0 {
+		return &submissionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "duplicateOf debe ser el número de un issue"}
+	}
+	return nil
+}
+
+// duplicateComment arma el comentario que se deja en cada issue. self es el
+// issue donde se publica y other el que se sugiere revisar.
+func duplicateComment(self, other, reported int) string {
+	var b strings.Builder
+	b.WriteString(duplicateCommentMarker + "\n")
+	if self == reported {
+		fmt.Fprintf(&b, "**Posible duplicado de #%d.** Quien reportó vio el aviso de un issue parecido y decidió enviar de todos modos.\n\n", other)
+	} else {
+		fmt.Fprintf(&b, "**Posible duplicado: #%d.** Se reportó después de mostrar este issue como parecido.\n\n", other)
+	}
+	fmt.Fprintf(&b, "Para fusionarlos, conserva el más completo, copia lo que falte y cierra el otro como duplicado (`Duplicate of #N`). Luego quita la etiqueta `%s` de ambos.", possibleDuplicateLabel)
+	return b.String()
+}
+
+// linkPossibleDuplicate comenta en ambos issues con la referencia cruzada y
+// les aplica possibleDuplicateLabel. Primero confirma que el original existe
+// y no es un pull request: duplicateOf llega del cliente.
+func linkPossibleDuplicate(ctx context.Context, issueNumber, originalNumber int) error {
+	var original struct {
+		PullRequest json.RawMessage `json:"pull_request"`
+	}
+	if err := githubIssuesREST(ctx, http.MethodGet, fmt.Sprintf("/%d", originalNumber), nil, http.StatusOK, &original); err != nil {
+		return fmt.Errorf("consultar #%d: %w", originalNumber, err)
+	}
+	if len(original.PullRequest) > 0 && string(original.PullRequest) != "null" {
+		return errDuplicateIsPullRequest
+	}
+
+	for _, pair := range [][2]int{{issueNumber, originalNumber}, {originalNumber, issueNumber}} {
+		comment := map[string]string{"body": duplicateComment(pair[0], pair[1], issueNumber)}
+		if err := githubIssuesREST(ctx, http.MethodPost, fmt.Sprintf("/%d/comments", pair[0]), comment, http.StatusCreated, nil); err != nil {
+			return fmt.Errorf("comentar en #%d: %w", pair[0], err)
+		}
+		labels := map[string][]string{"labels": {possibleDuplicateLabel}}
+		if err := githubIssuesREST(ctx, http.MethodPost, fmt.Sprintf("/%d/labels", pair[0]), labels, http.StatusOK, nil); err != nil {
+			return fmt.Errorf("etiquetar #%d: %w", pair[0], err)
+		}
+	}
+	return nil
+}
+
+// githubIssuesREST llama a /repos/{owner}/{repo}/issues{path} del destino
+// del envío con el mismo cliente que createIssue y decodifica la respuesta en out si no es nil.
+func githubIssuesREST(ctx context.Context, method, path string, payload any, wantStatus int, out any) error {
+	var body io.Reader
+	if payload != nil {
+		buf, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	target := issueTargetFromContext(ctx)
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues%s", target.Owner, target.Repo, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	client := &http.Client{Timeout: 15 * time.Second, Transport: &githubAuthTransport{base: &outboundLoggingTransport{}}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		return &errcodes.GitHubError{Status: resp.StatusCode}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// linkDuplicate aplica la sugerencia de fusión tras crear el issue. Como el
+// área del módulo, es un complemento: si falla, el issue ya existe y solo
+// dejamos registro para que triage lo enlace a mano.
+func linkDuplicate(ctx context.Context, deps *serviceDeps, p *preparedSubmission, issue *githubIssueResponse) {
+	if p.DuplicateOf == 0 || p.DuplicateOf == issue.Number || deps.DuplicateLinker == nil {
+		return
+	}
+	logger := loggerFromContext(ctx)
+	if err := deps.DuplicateLinker(ctx, issue.Number, p.DuplicateOf); err != nil {
+		if logger != nil {
+			logger.log(ctx, "duplicate_link", severityError, fmt.Sprintf("issue #%d: no se pudo enlazar el posible duplicado #%d: %v", issue.Number, p.DuplicateOf, err))
+		}
+		return
+	}
+	if logger != nil {
+		logger.log(ctx, "duplicate_link", severityInfo, fmt.Sprintf("issue #%d marcado como posible duplicado de #%d", issue.Number, p.DuplicateOf))
+	}
+}
+
+// Búsqueda de duplicados antes de crear el issue. Se buscan issues abiertos
+// con alguna palabra del título y se ordenan por cuántas palabras comparten;
+// solo los que superan duplicateMinScore se ofrecen a la persona.
+const (
+	duplicateMinScore       = 0.5
+	maxDuplicateCandidates  = 3
+	maxDuplicateSearchTerms = 6 // la búsqueda de GitHub admite cinco OR
+	duplicateSearchPageSize = 20
+)
+
+// duplicateStopwords son palabras tan comunes en los títulos que, sin
+// filtrarlas, cualquier par de reportes parecería duplicado.
+var duplicateStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "not": true,
+	"las": true, "los": true, "del": true, "con": true, "por": true, "para": true,
+	"una": true, "uno": true, "que": true, "sin": true, "como": true, "cuando": true,
+	"pero": true, "mas": true, "muy": true, "hay": true, "esta": true, "este": true,
+	"error": true, "falla": true, "problema": true, "bug": true,
+}
+
+var duplicateAccents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+
+// duplicateCandidate es un issue abierto parecido al que se quiere crear.
+type duplicateCandidate struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+// titleTerms normaliza un título a sus palabras significativas, sin
+// repetir y en orden de aparición.
+func titleTerms(title string) []string {
+	normalized := duplicateAccents.Replace(strings.ToLower(title))
+	words := strings.FieldsFunc(normalized, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	seen := map[string]bool{}
+	var terms []string
+	for _, word := range words {
+		if len(word) < 3 || duplicateStopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// titleSimilarity es la proporción de palabras compartidas (Jaccard).
+func titleSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inA := map[string]bool{}
+	for _, term := range a {
+		inA[term] = true
+	}
+	shared := 0
+	for _, term := range b {
+		if inA[term] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// duplicateSearchQuery arma la consulta de la API de búsqueda. Devuelve ""
+// si el título no tiene palabras significativas.
+func duplicateSearchQuery(title string, target issueTarget) string {
+	terms := titleTerms(title)
+	if len(terms) == 0 {
+		return ""
+	}
+	if len(terms) > maxDuplicateSearchTerms {
+		terms = terms[:maxDuplicateSearchTerms]
+	}
+	return fmt.Sprintf("%s repo:%s/%s is:issue is:open in:title", strings.Join(terms, " OR "), target.Owner, target.Repo)
+}
+
+// searchOpenIssues consulta la API de búsqueda de GitHub con el mismo token
+// que los envíos. Es el DuplicateSearcher de producción.
+func searchOpenIssues(ctx context.Context, title string) ([]duplicateCandidate, error) {
+	query := duplicateSearchQuery(title, issueTargetFromContext(ctx))
+	if query == "" {
+		return nil, nil
+	}
+	endpoint := fmt.Sprintf("https://api.github.com/search/issues?q=%s&per_page=%d", url.QueryEscape(query), duplicateSearchPageSize)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", userAgent)
+
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &githubAuthTransport{base: &outboundLoggingTransport{}}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &errcodes.GitHubError{Status: resp.StatusCode}
+	}
+	var result struct {
+		Items []struct {
+			Number  int    `json:"number"`
+			Title   string `json:"title"`
+			HTMLURL string `json:"html_url"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	found := make([]duplicateCandidate, 0, len(result.Items))
+	for _, item := range result.Items {
+		found = append(found, duplicateCandidate{Number: item.Number, Title: item.Title, URL: item.HTMLURL})
+	}
+	return found, nil
+}
+
+// rankDuplicates deja los candidatos suficientemente parecidos, del más
+// al menos similar.
+func rankDuplicates(title string, found []duplicateCandidate) []duplicateCandidate {
+	terms := titleTerms(title)
+	type scored struct {
+		candidate duplicateCandidate
+		score     float64
+	}
+	var ranked []scored
+	for _, candidate := range found {
+		if score := titleSimilarity(terms, titleTerms(candidate.Title)); score >= duplicateMinScore {
+			ranked = append(ranked, scored{candidate, score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	var candidates []duplicateCandidate
+	for i := 0; i < len(ranked) && i < maxDuplicateCandidates; i++ {
+		candidates = append(candidates, ranked[i].candidate)
+	}
+	return candidates
+}
+
+// offerDuplicates frena el envío si hay issues abiertos parecidos y los
+// devuelve con 409 possible_duplicates para que la interfaz pregunte "¿es
+// uno de estos?". Con force, o con duplicateOf (la persona ya vio el aviso),
+// no se busca. Si la búsqueda falla el envío sigue: un duplicado cuesta menos
+// que un reporte perdido. Devuelve true si ya respondió.
+func offerDuplicates(ctx context.Context, w http.ResponseWriter, req issueRequest, p *preparedSubmission) bool {
+	search := loadServiceDeps().DuplicateSearcher
+	if search == nil || req.Force || req.DuplicateOf != 0 {
+		return false
+	}
+	// Se busca donde se crearía el issue: con TEMPLATE_TARGETS puede ser
+	// otro repositorio.
+	ctx = withIssueTarget(ctx, targetForTemplate(p.TemplateID))
+	found, err := search(ctx, p.Title)
+	if err != nil {
+		logErrorWithFallback(ctx, "duplicate_search_error", "no se pudieron buscar duplicados; el envío sigue", err)
+		return false
+	}
+	candidates := rankDuplicates(p.Title, found)
+	if len(candidates) == 0 {
+		return false
+	}
+	writeResponse(ctx, w, http.StatusConflict, issueResponse{
+		Error: &apiError{
+			Code:    "possible_duplicates",
+			Message: "Hay issues abiertos parecidos; revisa si tu reporte es uno de ellos",
+		},
+		Duplicates: candidates,
+	})
+	return true
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"eos-roadmap-tools/internal/errcodes"
+)
+
+func TestDuplicateCommentCruzaReferencias(t *testing.T) {
+	reported := duplicateComment(12, 5, 12)
+	original := duplicateComment(5, 12, 12)
+	if !strings.HasPrefix(reported, duplicateCommentMarker) || !strings.Contains(reported, "Posible duplicado de #5") {
+		t.Fatalf("comentario del issue nuevo inesperado:\n%s", reported)
+	}
+	if !strings.Contains(original, "Posible duplicado: #12") || !strings.Contains(original, possibleDuplicateLabel) {
+		t.Fatalf("comentario del original inesperado:\n%s", original)
+	}
+}
+
+func TestPrepareSubmissionValidaDuplicateOf(t *testing.T) {
+	req := issueRequest{TemplateID: "blank", Title: "Falla", Fields: map[string]string{"descripcion": "x"}, Consent: validConsent(), DuplicateOf: 5}
+	prepared, subErr := prepareSubmission(context.Background(), req)
+	if subErr != nil || prepared.DuplicateOf != 5 {
+		t.Fatalf("prepareSubmission: %+v / %v", prepared, subErr)
+	}
+	req.DuplicateOf = -1
+	if _, subErr := prepareSubmission(context.Background(), req); subErr == nil || subErr.Code != "invalid_request" {
+		t.Fatalf("un duplicateOf negativo debe rechazarse, got %v", subErr)
+	}
+
+	form := url.Values{"templateId": {"blank"}, "duplicateOf": {"cinco"}}
+	if got := issueRequestFromForm(form, time.Now()).DuplicateOf; got != -1 {
+		t.Fatalf("un duplicateOf no numérico del formulario debe marcarse inválido, got %d", got)
+	}
+	if fields := issueRequestFromForm(url.Values{"duplicateOf": {"5"}}, time.Now()).Fields; len(fields) != 0 {
+		t.Fatalf("duplicateOf no es un campo de la plantilla: %v", fields)
+	}
+}
+
+func TestSubmitPreparedEnlazaPosibleDuplicado(t *testing.T) {
+	var linked [2]int
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			return &githubIssueResponse{Number: 12, HTMLURL: "https://example.com/issues/12", NodeID: "node-12"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+		deps.DuplicateLinker = func(_ context.Context, issue, original int) error {
+			linked = [2]int{issue, original}
+			return errors.New("sin permisos para etiquetar")
+		}
+	})
+
+	prepared := &preparedSubmission{TemplateID: "blank", Template: templates["blank"], Title: "x", Body: "y", DuplicateOf: 5}
+	resp, subErr := submitPrepared(context.Background(), prepared)
+	if subErr != nil || resp.Error != nil {
+		t.Fatalf("un fallo al enlazar el duplicado no debe afectar la respuesta, got (%+v, %+v)", resp, subErr)
+	}
+	if linked != [2]int{12, 5} {
+		t.Fatalf("DuplicateLinker recibió %v", linked)
+	}
+
+	linked = [2]int{}
+	prepared.DuplicateOf = 0
+	if _, subErr := submitPrepared(context.Background(), prepared); subErr != nil || linked != [2]int{} {
+		t.Fatalf("sin duplicateOf no debe enlazarse nada, got %v", linked)
+	}
+}
+
+func TestRankDuplicatesOrdenaPorPalabrasCompartidas(t *testing.T) {
+	found := []duplicateCandidate{
+		{Number: 1, Title: "El mapa no carga en Safari"},
+		{Number: 2, Title: "Exportar a CSV"},
+		{Number: 3, Title: "Mapa no carga"},
+		{Number: 4, Title: "El mapa no carga en Safari ni en Firefox"},
+	}
+	got := rankDuplicates("Error: el mapa no carga en Safari", found)
+	if len(got) != 3 || got[0].Number != 1 || got[1].Number != 4 || got[2].Number != 3 {
+		t.Fatalf("orden inesperado: %+v", got)
+	}
+	if got := rankDuplicates("Agregar modo oscuro", found); len(got) != 0 {
+		t.Fatalf("un título sin palabras en común no tiene duplicados: %+v", got)
+	}
+}
+
+func TestDuplicateSearchQuery(t *testing.T) {
+	query := duplicateSearchQuery("¿Por qué la Exportación falla con archivos grandes?", issueTarget{Owner: "org", Repo: "ideas"})
+	want := "exportacion OR archivos OR grandes repo:org/ideas is:issue is:open in:title"
+	if query != want {
+		t.Fatalf("consulta inesperada:\n got %q\nwant %q", query, want)
+	}
+	if got := duplicateSearchQuery("¡Un bug!", defaultIssueTarget()); got != "" {
+		t.Fatalf("sin palabras significativas no se busca, got %q", got)
+	}
+}
+
+func TestHandleRequestOfreceDuplicados(t *testing.T) {
+	created := 0
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.DuplicateSearcher = func(context.Context, string) ([]duplicateCandidate, error) {
+			return []duplicateCandidate{{Number: 8, Title: "El mapa no carga", URL: "https://example.com/issues/8"}}, nil
+		}
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			created++
+			return &githubIssueResponse{Number: 9, HTMLURL: "https://example.com/issues/9", NodeID: "node"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+		deps.DuplicateLinker = func(context.Context, int, int) error { return nil }
+	})
+
+	post := func(extra string) *httptest.ResponseRecorder {
+		body := `{"templateId":"blank","title":"El mapa no carga","fields":{"descripcion":"y"},` + extra + consentJSON() + `}`
+		rr := httptest.NewRecorder()
+		handleRequest(rr, httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body)))
+		return rr
+	}
+
+	rr := post("")
+	var resp issueResponse
+	_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+	if rr.Code != http.StatusConflict || resp.Error == nil || resp.Error.Code != "possible_duplicates" || len(resp.Duplicates) != 1 || resp.Duplicates[0].Number != 8 || created != 0 {
+		t.Fatalf("se esperaba 409 con el candidato: %d %s", rr.Code, rr.Body.String())
+	}
+	for _, extra := range []string{`"force":true,`, `"duplicateOf":8,`} {
+		if rr := post(extra); rr.Code != http.StatusOK {
+			t.Fatalf("%s debe crear el issue: %d %s", extra, rr.Code, rr.Body.String())
+		}
+	}
+	if created != 2 {
+		t.Fatalf("se esperaban dos issues creados, got %d", created)
+	}
+}
+
+func TestBusquedaDeDuplicadosCaidaDejaPasar(t *testing.T) {
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = &memoryLogBackend{}
+		deps.DuplicateSearcher = func(context.Context, string) ([]duplicateCandidate, error) {
+			return nil, &errcodes.GitHubError{Status: http.StatusForbidden}
+		}
+	})
+	rr := httptest.NewRecorder()
+	p := &preparedSubmission{Title: "El mapa no carga"}
+	if offerDuplicates(context.Background(), rr, issueRequest{}, p) {
+		t.Fatalf("una búsqueda fallida no debe frenar el envío: %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,184 @@
+//go:build e2e
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// La suite e2e corre el servicio real contra un repositorio y un Project de
+// sandbox: crea issues de verdad, los verifica en GitHub y los borra. No se
+// compila sin el tag:
+//
+//	E2E_GITHUB_TOKEN=... E2E_REPO=dueño/sandbox E2E_PROJECT_ID=PVT_... \
+//	  go test -tags e2e -run E2E -count=1 ./cmd/create-issue
+//
+// El token necesita permiso de escritura en issues del sandbox, de edición
+// en su Project y de administración para poder borrar lo que crea.
+
+// e2eTitlePrefix marca los issues de la suite para reconocerlos si la
+// limpieza falla y hay que borrarlos a mano.
+const e2eTitlePrefix = "[e2e] "
+
+type e2eSandbox struct {
+	server *httptest.Server
+	runID  string
+}
+
+// newE2ESandbox apunta el servicio al sandbox y lo levanta detrás de un
+// servidor HTTP. Falta de configuración falla en lugar de saltar la suite:
+// un gate de release que no corrió no puede quedar en verde.
+func newE2ESandbox(t *testing.T) *e2eSandbox {
+	t.Helper()
+	token := strings.TrimSpace(os.Getenv("E2E_GITHUB_TOKEN"))
+	repo := strings.TrimSpace(os.Getenv("E2E_REPO"))
+	project := strings.TrimSpace(os.Getenv("E2E_PROJECT_ID"))
+	if token == "" || repo == "" || project == "" {
+		t.Fatal("la suite e2e requiere E2E_GITHUB_TOKEN, E2E_REPO y E2E_PROJECT_ID")
+	}
+	if strings.EqualFold(repo, defaultGitHubRepo) {
+		t.Fatalf("E2E_REPO no puede ser el repositorio de producción %s", defaultGitHubRepo)
+	}
+
+	previousOwner, previousName, previousProject := githubRepoOwner, githubRepoName, projectID
+	githubRepoOwner, githubRepoName = splitGitHubRepo(repo)
+	projectID = project
+	t.Cleanup(func() { githubRepoOwner, githubRepoName, projectID = previousOwner, previousName, previousProject })
+
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.GitHubTokens = newTokenPool([]string{token}, time.Now)
+		deps.Incidents = nil
+		deps.Screener = nil
+		deps.LoadShedder = nil
+		deps.Cooldowns = newCooldownTracker(contentRejectionCooldown)
+	})
+
+	sandbox := &e2eSandbox{server: httptest.NewServer(http.HandlerFunc(handleRequest)), runID: time.Now().UTC().Format("20060102T150405Z")}
+	t.Cleanup(sandbox.server.Close)
+	return sandbox
+}
+
+// submit envía un bug por HTTP como el frontend y registra la limpieza del
+// issue creado, que corre aunque las verificaciones fallen.
+func (s *e2eSandbox) submit(t *testing.T, title string, extra string) (issueResponse, int) {
+	t.Helper()
+	body := fmt.Sprintf(`{"templateId":"bug","title":%q,"fields":{"summary":"Generado por la suite e2e","steps":"1. Correr la suite","expected":"Que pase","actual":"Se verifica"},%s%s}`,
+		e2eTitlePrefix+title+" "+s.runID, consentJSON(), extra)
+	resp, err := http.Post(s.server.URL+"/", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	defer resp.Body.Close()
+	var out issueResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("respuesta ilegible (%d): %v", resp.StatusCode, err)
+	}
+	if out.IssueURL == "" {
+		t.Fatalf("el envío no creó el issue (%d): %+v", resp.StatusCode, out.Error)
+	}
+	number, err := strconv.Atoi(path.Base(out.IssueURL))
+	if err != nil {
+		t.Fatalf("URL de issue inesperada %q", out.IssueURL)
+	}
+	t.Cleanup(func() { cleanupE2EIssue(t, out.IssueURL) })
+	if resp.StatusCode != http.StatusCreated || out.Error != nil {
+		t.Fatalf("estado %d con error %+v", resp.StatusCode, out.Error)
+	}
+	return out, number
+}
+
+// cleanupE2EIssue reutiliza la limpieza de la sonda: cierra y borra.
+func cleanupE2EIssue(t *testing.T, issueURL string) {
+	ctx := context.Background()
+	issue, err := inspectProbeIssue(ctx, issueURL)
+	if err == nil && issue.NodeID != "" {
+		err = deleteProbeIssue(ctx, issue.NodeID)
+	}
+	if err != nil {
+		t.Errorf("no se pudo limpiar %s; bórralo a mano: %v", issueURL, err)
+	}
+}
+
+// waitForProject espera a que el issue aparezca en el Project con el Tipo
+// esperado, con el mismo margen que la sonda.
+func waitForProject(t *testing.T, issueURL, wantTipo string) {
+	t.Helper()
+	var last string
+	for attempt := 1; attempt <= probeVerifyAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(probeVerifyDelay)
+		}
+		issue, err := inspectProbeIssue(context.Background(), issueURL)
+		switch {
+		case err != nil:
+			last = err.Error()
+		case !issue.InProject:
+			last = "el issue no aparece en el Project"
+		case issue.ProjectTipo != wantTipo:
+			last = fmt.Sprintf("Tipo en el Project = %q, se esperaba %q", issue.ProjectTipo, wantTipo)
+		default:
+			return
+		}
+	}
+	t.Fatalf("%s: %s", issueURL, last)
+}
+
+type e2eIssue struct {
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Comments int `json:"comments"`
+}
+
+func fetchE2EIssue(t *testing.T, number int) e2eIssue {
+	t.Helper()
+	var issue e2eIssue
+	if err := githubIssuesREST(context.Background(), http.MethodGet, fmt.Sprintf("/%d", number), nil, http.StatusOK, &issue); err != nil {
+		t.Fatalf("consultar #%d: %v", number, err)
+	}
+	return issue
+}
+
+func (issue e2eIssue) hasLabel(name string) bool {
+	for _, label := range issue.Labels {
+		if label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestE2ECreaIssueEnElProjectConSuTipo(t *testing.T) {
+	sandbox := newE2ESandbox(t)
+	resp, number := sandbox.submit(t, "alta en Project", "")
+	waitForProject(t, resp.IssueURL, templateTypeToFieldValue("bug"))
+	issue := fetchE2EIssue(t, number)
+	for _, label := range templates["bug"].Labels {
+		if !issue.hasLabel(label) {
+			t.Errorf("falta la etiqueta %q de la plantilla: %+v", label, issue.Labels)
+		}
+	}
+}
+
+func TestE2EEnlazaPosibleDuplicado(t *testing.T) {
+	sandbox := newE2ESandbox(t)
+	_, original := sandbox.submit(t, "original", "")
+	_, duplicate := sandbox.submit(t, "duplicado", fmt.Sprintf(`,"duplicateOf":%d`, original))
+
+	for _, number := range []int{original, duplicate} {
+		issue := fetchE2EIssue(t, number)
+		if !issue.hasLabel(possibleDuplicateLabel) || issue.Comments == 0 {
+			t.Errorf("#%d debe tener la etiqueta %s y el comentario cruzado: %+v", number, possibleDuplicateLabel, issue)
+		}
+	}
+}
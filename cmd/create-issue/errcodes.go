@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"eos-roadmap-tools/internal/errcodes"
+)
+
+type localeKey struct{}
+
+// withLocale guarda el idioma negociado para la petición.
+func withLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// localeFromContext devuelve el idioma de la petición o errcodes.DefaultLocale.
+func localeFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return errcodes.DefaultLocale
+}
+
+// localize traduce el mensaje y completa el hint del error al idioma dado,
+// con el español como respaldo.
+func (e *apiError) localize(locale string) {
+	if message, ok := errcodes.Message(locale, e.Code); ok {
+		e.Message = message
+	}
+	if e.Hint == "" {
+		e.Hint = errcodes.Hint(locale, e.Code)
+	}
+}
+
+// githubErrorResponses da, por código, el estado HTTP y el mensaje con que
+// respondemos una falla de GitHub. El estado decide también si la cola
+// reintenta: 5xx sí (GitHub o la configuración pueden recuperarse), 422 no.
+var githubErrorResponses = map[errcodes.Code]struct {
+	status  int
+	message string
+}{
+	errcodes.GitHubRateLimited:     {http.StatusServiceUnavailable, "GitHub limitó temporalmente las solicitudes"},
+	errcodes.GitHubAuth:            {http.StatusServiceUnavailable, "El servicio no pudo autenticarse con GitHub"},
+	errcodes.GitHubForbidden:       {http.StatusBadGateway, "El servicio no tiene permiso para crear issues"},
+	errcodes.GitHubNotFound:        {http.StatusBadGateway, "No se encontró el repositorio de destino"},
+	errcodes.GitHubIssuesDisabled:  {http.StatusBadGateway, "Los issues están deshabilitados en el repositorio de destino"},
+	errcodes.GitHubRejectedContent: {http.StatusUnprocessableEntity, "GitHub rechazó el contenido del issue; revisa el título, la longitud del texto y los campos"},
+	errcodes.GitHubUnavailable:     {http.StatusBadGateway, "GitHub no está disponible en este momento"},
+	errcodes.GitHubIssueError:      {http.StatusBadGateway, "No se pudo crear el issue en GitHub"},
+}
+
+// classifyGitHubError traduce la falla al crear el issue, por REST o por
+// GraphQL, en un error de envío con código estable.
+func classifyGitHubError(err error) *submissionError {
+	code := errcodes.Classify(err)
+	response := githubErrorResponses[code]
+	return &submissionError{Status: response.status, Code: code, Message: response.message, Cause: err}
+}
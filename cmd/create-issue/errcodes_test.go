@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"eos-roadmap-tools/internal/errcodes"
+	"github.com/shurcooL/githubv4"
+)
+
+func TestClassifyGitHubError(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		code      errcodes.Code
+		retryable bool
+	}{
+		{"red", errors.New("connection reset"), errcodes.GitHubIssueError, true},
+		{"token revocado", &errcodes.GitHubError{Status: http.StatusUnauthorized}, errcodes.GitHubAuth, true},
+		{"sin permisos", &errcodes.GitHubError{Status: http.StatusForbidden}, errcodes.GitHubForbidden, true},
+		{"cuota", &errcodes.GitHubError{Status: http.StatusForbidden, RateLimited: true}, errcodes.GitHubRateLimited, true},
+		{"repo inexistente", &errcodes.GitHubError{Status: http.StatusNotFound}, errcodes.GitHubNotFound, true},
+		{"issues deshabilitados", &errcodes.GitHubError{Status: http.StatusGone}, errcodes.GitHubIssuesDisabled, true},
+		{"contenido", &errcodes.GitHubError{Status: http.StatusUnprocessableEntity}, errcodes.GitHubRejectedContent, false},
+		{"caída", &errcodes.GitHubError{Status: http.StatusServiceUnavailable}, errcodes.GitHubUnavailable, true},
+		{"GraphQL sin permisos", &errcodes.GitHubError{Type: errcodes.GraphQLForbidden}, errcodes.GitHubForbidden, true},
+		{"GraphQL cuota", &errcodes.GitHubError{Type: errcodes.GraphQLRateLimited, RateLimited: true}, errcodes.GitHubRateLimited, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := classifyGitHubError(tc.err)
+			if got.Code != tc.code || got.retryable() != tc.retryable {
+				t.Fatalf("código/reintentable = %s/%v; se esperaba %s/%v", got.Code, got.retryable(), tc.code, tc.retryable)
+			}
+			if !errcodes.Known(got.Code) {
+				t.Fatalf("el código %s no está en el catálogo", got.Code)
+			}
+		})
+	}
+}
+
+// TestErrorHintsCubreTodosLosCodigos recorre el código del servicio y exige
+// que cada código de error escrito a mano figure en el catálogo.
+func TestErrorHintsCubreTodosLosCodigos(t *testing.T) {
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`Code:\s*"([a-z_]+)"`),
+		regexp.MustCompile(`writeError\([^,]+,[^,]+,[^,]+,\s*"([a-z_]+)"`),
+	}
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile %s: %v", file, err)
+		}
+		for _, pattern := range patterns {
+			for _, match := range pattern.FindAllStringSubmatch(string(src), -1) {
+				if !errcodes.Known(errcodes.Code(match[1])) {
+					t.Errorf("%s usa el código %q, que no está en el catálogo", file, match[1])
+				}
+			}
+		}
+	}
+}
+
+func TestErrorSeTraduceSegunAcceptLanguage(t *testing.T) {
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	post := func(language string) (*httptest.ResponseRecorder, apiError) {
+		req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(`{"templateId":"no-existe"}`))
+		if language != "" {
+			req.Header.Set("Accept-Language", language)
+		}
+		rr := httptest.NewRecorder()
+		handleRequest(rr, req)
+		var resp issueResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp.Error == nil {
+			t.Fatalf("respuesta sin error: %s", rr.Body.String())
+		}
+		return rr, *resp.Error
+	}
+
+	rr, got := post("en-GB,en;q=0.9")
+	if message, _ := errcodes.Message("en", got.Code); got.Message != message || got.Hint != errcodes.Hint("en", got.Code) || rr.Header().Get("Content-Language") != "en" {
+		t.Fatalf("se esperaba el error en inglés: %+v (%q)", got, rr.Header().Get("Content-Language"))
+	}
+	rr, got = post("")
+	if got.Hint != errcodes.Hint("es", got.Code) || rr.Header().Get("Content-Language") != "es" {
+		t.Fatalf("sin Accept-Language se responde en español: %+v", got)
+	}
+}
+
+func TestGraphQLErrorTransportClasificaErrores(t *testing.T) {
+	cases := map[string]errcodes.Code{
+		`{"data":{"addProjectV2ItemById":null},"errors":[{"type":"FORBIDDEN","message":"Resource not accessible by integration"}]}`: errcodes.GitHubForbidden,
+		`{"data":{"addProjectV2ItemById":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a node"}]}`:            errcodes.GitHubNotFound,
+		`{"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`:                                                  errcodes.GitHubRateLimited,
+		`{"errors":[{"message":"Something went wrong"}]}`:                                                                           errcodes.GitHubIssueError,
+	}
+	for body, want := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		client := githubv4.NewEnterpriseClient(server.URL, &http.Client{Transport: &graphQLErrorTransport{base: http.DefaultTransport}})
+		var query struct {
+			Viewer struct{ Login string }
+		}
+		err := client.Query(context.Background(), &query, nil)
+		server.Close()
+		if got := classifyGitHubError(err); got.Code != want {
+			t.Errorf("%s: código = %s (%v); se esperaba %s", body, got.Code, err, want)
+		}
+	}
+}
+
+func TestSingleCallClasificaErroresGraphQL(t *testing.T) {
+	cases := map[string]errcodes.Code{
+		`{"data":{"createIssue":null},"errors":[{"type":"FORBIDDEN","message":"Resource not accessible by integration"}]}`: errcodes.GitHubForbidden,
+		`{"data":{"createIssue":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a node"}]}`:            errcodes.GitHubNotFound,
+		`{"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`:                                         errcodes.GitHubRateLimited,
+		`{"errors":[{"message":"Something went wrong"}]}`:                                                                  errcodes.GitHubIssueError,
+	}
+	for body, want := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		creator := &singleCallCreator{
+			client: func(context.Context) *githubv4.Client {
+				return githubv4.NewEnterpriseClient(server.URL, &http.Client{Transport: &graphQLErrorTransport{base: http.DefaultTransport}})
+			},
+			repos: map[string]*repositoryIDs{"o/r": {ID: "R_1", Labels: map[string]githubv4.ID{}}},
+		}
+		ctx := withIssueTarget(context.Background(), issueTarget{Owner: "o", Repo: "r", ProjectID: "P_1"})
+		_, err := creator.Create(ctx, "x", nil, "y")
+		server.Close()
+		if got := classifyGitHubError(err); got.Code != want {
+			t.Errorf("%s: código = %s (%v); se esperaba %s", body, got.Code, err, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Banderas conocidas. Cada una recorta un comportamiento que ya se activa por
+// configuración (SUBMISSION_QUEUE, EXTERNAL_TRACKERS, CAPTCHA_PROVIDER,
+// GITHUB_SINGLE_CALL): la bandera decide a qué parte del tráfico se aplica
+// mientras se gana confianza.
+const (
+	flagAsyncQueue        = "async_queue"
+	flagExternalTracker   = "external_tracker"
+	flagCaptcha           = "captcha"
+	flagGraphQLSingleCall = "graphql_single_call"
+)
+
+var knownFlags = map[string]struct{}{
+	flagAsyncQueue:        {},
+	flagExternalTracker:   {},
+	flagCaptcha:           {},
+	flagGraphQLSingleCall: {},
+}
+
+// flagRule activa una bandera para un porcentaje de las peticiones y, además,
+// para todas las que vengan de los orígenes listados (por ejemplo, staging).
+type flagRule struct {
+	Percent int      `json:"percent"`
+	Origins []string `json:"origins,omitempty"`
+}
+
+// featureFlags es la configuración de banderas. Una bandera que no aparece
+// queda activa: sin FEATURE_FLAGS el servicio se comporta como siempre.
+type featureFlags struct {
+	rules map[string]flagRule
+}
+
+// parseFeatureFlags lee {"async_queue": {"percent": 25, "origins": [...]}}.
+// Rechaza banderas desconocidas y porcentajes fuera de rango: una bandera mal
+// escrita que se ignora en silencio es justo el error que no queremos.
+func parseFeatureFlags(raw string) (*featureFlags, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	rules := map[string]flagRule{}
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("JSON de banderas inválido: %w", err)
+	}
+	for name, rule := range rules {
+		if _, ok := knownFlags[name]; !ok {
+			return nil, fmt.Errorf("bandera desconocida %q", name)
+		}
+		if rule.Percent < 0 || rule.Percent > 100 {
+			return nil, fmt.Errorf("bandera %q: percent debe estar entre 0 y 100", name)
+		}
+		for i, origin := range rule.Origins {
+			rule.Origins[i] = strings.TrimRight(strings.TrimSpace(origin), "/")
+		}
+	}
+	return &featureFlags{rules: rules}, nil
+}
+
+// flagBucket reparte las peticiones en 100 grupos de forma estable: la misma
+// clave cae siempre en el mismo grupo, así un envío encolado se procesa con
+// las mismas banderas con que se aceptó.
+func flagBucket(flag, key string) int {
+	sum := sha256.Sum256([]byte(flag + "\x00" + key))
+	return int(binary.BigEndian.Uint32(sum[:4]) % 100)
+}
+
+// evaluate devuelve el estado de cada bandera configurada para la petición.
+func (f *featureFlags) evaluate(key, origin string) map[string]bool {
+	if f == nil {
+		return nil
+	}
+	origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+	states := make(map[string]bool, len(f.rules))
+	for name, rule := range f.rules {
+		enabled := flagBucket(name, key) < rule.Percent
+		for _, allowed := range rule.Origins {
+			if origin != "" && strings.EqualFold(origin, allowed) {
+				enabled = true
+			}
+		}
+		states[name] = enabled
+	}
+	return states
+}
+
+type featureFlagsKey struct{}
+
+// withFeatureFlags evalúa las banderas una vez por petición, las guarda en el
+// contexto y las anota en el logger para que cada entrada del log diga con
+// qué banderas corrió.
+func withFeatureFlags(ctx context.Context, flags *featureFlags, key, origin string) context.Context {
+	states := flags.evaluate(key, origin)
+	if states == nil {
+		return ctx
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.flags = describeFlags(states)
+	}
+	return context.WithValue(ctx, featureFlagsKey{}, states)
+}
+
+// flagEnabled indica si la bandera está activa en esta petición. Sin
+// evaluación en el contexto (o sin regla para la bandera) responde true.
+func flagEnabled(ctx context.Context, name string) bool {
+	states, _ := ctx.Value(featureFlagsKey{}).(map[string]bool)
+	enabled, configured := states[name]
+	return !configured || enabled
+}
+
+// describeFlags da la forma que va al log: "async_queue=on".
+func describeFlags(states map[string]bool) []string {
+	out := make([]string, 0, len(states))
+	for name, enabled := range states {
+		state := "off"
+		if enabled {
+			state = "on"
+		}
+		out = append(out, name+"="+state)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// loadFeatureFlags lee FEATURE_FLAGS_FILE si está definido (se puede editar y
+// recargar con SIGHUP como ALLOWED_ORIGIN_FILE) o, si no, FEATURE_FLAGS.
+func loadFeatureFlags(getenv func(string) string, readFile func(string) ([]byte, error)) (*featureFlags, error) {
+	raw := getenv("FEATURE_FLAGS")
+	if path := strings.TrimSpace(getenv("FEATURE_FLAGS_FILE")); path != "" {
+		data, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer FEATURE_FLAGS_FILE %q: %w", path, err)
+		}
+		raw = string(data)
+	}
+	return parseFeatureFlags(raw)
+}
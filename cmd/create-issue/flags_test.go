@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	flags, err := parseFeatureFlags(`{"async_queue": {"percent": 10, "origins": ["https://staging.example/"]}}`)
+	if err != nil {
+		t.Fatalf("parseFeatureFlags: %v", err)
+	}
+	if rule := flags.rules[flagAsyncQueue]; rule.Percent != 10 || rule.Origins[0] != "https://staging.example" {
+		t.Fatalf("regla inesperada: %+v", rule)
+	}
+	for _, raw := range []string{`{"graphql": {"percent": 5}}`, `{"async_queue": {"percent": 150}}`, `{`} {
+		if _, err := parseFeatureFlags(raw); err == nil {
+			t.Errorf("%s debe rechazarse", raw)
+		}
+	}
+	if flags, err := parseFeatureFlags(" "); flags != nil || err != nil {
+		t.Fatalf("sin configuración no hay banderas: %v, %v", flags, err)
+	}
+}
+
+func TestFeatureFlagsPorcentajeYOrigen(t *testing.T) {
+	flags, _ := parseFeatureFlags(`{"async_queue": {"percent": 30, "origins": ["https://staging.example"]}}`)
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("req-%d", i)
+		states := flags.evaluate(key, "https://otro.example")
+		if states[flagAsyncQueue] != flags.evaluate(key, "https://otro.example")[flagAsyncQueue] {
+			t.Fatal("la misma clave debe evaluar siempre igual")
+		}
+		if states[flagAsyncQueue] {
+			enabled++
+		}
+		if !flags.evaluate(key, "https://staging.example")[flagAsyncQueue] {
+			t.Fatal("un origen de la lista siempre tiene la bandera activa")
+		}
+	}
+	if enabled < 230 || enabled > 370 {
+		t.Fatalf("con 30%% se esperaban ~300 de 1000 activas, llegaron %d", enabled)
+	}
+
+	if !flagEnabled(context.Background(), flagAsyncQueue) {
+		t.Fatal("sin evaluación la bandera debe quedar activa")
+	}
+}
+
+func TestHandleRequestRespetaBanderaDeCola(t *testing.T) {
+	flags, _ := parseFeatureFlags(`{"async_queue": {"percent": 0}}`)
+	cfg := newOriginConfig("*", "*")
+	cfg.Flags = flags
+	useServiceConfig(t, cfg)
+	logs := &memoryLogBackend{}
+	queue := newMemorySubmissionQueue(1)
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = logs
+		deps.SubmissionQueue = queue
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			return &githubIssueResponse{Number: 4, HTMLURL: "https://github.com/o/r/issues/4", NodeID: "I_4"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	body := fmt.Sprintf(`{"templateId":"blank","title":"x","fields":{"descripcion":"y"},%s}`, consentJSON())
+	req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("con la bandera apagada el envío debe ser síncrono, llegó %d: %s", rr.Code, rr.Body.String())
+	}
+	found := false
+	for _, entry := range logs.Entries() {
+		if strings.Join(entry.Flags, ",") == "async_queue=off" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("el log debe registrar el estado de las banderas")
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formPostPath recibe formularios HTML clásicos (sin JavaScript). Es una
+// "simple request": el navegador no envía preflight OPTIONS, así que sirve en
+// kioscos o páginas embebidas donde no se puede usar fetch con JSON.
+const formPostPath = "/form"
+
+// defaultFormConfirmationURL es la página pública que muestra el resultado.
+const defaultFormConfirmationURL = "https://ron-datadriven.github.io/eos-roadmap/enviado.html"
+
+// formConfirmationURL solo se toma de la configuración: nunca redirigimos a
+// una URL que venga en la solicitud, para no abrir un redirect arbitrario.
+var formConfirmationURL = envOrDefault("FORM_CONFIRMATION_URL", defaultFormConfirmationURL)
+
+// formReservedKeys son los nombres del formulario que no son campos de la
+// plantilla.
+var formReservedKeys = map[string]struct{}{
+	"templateId":        {},
+	"title":             {},
+	"moduleId":          {},
+	"consent":           {},
+	"duplicateOf":       {},
+	"a11yCriterion":     {},
+	"a11yAssistiveTech": {},
+	"a11ySeverity":      {},
+	"requestId":         {},
+	honeypotField:       {},
+	// Ver captchaFormFields.
+	"captchaToken":          {},
+	"cf-turnstile-response": {},
+	"h-captcha-response":    {},
+}
+
+// issueRequestFromForm arma la misma issueRequest que envía el frontend. El
+// checkbox de privacidad llega como consent=<versión>; sin JavaScript no hay
+// hora del cliente, así que la aceptación se fecha al recibirla.
+func issueRequestFromForm(form url.Values, now time.Time) issueRequest {
+	req := issueRequest{
+		TemplateID: strings.TrimSpace(form.Get("templateId")),
+		Title:      form.Get("title"),
+		ModuleID:   strings.TrimSpace(form.Get("moduleId")),
+		RequestID:  strings.TrimSpace(form.Get("requestId")),
+		// Sin JavaScript no hay cómo mostrar los parecidos y preguntar, así
+		// que el formulario clásico crea el issue directamente.
+		Force:    true,
+		Honeypot: form.Get(honeypotField),
+		Fields:   map[string]string{},
+	}
+	for _, key := range captchaFormFields {
+		if token := strings.TrimSpace(form.Get(key)); token != "" {
+			req.CaptchaToken = token
+			break
+		}
+	}
+	if raw := strings.TrimSpace(form.Get("duplicateOf")); raw != "" {
+		// Un valor que no es número se rechaza en prepareSubmission.
+		if req.DuplicateOf, _ = strconv.Atoi(raw); req.DuplicateOf <= 0 {
+			req.DuplicateOf = -1
+		}
+	}
+	if a11y := (accessibilityInfo{Criterion: form.Get("a11yCriterion"), AssistiveTech: form.Get("a11yAssistiveTech"), Severity: form.Get("a11ySeverity")}); a11y != (accessibilityInfo{}) {
+		req.Accessibility = &a11y
+	}
+	if version := strings.TrimSpace(form.Get("consent")); version != "" {
+		req.Consent = &consentRecord{PolicyVersion: version, AcceptedAt: now}
+	}
+	for key, values := range form {
+		if _, reserved := formReservedKeys[key]; reserved || len(values) == 0 {
+			continue
+		}
+		req.Fields[key] = values[0]
+	}
+	return req
+}
+
+// capturedResponse guarda lo que escribiría el flujo JSON para traducirlo a
+// una redirección. Así el formulario clásico reutiliza exactamente la misma
+// validación y los mismos códigos de error.
+type capturedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (c *capturedResponse) Header() http.Header         { return c.header }
+func (c *capturedResponse) Write(p []byte) (int, error) { return c.body.Write(p) }
+func (c *capturedResponse) WriteHeader(status int)      { c.status = status }
+
+// handleFormPost atiende POST /form con application/x-www-form-urlencoded (o
+// multipart/form-data si trae capturas) y responde 303 hacia
+// formConfirmationURL con el resultado en la query.
+func handleFormPost(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" && mediaType != "multipart/form-data" {
+		writeError(ctx, w, http.StatusUnsupportedMediaType, "unsupported_media_type", "El formulario debe enviarse como application/x-www-form-urlencoded o multipart/form-data", nil)
+		return
+	}
+
+	capture := &capturedResponse{header: http.Header{}}
+	limit := requestBodyLimit()
+	r.Body = http.MaxBytesReader(capture, r.Body, limit)
+	var err error
+	if mediaType == "multipart/form-data" {
+		// Todo queda en memoria: el límite del cuerpo ya acota el tamaño.
+		err = r.ParseMultipartForm(limit)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(ctx, capture, http.StatusRequestEntityTooLarge, "payload_too_large", fmt.Sprintf("El cuerpo de la solicitud supera el límite de %d bytes", limit), err)
+		} else {
+			writeError(ctx, capture, http.StatusBadRequest, "invalid_request", "Formulario inválido", err)
+		}
+	} else {
+		req := issueRequestFromForm(r.PostForm, time.Now())
+		if r.MultipartForm != nil {
+			req.Attachments, err = attachmentsFromMultipart(r.MultipartForm.File[attachmentFormField])
+		}
+		if err != nil {
+			writeError(ctx, capture, http.StatusBadRequest, "invalid_request", "No se pudieron leer los adjuntos", err)
+		} else {
+			submitIssueRequest(ctx, capture, r, req)
+		}
+	}
+
+	var resp issueResponse
+	if err := json.Unmarshal(capture.body.Bytes(), &resp); err != nil {
+		logErrorWithFallback(ctx, "form_redirect_error", "no se pudo interpretar la respuesta del envío", err)
+		resp.Error = &apiError{Code: "internal_error"}
+	}
+	http.Redirect(w, r, formConfirmationTarget(formConfirmationURL, resp), http.StatusSeeOther)
+}
+
+// formConfirmationTarget agrega el resultado a la página de confirmación:
+// estado=ok con el issue, estado=encolado con el ID del envío o estado=error
+// con el código y el debugId para soporte. Si el reporte coincide con un
+// incidente activo, incidente lleva el texto del aviso.
+func formConfirmationTarget(base string, resp issueResponse) string {
+	target, err := url.Parse(base)
+	if err != nil {
+		target, _ = url.Parse(defaultFormConfirmationURL)
+	}
+	query := target.Query()
+	switch {
+	case resp.Error != nil && resp.IssueURL == "":
+		query.Set("estado", "error")
+		query.Set("codigo", string(resp.Error.Code))
+	case resp.PendingReview:
+		query.Set("estado", "revision")
+		query.Set("envio", resp.SubmissionID)
+	case resp.SubmissionID != "":
+		query.Set("estado", "encolado")
+		query.Set("envio", resp.SubmissionID)
+	default:
+		query.Set("estado", "ok")
+		query.Set("issue", resp.IssueURL)
+	}
+	if resp.Incident != nil && query.Get("estado") != "error" {
+		query.Set("incidente", resp.Incident.Banner)
+	}
+	if resp.DebugID != "" {
+		query.Set("debug", resp.DebugID)
+	}
+	target.RawQuery = query.Encode()
+	return target.String()
+}
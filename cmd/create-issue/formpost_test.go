@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func postForm(t *testing.T, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "http://service.local"+formPostPath, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Origin", "https://allowed.example")
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	return rr
+}
+
+func redirectQuery(t *testing.T, rr *httptest.ResponseRecorder) url.Values {
+	t.Helper()
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("se esperaba 303, llegó %d: %s", rr.Code, rr.Body.String())
+	}
+	location, err := url.Parse(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("Location inválido: %v", err)
+	}
+	if !strings.HasPrefix(location.String(), formConfirmationURL) {
+		t.Fatalf("la redirección debe ir a la página de confirmación, llegó %s", location)
+	}
+	return location.Query()
+}
+
+func TestHandleFormPostCreaIssueYRedirige(t *testing.T) {
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	var gotTitle, gotBody string
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(_ context.Context, title string, _ []string, body string) (*githubIssueResponse, error) {
+			gotTitle, gotBody = title, body
+			return &githubIssueResponse{Number: 9, HTMLURL: "https://github.com/o/r/issues/9", NodeID: "I_9"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	query := redirectQuery(t, postForm(t, url.Values{
+		"templateId":  {"blank"},
+		"title":       {"Desde kiosco"},
+		"descripcion": {"El lector no responde"},
+		"consent":     {privacyPolicyVersion},
+	}))
+	if query.Get("estado") != "ok" || query.Get("issue") != "https://github.com/o/r/issues/9" {
+		t.Fatalf("query de confirmación inesperada: %v", query)
+	}
+	if gotTitle != "Desde kiosco" || !strings.Contains(gotBody, "El lector no responde") {
+		t.Fatalf("el issue no recibió los campos del formulario: %q / %q", gotTitle, gotBody)
+	}
+}
+
+func TestHandleFormPostRedirigeConCodigoDeError(t *testing.T) {
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			t.Fatal("sin consentimiento no se debe llamar a GitHub")
+			return nil, nil
+		}
+	})
+
+	query := redirectQuery(t, postForm(t, url.Values{"templateId": {"blank"}, "title": {"x"}, "descripcion": {"y"}}))
+	if query.Get("estado") != "error" || query.Get("codigo") != "consent_required" || query.Get("debug") == "" {
+		t.Fatalf("query de error inesperada: %v", query)
+	}
+}
+
+func TestHandleFormPostRechazaJSON(t *testing.T) {
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	req := httptest.NewRequest(http.MethodPost, "http://service.local"+formPostPath, strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("se esperaba 415, llegó %d", rr.Code)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"eos-roadmap-tools/internal/issueform"
+)
+
+// defaultFormsDir es donde GitHub busca los formularios de "New issue".
+const defaultFormsDir = ".github/ISSUE_TEMPLATE"
+
+// formFileNames relaciona cada plantilla del catálogo con su formulario
+// nativo. Los nombres de archivo ya existían antes del generador, por eso no
+// siempre coinciden con el ID (bug → bug_report.yml).
+var formFileNames = map[string]string{
+	"blank":          "blank.yml",
+	"bug":            "bug_report.yml",
+	"change_request": "change_request.yml",
+	"feature":        "feature.yml",
+}
+
+// runFormsCommand atiende "create-issue forms export|import". El catálogo en
+// Go es la fuente de verdad; export regenera los .yml y import permite traer
+// al catálogo un cambio hecho directamente en un formulario de GitHub.
+func runFormsCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "uso: create-issue forms export [-dir DIR] [-check] | import [-dir DIR] [-out ARCHIVO]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("forms "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", defaultFormsDir, "carpeta de los formularios de GitHub")
+	check := fs.Bool("check", false, "solo verifica que los formularios coincidan con el catálogo")
+	out := fs.String("out", "", "archivo donde escribir el catálogo importado (por defecto stdout)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	switch args[0] {
+	case "export":
+		if *check {
+			stale, err := staleForms(templates, *dir)
+			if err != nil {
+				fmt.Fprintf(stderr, "no se pudieron verificar los formularios: %v\n", err)
+				return 1
+			}
+			if len(stale) > 0 {
+				fmt.Fprintf(stderr, "formularios desactualizados respecto al catálogo: %s\n", strings.Join(stale, ", "))
+				fmt.Fprintln(stderr, "ejecuta: go run ./cmd/create-issue forms export")
+				return 1
+			}
+			return 0
+		}
+		if err := exportForms(templates, *dir); err != nil {
+			fmt.Fprintf(stderr, "no se pudieron exportar los formularios: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "formularios escritos en %s\n", *dir)
+		return 0
+	case "import":
+		imported, err := importForms(*dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "no se pudieron importar los formularios: %v\n", err)
+			return 1
+		}
+		source, err := templatesSource(imported)
+		if err != nil {
+			fmt.Fprintf(stderr, "no se pudo generar el catálogo: %v\n", err)
+			return 1
+		}
+		if *out == "" {
+			stdout.Write(source)
+			return 0
+		}
+		if err := os.WriteFile(*out, source, 0o644); err != nil {
+			fmt.Fprintf(stderr, "no se pudo escribir %s: %v\n", *out, err)
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintf(stderr, "subcomando desconocido %q\n", args[0])
+		return 2
+	}
+}
+
+func templateToForm(tmpl issueTemplate) issueform.Form {
+	form := issueform.Form{
+		Name:        tmpl.Name,
+		Description: tmpl.Description,
+		Title:       tmpl.Title,
+		Labels:      tmpl.Labels,
+	}
+	for _, field := range tmpl.Body {
+		id := field.ID
+		if field.Type == fieldTypeMarkdown {
+			// GitHub no usa el id de un bloque markdown; el formulario web
+			// tampoco.
+			id = ""
+		}
+		form.Body = append(form.Body, issueform.Element{
+			Type:        string(field.Type),
+			ID:          id,
+			Label:       field.Label,
+			Description: field.Description,
+			Placeholder: field.Placeholder,
+			Value:       field.Value,
+			Required:    field.Required,
+		})
+	}
+	return form
+}
+
+// formToTemplate rechaza los tipos que el formulario web todavía no sabe
+// mostrar (dropdown, checkboxes). Importarlos en silencio dejaría una
+// plantilla que buildBody no puede armar.
+func formToTemplate(id string, form issueform.Form) (issueTemplate, error) {
+	tmpl := issueTemplate{
+		ID:          id,
+		Name:        form.Name,
+		Description: form.Description,
+		Title:       form.Title,
+		Labels:      form.Labels,
+	}
+	for _, element := range form.Body {
+		switch fieldType(element.Type) {
+		case fieldTypeMarkdown, fieldTypeInput, fieldTypeTextarea:
+		default:
+			return issueTemplate{}, fmt.Errorf("campo %q: tipo %q no soportado por el formulario web", element.ID, element.Type)
+		}
+		if element.Type != issueform.TypeMarkdown && element.ID == "" {
+			return issueTemplate{}, fmt.Errorf("campo %q sin id", element.Label)
+		}
+		tmpl.Body = append(tmpl.Body, templateField{
+			ID:          element.ID,
+			Label:       element.Label,
+			Type:        fieldType(element.Type),
+			Required:    element.Required,
+			Value:       element.Value,
+			Description: element.Description,
+			Placeholder: element.Placeholder,
+		})
+	}
+	return tmpl, nil
+}
+
+// exportForms reescribe solo los formularios que cambiaron: uno que ya dice
+// lo mismo que el catálogo se deja intacto, con sus comillas y comentarios.
+func exportForms(catalog map[string]issueTemplate, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	stale, err := staleForms(catalog, dir)
+	if err != nil {
+		return err
+	}
+	idsByFile := make(map[string]string, len(formFileNames))
+	for id, name := range formFileNames {
+		idsByFile[name] = id
+	}
+	for _, name := range stale {
+		form := templateToForm(catalog[idsByFile[name]])
+		if err := os.WriteFile(filepath.Join(dir, name), issueform.Marshal(form), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// staleForms devuelve los archivos que faltan o cuyo contenido no coincide
+// con el catálogo. Se comparan los formularios ya interpretados, no los
+// bytes: el formato del YAML es de quien lo edita.
+func staleForms(catalog map[string]issueTemplate, dir string) ([]string, error) {
+	var stale []string
+	for _, id := range sortedTemplateIDs(catalog) {
+		name, err := formFileName(id)
+		if err != nil {
+			return nil, err
+		}
+		current, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			stale = append(stale, name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		form, err := issueform.Parse(current)
+		if err != nil || !reflect.DeepEqual(form, templateToForm(catalog[id])) {
+			stale = append(stale, name)
+		}
+	}
+	return stale, nil
+}
+
+func importForms(dir string) (map[string]issueTemplate, error) {
+	imported := map[string]issueTemplate{}
+	for id, name := range formFileNames {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		form, err := issueform.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		tmpl, err := formToTemplate(id, form)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		imported[id] = tmpl
+	}
+	return imported, nil
+}
+
+func formFileName(id string) (string, error) {
+	name, ok := formFileNames[id]
+	if !ok {
+		return "", fmt.Errorf("la plantilla %q no tiene archivo asignado en formFileNames", id)
+	}
+	return name, nil
+}
+
+func sortedTemplateIDs(catalog map[string]issueTemplate) []string {
+	ids := make([]string, 0, len(catalog))
+	for id := range catalog {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// templatesSource genera el literal de Go del catálogo, listo para pegar en
+// main.go. Lo formateamos con go/format para que el diff solo muestre los
+// cambios de contenido.
+func templatesSource(catalog map[string]issueTemplate) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("var templates = map[string]issueTemplate{\n")
+	for _, id := range sortedTemplateIDs(catalog) {
+		tmpl := catalog[id]
+		fmt.Fprintf(&b, "%q: {\n", id)
+		fmt.Fprintf(&b, "ID: %q,\nName: %q,\nDescription: %q,\nTitle: %q,\n", tmpl.ID, tmpl.Name, tmpl.Description, tmpl.Title)
+		fmt.Fprintf(&b, "Labels: %#v,\n", tmpl.Labels)
+		b.WriteString("Body: []templateField{\n")
+		for _, field := range tmpl.Body {
+			fmt.Fprintf(&b, "{ID: %q, Label: %q, Type: %s", field.ID, field.Label, fieldTypeConst(field.Type))
+			if field.Required {
+				b.WriteString(", Required: true")
+			}
+			if field.Value != "" {
+				fmt.Fprintf(&b, ", Value: %q", field.Value)
+			}
+			if field.Description != "" {
+				fmt.Fprintf(&b, ", Description: %q", field.Description)
+			}
+			if field.Placeholder != "" {
+				fmt.Fprintf(&b, ", Placeholder: %q", field.Placeholder)
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("},\n},\n")
+	}
+	b.WriteString("}\n")
+	return format.Source(b.Bytes())
+}
+
+func fieldTypeConst(t fieldType) string {
+	switch t {
+	case fieldTypeMarkdown:
+		return "fieldTypeMarkdown"
+	case fieldTypeInput:
+		return "fieldTypeInput"
+	default:
+		return "fieldTypeTextarea"
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"eos-roadmap-tools/internal/issueform"
+)
+
+// TestFormulariosSincronizadosConCatalogo falla si alguien edita el catálogo
+// sin regenerar .github/ISSUE_TEMPLATE (o al revés), que es justo la deriva
+// que el generador busca evitar.
+func TestFormulariosSincronizadosConCatalogo(t *testing.T) {
+	stale, err := staleForms(templates, filepath.Join("..", "..", defaultFormsDir))
+	if err != nil {
+		t.Fatalf("staleForms: %v", err)
+	}
+	if len(stale) > 0 {
+		t.Fatalf("formularios desactualizados: %v; ejecuta go run ./cmd/create-issue forms export", stale)
+	}
+}
+
+func TestExportImportConservaCatalogo(t *testing.T) {
+	dir := t.TempDir()
+	if err := exportForms(templates, dir); err != nil {
+		t.Fatalf("exportForms: %v", err)
+	}
+	imported, err := importForms(dir)
+	if err != nil {
+		t.Fatalf("importForms: %v", err)
+	}
+	if !reflect.DeepEqual(imported, templates) {
+		t.Fatalf("el catálogo importado difiere del original:\n%#v\n%#v", imported, templates)
+	}
+
+	source, err := templatesSource(imported)
+	if err != nil {
+		t.Fatalf("templatesSource: %v", err)
+	}
+	if !bytes.Contains(source, []byte(`Placeholder: "@stakeholder"`)) {
+		t.Fatalf("el código generado no incluye los placeholders:\n%s", source)
+	}
+}
+
+// TestExportNoReescribeFormulariosAlDia exporta sobre una copia de los
+// formularios del repositorio y exige que queden idénticos byte a byte: el
+// generador no debe cambiar comillas, espacios finales ni saltos de línea de
+// un formulario que ya coincide con el catálogo.
+func TestExportNoReescribeFormulariosAlDia(t *testing.T) {
+	dir := t.TempDir()
+	original := map[string][]byte{}
+	for _, name := range formFileNames {
+		data, err := os.ReadFile(filepath.Join("..", "..", defaultFormsDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		original[name] = data
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := exportForms(templates, dir); err != nil {
+		t.Fatalf("exportForms: %v", err)
+	}
+	for name, want := range original {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%s cambió al exportar:\n%s", name, got)
+		}
+	}
+}
+
+func TestExportConservaVinetasVacias(t *testing.T) {
+	dir := t.TempDir()
+	if err := exportForms(templates, dir); err != nil {
+		t.Fatalf("exportForms: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, formFileNames["blank"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("      value: |\n        **Contexto**\n        - \n")) {
+		t.Fatalf("el valor por defecto debe conservar la viñeta vacía y el salto final:\n%s", data)
+	}
+}
+
+func TestFormToTemplateRechazaTiposNoSoportados(t *testing.T) {
+	form := issueform.Form{Body: []issueform.Element{{Type: issueform.TypeDropdown, ID: "area"}}}
+	if _, err := formToTemplate("x", form); err == nil {
+		t.Fatal("un dropdown no debería importarse mientras el formulario web no lo soporte")
+	}
+}
+
+func TestRunFormsCommandCheck(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := runFormsCommand([]string{"export", "-dir", dir, "-check"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("check sobre carpeta vacía = %d; want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "bug_report.yml") {
+		t.Fatalf("el mensaje debería listar los archivos desactualizados: %s", stderr.String())
+	}
+	if code := runFormsCommand([]string{"export", "-dir", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("export = %d; stderr %s", code, stderr.String())
+	}
+	if code := runFormsCommand([]string{"export", "-dir", dir, "-check"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("check tras exportar = %d; stderr %s", code, stderr.String())
+	}
+}
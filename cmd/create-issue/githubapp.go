@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// githubAppRefreshMargin es cuánto antes del vencimiento renovamos el token
+// de instalación, para que una llamada en curso no llegue con uno vencido.
+const githubAppRefreshMargin = 5 * time.Minute
+
+// githubAppSource obtiene tokens de instalación de una GitHub App. GitHub
+// los emite por una hora; guardamos el último y pedimos otro cuando está por
+// vencer, así el pool no depende de un token personal de larga duración.
+type githubAppSource struct {
+	appID          string
+	installationID string
+	key            *rsa.PrivateKey
+	endpoint       string
+	client         *http.Client
+	now            func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// newGitHubAppSourceFromEnv lee GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID y
+// GITHUB_APP_PRIVATE_KEY (o GITHUB_APP_PRIVATE_KEY_FILE). Sin GITHUB_APP_ID
+// devuelve nil: la App es opcional y el pool sigue con los tokens fijos.
+func newGitHubAppSourceFromEnv(getenv func(string) string, readFile func(string) ([]byte, error)) (*githubAppSource, error) {
+	appID := strings.TrimSpace(getenv("GITHUB_APP_ID"))
+	if appID == "" {
+		return nil, nil
+	}
+	installationID := strings.TrimSpace(getenv("GITHUB_APP_INSTALLATION_ID"))
+	if installationID == "" {
+		return nil, errors.New("GITHUB_APP_ID requiere GITHUB_APP_INSTALLATION_ID")
+	}
+	pemData, err := envOrFile(getenv, readFile, "GITHUB_APP_PRIVATE_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(pemData) == "" {
+		return nil, errors.New("GITHUB_APP_ID requiere GITHUB_APP_PRIVATE_KEY")
+	}
+	key, err := parseRSAPrivateKey(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("GITHUB_APP_PRIVATE_KEY: %w", err)
+	}
+	return &githubAppSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		endpoint:       "https://api.github.com",
+		client:         &http.Client{Timeout: 10 * time.Second},
+		now:            time.Now,
+	}, nil
+}
+
+// Token devuelve el token de instalación vigente y lo renueva si vence en
+// menos de githubAppRefreshMargin.
+func (s *githubAppSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.now().Add(githubAppRefreshMargin).Before(s.expiresAt) {
+		return s.token, nil
+	}
+	token, expiresAt, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiresAt = token, expiresAt
+	return token, nil
+}
+
+// invalidate descarta el token guardado para que la próxima llamada pida uno
+// nuevo; se usa cuando GitHub lo rechaza antes de su vencimiento.
+func (s *githubAppSource) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+func (s *githubAppSource) fetch(ctx context.Context) (string, time.Time, error) {
+	// GitHub acepta JWT de hasta 10 minutos; el iat se adelanta un minuto
+	// por si el reloj del servidor va por delante del nuestro.
+	now := s.now()
+	jwt, err := signJWT(s.key, map[string]any{
+		"iss": s.appID,
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", s.endpoint, s.installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error al pedir el token de instalación: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", time.Time{}, fmt.Errorf("GitHub devolvió %d al pedir el token de instalación: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	var tokenResp struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", time.Time{}, err
+	}
+	if strings.TrimSpace(tokenResp.Token) == "" {
+		return "", time.Time{}, errors.New("GitHub devolvió un token de instalación vacío")
+	}
+	return tokenResp.Token, tokenResp.ExpiresAt, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAppKeyPEM(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestGitHubAppSourceRenuevaAntesDeVencer(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	var issued int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			t.Errorf("solicitud inesperada: %s %s", r.Method, r.URL.Path)
+		}
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			t.Fatalf("Authorization no es un JWT: %q", r.Header.Get("Authorization"))
+		}
+		raw, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims struct {
+			Iss string `json:"iss"`
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+		}
+		if err := json.Unmarshal(raw, &claims); err != nil || claims.Iss != "7" || claims.Exp-claims.Iat > 600 {
+			t.Errorf("claims inválidos: %+v (%v)", claims, err)
+		}
+		issued++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, issued, now.Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	env := map[string]string{
+		"GITHUB_APP_ID":              "7",
+		"GITHUB_APP_INSTALLATION_ID": "42",
+		"GITHUB_APP_PRIVATE_KEY":     testAppKeyPEM(t),
+	}
+	source, err := newGitHubAppSourceFromEnv(func(k string) string { return env[k] }, nil)
+	if err != nil {
+		t.Fatalf("newGitHubAppSourceFromEnv: %v", err)
+	}
+	source.endpoint = server.URL
+	source.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if token, err := source.Token(t.Context()); err != nil || token != "ghs_1" {
+			t.Fatalf("Token() = %q, %v; debía reutilizar el token guardado", token, err)
+		}
+	}
+	now = now.Add(56 * time.Minute)
+	if token, _ := source.Token(t.Context()); token != "ghs_2" {
+		t.Fatalf("a minutos del vencimiento debía renovarse, llegó %q", token)
+	}
+	source.invalidate()
+	if token, _ := source.Token(t.Context()); token != "ghs_3" || issued != 3 {
+		t.Fatalf("tras invalidar debía pedirse otro, llegó %q (%d emitidos)", token, issued)
+	}
+}
+
+func TestGitHubAppSourceDesdeEnv(t *testing.T) {
+	if source, err := newGitHubAppSourceFromEnv(func(string) string { return "" }, nil); source != nil || err != nil {
+		t.Fatalf("sin GITHUB_APP_ID la App es opcional: %v, %v", source, err)
+	}
+	env := map[string]string{"GITHUB_APP_ID": "7"}
+	getenv := func(k string) string { return env[k] }
+	if _, err := newGitHubAppSourceFromEnv(getenv, nil); err == nil {
+		t.Fatal("sin instalación debe fallar")
+	}
+	env["GITHUB_APP_INSTALLATION_ID"] = "42"
+	env["GITHUB_APP_PRIVATE_KEY"] = "no es PEM"
+	if _, err := newGitHubAppSourceFromEnv(getenv, nil); err == nil {
+		t.Fatal("una clave ilegible debe fallar")
+	}
+}
+
+func TestGitHubAuthTransportUsaTokenDeLaApp(t *testing.T) {
+	pool := newTokenPool(nil, time.Now)
+	pool.addApp(&githubAppSource{installationID: "42", token: "ghs_vigente", expiresAt: time.Now().Add(time.Hour), now: time.Now})
+	useServiceDeps(t, func(d *serviceDeps) { d.GitHubTokens = pool })
+
+	var auth string
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		auth = req.Header.Get("Authorization")
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+	resp, err := (&http.Client{Transport: &githubAuthTransport{base: base}}).Get("https://api.github.com/")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if auth != "Bearer ghs_vigente" {
+		t.Fatalf("Authorization = %q", auth)
+	}
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idempotencyHeader es el encabezado con el que el cliente identifica un
+// envío. También se acepta requestId en el cuerpo, para el formulario
+// clásico y para clientes que no pueden fijar encabezados.
+const idempotencyHeader = "Idempotency-Key"
+
+// idempotencyReplayedHeader marca una respuesta repetida de un envío
+// anterior, para que quien depura sepa que no se creó nada nuevo.
+const idempotencyReplayedHeader = "Idempotent-Replayed"
+
+// defaultIdempotencyWindow es cuánto recordamos una clave. Cubre el doble
+// clic y los reintentos de una red inestable sin guardar claves para siempre.
+const defaultIdempotencyWindow = 24 * time.Hour
+
+// maxIdempotencyKeys limita el almacén en memoria; al llenarse se olvidan
+// primero las claves usadas hace más tiempo.
+const maxIdempotencyKeys = 10000
+
+// maxIdempotencyKeyLength sigue el límite habitual de otras APIs; un UUID
+// ocupa 36.
+const maxIdempotencyKeyLength = 255
+
+// idempotencyRecord es lo que guardamos de un envío. Pending indica que el
+// primero todavía no termina; al completarse guarda la respuesta original.
+type idempotencyRecord struct {
+	Fingerprint string
+	Pending     bool
+	Status      int
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// idempotencyStore recuerda las claves recientes. Reserve devuelve el
+// registro existente si la clave ya se usó dentro de la ventana, o nil si la
+// reservó para este envío.
+type idempotencyStore interface {
+	Reserve(ctx context.Context, key, fingerprint string, now time.Time) (*idempotencyRecord, error)
+	Complete(ctx context.Context, key string, status int, body []byte) error
+	Release(ctx context.Context, key string) error
+}
+
+// newIdempotencyStore construye el almacén indicado por IDEMPOTENCY_STORE.
+// "off" desactiva la deduplicación.
+func newIdempotencyStore(kind string, getenv func(string) string) (idempotencyStore, error) {
+	window := defaultIdempotencyWindow
+	if raw := strings.TrimSpace(getenv("IDEMPOTENCY_WINDOW")); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < time.Minute {
+			return nil, fmt.Errorf("IDEMPOTENCY_WINDOW inválido (mínimo 1m): %q", raw)
+		}
+		window = parsed
+	}
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "memory":
+		return newMemoryIdempotencyStore(maxIdempotencyKeys, window), nil
+	case "off", "none":
+		return nil, nil
+	case "firestore":
+		return newFirestoreIdempotencyStore(
+			strings.TrimSpace(getenv("FIRESTORE_PROJECT_ID")),
+			envOrDefault("FIRESTORE_DATABASE", "(default)"),
+			envOrDefault("IDEMPOTENCY_COLLECTION", "create-issue-idempotency"),
+			window,
+		)
+	case "redis":
+		// No hay cliente de Redis entre las dependencias del módulo. Fallamos
+		// al arrancar en lugar de caer a memoria: con varias réplicas la
+		// deduplicación dejaría de funcionar sin que nadie lo note.
+		return nil, errors.New("IDEMPOTENCY_STORE=redis no está disponible en esta compilación; usa firestore")
+	default:
+		return nil, fmt.Errorf("IDEMPOTENCY_STORE desconocido: %q", kind)
+	}
+}
+
+// requestFingerprint resume el contenido del envío. Si una clave vuelve con
+// otro contenido es un error del cliente, no un reintento.
+func requestFingerprint(req issueRequest) string {
+	data, _ := json.Marshal(struct {
+		TemplateID string            `json:"t"`
+		Title      string            `json:"ti"`
+		Fields     map[string]string `json:"f"`
+		ModuleID   string            `json:"m"`
+	}{req.TemplateID, strings.TrimSpace(req.Title), req.Fields, req.ModuleID})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// idempotencyKey lee la clave del encabezado o, si falta, de requestId. La
+// clave se guarda junto al origen para que dos sitios no choquen.
+func idempotencyKey(r *http.Request, req issueRequest) (string, *submissionError) {
+	key := strings.TrimSpace(r.Header.Get(idempotencyHeader))
+	if key == "" {
+		key = strings.TrimSpace(req.RequestID)
+	}
+	if key == "" {
+		return "", nil
+	}
+	if len(key) > maxIdempotencyKeyLength || strings.ContainsFunc(key, func(c rune) bool { return c < 0x21 || c > 0x7e }) {
+		return "", &submissionError{Status: http.StatusBadRequest, Code: "invalid_idempotency_key", Message: fmt.Sprintf("%s debe ser ASCII visible de hasta %d caracteres", idempotencyHeader, maxIdempotencyKeyLength)}
+	}
+	return strings.TrimSpace(r.Header.Get("Origin")) + " " + key, nil
+}
+
+// submitIdempotent envuelve processIssueRequest: la primera vez reserva la
+// clave y guarda la respuesta si el envío quedó aceptado; las repeticiones
+// reciben esa misma respuesta. Si el envío falla la clave se libera para que
+// el reintento pueda crear el issue.
+func submitIdempotent(ctx context.Context, w http.ResponseWriter, r *http.Request, req issueRequest, store idempotencyStore, key string) bool {
+	existing, err := store.Reserve(ctx, key, requestFingerprint(req), time.Now())
+	if err != nil {
+		// Sin almacén preferimos arriesgar un duplicado a perder el envío.
+		logErrorWithFallback(ctx, "idempotency_store_error", "no se pudo reservar la clave de idempotencia", err)
+		return processIssueRequest(ctx, w, r, req)
+	}
+	if existing != nil {
+		return replayIdempotent(ctx, w, req, existing)
+	}
+
+	capture := &capturedResponse{header: w.Header()}
+	accepted := processIssueRequest(ctx, capture, r, req)
+	status := capture.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if accepted {
+		err = store.Complete(ctx, key, status, capture.body.Bytes())
+	} else {
+		err = store.Release(ctx, key)
+	}
+	if err != nil {
+		logErrorWithFallback(ctx, "idempotency_store_error", "no se pudo actualizar la clave de idempotencia", err)
+	}
+	w.WriteHeader(status)
+	if _, err := w.Write(capture.body.Bytes()); err != nil {
+		logErrorWithFallback(ctx, "write_response_error", "error al escribir la respuesta", err)
+	}
+	return accepted
+}
+
+func replayIdempotent(ctx context.Context, w http.ResponseWriter, req issueRequest, existing *idempotencyRecord) bool {
+	switch {
+	case existing.Fingerprint != requestFingerprint(req):
+		writeSubmissionError(ctx, w, &submissionError{Status: http.StatusUnprocessableEntity, Code: "idempotency_key_reused", Message: "La clave de idempotencia ya se usó con otro contenido"})
+		return false
+	case existing.Pending:
+		w.Header().Set("Retry-After", "2")
+		writeSubmissionError(ctx, w, &submissionError{Status: http.StatusConflict, Code: "idempotency_in_progress", Message: "El envío original todavía se está procesando"})
+		return false
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.RecordStatus(existing.Status)
+		logger.log(ctx, "idempotent_replay", severityInfo, "envío repetido; se devuelve la respuesta original")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(existing.Status)
+	if _, err := w.Write(existing.Body); err != nil {
+		logErrorWithFallback(ctx, "write_response_error", "error al escribir la respuesta", err)
+	}
+	return existing.Status < http.StatusMultipleChoices
+}
+
+// memoryIdempotencyStore es un LRU con vencimiento. Alcanza para una réplica;
+// con varias hace falta un almacén compartido.
+type memoryIdempotencyStore struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryIdempotencyEntry struct {
+	key    string
+	record idempotencyRecord
+}
+
+func newMemoryIdempotencyStore(limit int, window time.Duration) *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{limit: limit, window: window, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (m *memoryIdempotencyStore) Reserve(_ context.Context, key, fingerprint string, now time.Time) (*idempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryIdempotencyEntry)
+		if now.Sub(entry.record.CreatedAt) < m.window {
+			m.order.MoveToFront(element)
+			record := entry.record
+			return &record, nil
+		}
+		m.removeLocked(element)
+	}
+	for m.order.Len() >= m.limit {
+		m.removeLocked(m.order.Back())
+	}
+	entry := &memoryIdempotencyEntry{key: key, record: idempotencyRecord{Fingerprint: fingerprint, Pending: true, CreatedAt: now}}
+	m.entries[key] = m.order.PushFront(entry)
+	return nil, nil
+}
+
+func (m *memoryIdempotencyStore) Complete(_ context.Context, key string, status int, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryIdempotencyEntry)
+		entry.record.Pending = false
+		entry.record.Status = status
+		entry.record.Body = append([]byte(nil), body...)
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		m.removeLocked(element)
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyStore) removeLocked(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryIdempotencyEntry).key)
+}
+
+const firestoreEndpoint = "https://firestore.googleapis.com/v1"
+
+// firestoreScope es el permiso para leer y escribir documentos.
+const firestoreScope = "https://www.googleapis.com/auth/datastore"
+
+// firestoreIdempotencyStore comparte las claves entre réplicas usando la API
+// REST de Firestore: la creación con documentId falla con 409 si la clave ya
+// existe, lo que hace atómica la reserva. El campo expireAt sirve para una
+// política de TTL de la colección; sin ella los documentos vencidos se
+// reemplazan al volver a usarse.
+type firestoreIdempotencyStore struct {
+	documents string
+	window    time.Duration
+	endpoint  string
+	client    *http.Client
+	tokens    *googleTokenCache
+}
+
+func newFirestoreIdempotencyStore(projectID, database, collection string, window time.Duration) (*firestoreIdempotencyStore, error) {
+	if projectID == "" {
+		return nil, errors.New("IDEMPOTENCY_STORE=firestore requiere FIRESTORE_PROJECT_ID")
+	}
+	return &firestoreIdempotencyStore{
+		documents: fmt.Sprintf("projects/%s/databases/%s/documents/%s", projectID, url.PathEscape(database), url.PathEscape(collection)),
+		window:    window,
+		endpoint:  firestoreEndpoint,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		tokens:    &googleTokenCache{scope: firestoreScope},
+	}, nil
+}
+
+// firestoreDocumentID evita caracteres que Firestore no admite en un ID
+// (la barra, por ejemplo) y acota el largo.
+func firestoreDocumentID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type firestoreValue struct {
+	StringValue    *string `json:"stringValue,omitempty"`
+	IntegerValue   *string `json:"integerValue,omitempty"`
+	BooleanValue   *bool   `json:"booleanValue,omitempty"`
+	TimestampValue *string `json:"timestampValue,omitempty"`
+}
+
+type firestoreDocument struct {
+	Fields map[string]firestoreValue `json:"fields"`
+	// UpdateTime lo completa Firestore al leer; sirve de precondición para
+	// reescribir el documento sin pisar a otra réplica.
+	UpdateTime string `json:"updateTime,omitempty"`
+}
+
+func firestoreString(value string) firestoreValue { return firestoreValue{StringValue: &value} }
+func firestoreBool(value bool) firestoreValue     { return firestoreValue{BooleanValue: &value} }
+func firestoreInt(value int) firestoreValue {
+	raw := strconv.Itoa(value)
+	return firestoreValue{IntegerValue: &raw}
+}
+func firestoreTime(value time.Time) firestoreValue {
+	raw := value.UTC().Format(time.RFC3339Nano)
+	return firestoreValue{TimestampValue: &raw}
+}
+
+// firestoreReserveAttempts acota los reintentos de Reserve cuando la clave
+// se libera entre la creación y la lectura, o cuando otra réplica la toma
+// mientras la reemplazamos.
+const firestoreReserveAttempts = 3
+
+func (f *firestoreIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, now time.Time) (*idempotencyRecord, error) {
+	id := firestoreDocumentID(key)
+	doc := firestoreDocument{Fields: map[string]firestoreValue{
+		"fingerprint": firestoreString(fingerprint),
+		"pending":     firestoreBool(true),
+		"createdAt":   firestoreTime(now),
+		"expireAt":    firestoreTime(now.Add(f.window)),
+	}}
+	for attempt := 0; attempt < firestoreReserveAttempts; attempt++ {
+		status, err := f.call(ctx, http.MethodPost, f.documents+"?documentId="+id, doc, nil)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case status == http.StatusConflict:
+		case status >= 200 && status < 300:
+			return nil, nil
+		default:
+			// Un 404 acá es la base o la colección que no existe, no una
+			// clave libre.
+			return nil, fmt.Errorf("Firestore devolvió %d al reservar la clave", status)
+		}
+
+		var stored firestoreDocument
+		if status, err = f.call(ctx, http.MethodGet, f.documents+"/"+id, nil, &stored); err != nil {
+			return nil, err
+		}
+		if status == http.StatusNotFound {
+			// Se liberó entre la creación y la lectura: lo intentamos de nuevo.
+			continue
+		}
+		record := stored.record()
+		if now.Sub(record.CreatedAt) < f.window {
+			return &record, nil
+		}
+		// Venció pero la política de TTL todavía no lo borró. Lo reemplazamos
+		// solo si nadie lo tocó desde que lo leímos: dos réplicas que vean
+		// la misma clave vencida no pueden tomarla las dos.
+		precondition := "?currentDocument.updateTime=" + url.QueryEscape(stored.UpdateTime)
+		status, err = f.call(ctx, http.MethodPatch, f.documents+"/"+id+precondition, doc, nil)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case status == http.StatusPreconditionFailed:
+			// Otra réplica la reservó primero: la próxima vuelta lee su
+			// reserva.
+			continue
+		case status >= 200 && status < 300:
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("Firestore devolvió %d al reemplazar la clave vencida", status)
+		}
+	}
+	return nil, fmt.Errorf("la clave cambió %d veces seguidas mientras se reservaba", firestoreReserveAttempts)
+}
+
+func (f *firestoreIdempotencyStore) Complete(ctx context.Context, key string, status int, body []byte) error {
+	doc := firestoreDocument{Fields: map[string]firestoreValue{
+		"pending": firestoreBool(false),
+		"status":  firestoreInt(status),
+		"body":    firestoreString(string(body)),
+	}}
+	mask := "?updateMask.fieldPaths=pending&updateMask.fieldPaths=status&updateMask.fieldPaths=body"
+	_, err := f.call(ctx, http.MethodPatch, f.documents+"/"+firestoreDocumentID(key)+mask, doc, nil)
+	return err
+}
+
+func (f *firestoreIdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := f.call(ctx, http.MethodDelete, f.documents+"/"+firestoreDocumentID(key), nil, nil)
+	return err
+}
+
+func (d firestoreDocument) record() idempotencyRecord {
+	var record idempotencyRecord
+	if v := d.Fields["fingerprint"].StringValue; v != nil {
+		record.Fingerprint = *v
+	}
+	if v := d.Fields["pending"].BooleanValue; v != nil {
+		record.Pending = *v
+	}
+	if v := d.Fields["status"].IntegerValue; v != nil {
+		record.Status, _ = strconv.Atoi(*v)
+	}
+	if v := d.Fields["body"].StringValue; v != nil {
+		record.Body = []byte(*v)
+	}
+	if v := d.Fields["createdAt"].TimestampValue; v != nil {
+		record.CreatedAt, _ = time.Parse(time.RFC3339Nano, *v)
+	}
+	return record
+}
+
+// call devuelve el estado HTTP para que Reserve distinga 409, 404 y una
+// precondición fallida; los demás estados fuera de 2xx son error. Firestore
+// informa la precondición como 400 FAILED_PRECONDITION, que se traduce a 412.
+func (f *firestoreIdempotencyStore) call(ctx context.Context, method, path string, payload, out any) (int, error) {
+	token, err := f.tokens.Token(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("no se pudo obtener token para Firestore: %w", err)
+	}
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, f.endpoint+"/"+path, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error al llamar a Firestore: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusPreconditionFailed:
+		return resp.StatusCode, nil
+	case resp.StatusCode >= 300:
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(raw), "FAILED_PRECONDITION") {
+			return http.StatusPreconditionFailed, nil
+		}
+		return resp.StatusCode, fmt.Errorf("Firestore devolvió %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if out != nil {
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode, nil
+}
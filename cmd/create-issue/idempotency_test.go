@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"eos-roadmap-tools/internal/errcodes"
+)
+
+func idempotentPost(key, title string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"templateId":"blank","title":%q,"fields":{"descripcion":"y"},%s}`, title, consentJSON())
+	req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyHeader, key)
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	return rr
+}
+
+func TestIdempotencyKeyDevuelveElIssueOriginal(t *testing.T) {
+	created := 0
+	var failing error
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Idempotency = newMemoryIdempotencyStore(10, time.Hour)
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			if failing != nil {
+				return nil, failing
+			}
+			created++
+			return &githubIssueResponse{Number: created, HTMLURL: fmt.Sprintf("https://example.com/issues/%d", created), NodeID: "node"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	first := idempotentPost("clave-1", "Doble clic")
+	second := idempotentPost("clave-1", "Doble clic")
+	if first.Code != http.StatusOK || second.Code != http.StatusOK || created != 1 {
+		t.Fatalf("se esperaba un solo issue: %d/%d, creados %d", first.Code, second.Code, created)
+	}
+	var original, replayed issueResponse
+	_ = json.Unmarshal(first.Body.Bytes(), &original)
+	_ = json.Unmarshal(second.Body.Bytes(), &replayed)
+	if replayed.IssueURL != original.IssueURL || second.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Fatalf("la repetición debe devolver el issue original: %+v vs %+v", replayed, original)
+	}
+
+	if rr := idempotentPost("clave-1", "Otro título"); rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "idempotency_key_reused") {
+		t.Fatalf("otro contenido con la misma clave = %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := idempotentPost("clave-2", "Otro reporte"); rr.Code != http.StatusOK || created != 2 {
+		t.Fatalf("una clave nueva crea otro issue: %d, creados %d", rr.Code, created)
+	}
+
+	// Un envío fallido libera la clave para que el reintento cree el issue.
+	failing = &errcodes.GitHubError{Status: http.StatusServiceUnavailable}
+	if rr := idempotentPost("clave-3", "Reintento"); rr.Code < 500 {
+		t.Fatalf("se esperaba el error de GitHub, llegó %d", rr.Code)
+	}
+	failing = nil
+	if rr := idempotentPost("clave-3", "Reintento"); rr.Code != http.StatusOK || created != 3 || rr.Header().Get(idempotencyReplayedHeader) != "" {
+		t.Fatalf("el reintento tras un fallo debe crear el issue: %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := idempotentPost("con espacio\x7f", "x"); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalid_idempotency_key") {
+		t.Fatalf("clave inválida = %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestMemoryIdempotencyStoreVenceYDescartaLaMasVieja(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryIdempotencyStore(2, time.Hour)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	if existing, _ := store.Reserve(ctx, "a", "f", now); existing != nil {
+		t.Fatal("la primera reserva no debe encontrar nada")
+	}
+	if existing, _ := store.Reserve(ctx, "a", "f", now); existing == nil || !existing.Pending {
+		t.Fatalf("mientras el original corre la clave queda pendiente: %+v", existing)
+	}
+	_ = store.Complete(ctx, "a", http.StatusOK, []byte(`{"issueUrl":"x"}`))
+	if existing, _ := store.Reserve(ctx, "a", "f", now.Add(time.Hour)); existing != nil {
+		t.Fatalf("pasada la ventana la clave se olvida: %+v", existing)
+	}
+
+	_, _ = store.Reserve(ctx, "b", "f", now)
+	_, _ = store.Reserve(ctx, "c", "f", now)
+	if _, ok := store.entries["a"]; ok {
+		t.Fatal("al llenarse se descarta la clave usada hace más tiempo")
+	}
+}
+
+func TestFirestoreIdempotencyStore(t *testing.T) {
+	docs := map[string]firestoreDocument{}
+	version := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("falta el token: %v", r.Header)
+		}
+		id := r.URL.Query().Get("documentId")
+		if id == "" {
+			id = r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		}
+		var doc firestoreDocument
+		_ = json.NewDecoder(r.Body).Decode(&doc)
+		stored, exists := docs[id]
+		switch r.Method {
+		case http.MethodPost:
+			if exists {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			version++
+			doc.UpdateTime = strconv.Itoa(version)
+			docs[id] = doc
+		case http.MethodPatch:
+			if want, ok := r.URL.Query()["currentDocument.updateTime"]; ok && want[0] != stored.UpdateTime {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"status":"FAILED_PRECONDITION"}}`))
+				return
+			}
+			if stored.Fields == nil {
+				stored.Fields = map[string]firestoreValue{}
+			}
+			for name, value := range doc.Fields {
+				stored.Fields[name] = value
+			}
+			version++
+			stored.UpdateTime = strconv.Itoa(version)
+			docs[id] = stored
+		case http.MethodGet:
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(stored)
+		case http.MethodDelete:
+			delete(docs, id)
+		}
+	}))
+	defer server.Close()
+
+	store, err := newFirestoreIdempotencyStore("proyecto", "(default)", "claves", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.endpoint = server.URL
+	store.tokens = &googleTokenCache{token: "token", expiry: time.Now().Add(time.Hour)}
+
+	ctx := context.Background()
+	now := time.Now()
+	if existing, err := store.Reserve(ctx, "origen clave", "f", now); err != nil || existing != nil {
+		t.Fatalf("primera reserva: %+v / %v", existing, err)
+	}
+	if err := store.Complete(ctx, "origen clave", http.StatusOK, []byte(`{"issueUrl":"x"}`)); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := store.Reserve(ctx, "origen clave", "f", now.Add(time.Minute))
+	if err != nil || existing == nil || existing.Pending || existing.Status != http.StatusOK || string(existing.Body) != `{"issueUrl":"x"}` || existing.Fingerprint != "f" {
+		t.Fatalf("se esperaba la respuesta guardada: %+v / %v", existing, err)
+	}
+	if existing, err := store.Reserve(ctx, "origen clave", "f", now.Add(2*time.Hour)); err != nil || existing != nil {
+		t.Fatalf("vencida la ventana se reserva de nuevo: %+v / %v", existing, err)
+	}
+	if err := store.Release(ctx, "origen clave"); err != nil || len(docs) != 0 {
+		t.Fatalf("Release debe borrar el documento: %v / %v", err, docs)
+	}
+}
+
+// TestFirestoreReserveVencidaNoSeTomaDosVeces simula que otra réplica
+// reemplaza la clave vencida entre nuestra lectura y nuestra escritura: la
+// precondición falla y Reserve devuelve la reserva ajena en lugar de pisarla.
+func TestFirestoreReserveVencidaNoSeTomaDosVeces(t *testing.T) {
+	now := time.Now()
+	expired := firestoreDocument{UpdateTime: "1", Fields: map[string]firestoreValue{
+		"fingerprint": firestoreString("f"), "pending": firestoreBool(false), "createdAt": firestoreTime(now.Add(-2 * time.Hour)),
+	}}
+	current := expired
+	patchStatus := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusConflict)
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(current)
+		case http.MethodPatch:
+			if patchStatus != 0 {
+				w.WriteHeader(patchStatus)
+				return
+			}
+			if r.URL.Query().Get("currentDocument.updateTime") != "1" {
+				t.Errorf("el reemplazo debe llevar la precondición: %s", r.URL.RawQuery)
+			}
+			// La otra réplica ganó: la clave ya es suya y está en curso.
+			current = firestoreDocument{UpdateTime: "2", Fields: map[string]firestoreValue{
+				"fingerprint": firestoreString("f"), "pending": firestoreBool(true), "createdAt": firestoreTime(now),
+			}}
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":400,"status":"FAILED_PRECONDITION"}}`))
+		}
+	}))
+	defer server.Close()
+	store, err := newFirestoreIdempotencyStore("proyecto", "(default)", "claves", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.endpoint = server.URL
+	store.tokens = &googleTokenCache{token: "token", expiry: time.Now().Add(time.Hour)}
+
+	existing, err := store.Reserve(context.Background(), "k", "f", now)
+	if err != nil || existing == nil || !existing.Pending {
+		t.Fatalf("se esperaba la reserva de la otra réplica: %+v / %v", existing, err)
+	}
+
+	current, patchStatus = expired, http.StatusForbidden
+	if existing, err := store.Reserve(context.Background(), "k", "f", now); err == nil || existing != nil {
+		t.Fatalf("un 403 al reemplazar no es una reserva: %+v / %v", existing, err)
+	}
+}
+
+func TestFirestoreReserveAcotaReintentosYErrores(t *testing.T) {
+	var posts int
+	postStatus := http.StatusConflict
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(postStatus)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	store, err := newFirestoreIdempotencyStore("proyecto", "(default)", "claves", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.endpoint = server.URL
+	store.tokens = &googleTokenCache{token: "token", expiry: time.Now().Add(time.Hour)}
+
+	if existing, err := store.Reserve(context.Background(), "k", "f", time.Now()); err == nil || existing != nil || posts != firestoreReserveAttempts {
+		t.Fatalf("409 seguido de 404 debe reintentarse %d veces y fallar: %+v / %v (%d)", firestoreReserveAttempts, existing, err, posts)
+	}
+	postStatus = http.StatusNotFound
+	if existing, err := store.Reserve(context.Background(), "k", "f", time.Now()); err == nil || existing != nil {
+		t.Fatalf("un 404 al crear no es una reserva: %+v / %v", existing, err)
+	}
+}
+
+func TestNewIdempotencyStore(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(key string) string { return values[key] }
+	}
+	if store, err := newIdempotencyStore("", env(nil)); err != nil || store == nil {
+		t.Fatalf("por defecto se usa memoria: %v / %v", store, err)
+	}
+	if store, err := newIdempotencyStore("off", env(nil)); err != nil || store != nil {
+		t.Fatalf("off desactiva: %v / %v", store, err)
+	}
+	for kind, values := range map[string]map[string]string{
+		"firestore": nil,
+		"redis":     nil,
+		"memory":    {"IDEMPOTENCY_WINDOW": "5s"},
+		"otro":      nil,
+	} {
+		if _, err := newIdempotencyStore(kind, env(values)); err == nil {
+			t.Errorf("%s con %v debió fallar", kind, values)
+		}
+	}
+}
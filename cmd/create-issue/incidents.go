@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// incidentFeedTTL es cuánto reutilizamos la lista de incidentes. Durante una
+// caída llegan muchos reportes seguidos y no queremos sumar una descarga por
+// cada uno.
+const incidentFeedTTL = time.Minute
+
+// incidentTemplates son las plantillas que se cruzan con los incidentes: una
+// feature nueva no es un reporte de la caída.
+var incidentTemplates = map[string]bool{"bug": true}
+
+// resolvedIncidentStatuses son los estados de Statuspage que ya no cuentan
+// como incidente activo.
+var resolvedIncidentStatuses = map[string]bool{
+	"resolved":   true,
+	"postmortem": true,
+	"completed":  true,
+}
+
+// activeIncident es un incidente abierto en la página de estado.
+type activeIncident struct {
+	ID       string
+	Name     string
+	URL      string
+	Banner   string
+	Keywords []string
+}
+
+// incidentNotice es lo que recibe el frontend para mostrar el aviso.
+type incidentNotice struct {
+	Name   string `json:"name"`
+	URL    string `json:"url,omitempty"`
+	Banner string `json:"banner"`
+}
+
+// incidentFeed lee INCIDENTS_URL con un caché corto. Acepta el formato de
+// Statuspage (/api/v2/incidents/unresolved.json) y un campo opcional
+// "keywords" por incidente; sin él, se usan las palabras del nombre.
+type incidentFeed struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+	incidents []activeIncident
+}
+
+// newIncidentFeedFromEnv devuelve nil si INCIDENTS_URL no está definido.
+func newIncidentFeedFromEnv(getenv func(string) string) *incidentFeed {
+	url := strings.TrimSpace(getenv("INCIDENTS_URL"))
+	if url == "" {
+		return nil
+	}
+	return &incidentFeed{
+		url:    url,
+		ttl:    incidentFeedTTL,
+		client: &http.Client{Timeout: 3 * time.Second, Transport: &outboundLoggingTransport{}},
+	}
+}
+
+// Active devuelve los incidentes abiertos. Si la página de estado no
+// responde seguimos con la última lista conocida y no volvemos a intentar
+// hasta que venza el TTL, para no sumar su timeout a cada envío.
+func (f *incidentFeed) Active(ctx context.Context) ([]activeIncident, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fetchedAt.IsZero() || time.Since(f.fetchedAt) > f.ttl {
+		incidents, err := f.fetch(ctx)
+		f.fetchedAt = time.Now()
+		if err != nil {
+			return f.incidents, err
+		}
+		f.incidents = incidents
+	}
+	return f.incidents, nil
+}
+
+func (f *incidentFeed) fetch(ctx context.Context) ([]activeIncident, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("descargar %s: %w", f.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("descargar %s: estado %d", f.url, resp.StatusCode)
+	}
+	return parseIncidents(resp.Body)
+}
+
+func parseIncidents(r io.Reader) ([]activeIncident, error) {
+	var feed struct {
+		Incidents []struct {
+			ID              string   `json:"id"`
+			Name            string   `json:"name"`
+			Status          string   `json:"status"`
+			Shortlink       string   `json:"shortlink"`
+			Banner          string   `json:"banner"`
+			Keywords        []string `json:"keywords"`
+			IncidentUpdates []struct {
+				Body string `json:"body"`
+			} `json:"incident_updates"`
+		} `json:"incidents"`
+	}
+	if err := json.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("interpretar incidentes: %w", err)
+	}
+	var incidents []activeIncident
+	for _, in := range feed.Incidents {
+		if strings.TrimSpace(in.Name) == "" || resolvedIncidentStatuses[strings.ToLower(in.Status)] {
+			continue
+		}
+		incident := activeIncident{ID: in.ID, Name: strings.TrimSpace(in.Name), URL: strings.TrimSpace(in.Shortlink), Banner: strings.TrimSpace(in.Banner)}
+		// Statuspage pone la actualización más reciente primero.
+		if incident.Banner == "" && len(in.IncidentUpdates) > 0 {
+			incident.Banner = strings.TrimSpace(in.IncidentUpdates[0].Body)
+		}
+		if incident.Banner == "" {
+			incident.Banner = "Estamos atendiendo un incidente: " + incident.Name
+		}
+		keywords := in.Keywords
+		if len(keywords) == 0 {
+			keywords = significantWords(in.Name)
+		}
+		for _, keyword := range keywords {
+			if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+				incident.Keywords = append(incident.Keywords, keyword)
+			}
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, nil
+}
+
+// significantWords toma las palabras de cinco letras o más: "Falla en el
+// módulo de pagos" coincide por "falla", "módulo" y "pagos", no por "en".
+func significantWords(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if len([]rune(word)) >= 5 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// matchIncident busca un incidente activo cuyas palabras clave aparezcan en
+// el reporte. Nunca bloquea el envío: si la página de estado falla, el issue
+// se crea sin la nota.
+func matchIncident(ctx context.Context, templateID, title, body string) *activeIncident {
+	feed := loadServiceDeps().Incidents
+	if feed == nil || !incidentTemplates[templateID] {
+		return nil
+	}
+	incidents, err := feed.Active(ctx)
+	if err != nil {
+		if logger := loggerFromContext(ctx); logger != nil {
+			logger.log(ctx, "incident_lookup", severityError, fmt.Sprintf("no se pudo consultar la página de estado: %v", err))
+		}
+	}
+	text := strings.ToLower(title + "\n" + body)
+	for i := range incidents {
+		for _, keyword := range incidents[i].Keywords {
+			if strings.Contains(text, keyword) {
+				return &incidents[i]
+			}
+		}
+	}
+	return nil
+}
+
+// incidentNote es la nota que se agrega al cuerpo del issue para que triage
+// lo asocie con el incidente sin investigar de nuevo.
+func incidentNote(incident *activeIncident) string {
+	note := fmt.Sprintf("> [!NOTE]\n> Reportado durante el incidente activo «%s».", incident.Name)
+	if incident.URL != "" {
+		note += "\n> Seguimiento: " + incident.URL
+	}
+	return note
+}
+
+func (incident *activeIncident) notice() *incidentNotice {
+	if incident == nil {
+		return nil
+	}
+	return &incidentNotice{Name: incident.Name, URL: incident.URL, Banner: incident.Banner}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const statuspageFeed = `{"incidents": [
+	{"id": "a1", "name": "Falla en pagos con tarjeta", "status": "investigating", "shortlink": "https://stspg.io/a1",
+	 "incident_updates": [{"body": "Los pagos con tarjeta fallan; ya lo estamos atendiendo."}, {"body": "Investigando"}]},
+	{"id": "b2", "name": "Lentitud general", "status": "monitoring", "keywords": ["lento", "Timeout"], "banner": "El sistema está lento."},
+	{"id": "c3", "name": "Caída de reportes", "status": "resolved"}
+]}`
+
+func TestParseIncidents(t *testing.T) {
+	incidents, err := parseIncidents(strings.NewReader(statuspageFeed))
+	if err != nil {
+		t.Fatalf("parseIncidents: %v", err)
+	}
+	if len(incidents) != 2 {
+		t.Fatalf("los resueltos no cuentan: %+v", incidents)
+	}
+	pagos := incidents[0]
+	if pagos.Banner != "Los pagos con tarjeta fallan; ya lo estamos atendiendo." || strings.Join(pagos.Keywords, ",") != "falla,pagos,tarjeta" {
+		t.Fatalf("incidente inesperado: %+v", pagos)
+	}
+	if strings.Join(incidents[1].Keywords, ",") != "lento,timeout" {
+		t.Fatalf("las palabras clave explícitas tienen prioridad: %+v", incidents[1])
+	}
+}
+
+func TestPrepareSubmissionAnotaIncidenteActivo(t *testing.T) {
+	var fetches atomic.Int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			http.Error(w, "caído", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(statuspageFeed))
+	}))
+	defer server.Close()
+	feed := newIncidentFeedFromEnv(func(string) string { return server.URL })
+	useServiceDeps(t, func(deps *serviceDeps) { deps.Incidents = feed })
+
+	bug := issueRequest{TemplateID: "bug", Title: "No puedo pagar", Fields: map[string]string{"summary": "El cobro con TARJETA da error", "steps": "1", "expected": "a", "actual": "b"}, Consent: validConsent()}
+	prepared, subErr := prepareSubmission(context.Background(), bug)
+	if subErr != nil {
+		t.Fatalf("prepareSubmission: %v", subErr)
+	}
+	if prepared.Incident == nil || prepared.Incident.ID != "a1" || !strings.Contains(prepared.Body, "incidente activo «Falla en pagos con tarjeta»") {
+		t.Fatalf("se esperaba la nota del incidente:\n%s", prepared.Body)
+	}
+
+	feature := issueRequest{TemplateID: "feature", Title: "Pagar con tarjeta de regalo", Fields: map[string]string{"descripcion": "x", "criterio": "y"}, Consent: validConsent()}
+	if prepared, _ := prepareSubmission(context.Background(), feature); prepared.Incident != nil {
+		t.Fatal("solo los bugs se cruzan con incidentes")
+	}
+
+	// Con la página de estado caída seguimos con la última lista conocida.
+	failing.Store(true)
+	feed.ttl = 0
+	if prepared, subErr := prepareSubmission(context.Background(), bug); subErr != nil || prepared.Incident == nil {
+		t.Fatalf("una página de estado caída no debe bloquear ni perder el aviso: %v", subErr)
+	}
+	if fetches.Load() != 2 {
+		t.Fatalf("descargas = %d; se esperaban 2", fetches.Load())
+	}
+}
+
+func TestFormConfirmationTargetIncluyeIncidente(t *testing.T) {
+	resp := issueResponse{IssueURL: "https://github.com/o/r/issues/1", Incident: &incidentNotice{Name: "Falla", Banner: "Ya lo atendemos"}}
+	target, err := url.Parse(formConfirmationTarget(defaultFormConfirmationURL, resp))
+	if err != nil {
+		t.Fatalf("url: %v", err)
+	}
+	if got := target.Query().Get("incidente"); got != "Ya lo atendemos" {
+		t.Fatalf("incidente = %q", got)
+	}
+}
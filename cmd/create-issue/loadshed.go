@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// loadStatsPath expone la carga y los descartes (ver handleLoadStats).
+const loadStatsPath = "/admin/load"
+
+// cpuSampleInterval es cada cuánto se mide el uso de CPU del proceso.
+const cpuSampleInterval = time.Second
+
+// loadShedRetryAfter es lo que pedimos esperar a un envío descartado. Los
+// picos de tráfico que motivan el descarte suelen durar pocos minutos.
+const loadShedRetryAfter = 30 * time.Second
+
+// Prioridades por plantilla: 0 es crítica y nunca se descarta. Los bugs
+// pasan primero porque son los reportes que no pueden esperar al pico.
+var defaultTemplatePriorities = map[string]int{
+	"bug":            0,
+	"change_request": 1,
+	"feature":        1,
+	"blank":          2,
+}
+
+// loadSignal es la foto de saturación con la que se decide cada envío.
+// QueueDepth es -1 si la cola no informa su profundidad (Pub/Sub) y CPU, el
+// porcentaje de uso sobre GOMAXPROCS, es -1 si no se mide.
+type loadSignal struct {
+	InFlight   int
+	QueueDepth int
+	CPU        int
+}
+
+// sheddingPolicy decide si admitir un envío según su plantilla y la carga.
+// Es intercambiable para probar otras reglas sin tocar el handler.
+type sheddingPolicy interface {
+	Admit(templateID string, load loadSignal) bool
+}
+
+// queueDepthReporter lo implementan las colas que conocen su profundidad.
+type queueDepthReporter interface {
+	Depth() int
+}
+
+// priorityPolicy descarta por escalones: con la carga al 80 % de cualquiera
+// de los límites rechaza la prioridad más baja y al 100 % todo lo que no sea
+// crítico. Un límite en cero no se vigila.
+type priorityPolicy struct {
+	MaxInFlight   int
+	MaxQueueDepth int
+	MaxCPU        int
+	Priorities    map[string]int
+}
+
+// saturation devuelve la carga en porcentaje del límite más exigido.
+func (p priorityPolicy) saturation(load loadSignal) int {
+	level := 0
+	if p.MaxInFlight > 0 {
+		level = max(level, load.InFlight*100/p.MaxInFlight)
+	}
+	if p.MaxQueueDepth > 0 && load.QueueDepth >= 0 {
+		level = max(level, load.QueueDepth*100/p.MaxQueueDepth)
+	}
+	if p.MaxCPU > 0 && load.CPU >= 0 {
+		level = max(level, load.CPU*100/p.MaxCPU)
+	}
+	return level
+}
+
+func (p priorityPolicy) priority(templateID string) int {
+	if priority, ok := p.Priorities[templateID]; ok {
+		return priority
+	}
+	// Una plantilla sin prioridad declarada se trata como la más baja.
+	return p.lowest()
+}
+
+func (p priorityPolicy) lowest() int {
+	lowest := 0
+	for _, priority := range p.Priorities {
+		lowest = max(lowest, priority)
+	}
+	return lowest
+}
+
+func (p priorityPolicy) Admit(templateID string, load loadSignal) bool {
+	priority := p.priority(templateID)
+	if priority == 0 {
+		return true
+	}
+	switch level := p.saturation(load); {
+	case level >= 100:
+		return false
+	case level >= 80:
+		return priority < p.lowest()
+	default:
+		return true
+	}
+}
+
+// loadShedder lleva la cuenta de envíos en curso y de los descartados por
+// plantilla, que GET /admin/load expone para el monitoreo.
+type loadShedder struct {
+	policy sheddingPolicy
+	// cpu mide el uso de CPU si la política lo vigila; nil si no.
+	cpu *cpuSampler
+
+	inFlight atomic.Int64
+
+	mu   sync.Mutex
+	shed map[string]int
+}
+
+func newLoadShedder(policy sheddingPolicy) *loadShedder {
+	return &loadShedder{policy: policy, shed: map[string]int{}}
+}
+
+// newLoadShedderFromEnv lee LOAD_SHED_MAX_INFLIGHT (envíos simultáneos),
+// LOAD_SHED_MAX_QUEUE_DEPTH (trabajos esperando en la cola),
+// LOAD_SHED_MAX_CPU (porcentaje de uso de CPU sobre GOMAXPROCS) y,
+// opcionalmente, LOAD_SHED_PRIORITIES ("bug=0,feature=1,blank=2"). Sin
+// ningún límite el descarte queda desactivado.
+func newLoadShedderFromEnv(getenv func(string) string) (*loadShedder, error) {
+	policy := priorityPolicy{Priorities: defaultTemplatePriorities}
+	var err error
+	if policy.MaxInFlight, err = nonNegativeIntEnv(getenv, "LOAD_SHED_MAX_INFLIGHT"); err != nil {
+		return nil, err
+	}
+	if policy.MaxQueueDepth, err = nonNegativeIntEnv(getenv, "LOAD_SHED_MAX_QUEUE_DEPTH"); err != nil {
+		return nil, err
+	}
+	if policy.MaxCPU, err = nonNegativeIntEnv(getenv, "LOAD_SHED_MAX_CPU"); err != nil {
+		return nil, err
+	}
+	if policy.MaxCPU > 100 {
+		return nil, fmt.Errorf("LOAD_SHED_MAX_CPU inválido: %d (es un porcentaje)", policy.MaxCPU)
+	}
+	if policy.MaxInFlight == 0 && policy.MaxQueueDepth == 0 && policy.MaxCPU == 0 {
+		return nil, nil
+	}
+	if raw := strings.TrimSpace(getenv("LOAD_SHED_PRIORITIES")); raw != "" {
+		priorities := map[string]int{}
+		for _, pair := range strings.Split(raw, ",") {
+			templateID, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			templateID = strings.TrimSpace(templateID)
+			priority, convErr := strconv.Atoi(strings.TrimSpace(value))
+			if !ok || convErr != nil || priority < 0 {
+				return nil, fmt.Errorf("LOAD_SHED_PRIORITIES inválido: %q (se espera plantilla=número)", pair)
+			}
+			if templateID == "" {
+				return nil, fmt.Errorf("LOAD_SHED_PRIORITIES inválido: %q (se espera plantilla=número)", pair)
+			}
+			priorities[templateID] = priority
+		}
+		policy.Priorities = priorities
+	}
+	shedder := newLoadShedder(policy)
+	if policy.MaxCPU > 0 {
+		shedder.cpu = newCPUSampler()
+	}
+	return shedder, nil
+}
+
+// warnUnknownTemplates avisa de las plantillas de LOAD_SHED_PRIORITIES que el
+// catálogo vigente no conoce. Se llama después de recargar los formularios.
+func (l *loadShedder) warnUnknownTemplates() {
+	if policy, ok := l.policy.(priorityPolicy); ok {
+		warnUnknownTemplates("LOAD_SHED_PRIORITIES", policy.Priorities)
+	}
+}
+
+// cpuSampler mide el uso de CPU del proceso entre muestras, como porcentaje
+// de lo que permite GOMAXPROCS. En Cloud Run GOMAXPROCS sigue a las CPU
+// asignadas, así que 100 % es la instancia saturada.
+type cpuSampler struct {
+	percent atomic.Int64
+
+	lastWall time.Time
+	lastCPU  time.Duration
+}
+
+func newCPUSampler() *cpuSampler {
+	s := &cpuSampler{}
+	s.percent.Store(-1)
+	return s
+}
+
+// Percent devuelve la última medición o -1 si todavía no hay.
+func (s *cpuSampler) Percent() int { return int(s.percent.Load()) }
+
+// Sample toma una muestra; la primera solo fija el punto de partida.
+func (s *cpuSampler) Sample(now time.Time, cpu time.Duration) {
+	if !s.lastWall.IsZero() {
+		if wall := now.Sub(s.lastWall) * time.Duration(runtime.GOMAXPROCS(0)); wall > 0 {
+			s.percent.Store(int64((cpu - s.lastCPU) * 100 / wall))
+		}
+	}
+	s.lastWall, s.lastCPU = now, cpu
+}
+
+// Run mide cada interval hasta que ctx se cancela. Si la plataforma no
+// informa el uso de CPU la señal queda en -1 y no se vigila.
+func (s *cpuSampler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		cpu, err := processCPUTime()
+		if err != nil {
+			log.Printf("descarte por carga: no se puede medir la CPU: %v", err)
+			return
+		}
+		s.Sample(time.Now(), cpu)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func nonNegativeIntEnv(getenv func(string) string, key string) (int, error) {
+	raw := strings.TrimSpace(getenv(key))
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s inválido: %q", key, raw)
+	}
+	return value, nil
+}
+
+// Begin decide si el envío entra. Si entra, el llamador debe invocar done al
+// terminar para liberar su lugar.
+func (l *loadShedder) Begin(templateID string, queue submissionQueue) (done func(), admitted bool) {
+	load := loadSignal{InFlight: int(l.inFlight.Load()), QueueDepth: -1, CPU: -1}
+	if l.cpu != nil {
+		load.CPU = l.cpu.Percent()
+	}
+	if reporter, ok := queue.(queueDepthReporter); ok {
+		load.QueueDepth = reporter.Depth()
+	}
+	if !l.policy.Admit(templateID, load) {
+		l.mu.Lock()
+		l.shed[templateID]++
+		l.mu.Unlock()
+		return nil, false
+	}
+	l.inFlight.Add(1)
+	return func() { l.inFlight.Add(-1) }, true
+}
+
+// loadStats es lo que GET /admin/load devuelve.
+type loadStats struct {
+	InFlight   int            `json:"inFlight"`
+	QueueDepth *int           `json:"queueDepth,omitempty"`
+	CPU        *int           `json:"cpuPercent,omitempty"`
+	Shed       map[string]int `json:"shed"`
+}
+
+func (l *loadShedder) Stats() loadStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	shed := make(map[string]int, len(l.shed))
+	for templateID, n := range l.shed {
+		shed[templateID] = n
+	}
+	stats := loadStats{InFlight: int(l.inFlight.Load()), Shed: shed}
+	if l.cpu != nil {
+		if percent := l.cpu.Percent(); percent >= 0 {
+			stats.CPU = &percent
+		}
+	}
+	return stats
+}
+
+// admitSubmission aplica el descarte antes de validar el envío: bajo carga
+// ni siquiera gastamos en prepararlo. Devuelve la función que libera el
+// lugar (nunca nil) o un error 503 con Retry-After.
+func admitSubmission(ctx context.Context, w http.ResponseWriter, templateID string) (func(), *submissionError) {
+	deps := loadServiceDeps()
+	if deps.LoadShedder == nil {
+		return func() {}, nil
+	}
+	done, admitted := deps.LoadShedder.Begin(templateID, deps.SubmissionQueue)
+	if admitted {
+		return done, nil
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.log(ctx, "load_shed", severityInfo, fmt.Sprintf("envío de la plantilla %q descartado por saturación", templateID))
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(loadShedRetryAfter.Seconds())))
+	return nil, &submissionError{
+		Status:  http.StatusServiceUnavailable,
+		Code:    "overloaded",
+		Message: "El servicio está recibiendo muchos reportes; intenta de nuevo en unos segundos",
+	}
+}
+
+// handleLoadStats expone la carga y los descartes con el mismo ADMIN_TOKEN
+// que la cuarentena.
+func handleLoadStats(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	deps := loadServiceDeps()
+	if !authorizeAdmin(ctx, w, r, deps.LoadShedder != nil) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(ctx, w, http.StatusMethodNotAllowed, "method_not_allowed", "método no permitido", nil)
+		return
+	}
+	stats := deps.LoadShedder.Stats()
+	if reporter, ok := deps.SubmissionQueue.(queueDepthReporter); ok {
+		depth := reporter.Depth()
+		stats.QueueDepth = &depth
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, stats)
+}
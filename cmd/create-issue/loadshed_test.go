@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPriorityPolicyDescartaPorEscalones(t *testing.T) {
+	policy := priorityPolicy{MaxInFlight: 10, MaxQueueDepth: 100, Priorities: defaultTemplatePriorities}
+	cases := []struct {
+		name     string
+		template string
+		load     loadSignal
+		want     bool
+	}{
+		{"sin carga", "blank", loadSignal{InFlight: 2, QueueDepth: 0}, true},
+		{"80% descarta la más baja", "blank", loadSignal{InFlight: 8, QueueDepth: -1}, false},
+		{"80% admite feature", "feature", loadSignal{InFlight: 8, QueueDepth: -1}, true},
+		{"cola llena descarta feature", "feature", loadSignal{InFlight: 0, QueueDepth: 100}, false},
+		{"bug siempre entra", "bug", loadSignal{InFlight: 50, QueueDepth: 500}, true},
+		{"plantilla sin prioridad es la más baja", "otra", loadSignal{InFlight: 9, QueueDepth: -1}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := policy.Admit(tc.template, tc.load); got != tc.want {
+				t.Fatalf("Admit(%q, %+v) = %v; se esperaba %v", tc.template, tc.load, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewLoadShedderFromEnv(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(key string) string { return values[key] }
+	}
+	if shedder, err := newLoadShedderFromEnv(env(nil)); err != nil || shedder != nil {
+		t.Fatalf("sin límites el descarte debe quedar desactivado, llegó %v / %v", shedder != nil, err)
+	}
+	shedder, err := newLoadShedderFromEnv(env(map[string]string{"LOAD_SHED_MAX_INFLIGHT": "4", "LOAD_SHED_PRIORITIES": "bug=0, feature=2"}))
+	if err != nil || shedder == nil {
+		t.Fatalf("newLoadShedderFromEnv: %v", err)
+	}
+	if policy := shedder.policy.(priorityPolicy); policy.MaxInFlight != 4 || policy.Priorities["feature"] != 2 {
+		t.Fatalf("política inesperada: %+v", policy)
+	}
+	for _, bad := range []map[string]string{
+		{"LOAD_SHED_MAX_INFLIGHT": "-1"},
+		{"LOAD_SHED_MAX_QUEUE_DEPTH": "mucho"},
+		{"LOAD_SHED_MAX_CPU": "150"},
+		{"LOAD_SHED_MAX_INFLIGHT": "4", "LOAD_SHED_PRIORITIES": "bug"},
+	} {
+		if _, err := newLoadShedderFromEnv(env(bad)); err == nil {
+			t.Errorf("se esperaba error con %v", bad)
+		}
+	}
+
+	// Una plantilla que el catálogo todavía no tiene se acepta: puede llegar
+	// con la próxima recarga de los formularios.
+	if _, err := newLoadShedderFromEnv(env(map[string]string{"LOAD_SHED_MAX_INFLIGHT": "4", "LOAD_SHED_PRIORITIES": "nueva=0"})); err != nil {
+		t.Fatalf("una plantilla nueva no debe impedir el arranque: %v", err)
+	}
+	shedder, err = newLoadShedderFromEnv(env(map[string]string{"LOAD_SHED_MAX_CPU": "80"}))
+	if err != nil || shedder == nil || shedder.cpu == nil {
+		t.Fatalf("LOAD_SHED_MAX_CPU solo activa el descarte y la medición: %+v / %v", shedder, err)
+	}
+}
+
+func TestPriorityPolicyVigilaLaCPU(t *testing.T) {
+	policy := priorityPolicy{MaxCPU: 80, Priorities: defaultTemplatePriorities}
+	if !policy.Admit("blank", loadSignal{CPU: -1, QueueDepth: -1}) {
+		t.Fatal("sin medición de CPU no se descarta")
+	}
+	if policy.Admit("blank", loadSignal{CPU: 70, QueueDepth: -1}) {
+		t.Fatal("al 87 % del límite de CPU se descarta la prioridad más baja")
+	}
+	if !policy.Admit("feature", loadSignal{CPU: 70, QueueDepth: -1}) || policy.Admit("feature", loadSignal{CPU: 90, QueueDepth: -1}) {
+		t.Fatal("pasado el límite de CPU solo entra la prioridad 0")
+	}
+}
+
+func TestCPUSamplerMideSobreGOMAXPROCS(t *testing.T) {
+	sampler := newCPUSampler()
+	start := time.Unix(0, 0)
+	sampler.Sample(start, 0)
+	if sampler.Percent() != -1 {
+		t.Fatal("la primera muestra solo fija el punto de partida")
+	}
+	procs := time.Duration(runtime.GOMAXPROCS(0))
+	sampler.Sample(start.Add(time.Second), procs*time.Second/2)
+	if got := sampler.Percent(); got != 50 {
+		t.Fatalf("medio segundo de CPU por procesador en un segundo es 50 %%, llegó %d", got)
+	}
+}
+
+func TestLoadShedderLiberaElLugar(t *testing.T) {
+	shedder := newLoadShedder(priorityPolicy{MaxInFlight: 1, Priorities: defaultTemplatePriorities})
+	done, ok := shedder.Begin("feature", nil)
+	if !ok {
+		t.Fatal("el primer envío debe entrar")
+	}
+	if _, ok := shedder.Begin("feature", nil); ok {
+		t.Fatal("con el límite alcanzado una feature debe descartarse")
+	}
+	if _, ok := shedder.Begin("bug", nil); !ok {
+		t.Fatal("un bug debe entrar aunque haya saturación")
+	}
+	done()
+	if stats := shedder.Stats(); stats.InFlight != 1 || stats.Shed["feature"] != 1 {
+		t.Fatalf("estadísticas inesperadas: %+v", stats)
+	}
+}
+
+func TestDescarteResponde503ConRetryAfter(t *testing.T) {
+	logs := &memoryLogBackend{}
+	queue := newMemorySubmissionQueue(4)
+	if err := queue.Enqueue(context.Background(), submissionJob{ID: "pendiente"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = logs
+		deps.SubmissionQueue = queue
+		deps.AdminToken = "admin"
+		deps.LoadShedder = newLoadShedder(priorityPolicy{MaxQueueDepth: 1, Priorities: defaultTemplatePriorities})
+	})
+
+	body := fmt.Sprintf(`{"templateId":"blank","title":"x","fields":{"descripcion":"y"},%s}`, consentJSON())
+	req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") != "30" {
+		t.Fatalf("se esperaba 503 con Retry-After, llegó %d (%q): %s", rr.Code, rr.Header().Get("Retry-After"), rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"code":"overloaded"`) {
+		t.Fatalf("código inesperado: %s", rr.Body.String())
+	}
+
+	stats := adminRequest(http.MethodGet, loadStatsPath, "admin", "")
+	var got loadStats
+	if err := json.Unmarshal(stats.Body.Bytes(), &got); err != nil || stats.Code != http.StatusOK {
+		t.Fatalf("estadísticas ilegibles (%d): %s", stats.Code, stats.Body.String())
+	}
+	if got.Shed["blank"] != 1 || got.QueueDepth == nil || *got.QueueDepth != 1 {
+		t.Fatalf("estadísticas inesperadas: %+v", got)
+	}
+}
@@ -20,12 +20,14 @@ import (
 	"net/textproto"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"eos-roadmap-tools/internal/errcodes"
 	"github.com/shurcooL/githubv4"
-	"golang.org/x/oauth2"
 )
 
 type fieldType string
@@ -37,24 +39,32 @@ const (
 )
 
 type templateField struct {
-	ID       string
-	Label    string
-	Type     fieldType
-	Required bool
-	Value    string
+	ID          string
+	Label       string
+	Type        fieldType
+	Required    bool
+	Value       string
+	Description string
+	Placeholder string
 }
 
+// issueTemplate es la fuente de verdad de cada formulario. Name y Description
+// solo se usan al exportar el formulario nativo de GitHub (ver forms.go).
 type issueTemplate struct {
-	ID     string
-	Title  string
-	Labels []string
-	Body   []templateField
+	ID          string
+	Name        string
+	Description string
+	Title       string
+	Labels      []string
+	Body        []templateField
 }
 
 var templates = map[string]issueTemplate{
 	"blank": {
-		ID:    "blank",
-		Title: "[ISSUE] Título",
+		ID:          "blank",
+		Name:        "🗿 Issue",
+		Description: "Issue libre con estructura mínima",
+		Title:       "[ISSUE] Título",
 		// Mantenemos las etiquetas exactamente como existen en GitHub para
 		// evitar rechazos por diferencias mínimas (poka-yoke: prevenir errores
 		// antes de que sucedan al confiar en textos iguales a los del tablero).
@@ -64,58 +74,63 @@ var templates = map[string]issueTemplate{
 		},
 		Body: []templateField{
 			{
-				ID:    "descripcion",
-				Label: "Descripción",
-				Type:  fieldTypeTextarea,
-				Value: "**Contexto**\n-\n\n**Detalles**\n-\n\n**Criterio de aceptación**\n-",
+				ID:          "descripcion",
+				Label:       "Descripción",
+				Type:        fieldTypeTextarea,
+				Value:       "**Contexto**\n- \n\n**Detalles**\n- \n\n**Criterio de aceptación**\n- \n",
+				Placeholder: "Escribe aquí…",
 			},
 		},
 	},
 	"bug": {
-		ID:    "bug",
-		Title: "fix: <resumen>",
+		ID:          "bug",
+		Name:        "🐞 Bug",
+		Description: "Reportar un defecto",
+		Title:       "fix: <resumen>",
 		Labels: []string{
 			"Tipo: Bug",
 			"Status :En planeación",
 		},
 		Body: []templateField{
-			{ID: "summary", Label: "Resumen", Type: fieldTypeInput, Required: true},
-			{ID: "steps", Label: "Pasos para reproducir", Type: fieldTypeTextarea, Required: true},
+			{ID: "summary", Label: "Resumen", Type: fieldTypeInput, Required: true, Placeholder: "Error 500 al crear programa"},
+			{ID: "steps", Label: "Pasos para reproducir", Type: fieldTypeTextarea, Required: true, Placeholder: "1. Ir a /programas → 2. Click en crear → 3. ..."},
 			{ID: "expected", Label: "Comportamiento esperado", Type: fieldTypeTextarea, Required: true},
 			{ID: "actual", Label: "Comportamiento actual", Type: fieldTypeTextarea, Required: true},
-			{ID: "env", Label: "Entorno", Type: fieldTypeTextarea},
+			{ID: "env", Label: "Entorno", Type: fieldTypeTextarea, Placeholder: "Prod/Stg/Dev, navegador, versión"},
 			{ID: "logs", Label: "Logs/evidencia", Type: fieldTypeTextarea},
 		},
 	},
 	"change_request": {
-		ID:    "change_request",
-		Title: "chore: change-request <resumen>",
+		ID:          "change_request",
+		Name:        "📝 Solicitud de Cambio",
+		Description: "Solicitar un cambio de alcance/alcance técnico",
+		Title:       "chore: change-request <resumen>",
 		Labels: []string{
 			"Tipo: Change Request",
 			"Status: Ideas",
 		},
 		Body: []templateField{
 			{
-				ID:    "intro",
-				Label: "",
 				Type:  fieldTypeMarkdown,
-				Value: "Describe el cambio propuesto y el impacto (tiempo, costo, riesgo). Será evaluado.",
+				Value: "Describe el cambio propuesto y el impacto (tiempo, costo, riesgo). Será evaluado.\n",
 			},
 			{ID: "description", Label: "Descripción del cambio", Type: fieldTypeTextarea, Required: true},
 			{ID: "impact", Label: "Impacto (alcance/tiempo/costo/riesgo)", Type: fieldTypeTextarea, Required: true},
-			{ID: "requester", Label: "Solicitante", Type: fieldTypeInput, Required: true},
+			{ID: "requester", Label: "Solicitante", Type: fieldTypeInput, Required: true, Placeholder: "@stakeholder"},
 		},
 	},
 	"feature": {
-		ID:    "feature",
-		Title: "[FEAT] Título de la feature",
+		ID:          "feature",
+		Name:        "⚙ Feature",
+		Description: "Nueva capacidad o mejora",
+		Title:       "[FEAT] Título de la feature",
 		Labels: []string{
 			"Tipo: Feature",
 			"Status: Ideas",
 		},
 		Body: []templateField{
-			{ID: "descripcion", Label: "Descripción", Type: fieldTypeTextarea, Required: true},
-			{ID: "criterio", Label: "Criterio de aceptación (resumen)", Type: fieldTypeInput, Required: true},
+			{ID: "descripcion", Label: "Descripción", Type: fieldTypeTextarea, Required: true, Placeholder: "Como [rol] quiero [función] para [beneficio]"},
+			{ID: "criterio", Label: "Criterio de aceptación (resumen)", Type: fieldTypeInput, Required: true, Placeholder: "Dado/Cuando/Entonces..."},
 		},
 	},
 }
@@ -124,17 +139,53 @@ type issueRequest struct {
 	TemplateID string            `json:"templateId"`
 	Title      string            `json:"title"`
 	Fields     map[string]string `json:"fields"`
+	Client     *clientInfo       `json:"client,omitempty"`
+	ModuleID   string            `json:"moduleId,omitempty"`
+	Consent    *consentRecord    `json:"consent,omitempty"`
+	// DuplicateOf es el issue que la interfaz mostró como posible duplicado
+	// cuando la persona decidió enviar de todos modos.
+	DuplicateOf int `json:"duplicateOf,omitempty"`
+	// Accessibility es la sección opcional para problemas de accesibilidad.
+	Accessibility *accessibilityInfo `json:"accessibility,omitempty"`
+	// Honeypot es el campo oculto del formulario (ver honeypotField); solo
+	// lo llenan los bots.
+	Honeypot string `json:"website,omitempty"`
+	// RequestID es la clave de idempotencia para clientes que no pueden
+	// enviar el encabezado Idempotency-Key.
+	RequestID string `json:"requestId,omitempty"`
+	// CaptchaToken es la respuesta del widget de Turnstile o hCaptcha; solo
+	// se exige con CAPTCHA_PROVIDER definido.
+	CaptchaToken string `json:"captchaToken,omitempty"`
+	// Attachments son capturas en base64; solo se aceptan con
+	// ATTACHMENTS_STORE definido.
+	Attachments []attachmentInput `json:"attachments,omitempty"`
+	// Force crea el issue aunque haya posibles duplicados abiertos.
+	Force bool `json:"force,omitempty"`
 }
 
 type apiError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    errcodes.Code `json:"code"`
+	Message string        `json:"message"`
+	// Hint sugiere qué hacer; sale de errorHints según el código y el idioma
+	// negociado.
+	Hint string `json:"hint,omitempty"`
 }
 
 type issueResponse struct {
-	IssueURL string    `json:"issueUrl,omitempty"`
-	Error    *apiError `json:"error,omitempty"`
-	DebugID  string    `json:"debugId,omitempty"`
+	IssueURL     string `json:"issueUrl,omitempty"`
+	ShortURL     string `json:"shortUrl,omitempty"`
+	SessionToken string `json:"sessionToken,omitempty"`
+	ExpiresAt    string `json:"expiresAt,omitempty"`
+	SubmissionID string `json:"submissionId,omitempty"`
+	// PendingReview indica que el envío quedó en cuarentena hasta que
+	// alguien lo apruebe; SubmissionID lo identifica.
+	PendingReview bool `json:"pendingReview,omitempty"`
+	// Incident avisa que el reporte coincide con un incidente ya conocido.
+	Incident *incidentNotice `json:"incident,omitempty"`
+	// Duplicates acompaña a possible_duplicates con los issues parecidos.
+	Duplicates []duplicateCandidate `json:"duplicates,omitempty"`
+	Error      *apiError            `json:"error,omitempty"`
+	DebugID    string               `json:"debugId,omitempty"`
 }
 
 type githubIssueResponse struct {
@@ -144,11 +195,26 @@ type githubIssueResponse struct {
 }
 
 const (
-	githubRepoOwner = "RON-DATADRIVEN"
-	githubRepoName  = "eos-roadmap"
-	userAgent       = "eos-roadmap-create-issue/1.0"
+	defaultGitHubRepo = "RON-DATADRIVEN/eos-roadmap"
+	userAgent         = "eos-roadmap-create-issue/1.0"
 )
 
+// githubRepoOwner y githubRepoName son el repositorio donde se crean los
+// issues. GITHUB_REPO ("dueño/nombre") los cambia para desplegar contra un
+// repositorio de pruebas; la suite e2e lo usa con su sandbox.
+var githubRepoOwner, githubRepoName = splitGitHubRepo(envOrDefault("GITHUB_REPO", defaultGitHubRepo))
+
+// splitGitHubRepo separa "dueño/nombre". Un valor mal formado cae al
+// repositorio por defecto con un aviso en lugar de armar URLs inválidas.
+func splitGitHubRepo(raw string) (string, string) {
+	owner, name, ok := strings.Cut(strings.TrimSpace(raw), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		log.Printf("GITHUB_REPO inválido %q; se usa %s", raw, defaultGitHubRepo)
+		owner, name, _ = strings.Cut(defaultGitHubRepo, "/")
+	}
+	return owner, name
+}
+
 const defaultAllowedOrigin = "https://ron-datadriven.github.io"
 
 // maxRequestBodyBytes limita el tamaño del JSON recibido para evitar que un
@@ -163,6 +229,11 @@ const maxRequestBodyBytes = 1 << 20
 // consola de operaciones.
 const defaultLogID = "create-issue-requests"
 
+// serverShutdownTimeout es cuánto esperamos a las solicitudes en curso tras
+// SIGTERM. Sumado a los 5 s del último envío de analítica queda dentro de
+// los 10 s que Cloud Run concede antes de matar la instancia.
+const serverShutdownTimeout = 4 * time.Second
+
 type originEntry struct {
 	raw        string
 	normalized string
@@ -182,17 +253,9 @@ var (
 	// despliegue apresurado.
 	buildDefaultAllowedOrigins = defaultAllowedOrigin
 
-	allowAnyOrigin       bool
-	allowedOriginEntries            = configureAllowedOrigins(allowedOrigin, buildDefaultAllowedOrigins)
-	requestLogBackend    logBackend = &noopLogBackend{}
-)
-
-// issueCreator y projectAdder son funciones intercambiables para facilitar el
-// reemplazo en pruebas. Gracias a esto podemos simular respuestas de GitHub sin
-// depender de la red, evitando sorpresas durante la automatización.
-var (
-	issueCreator = createIssue
-	projectAdder = addToProjectAndSetType
+	// reloadOnSIGHUP permite desactivar la recarga por señal en entornos donde
+	// SIGHUP tiene otro significado.
+	reloadOnSIGHUP = !parseBoolEnv(os.Getenv("DISABLE_SIGHUP_RELOAD"))
 )
 
 // logBackend describe el sistema externo al que enviamos cada registro. Nos
@@ -217,18 +280,21 @@ const (
 // solicitud. Se serializa a JSON antes de enviarse al backend, de modo que un
 // analista pueda buscar fácilmente por ID, método, plantilla o código de error.
 type logEntry struct {
-	Timestamp      time.Time   `json:"timestamp"`
-	RequestID      string      `json:"requestId"`
-	Stage          string      `json:"stage"`
-	Severity       logSeverity `json:"severity"`
-	Method         string      `json:"method"`
-	Path           string      `json:"path"`
-	Origin         string      `json:"origin"`
-	TemplateID     string      `json:"templateId,omitempty"`
-	Status         int         `json:"status"`
-	ErrorCode      string      `json:"errorCode,omitempty"`
-	Message        string      `json:"message,omitempty"`
-	DurationMillis int64       `json:"durationMillis,omitempty"`
+	Timestamp      time.Time      `json:"timestamp"`
+	RequestID      string         `json:"requestId"`
+	Stage          string         `json:"stage"`
+	Severity       logSeverity    `json:"severity"`
+	Method         string         `json:"method"`
+	Path           string         `json:"path"`
+	Origin         string         `json:"origin"`
+	TemplateID     string         `json:"templateId,omitempty"`
+	Status         int            `json:"status"`
+	ErrorCode      string         `json:"errorCode,omitempty"`
+	Message        string         `json:"message,omitempty"`
+	DurationMillis int64          `json:"durationMillis,omitempty"`
+	Outbound       *outboundCall  `json:"outbound,omitempty"`
+	Consent        *consentRecord `json:"consent,omitempty"`
+	Flags          []string       `json:"flags,omitempty"`
 }
 
 // noopLogBackend actúa como un respaldo seguro cuando todavía no hemos
@@ -281,6 +347,10 @@ type requestLogger struct {
 	status     int
 	errorCode  string
 	startedAt  time.Time
+	// clientIP no se registra; solo agrupa las pausas de envíos sin origen.
+	clientIP string
+	// flags son las banderas evaluadas para la petición, ya en forma de log.
+	flags []string
 }
 
 // requestLoggerKey es la clave privada que usamos para guardar el logger en el
@@ -329,6 +399,7 @@ func newRequestLogger(ctx context.Context, backend logBackend, r *http.Request)
 		method:    r.Method,
 		path:      r.URL.Path,
 		origin:    strings.TrimSpace(r.Header.Get("Origin")),
+		clientIP:  requestClientIP(r),
 		startedAt: time.Now().UTC(),
 	}
 
@@ -416,6 +487,7 @@ func (rl *requestLogger) logWithEntry(ctx context.Context, stage string, severit
 	entry.Status = rl.status
 	entry.ErrorCode = rl.errorCode
 	entry.Message = message
+	entry.Flags = rl.flags
 
 	if err := rl.backend.Log(ctx, entry); err != nil {
 		log.Printf("no se pudo registrar en el backend de logs: %v", err)
@@ -438,15 +510,16 @@ type cloudLoggingBackend struct {
 	projectID string
 	logName   string
 	client    *http.Client
-
-	tokenMu sync.Mutex
-	token   string
-	expiry  time.Time
+	tokens    *googleTokenCache
 }
 
 const loggingEndpoint = "https://logging.googleapis.com/v2/entries:write"
 const metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
 
+// loggingScope es el permiso mínimo que solicitamos cuando firmamos un JWT con
+// credenciales locales para escribir en Cloud Logging.
+const loggingScope = "https://www.googleapis.com/auth/logging.write"
+
 // newCloudLoggingBackend inicializa la estructura y valida los parámetros. Al
 // fallar devolvemos un error explícito para que el operador corrija credenciales
 // o permisos antes de iniciar el servicio.
@@ -465,6 +538,7 @@ func newCloudLoggingBackend(ctx context.Context, projectID, logName string) (log
 		projectID: projectID,
 		logName:   fullLogName,
 		client:    &http.Client{Timeout: 10 * time.Second},
+		tokens:    &googleTokenCache{scope: loggingScope},
 	}, nil
 }
 
@@ -515,27 +589,44 @@ func (c *cloudLoggingBackend) Log(ctx context.Context, entry logEntry) error {
 }
 
 func (c *cloudLoggingBackend) ensureToken(ctx context.Context) (string, error) {
-	c.tokenMu.Lock()
-	defer c.tokenMu.Unlock()
+	return c.tokens.Token(ctx)
+}
+
+func (c *cloudLoggingBackend) Close() error { return nil }
 
-	if c.token != "" && time.Until(c.expiry) > time.Minute {
-		return c.token, nil
+// googleTokenCache guarda el último token de Google obtenido para un scope y
+// lo renueva un minuto antes de expirar. Lo comparten todos los clientes de
+// APIs de Google del servicio para no repetir la lógica de renovación.
+type googleTokenCache struct {
+	scope string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (g *googleTokenCache) Token(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.token != "" && time.Until(g.expiry) > time.Minute {
+		return g.token, nil
 	}
 
-	token, expiry, err := fetchToken(ctx)
+	token, expiry, err := fetchToken(ctx, g.scope)
 	if err != nil {
 		return "", err
 	}
-	c.token = token
-	c.expiry = expiry
-	return c.token, nil
+	g.token = token
+	g.expiry = expiry
+	return g.token, nil
 }
 
-func (c *cloudLoggingBackend) Close() error { return nil }
-
 // fetchToken intenta primero obtener un token mediante metadata y, si falla,
-// recurre a las credenciales locales definidas por el operador.
-func fetchToken(ctx context.Context) (string, time.Time, error) {
+// recurre a las credenciales locales definidas por el operador. El scope solo
+// aplica a las credenciales locales: el token de metadata hereda los permisos
+// de la cuenta de servicio.
+func fetchToken(ctx context.Context, scope string) (string, time.Time, error) {
 	token, expiry, metadataErr := fetchTokenFromMetadata(ctx)
 	if metadataErr == nil {
 		return token, expiry, nil
@@ -550,7 +641,7 @@ func fetchToken(ctx context.Context) (string, time.Time, error) {
 		return "", time.Time{}, errors.New("GOOGLE_APPLICATION_CREDENTIALS no definido y metadata inaccesible")
 	}
 
-	return fetchTokenFromCredentials(ctx, credentialsPath)
+	return fetchTokenFromCredentials(ctx, credentialsPath, scope)
 }
 
 // fetchTokenFromMetadata utiliza el servidor de metadata disponible en Cloud
@@ -589,60 +680,31 @@ func fetchTokenFromMetadata(ctx context.Context) (string, time.Time, error) {
 	return tokenResp.AccessToken, expiry, nil
 }
 
-// fetchTokenFromCredentials lee un archivo JSON de cuenta de servicio y obtiene
-// un token OAuth2 válido para escribir en Cloud Logging.
-func fetchTokenFromCredentials(ctx context.Context, path string) (string, time.Time, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", time.Time{}, fmt.Errorf("no se pudo leer credenciales: %w", err)
-	}
-
-	var creds struct {
-		ClientEmail string `json:"client_email"`
-		PrivateKey  string `json:"private_key"`
-		TokenURI    string `json:"token_uri"`
-	}
-	if err := json.Unmarshal(data, &creds); err != nil {
-		return "", time.Time{}, fmt.Errorf("formato de credenciales inválido: %w", err)
-	}
-
-	if strings.TrimSpace(creds.ClientEmail) == "" || strings.TrimSpace(creds.PrivateKey) == "" {
-		return "", time.Time{}, errors.New("credenciales sin client_email o private_key")
-	}
-
-	tokenURI := strings.TrimSpace(creds.TokenURI)
-	if tokenURI == "" {
-		tokenURI = "https://oauth2.googleapis.com/token"
-	}
-
-	block, _ := pem.Decode([]byte(creds.PrivateKey))
+// parseRSAPrivateKey decodifica una clave RSA en PEM, ya sea PKCS#8 (cuentas
+// de servicio de Google) o PKCS#1 (GitHub Apps).
+func parseRSAPrivateKey(pemData string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
 	if block == nil {
-		return "", time.Time{}, errors.New("no se pudo decodificar la clave privada")
+		return nil, errors.New("no se pudo decodificar la clave privada")
 	}
 
-	var parsedKey any
-	parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 	if err != nil {
 		parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
 		if err != nil {
-			return "", time.Time{}, fmt.Errorf("clave privada con formato no soportado: %w", err)
+			return nil, fmt.Errorf("clave privada con formato no soportado: %w", err)
 		}
 	}
 
 	rsaKey, ok := parsedKey.(*rsa.PrivateKey)
 	if !ok {
-		return "", time.Time{}, errors.New("la clave privada no es RSA")
-	}
-
-	now := time.Now()
-	claims := map[string]any{
-		"iss":   creds.ClientEmail,
-		"scope": "https://www.googleapis.com/auth/logging.write",
-		"aud":   tokenURI,
-		"iat":   now.Unix(),
-		"exp":   now.Add(time.Hour).Unix(),
+		return nil, errors.New("la clave privada no es RSA")
 	}
+	return rsaKey, nil
+}
 
+// signJWT arma un JWT RS256 con los claims indicados.
+func signJWT(key *rsa.PrivateKey, claims map[string]any) (string, error) {
 	header := map[string]string{"alg": "RS256", "typ": "JWT"}
 
 	encode := func(value any) (string, error) {
@@ -655,21 +717,64 @@ func fetchTokenFromCredentials(ctx context.Context, path string) (string, time.T
 
 	encodedHeader, err := encode(header)
 	if err != nil {
-		return "", time.Time{}, err
+		return "", err
 	}
 	encodedClaims, err := encode(claims)
 	if err != nil {
-		return "", time.Time{}, err
+		return "", err
 	}
 
 	signingInput := encodedHeader + "." + encodedClaims
 	hash := sha256.Sum256([]byte(signingInput))
-	signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, hash[:])
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("no se pudo firmar el JWT: %w", err)
+		return "", fmt.Errorf("no se pudo firmar el JWT: %w", err)
 	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
 
-	assertion := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+// fetchTokenFromCredentials lee un archivo JSON de cuenta de servicio y obtiene
+// un token OAuth2 válido para el scope solicitado.
+func fetchTokenFromCredentials(ctx context.Context, path string, scope string) (string, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("no se pudo leer credenciales: %w", err)
+	}
+
+	var creds struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", time.Time{}, fmt.Errorf("formato de credenciales inválido: %w", err)
+	}
+
+	if strings.TrimSpace(creds.ClientEmail) == "" || strings.TrimSpace(creds.PrivateKey) == "" {
+		return "", time.Time{}, errors.New("credenciales sin client_email o private_key")
+	}
+
+	tokenURI := strings.TrimSpace(creds.TokenURI)
+	if tokenURI == "" {
+		tokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	rsaKey, err := parseRSAPrivateKey(creds.PrivateKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	assertion, err := signJWT(rsaKey, map[string]any{
+		"iss":   creds.ClientEmail,
+		"scope": scope,
+		"aud":   tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
 
 	form := url.Values{}
 	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
@@ -708,8 +813,23 @@ func fetchTokenFromCredentials(ctx context.Context, path string) (string, time.T
 }
 
 func main() {
-	if githubToken == "" {
-		log.Fatal("GITHUB_TOKEN no configurado")
+	// Los subcomandos de mantenimiento no necesitan credenciales; los
+	// atendemos antes de validar la configuración del servicio.
+	if len(os.Args) > 1 && os.Args[1] == "forms" {
+		os.Exit(runFormsCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	app, err := newGitHubAppSourceFromEnv(os.Getenv, os.ReadFile)
+	if err != nil {
+		log.Fatalf("GitHub App mal configurada: %v", err)
+	}
+	if app != nil {
+		loadServiceDeps().GitHubTokens.addApp(app)
+		log.Printf("GitHub App %s sumada al pool (instalación %s)", app.appID, app.installationID)
+	}
+	if pool := loadServiceDeps().GitHubTokens; pool.Len() == 0 {
+		log.Fatal("GITHUB_TOKEN o GITHUB_APP_ID no configurado")
+	} else if pool.Len() > 1 {
+		log.Printf("pool de GitHub con %d tokens", pool.Len())
 	}
 	if projectID == "" {
 		log.Fatal("GITHUB_PROJECT_ID no configurado")
@@ -718,7 +838,11 @@ func main() {
 		logID = defaultLogID
 	}
 
-	ctx := context.Background()
+	// Cloud Run avisa con SIGTERM antes de apagar la instancia; cancelar ctx
+	// detiene los bucles en segundo plano y dispara el apagado ordenado.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	deps := *loadServiceDeps()
 	if logProjectID == "" {
 		// Si la persona operadora decidió no usar Google Cloud seguimos
 		// ofreciendo observabilidad escribiendo en stdout. De esta
@@ -726,7 +850,7 @@ func main() {
 		// simple pueden almacenar los registros sin configuraciones
 		// adicionales.
 		stdoutBackend := &stdoutLogBackend{}
-		requestLogBackend = stdoutBackend
+		deps.LogBackend = stdoutBackend
 		defer func() {
 			if err := stdoutBackend.Close(); err != nil {
 				log.Printf("error al cerrar el backend de stdout: %v", err)
@@ -738,7 +862,7 @@ func main() {
 		if err != nil {
 			log.Fatalf("no se pudo inicializar Cloud Logging: %v", err)
 		}
-		requestLogBackend = backend
+		deps.LogBackend = backend
 		defer func() {
 			if err := backend.Close(); err != nil {
 				log.Printf("error al cerrar el cliente de logging: %v", err)
@@ -746,12 +870,173 @@ func main() {
 		}()
 	}
 
-	if allowAnyOrigin {
-		log.Print("CORS abierto: se permiten todos los orígenes (ALLOWED_ORIGIN=*)")
-	} else if len(allowedOriginEntries) == 0 {
-		log.Print("ADVERTENCIA: ALLOWED_ORIGIN vacío o sin valores válidos, se rechazarán solicitudes con origen")
+	idempotency, err := newIdempotencyStore(os.Getenv("IDEMPOTENCY_STORE"), os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo inicializar el almacén de idempotencia: %v", err)
+	}
+	deps.Idempotency = idempotency
+
+	sessions, err := newSessionStore(os.Getenv("SESSION_STORE"))
+	if err != nil {
+		log.Fatalf("no se pudo inicializar el almacén de sesiones: %v", err)
+	}
+	deps.Sessions = sessions
+
+	trackers, err := newTrackerRouterFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo configurar los trackers externos: %v", err)
+	}
+	deps.ExternalTrackers = trackers
+
+	if envOrDefault("GITHUB_SINGLE_CALL", "off") == "on" {
+		deps.SingleCallCreator = newSingleCallCreator().Create
+		log.Print("Issues creados con una sola mutación GraphQL (bandera graphql_single_call)")
+	}
+
+	// Se activa aquí y no en init para que las pruebas no consulten GitHub.
+	if envOrDefault("DUPLICATE_SEARCH", "on") != "off" {
+		deps.DuplicateSearcher = searchOpenIssues
+	}
+
+	attachments, err := newAttachmentUploaderFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo configurar los adjuntos: %v", err)
+	}
+	if attachments != nil {
+		deps.Attachments = attachments
+		log.Printf("Adjuntos habilitados: hasta %d imágenes de %d bytes", attachments.maxFiles, attachments.maxBytes)
+	}
+
+	captcha, err := newCaptchaVerifierFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo configurar el captcha: %v", err)
+	}
+	if captcha != nil {
+		deps.Captcha = captcha
+		log.Printf("Captcha %s obligatorio en los envíos", captcha.provider)
+	}
+
+	scorer, err := newSpamScorerFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo configurar el filtro de abuso: %v", err)
+	}
+	deps.Screener = scorer.Screen
+	withoutReviewer := requireQuarantineReviewer(&deps)
+	if len(scorer.filters) > 1 {
+		if withoutReviewer {
+			log.Print("Cuarentena desactivada por falta de ADMIN_TOKEN: los envíos dudosos se crean directo")
+		} else {
+			log.Print("Cuarentena activa para envíos sospechosos")
+		}
+	}
+
+	if deps.ProxyHops, err = proxyHopsFromEnv(os.Getenv); err != nil {
+		log.Fatalf("no se pudo configurar el límite de envíos: %v", err)
+	}
+	limiter, err := newRateLimiterFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo configurar el límite de envíos: %v", err)
+	}
+	if limiter != nil {
+		deps.RateLimiter = limiter
+		log.Printf("Límite de envíos: %d/min por IP, %d/min por origen", limiter.ipRPM, limiter.originRPM)
+	}
+
+	shedder, err := newLoadShedderFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo configurar el descarte por carga: %v", err)
+	}
+	if shedder != nil {
+		deps.LoadShedder = shedder
+		if shedder.cpu != nil {
+			go shedder.cpu.Run(ctx, cpuSampleInterval)
+		}
+		log.Print("Descarte por carga activo")
+	}
+
+	exporter, err := newBigQueryExporterFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo configurar la analítica: %v", err)
+	}
+	// La analítica no se cuelga de ctx: se detiene recién después de que el
+	// servidor terminó las solicitudes en curso, para no perder sus eventos.
+	analyticsCtx, stopAnalytics := context.WithCancel(context.Background())
+	analyticsDone := make(chan struct{})
+	if exporter != nil {
+		deps.Analytics = exporter
+		go func() {
+			defer close(analyticsDone)
+			exporter.Run(analyticsCtx)
+		}()
+		log.Print("Exportando eventos de envío a BigQuery")
 	} else {
-		log.Printf("Orígenes permitidos: %s", allowedOrigin)
+		close(analyticsDone)
+	}
+
+	queueKind := strings.TrimSpace(os.Getenv("SUBMISSION_QUEUE"))
+	queue, err := newSubmissionQueue(queueKind, os.Getenv)
+	if err != nil {
+		log.Fatalf("no se pudo inicializar la cola de envíos: %v", err)
+	}
+	if queue != nil {
+		deps.SubmissionQueue = queue
+		workerCtx, cancelWorker := context.WithCancel(ctx)
+		defer cancelWorker()
+		defer queue.Close()
+		go runSubmissionWorker(workerCtx, queue, deps.LogBackend)
+		log.Printf("Cola de envíos asíncrona activa (%s)", queueKind)
+	}
+	storeServiceDeps(&deps)
+
+	if raw := strings.TrimSpace(os.Getenv("PROBE_INTERVAL")); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval < time.Minute {
+			log.Fatalf("PROBE_INTERVAL inválido (mínimo 1m): %q", raw)
+		}
+		probeCtx, cancelProbe := context.WithCancel(ctx)
+		defer cancelProbe()
+		go runProbeLoop(probeCtx, interval, deps.Probe, deps.LogBackend)
+		log.Printf("Sonda sintética cada %s", interval)
+	}
+
+	if raw := envOrDefault("TEMPLATES_REFRESH", defaultTemplatesRefresh.String()); raw != "off" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval < time.Minute {
+			log.Fatalf("TEMPLATES_REFRESH inválido (mínimo 1m u \"off\"): %q", raw)
+		}
+		loader := newTemplateLoader(fetchRepoForms)
+		if err := loader.Refresh(ctx); err != nil {
+			log.Printf("template_refresh_error: se usan las plantillas compiladas: %v", err)
+		}
+		refreshCtx, cancelRefresh := context.WithCancel(ctx)
+		defer cancelRefresh()
+		go runTemplateRefreshLoop(refreshCtx, interval, loader)
+		log.Printf("Plantillas de %s recargadas cada %s", defaultFormsDir, interval)
+	}
+
+	flags, err := loadFeatureFlags(os.Getenv, os.ReadFile)
+	if err != nil {
+		log.Fatalf("FEATURE_FLAGS inválido: %v", err)
+	}
+	startupConfig := *loadServiceConfig()
+	startupConfig.Flags = flags
+	// Después de recargar las plantillas, para avisar solo de las que de
+	// verdad faltan en el repositorio.
+	if err := loadTemplateSettings(&startupConfig, os.Getenv, os.ReadFile); err != nil {
+		log.Fatalf("no se pudo configurar los campos o el destino por plantilla: %v", err)
+	}
+	storeServiceConfig(&startupConfig)
+	if shedder := loadServiceDeps().LoadShedder; shedder != nil {
+		shedder.warnUnknownTemplates()
+	}
+	loadServiceDeps().ExternalTrackers.warnUnknownTemplates()
+	for templateID, target := range startupConfig.TemplateTargets {
+		log.Printf("La plantilla %s crea issues en %s/%s (Project %s)", templateID, target.Owner, target.Repo, target.ProjectID)
+	}
+
+	logOriginConfig(loadServiceConfig())
+	if reloadOnSIGHUP {
+		watchReloadSignal(ctx)
 	}
 
 	http.HandleFunc("/", handleRequest)
@@ -760,26 +1045,64 @@ func main() {
 	if port == "" {
 		port = "8080"
 	}
+	server := &http.Server{Addr: ":" + port}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
 	log.Printf("Escuchando en :%s", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("error al iniciar servidor: %v", err)
+	case <-ctx.Done():
+	}
+	log.Print("SIGTERM recibido: terminando las solicitudes en curso")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("el servidor no terminó a tiempo: %v", err)
 	}
+	stopAnalytics()
+	<-analyticsDone
 }
 
 func handleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == healthPath || r.URL.Path == readinessPath {
+		handleHealth(w, r)
+		return
+	}
 	lrw := &loggingResponseWriter{ResponseWriter: w, status: http.StatusOK}
 	ctx := r.Context()
-	logger := newRequestLogger(ctx, requestLogBackend, r)
+	logger := newRequestLogger(ctx, loadServiceDeps().LogBackend, r)
 	ctx = logger.Attach(ctx)
+	ctx = withFeatureFlags(ctx, loadServiceConfig().Flags, logger.ID(), logger.origin)
+	ctx = withLocale(ctx, errcodes.NegotiateLocale(r.Header.Get("Accept-Language")))
 	r = r.WithContext(ctx)
 
 	defer func() {
 		if lrw.status != 0 {
 			logger.RecordStatus(lrw.status)
 		}
+		exportSubmissionEvent(loadServiceDeps().Analytics, logger, r)
 		logger.Finish(ctx)
 	}()
 
+	// Los enlaces cortos se abren como navegación normal desde cualquier
+	// lugar (correo, chat), así que no pasan por la validación de origen.
+	if strings.HasPrefix(r.URL.Path, shortLinkPrefix) {
+		handleShortLink(ctx, lrw, r)
+		return
+	}
+	// Las rutas de administración se usan con curl o un script, sin
+	// navegador: las protege ADMIN_TOKEN, no la lista de orígenes.
+	if r.URL.Path == quarantinePrefix || strings.HasPrefix(r.URL.Path, quarantinePrefix+"/") {
+		handleQuarantine(ctx, lrw, r)
+		return
+	}
+	if r.URL.Path == loadStatsPath {
+		handleLoadStats(ctx, lrw, r)
+		return
+	}
+
 	if !handleCORS(ctx, lrw, r) {
 		return
 	}
@@ -789,7 +1112,33 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 		logger.RecordStatus(http.StatusNoContent)
 		lrw.WriteHeader(http.StatusNoContent)
 	case http.MethodPost:
+		// La sonda se autentica con PROBE_SECRET y no debe competir por el
+		// cupo de los visitantes.
+		if r.URL.Path != probePath {
+			if subErr := checkRateLimit(lrw, r); subErr != nil {
+				writeSubmissionError(ctx, lrw, subErr)
+				return
+			}
+		}
+		if r.URL.Path == sessionPrefix || strings.HasPrefix(r.URL.Path, sessionPrefix+"/") {
+			handleSessionPost(ctx, lrw, r)
+			return
+		}
+		if r.URL.Path == formPostPath {
+			handleFormPost(ctx, lrw, r)
+			return
+		}
+		if r.URL.Path == probePath {
+			handleProbe(ctx, lrw, r)
+			return
+		}
 		handlePost(ctx, lrw, r)
+	case http.MethodGet, http.MethodHead:
+		if r.URL.Path == templateCatalogPath {
+			handleTemplateCatalog(ctx, lrw, r)
+			return
+		}
+		writeError(ctx, lrw, http.StatusNotFound, "not_found", "Ruta no encontrada", nil)
 	default:
 		writeError(ctx, lrw, http.StatusMethodNotAllowed, "method_not_allowed", "método no permitido", nil)
 	}
@@ -801,18 +1150,19 @@ func handleCORS(ctx context.Context, w http.ResponseWriter, r *http.Request) boo
 		return true
 	}
 
-	if !isOriginAllowed(origin) {
+	cfg := loadServiceConfig()
+	if !cfg.isOriginAllowed(origin) {
 		denyOrigin(ctx, w, origin)
 		return false
 	}
 
-	if allowAnyOrigin {
+	if cfg.AllowAnyOrigin {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 	} else {
 		w.Header().Set("Access-Control-Allow-Origin", origin)
 		w.Header().Set("Vary", "Origin")
 	}
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 	// Construimos la lista de encabezados permitidos replicando cualquier valor
 	// solicitado por el navegador. De este modo evitamos errores cuando el
 	// agente de usuario envía los nombres en minúsculas o agrega elementos
@@ -837,6 +1187,7 @@ func handleCORS(ctx context.Context, w http.ResponseWriter, r *http.Request) boo
 	}
 
 	addHeader("Content-Type")
+	addHeader(idempotencyHeader)
 
 	requestedHeaders := r.Header.Get("Access-Control-Request-Headers")
 	if requestedHeaders != "" {
@@ -855,12 +1206,13 @@ func denyOrigin(ctx context.Context, w http.ResponseWriter, origin string) {
 	writeError(ctx, w, http.StatusForbidden, "forbidden_origin", message, nil)
 }
 
-func isOriginAllowed(origin string) bool {
-	if allowAnyOrigin {
+// isOriginAllowed compara el origen normalizado contra la lista configurada.
+func (cfg *serviceConfig) isOriginAllowed(origin string) bool {
+	if cfg.AllowAnyOrigin {
 		return true
 	}
 
-	if len(allowedOriginEntries) == 0 {
+	if len(cfg.AllowedOriginEntries) == 0 {
 		return false
 	}
 
@@ -869,7 +1221,7 @@ func isOriginAllowed(origin string) bool {
 		return false
 	}
 
-	for _, entry := range allowedOriginEntries {
+	for _, entry := range cfg.AllowedOriginEntries {
 		if entry.normalized == normalizedOrigin {
 			return true
 		}
@@ -878,8 +1230,12 @@ func isOriginAllowed(origin string) bool {
 	return false
 }
 
-func configureAllowedOrigins(current, fallback string) []originEntry {
+// newOriginConfig construye la configuración de CORS a partir de la variable
+// ALLOWED_ORIGIN y de la lista de respaldo. No modifica estado global: quien
+// la llama decide cuándo publicarla con storeServiceConfig.
+func newOriginConfig(current, fallback string) *serviceConfig {
 	seen := map[string]struct{}{}
+	cfg := &serviceConfig{}
 	var entries []originEntry
 
 	addOrigin := func(value string, source string) {
@@ -889,7 +1245,7 @@ func configureAllowedOrigins(current, fallback string) []originEntry {
 		}
 
 		if value == "*" {
-			allowAnyOrigin = true
+			cfg.AllowAnyOrigin = true
 			return
 		}
 
@@ -920,14 +1276,14 @@ func configureAllowedOrigins(current, fallback string) []originEntry {
 
 	for _, candidate := range fallbackCandidates {
 		addOrigin(candidate, "predeterminado")
-		if allowAnyOrigin {
+		if cfg.AllowAnyOrigin {
 			break
 		}
 	}
 
-	if allowAnyOrigin {
-		allowedOrigin = "*"
-		return nil
+	if cfg.AllowAnyOrigin {
+		cfg.AllowedOrigin = "*"
+		return cfg
 	}
 
 	// Procesamos las entradas suministradas en la variable de entorno, sabiendo que
@@ -936,14 +1292,14 @@ func configureAllowedOrigins(current, fallback string) []originEntry {
 	candidates := splitOriginCandidates(current)
 	for _, candidate := range candidates {
 		addOrigin(candidate, "ALLOWED_ORIGIN")
-		if allowAnyOrigin {
+		if cfg.AllowAnyOrigin {
 			break
 		}
 	}
 
-	if allowAnyOrigin {
-		allowedOrigin = "*"
-		return nil
+	if cfg.AllowAnyOrigin {
+		cfg.AllowedOrigin = "*"
+		return cfg
 	}
 
 	if len(entries) == 0 {
@@ -953,29 +1309,29 @@ func configureAllowedOrigins(current, fallback string) []originEntry {
 		forcedFallback := splitOriginCandidates(defaultAllowedOrigin)
 		for _, candidate := range forcedFallback {
 			addOrigin(candidate, "predeterminado forzado")
-			if allowAnyOrigin {
+			if cfg.AllowAnyOrigin {
 				break
 			}
 		}
 	}
 
-	if allowAnyOrigin {
-		allowedOrigin = "*"
-		return nil
+	if cfg.AllowAnyOrigin {
+		cfg.AllowedOrigin = "*"
+		return cfg
 	}
 
 	if len(entries) == 0 {
-		allowedOrigin = ""
-		return nil
+		return cfg
 	}
 
 	rawOrigins := make([]string, 0, len(entries))
 	for _, entry := range entries {
 		rawOrigins = append(rawOrigins, entry.raw)
 	}
-	allowedOrigin = strings.Join(rawOrigins, ",")
+	cfg.AllowedOrigin = strings.Join(rawOrigins, ",")
+	cfg.AllowedOriginEntries = entries
 
-	return entries
+	return cfg
 }
 
 func normalizeOrigin(value string) (string, error) {
@@ -1027,35 +1383,171 @@ func splitOriginCandidates(raw string) []string {
 }
 
 func handlePost(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	limitedBody := http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	var req issueRequest
+	if !decodeRequestBody(ctx, w, r, &req) {
+		return
+	}
+	submitIssueRequest(ctx, w, r, req)
+}
+
+// decodeRequestBody lee el JSON con el límite de tamaño del servicio. Si
+// falla ya respondió al cliente y devuelve false.
+func decodeRequestBody(ctx context.Context, w http.ResponseWriter, r *http.Request, dst any) bool {
+	limit := requestBodyLimit()
+	limitedBody := http.MaxBytesReader(w, r.Body, limit)
 	defer limitedBody.Close()
 
-	var req issueRequest
-	if err := json.NewDecoder(limitedBody).Decode(&req); err != nil {
+	if err := json.NewDecoder(limitedBody).Decode(dst); err != nil {
 		var maxErr *http.MaxBytesError
 		if errors.As(err, &maxErr) {
-			message := fmt.Sprintf("El cuerpo de la solicitud supera el límite de %d bytes", maxRequestBodyBytes)
+			message := fmt.Sprintf("El cuerpo de la solicitud supera el límite de %d bytes", limit)
 			writeError(ctx, w, http.StatusRequestEntityTooLarge, "payload_too_large", message, err)
-			return
+			return false
 		}
 		writeError(ctx, w, http.StatusBadRequest, "invalid_request", "JSON inválido", err)
-		return
+		return false
 	}
+	return true
+}
 
+// submitIssueRequest valida y crea (o encola) el issue. Lo comparten el POST
+// directo y el cierre de una sesión por pasos; devuelve true si el envío quedó
+// aceptado. Con una clave de idempotencia, una repetición recibe la respuesta
+// del envío original en lugar de crear otro issue.
+func submitIssueRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, req issueRequest) bool {
 	if logger := loggerFromContext(ctx); logger != nil {
 		logger.SetTemplate(req.TemplateID)
 	}
+	key, subErr := idempotencyKey(r, req)
+	if subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return false
+	}
+	if store := loadServiceDeps().Idempotency; store != nil && key != "" {
+		return submitIdempotent(ctx, w, r, req, store, key)
+	}
+	return processIssueRequest(ctx, w, r, req)
+}
+
+func processIssueRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, req issueRequest) bool {
+	if subErr := checkCooldown(w, strings.TrimSpace(r.Header.Get("Origin")), requestClientIP(r), req.TemplateID); subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return false
+	}
+	done, subErr := admitSubmission(ctx, w, req.TemplateID)
+	if subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return false
+	}
+	defer done()
+	if req.Consent != nil {
+		// La fecha de recepción la fija el servidor; ignoramos la del cliente.
+		req.Consent.ReceivedAt = time.Time{}
+	}
+
+	prepared, subErr := prepareSubmission(ctx, req)
+	if subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return false
+	}
+
+	if subErr := checkCaptcha(ctx, r, req); subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return false
+	}
+
+	verdict, subErr := rejectSpam(ctx, req, prepared)
+	if subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return false
+	}
 
-	tmpl, ok := templates[req.TemplateID]
+	if offerDuplicates(ctx, w, req, prepared) {
+		return false
+	}
+
+	if handled, ok := screenSubmission(ctx, w, req, prepared, verdict); handled {
+		return ok
+	}
+
+	if queue := loadServiceDeps().SubmissionQueue; queue != nil && flagEnabled(ctx, flagAsyncQueue) {
+		req.Consent = prepared.Consent
+		return enqueueSubmission(ctx, w, queue, req, prepared.Incident.notice())
+	}
+
+	resp, subErr := submitPrepared(ctx, prepared)
+	if subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return false
+	}
+	writeResponse(ctx, w, http.StatusOK, resp)
+	return true
+}
+
+// submissionError describe por qué no pudimos procesar una solicitud. Separar
+// el error de la escritura HTTP permite reutilizar la misma validación desde
+// el handler síncrono y desde el worker de la cola.
+type submissionError struct {
+	Status  int
+	Code    errcodes.Code
+	Message string
+	Cause   error
+}
+
+func (e *submissionError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// retryable indica si vale la pena reintentar: los rechazos de validación
+// fallarán igual en cada intento, mientras que los errores de GitHub pueden
+// ser pasajeros.
+func (e *submissionError) retryable() bool {
+	return e.Status >= http.StatusInternalServerError
+}
+
+func writeSubmissionError(ctx context.Context, w http.ResponseWriter, err *submissionError) {
+	writeError(ctx, w, err.Status, err.Code, err.Message, err.Cause)
+}
+
+// preparedSubmission contiene la solicitud ya validada y el cuerpo final del
+// issue, listo para enviarse a GitHub.
+type preparedSubmission struct {
+	TemplateID string
+	Template   issueTemplate
+	Title      string
+	Body       string
+	Related    *moduleRef
+	Consent    *consentRecord
+	// DuplicateOf es el issue con el que se sugiere fusionar; 0 si no hay.
+	DuplicateOf int
+	// Incident es el incidente activo con el que coincide el reporte.
+	Incident *activeIncident
+	// Accessibility agrega el bloque y la etiqueta de accesibilidad.
+	Accessibility *accessibilityInfo
+	// Attachments se suben justo antes de crear el issue.
+	Attachments []attachment
+}
+
+// prepareSubmission valida la plantilla, el título y los campos obligatorios
+// antes de tocar GitHub. Así rechazamos los errores de la persona usuaria sin
+// gastar cuota de la API.
+func prepareSubmission(ctx context.Context, req issueRequest) (*preparedSubmission, *submissionError) {
+	tmpl, ok := lookupTemplate(req.TemplateID)
 	if !ok {
-		writeError(ctx, w, http.StatusBadRequest, "invalid_template", "Plantilla no válida", nil)
-		return
+		return nil, &submissionError{Status: http.StatusBadRequest, Code: "invalid_template", Message: "Plantilla no válida"}
+	}
+
+	consent, subErr := validateConsent(req.Consent, time.Now())
+	if subErr != nil {
+		return nil, subErr
 	}
 
 	title := strings.TrimSpace(req.Title)
 	if title == "" {
-		writeError(ctx, w, http.StatusBadRequest, "invalid_request", "El título es obligatorio", nil)
-		return
+		return nil, &submissionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "El título es obligatorio"}
 	}
 
 	fields := map[string]string{}
@@ -1065,35 +1557,126 @@ func handlePost(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 
 	body, err := buildBody(tmpl, fields)
 	if err != nil {
-		writeError(ctx, w, http.StatusBadRequest, "invalid_request", err.Error(), err)
-		return
+		return nil, &submissionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: err.Error(), Cause: err}
 	}
 
-	issue, err := issueCreator(ctx, title, tmpl.Labels, body)
+	related, subErr := resolveRelatedModule(ctx, req.ModuleID)
+	if subErr != nil {
+		return nil, subErr
+	}
+	if related != nil {
+		body = strings.TrimSpace(fmt.Sprintf("%s\n\nRelacionado con %s", body, targetForTemplate(req.TemplateID).issueReference(related.Number)))
+	}
+
+	incident := matchIncident(ctx, req.TemplateID, title, body)
+	if incident != nil {
+		body = strings.TrimSpace(body + "\n\n" + incidentNote(incident))
+	}
+
+	if subErr := validateDuplicateOf(req.DuplicateOf); subErr != nil {
+		return nil, subErr
+	}
+
+	accessibility, err := sanitizeAccessibilityInfo(req.Accessibility)
 	if err != nil {
+		return nil, &submissionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: err.Error(), Cause: err}
+	}
+	if block := renderAccessibilityInfo(accessibility); block != "" {
+		body = strings.TrimSpace(body + "\n\n" + block)
+	}
+
+	attachments, subErr := decodeAttachments(req.Attachments)
+	if subErr != nil {
+		return nil, subErr
+	}
+
+	client, err := sanitizeClientInfo(req.Client)
+	if err != nil {
+		return nil, &submissionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: err.Error(), Cause: err}
+	}
+	if block := renderClientInfo(client); block != "" {
+		body = strings.TrimSpace(body + "\n\n" + block)
+	}
+
+	return &preparedSubmission{
+		TemplateID:    req.TemplateID,
+		Template:      tmpl,
+		Title:         title,
+		Body:          body,
+		Related:       related,
+		Consent:       consent,
+		DuplicateOf:   req.DuplicateOf,
+		Incident:      incident,
+		Accessibility: accessibility,
+		Attachments:   attachments,
+	}, nil
+}
+
+// submitPrepared crea el issue y lo agrega al proyecto. Un fallo al agregarlo
+// al proyecto no invalida el issue ya creado, por eso se informa dentro de la
+// respuesta en lugar de devolverse como error.
+func submitPrepared(ctx context.Context, p *preparedSubmission) (issueResponse, *submissionError) {
+	ctx = withIssueTarget(ctx, targetForTemplate(p.TemplateID))
+	deps := loadServiceDeps()
+	if len(p.Attachments) > 0 {
+		// Se suben aquí y no al validar para no publicar imágenes de envíos
+		// que terminan rechazados o en cuarentena.
+		p.Body = uploadAttachments(ctx, deps.Attachments, p.Body, p.Attachments)
+		p.Attachments = nil
+	}
+	create := deps.IssueCreator
+	if deps.SingleCallCreator != nil && flagEnabled(ctx, flagGraphQLSingleCall) {
+		// El issue ya queda en el Project; ProjectAdder igual corre para
+		// llenar los campos (agregar un item existente solo devuelve su ID).
+		create = deps.SingleCallCreator
+	}
+	issue, err := create(ctx, p.Title, p.issueLabels(), p.Body)
+	if err != nil {
+		subErr := classifyGitHubError(err)
 		if logger := loggerFromContext(ctx); logger != nil {
-			logger.LogError(ctx, "github_issue_error", "error al crear issue en GitHub", err)
+			logger.LogError(ctx, string(subErr.Code), "error al crear issue en GitHub", err)
 		}
-		writeError(ctx, w, http.StatusBadGateway, "github_issue_error", "No se pudo crear el issue en GitHub", err)
-		return
+		if subErr.Code == errcodes.GitHubRejectedContent {
+			// Reintentar el mismo contenido fallaría igual: pausamos el origen
+			// y lo marcamos como no reintentable para que la cola no insista.
+			startCooldown(ctx, p.TemplateID)
+		}
+		return issueResponse{}, subErr
 	}
 
-	err = projectAdder(ctx, issue.NodeID, req.TemplateID, tmpl.Labels)
+	logConsent(ctx, issue.Number, p.Consent)
+	shortURL := issueShortURL(ctx, deps.ShortLinks, issue)
+	mirrorToExternalTracker(ctx, deps, p, issue)
+
+	err = deps.ProjectAdder(ctx, issue.NodeID, p.TemplateID, p.issueLabels())
 	if err != nil {
 		if logger := loggerFromContext(ctx); logger != nil {
 			logger.LogError(ctx, "github_project_error", fmt.Sprintf("issue #%d creado pero no se pudo agregar al proyecto", issue.Number), err)
 		}
-		writeResponse(ctx, w, http.StatusOK, issueResponse{
+		return issueResponse{
 			IssueURL: issue.HTMLURL,
+			ShortURL: shortURL,
+			Incident: p.Incident.notice(),
 			Error: &apiError{
 				Code:    "github_project_error",
 				Message: "Issue creado pero no se pudo agregar al proyecto",
 			},
-		})
-		return
+		}, nil
 	}
 
-	writeResponse(ctx, w, http.StatusOK, issueResponse{IssueURL: issue.HTMLURL})
+	if p.Related != nil && deps.ModuleAreaLinker != nil {
+		// El área es un complemento: si falla, el issue ya quedó enlazado al
+		// módulo en el cuerpo y solo dejamos registro para corregirlo a mano.
+		if err := deps.ModuleAreaLinker(ctx, issue.NodeID, p.Related.Number); err != nil {
+			if logger := loggerFromContext(ctx); logger != nil {
+				logger.log(ctx, "module_area", severityError, fmt.Sprintf("issue #%d: no se pudo copiar el área del módulo #%d: %v", issue.Number, p.Related.Number, err))
+			}
+		}
+	}
+
+	linkDuplicate(ctx, deps, p, issue)
+
+	return issueResponse{IssueURL: issue.HTMLURL, ShortURL: shortURL, Incident: p.Incident.notice()}, nil
 }
 
 func buildBody(tmpl issueTemplate, fields map[string]string) (string, error) {
@@ -1135,19 +1718,19 @@ func createIssue(ctx context.Context, title string, labels []string, body string
 		return nil, err
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", githubRepoOwner, githubRepoName)
+	target := issueTargetFromContext(ctx)
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", target.Owner, target.Repo)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+githubToken)
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", userAgent)
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := &http.Client{Timeout: 15 * time.Second, Transport: &githubAuthTransport{base: &outboundLoggingTransport{}}}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -1156,11 +1739,7 @@ func createIssue(ctx context.Context, title string, labels []string, body string
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		var apiResp map[string]any
-		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-			return nil, fmt.Errorf("estado inesperado %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("estado inesperado %d: %v", resp.StatusCode, apiResp)
+		return nil, githubErrorFromResponse(resp)
 	}
 
 	var issue githubIssueResponse
@@ -1173,6 +1752,20 @@ func createIssue(ctx context.Context, title string, labels []string, body string
 	return &issue, nil
 }
 
+// githubErrorFromResponse arma el error tipado de una respuesta fallida de
+// GitHub, con el cuerpo como detalle si es JSON.
+func githubErrorFromResponse(resp *http.Response) *errcodes.GitHubError {
+	apiErr := &errcodes.GitHubError{Status: resp.StatusCode}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		apiErr.RateLimited = resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""
+	}
+	var apiResp map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil {
+		apiErr.Detail = apiResp
+	}
+	return apiErr
+}
+
 // buildIssuePayload centraliza la construcción del JSON que enviamos a GitHub, de modo
 // que podamos validarlo en pruebas y evitar errores de tipeo o cambios silenciosos en
 // las etiquetas.
@@ -1204,22 +1797,21 @@ func templateTypeToFieldValue(templateID string) string {
 	}
 }
 
-// addToProjectAndSetType agrega el issue al proyecto y configura el campo "Tipo"
-// con el valor correspondiente a la plantilla utilizada. De esta manera el issue
-// queda correctamente categorizado desde su creación, evitando trabajo manual
+// addToProjectAndSetType agrega el issue al proyecto y configura sus campos
+// (Tipo, Status y los de PROJECT_FIELDS, ver projectFieldValues) con los
+// valores de la plantilla utilizada. De esta manera el issue queda
+// correctamente categorizado desde su creación, evitando trabajo manual
 // posterior.
 func addToProjectAndSetType(ctx context.Context, nodeID string, templateID string, labels []string) error {
 	if strings.TrimSpace(nodeID) == "" {
 		return errors.New("node_id vacío")
 	}
 
-	src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: githubToken})
-	httpClient := oauth2.NewClient(ctx, src)
-	gqlClient := githubv4.NewClient(httpClient)
+	gqlClient := newGraphQLClient(ctx)
 
 	// Primero agregamos el issue al proyecto para obtener el project item ID
 	addInput := githubv4.AddProjectV2ItemByIdInput{
-		ProjectID: githubv4.ID(projectID),
+		ProjectID: githubv4.ID(issueTargetFromContext(ctx).ProjectID),
 		ContentID: githubv4.ID(nodeID),
 	}
 
@@ -1240,7 +1832,28 @@ func addToProjectAndSetType(ctx context.Context, nodeID string, templateID strin
 		return errors.New("no se obtuvo project item ID tras agregar al proyecto")
 	}
 
-	// Ahora consultamos el proyecto para obtener el ID del campo "Tipo"
+	// Intentamos todos los campos aunque uno falle: un Status renombrado en el
+	// tablero no debería dejar también sin Tipo al issue.
+	var errs []error
+	hasTipo := false
+	for _, value := range projectFieldValues(templateID, labels) {
+		hasTipo = hasTipo || value.Field == "Tipo"
+		if err := setProjectSingleSelectField(ctx, gqlClient, projectItemID, value.Field, value.Value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !hasTipo && templateID != "" {
+		// Normal para plantillas nuevas que aún no tienen mapeo explícito.
+		log.Printf("Template %q sin mapeo de tipo, campo Tipo no será actualizado", templateID)
+	}
+	return errors.Join(errs...)
+}
+
+// setProjectSingleSelectField asigna una opción de un campo de selección
+// única del proyecto buscando la opción por su nombre visible. Fallamos con
+// un código explícito si el campo o la opción no existen, porque suele
+// significar que alguien renombró algo en el tablero.
+func setProjectSingleSelectField(ctx context.Context, gqlClient *githubv4.Client, itemID githubv4.ID, fieldName, value string) error {
 	var projectQuery struct {
 		Node struct {
 			ProjectV2 struct {
@@ -1252,57 +1865,42 @@ func addToProjectAndSetType(ctx context.Context, nodeID string, templateID strin
 							Name githubv4.String
 						}
 					} `graphql:"... on ProjectV2SingleSelectField"`
-				} `graphql:"field(name: \"Tipo\")"`
+				} `graphql:"field(name: $fieldName)"`
 			} `graphql:"... on ProjectV2"`
 		} `graphql:"node(id: $projectId)"`
 	}
 
 	projectQueryVars := map[string]interface{}{
-		"projectId": githubv4.ID(projectID),
+		"projectId": githubv4.ID(issueTargetFromContext(ctx).ProjectID),
+		"fieldName": githubv4.String(fieldName),
 	}
 
 	if err := gqlClient.Query(ctx, &projectQuery, projectQueryVars); err != nil {
-		return fmt.Errorf("error al consultar campo Tipo del proyecto: %w", err)
+		return fmt.Errorf("error al consultar campo %s del proyecto: %w", fieldName, err)
 	}
 
-	tipoFieldID := projectQuery.Node.ProjectV2.Field.ProjectV2SingleSelectField.ID
-	if tipoFieldID == "" {
-		return errors.New("project_tipo_field_missing: no se encontró el campo Tipo en el proyecto o no es de tipo SingleSelect")
-	}
-
-	// Obtenemos el valor del campo priorizando la etiqueta "Tipo" que acompaña al
-	// issue. Esta verificación nos ayuda a prevenir errores humanos
-	// (poka-yoke), ya que el tipo elegido en la interfaz queda reflejado en el
-	// proyecto aunque cambie el mapeo interno de plantillas.
-	tipoValue := determineProjectTipoValue(templateID, labels)
-	if tipoValue == "" {
-		// Si el template no tiene un tipo definido, no configuramos el campo.
-		// Esto es normal para templates personalizados o futuros que aún no
-		// tienen mapeo explícito.
-		if templateID != "" {
-			log.Printf("Template %q sin mapeo de tipo, campo Tipo no será actualizado", templateID)
-		}
-		return nil
+	field := projectQuery.Node.ProjectV2.Field.ProjectV2SingleSelectField
+	if field.ID == "" {
+		return fmt.Errorf("project_field_missing: no se encontró el campo %s en el proyecto o no es de tipo SingleSelect", fieldName)
 	}
 
 	// Buscamos el ID de la opción que coincida con el valor deseado
 	var optionID githubv4.String
-	for _, opt := range projectQuery.Node.ProjectV2.Field.ProjectV2SingleSelectField.Options {
-		if string(opt.Name) == tipoValue {
+	for _, opt := range field.Options {
+		if string(opt.Name) == value {
 			optionID = opt.ID
 			break
 		}
 	}
 
 	if optionID == "" {
-		return fmt.Errorf("project_tipo_option_missing: no se encontró la opción %q en el campo Tipo del proyecto", tipoValue)
+		return fmt.Errorf("project_option_missing: no se encontró la opción %q en el campo %s del proyecto", value, fieldName)
 	}
 
-	// Finalmente, actualizamos el campo "Tipo" del project item
 	updateInput := githubv4.UpdateProjectV2ItemFieldValueInput{
-		ProjectID: githubv4.ID(projectID),
-		ItemID:    projectItemID,
-		FieldID:   tipoFieldID,
+		ProjectID: githubv4.ID(issueTargetFromContext(ctx).ProjectID),
+		ItemID:    itemID,
+		FieldID:   field.ID,
 		Value: githubv4.ProjectV2FieldValue{
 			SingleSelectOptionID: (*githubv4.String)(&optionID),
 		},
@@ -1317,12 +1915,50 @@ func addToProjectAndSetType(ctx context.Context, nodeID string, templateID strin
 	}
 
 	if err := gqlClient.Mutate(ctx, &updateMutation, updateInput, nil); err != nil {
-		return fmt.Errorf("error al actualizar campo Tipo: %w", err)
+		return fmt.Errorf("error al actualizar campo %s: %w", fieldName, err)
 	}
 
 	return nil
 }
 
+// newGraphQLClient arma el cliente GraphQL autenticado. Usa el mismo
+// transporte que las llamadas REST: tokens del pool con failover y cada
+// mutación registrada en el log.
+func newGraphQLClient(ctx context.Context) *githubv4.Client {
+	httpClient := &http.Client{Transport: &graphQLErrorTransport{base: &githubAuthTransport{base: &outboundLoggingTransport{}}}}
+	return githubv4.NewClient(httpClient)
+}
+
+// graphQLErrorTransport convierte en *errcodes.GitHubError las respuestas
+// GraphQL con estado distinto de 200 y los errores que sabemos clasificar
+// (FORBIDDEN, NOT_FOUND, RATE_LIMITED). La librería descarta el estado y el
+// campo type y solo devuelve el texto, así que sin esto una falla de la
+// creación en una llamada terminaría como github_issue_error.
+type graphQLErrorTransport struct {
+	base http.RoundTripper
+}
+
+func (t *graphQLErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, githubErrorFromResponse(resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if ghErr := errcodes.ParseGraphQLErrors(body); ghErr != nil {
+		return nil, ghErr
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
 // addToProject mantiene la función original para compatibilidad con tests que
 // no necesitan configurar el tipo. Esta función simplemente delega a
 // addToProjectAndSetType con un templateID vacío.
@@ -1337,30 +1973,16 @@ func addToProject(ctx context.Context, nodeID string) error {
 // para impedir discrepancias). Si ninguna etiqueta define el tipo, recurrimos
 // al mapeo por plantilla como respaldo seguro.
 func determineProjectTipoValue(templateID string, labels []string) string {
-	for _, label := range labels {
-		parts := strings.SplitN(label, ":", 2)
-		if len(parts) != 2 {
-			continue
-		}
-
-		prefix := strings.TrimSpace(parts[0])
-		if !strings.EqualFold(prefix, "tipo") {
-			continue
-		}
-
-		value := strings.TrimSpace(parts[1])
-		if value != "" {
-			return value
-		}
+	if value := labelFieldValue(labels, "Tipo"); value != "" {
+		return value
 	}
-
 	return templateTypeToFieldValue(templateID)
 }
 
-func writeError(ctx context.Context, w http.ResponseWriter, status int, code, message string, cause error) {
+func writeError(ctx context.Context, w http.ResponseWriter, status int, code errcodes.Code, message string, cause error) {
 	if logger := loggerFromContext(ctx); logger != nil {
 		logger.RecordStatus(status)
-		logger.LogError(ctx, code, message, cause)
+		logger.LogError(ctx, string(code), message, cause)
 	}
 	writeResponse(ctx, w, status, issueResponse{Error: &apiError{Code: code, Message: message}})
 }
@@ -1369,12 +1991,17 @@ func writeResponse(ctx context.Context, w http.ResponseWriter, status int, resp
 	if logger := loggerFromContext(ctx); logger != nil {
 		logger.RecordStatus(status)
 		if resp.Error != nil {
-			logger.RecordError(resp.Error.Code)
+			logger.RecordError(string(resp.Error.Code))
 		}
 		if strings.TrimSpace(resp.DebugID) == "" {
 			resp.DebugID = logger.ID()
 		}
 	}
+	if resp.Error != nil {
+		locale := localeFromContext(ctx)
+		resp.Error.localize(locale)
+		w.Header().Set("Content-Language", locale)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -13,30 +13,25 @@ import (
 	"testing"
 )
 
-func preserveOriginGlobals(t *testing.T) func() {
+// useServiceConfig publica cfg durante la prueba y restaura la instantánea
+// anterior al terminar. Al reemplazar el puntero completo evitamos que un
+// handler en curso vea una mezcla de valores viejos y nuevos.
+func useServiceConfig(t *testing.T, cfg *serviceConfig) {
 	t.Helper()
-	previousAllowAny := allowAnyOrigin
-	previousAllowed := allowedOrigin
-	previousEntries := allowedOriginEntries
-
-	return func() {
-		allowAnyOrigin = previousAllowAny
-		allowedOrigin = previousAllowed
-		allowedOriginEntries = previousEntries
-	}
+	previous := loadServiceConfig()
+	storeServiceConfig(cfg)
+	t.Cleanup(func() { storeServiceConfig(previous) })
 }
 
-func preserveRequestLogger(t *testing.T) func() {
+// useServiceDeps parte de las dependencias actuales, aplica los cambios de la
+// prueba y publica el resultado como una sola instantánea.
+func useServiceDeps(t *testing.T, mutate func(*serviceDeps)) {
 	t.Helper()
-	previousBackend := requestLogBackend
-	previousIssueCreator := issueCreator
-	previousProjectAdder := projectAdder
-
-	return func() {
-		requestLogBackend = previousBackend
-		issueCreator = previousIssueCreator
-		projectAdder = previousProjectAdder
-	}
+	previous := loadServiceDeps()
+	next := *previous
+	mutate(&next)
+	storeServiceDeps(&next)
+	t.Cleanup(func() { storeServiceDeps(previous) })
 }
 
 type memoryLogBackend struct {
@@ -56,6 +51,17 @@ func (m *memoryLogBackend) Entries() []logEntry {
 	return out
 }
 
+func TestSplitGitHubRepo(t *testing.T) {
+	if owner, name := splitGitHubRepo(" org/sandbox "); owner != "org" || name != "sandbox" {
+		t.Fatalf("splitGitHubRepo = %s/%s", owner, name)
+	}
+	for _, raw := range []string{"sandbox", "org/", "/sandbox", "org/a/b"} {
+		if owner, name := splitGitHubRepo(raw); owner+"/"+name != defaultGitHubRepo {
+			t.Fatalf("%q debe caer al repositorio por defecto, got %s/%s", raw, owner, name)
+		}
+	}
+}
+
 func TestNormalizeOrigin(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -233,14 +239,8 @@ func TestCreateIssueEnviaEtiquetasDePlantillaEnBlanco(t *testing.T) {
 	}
 }
 
-func TestConfigureAllowedOriginsDefaultFallback(t *testing.T) {
-	restore := preserveOriginGlobals(t)
-	defer restore()
-
-	allowAnyOrigin = false
-	allowedOrigin = ""
-
-	entries := configureAllowedOrigins("", "https://ron-datadriven.github.io")
+func TestNewOriginConfigDefaultFallback(t *testing.T) {
+	entries := newOriginConfig("", "https://ron-datadriven.github.io").AllowedOriginEntries
 
 	if len(entries) != 1 {
 		t.Fatalf("expected 1 entry, got %d", len(entries))
@@ -251,17 +251,12 @@ func TestConfigureAllowedOriginsDefaultFallback(t *testing.T) {
 	}
 }
 
-func TestConfigureAllowedOriginsWildcard(t *testing.T) {
-	restore := preserveOriginGlobals(t)
-	defer restore()
+func TestNewOriginConfigWildcard(t *testing.T) {
+	cfg := newOriginConfig("*", "https://fallback.example")
+	entries := cfg.AllowedOriginEntries
 
-	allowAnyOrigin = false
-	allowedOrigin = ""
-
-	entries := configureAllowedOrigins("*", "https://fallback.example")
-
-	if !allowAnyOrigin {
-		t.Fatal("allowAnyOrigin should be true")
+	if !cfg.AllowAnyOrigin {
+		t.Fatal("AllowAnyOrigin should be true")
 	}
 
 	if entries != nil {
@@ -269,7 +264,7 @@ func TestConfigureAllowedOriginsWildcard(t *testing.T) {
 	}
 }
 
-func TestConfigureAllowedOrigins(t *testing.T) {
+func TestNewOriginConfig(t *testing.T) {
 	const fallbackOrigin = "https://fallback.example"
 
 	tests := []struct {
@@ -308,16 +303,11 @@ func TestConfigureAllowedOrigins(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			restore := preserveOriginGlobals(t)
-			defer restore()
-
-			allowAnyOrigin = false
-			allowedOrigin = ""
+			cfg := newOriginConfig(tt.envVar, fallbackOrigin)
+			entries := cfg.AllowedOriginEntries
 
-			entries := configureAllowedOrigins(tt.envVar, fallbackOrigin)
-
-			if allowAnyOrigin != tt.wantWildcard {
-				t.Fatalf("allowAnyOrigin = %v, want %v", allowAnyOrigin, tt.wantWildcard)
+			if cfg.AllowAnyOrigin != tt.wantWildcard {
+				t.Fatalf("AllowAnyOrigin = %v, want %v", cfg.AllowAnyOrigin, tt.wantWildcard)
 			}
 
 			if tt.wantWildcard {
@@ -343,10 +333,7 @@ func TestConfigureAllowedOrigins(t *testing.T) {
 }
 
 func TestIsOriginAllowed(t *testing.T) {
-	restore := preserveOriginGlobals(t)
-	defer restore()
-
-	allowedOriginEntries = configureAllowedOrigins("https://a.example.com, https://b.example.com", "https://default.example")
+	cfg := newOriginConfig("https://a.example.com, https://b.example.com", "https://default.example")
 
 	tests := []struct {
 		name   string
@@ -363,7 +350,7 @@ func TestIsOriginAllowed(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isOriginAllowed(tt.origin); got != tt.want {
+			if got := cfg.isOriginAllowed(tt.origin); got != tt.want {
 				t.Fatalf("isOriginAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
 			}
 		})
@@ -390,15 +377,10 @@ func TestHandleCORSRejectsWhenNoOriginsConfigured(t *testing.T) {
 
 	// Explicamos que restauramos los valores globales para no afectar a otras pruebas,
 	// igual que haría una persona que ordena su espacio de trabajo antes de comenzar.
-	restore := preserveOriginGlobals(t)
-	defer restore()
-
 	// Dejamos el sistema sin orígenes permitidos, representando un despliegue con
 	// configuración vacía o dañada. Lo hacemos manualmente para imitar el fallo
 	// original incluso después de mejorar la lógica de respaldo.
-	allowAnyOrigin = false
-	allowedOrigin = ""
-	allowedOriginEntries = nil
+	useServiceConfig(t, &serviceConfig{})
 
 	// Construimos una petición desde el dominio público actual para validar que la
 	// respuesta sea de rechazo y así detectar el problema original.
@@ -419,8 +401,7 @@ func TestHandleCORSRejectsWhenNoOriginsConfigured(t *testing.T) {
 func TestHandlePostRechazaCuerposGigantes(t *testing.T) {
 	t.Helper()
 
-	restore := preserveRequestLogger(t)
-	defer restore()
+	useServiceDeps(t, func(*serviceDeps) {})
 
 	rr := httptest.NewRecorder()
 
@@ -457,29 +438,24 @@ func TestHandlePostRechazaCuerposGigantes(t *testing.T) {
 func TestHandleRequestCORSPreflightAndPost(t *testing.T) {
 	t.Helper()
 
-	restoreOrigins := preserveOriginGlobals(t)
-	defer restoreOrigins()
-
-	restoreLogger := preserveRequestLogger(t)
-	defer restoreLogger()
-
 	// Configuramos los orígenes permitidos incluyendo el dominio público, evitando
 	// depender de variables de entorno implícitas dentro de la prueba.
-	allowAnyOrigin = false
-	allowedOriginEntries = configureAllowedOrigins(defaultAllowedOrigin, defaultAllowedOrigin)
+	useServiceConfig(t, newOriginConfig(defaultAllowedOrigin, defaultAllowedOrigin))
 
 	// Reemplazamos las funciones externas para observar que handlePost se ejecuta
 	// sin invocar servicios reales. Guardamos banderas para detectar la llamada.
 	postCalled := false
 	projectCalled := false
-	issueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
-		postCalled = true
-		return &githubIssueResponse{Number: 7, HTMLURL: "https://example.com/issues/7", NodeID: "node-7"}, nil
-	}
-	projectAdder = func(context.Context, string, string, []string) error {
-		projectCalled = true
-		return nil
-	}
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			postCalled = true
+			return &githubIssueResponse{Number: 7, HTMLURL: "https://example.com/issues/7", NodeID: "node-7"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error {
+			projectCalled = true
+			return nil
+		}
+	})
 
 	server := httptest.NewServer(http.HandlerFunc(handleRequest))
 	defer server.Close()
@@ -531,11 +507,13 @@ func TestHandleRequestCORSPreflightAndPost(t *testing.T) {
 		TemplateID string                 `json:"templateId"`
 		Title      string                 `json:"title"`
 		Fields     map[string]interface{} `json:"fields"`
+		Consent    *consentRecord         `json:"consent"`
 	}
 	reqBody := postRequestBody{
 		TemplateID: "blank",
 		Title:      "Ejemplo",
 		Fields:     map[string]interface{}{"descripcion": "Texto"},
+		Consent:    validConsent(),
 	}
 	jsonBytes, err := json.Marshal(reqBody)
 	if err != nil {
@@ -579,28 +557,23 @@ func TestHandleRequestCORSPreflightAndPost(t *testing.T) {
 func TestHandleRequestCORSForbiddenOrigin(t *testing.T) {
 	t.Helper()
 
-	restoreOrigins := preserveOriginGlobals(t)
-	defer restoreOrigins()
-
-	restoreLogger := preserveRequestLogger(t)
-	defer restoreLogger()
-
-	allowAnyOrigin = false
-	allowedOriginEntries = configureAllowedOrigins(defaultAllowedOrigin, defaultAllowedOrigin)
+	useServiceConfig(t, newOriginConfig(defaultAllowedOrigin, defaultAllowedOrigin))
 
 	postCalled := false
-	issueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
-		postCalled = true
-		return nil, nil
-	}
-	projectAdder = func(context.Context, string, string, []string) error { return nil }
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			postCalled = true
+			return nil, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
 
 	server := httptest.NewServer(http.HandlerFunc(handleRequest))
 	defer server.Close()
 
 	client := server.Client()
 
-	body := strings.NewReader("{\"templateId\":\"blank\",\"title\":\"Ejemplo\",\"fields\":{\"descripcion\":\"Texto\"}}")
+	body := strings.NewReader(`{"templateId":"blank","title":"Ejemplo","fields":{"descripcion":"Texto"},` + consentJSON() + `}`)
 	req, err := http.NewRequest(http.MethodPost, server.URL+"/", body)
 	if err != nil {
 		t.Fatalf("no se pudo crear la solicitud POST: %v", err)
@@ -648,26 +621,20 @@ func headerListContains(raw string, target string) bool {
 func TestRequestLoggerCapturesSuccessfulPost(t *testing.T) {
 	t.Helper()
 
-	restoreOrigins := preserveOriginGlobals(t)
-	defer restoreOrigins()
-
-	restoreLogger := preserveRequestLogger(t)
-	defer restoreLogger()
-
-	allowAnyOrigin = true
-	allowedOriginEntries = nil
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
 
 	fakeBackend := &memoryLogBackend{}
-	requestLogBackend = fakeBackend
-
-	issueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
-		// Entregamos datos estáticos para que la prueba se enfoque en el logging
-		// y no dependa de GitHub.
-		return &githubIssueResponse{Number: 1, HTMLURL: "https://example.com/issue/1", NodeID: "node-1"}, nil
-	}
-	projectAdder = func(context.Context, string, string, []string) error { return nil }
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = fakeBackend
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			// Entregamos datos estáticos para que la prueba se enfoque en el logging
+			// y no dependa de GitHub.
+			return &githubIssueResponse{Number: 1, HTMLURL: "https://example.com/issue/1", NodeID: "node-1"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
 
-	body := strings.NewReader("{\"templateId\":\"blank\",\"title\":\"Nuevo módulo\",\"fields\":{\"descripcion\":\"Detalle\"}}")
+	body := strings.NewReader(`{"templateId":"blank","title":"Nuevo módulo","fields":{"descripcion":"Detalle"},` + consentJSON() + `}`)
 	req := httptest.NewRequest(http.MethodPost, "http://service.local/", body)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Origin", "https://allowed.example")
@@ -751,18 +718,10 @@ func TestRequestLoggerCapturesSuccessfulPost(t *testing.T) {
 func TestRequestLoggerCapturesCORSRejection(t *testing.T) {
 	t.Helper()
 
-	restoreOrigins := preserveOriginGlobals(t)
-	defer restoreOrigins()
-
-	restoreLogger := preserveRequestLogger(t)
-	defer restoreLogger()
-
-	allowAnyOrigin = false
-	allowedOriginEntries = nil
-	allowedOrigin = ""
+	useServiceConfig(t, &serviceConfig{})
 
 	fakeBackend := &memoryLogBackend{}
-	requestLogBackend = fakeBackend
+	useServiceDeps(t, func(deps *serviceDeps) { deps.LogBackend = fakeBackend })
 
 	body := strings.NewReader("{\"templateId\":\"blank\"}")
 	req := httptest.NewRequest(http.MethodPost, "http://service.local/", body)
@@ -885,33 +844,29 @@ func TestDetermineProjectTipoValuePriorizaEtiqueta(t *testing.T) {
 func TestAddToProjectAndSetTypeIsCalledWithTemplateID(t *testing.T) {
 	t.Helper()
 
-	restoreOrigins := preserveOriginGlobals(t)
-	defer restoreOrigins()
-
-	restoreLogger := preserveRequestLogger(t)
-	defer restoreLogger()
-
-	allowAnyOrigin = true
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
 
 	var capturedNodeID string
 	var capturedTemplateID string
 
-	issueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
-		return &githubIssueResponse{Number: 1, HTMLURL: "https://example.com/issues/1", NodeID: "test-node-id"}, nil
-	}
-	projectAdder = func(_ context.Context, nodeID string, templateID string, labels []string) error {
-		capturedNodeID = nodeID
-		capturedTemplateID = templateID
-		if len(labels) == 0 {
-			t.Fatalf("se esperaban etiquetas para validar el tipo y llegó una lista vacía")
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			return &githubIssueResponse{Number: 1, HTMLURL: "https://example.com/issues/1", NodeID: "test-node-id"}, nil
 		}
-		if determineProjectTipoValue("desconocido", labels) != "Bug" {
-			t.Fatalf("el cálculo del tipo usando etiquetas no devolvió 'Bug': %v", labels)
+		deps.ProjectAdder = func(_ context.Context, nodeID string, templateID string, labels []string) error {
+			capturedNodeID = nodeID
+			capturedTemplateID = templateID
+			if len(labels) == 0 {
+				t.Fatalf("se esperaban etiquetas para validar el tipo y llegó una lista vacía")
+			}
+			if determineProjectTipoValue("desconocido", labels) != "Bug" {
+				t.Fatalf("el cálculo del tipo usando etiquetas no devolvió 'Bug': %v", labels)
+			}
+			return nil
 		}
-		return nil
-	}
+	})
 
-	body := strings.NewReader("{\"templateId\":\"bug\",\"title\":\"Test bug\",\"fields\":{\"summary\":\"Test\",\"steps\":\"1. Step\",\"expected\":\"Expected\",\"actual\":\"Actual\"}}")
+	body := strings.NewReader(`{"templateId":"bug","title":"Test bug","fields":{"summary":"Test","steps":"1. Step","expected":"Expected","actual":"Actual"},` + consentJSON() + `}`)
 	req := httptest.NewRequest(http.MethodPost, "http://service.local/", body)
 	req.Header.Set("Content-Type", "application/json")
 
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// defaultModulesURL es el modules.json que publica GitHub Pages. Lo usamos
+// como directorio de módulos porque es exactamente lo que ve la persona que
+// hace clic en "Reportar problema" desde una tarjeta.
+const defaultModulesURL = "https://ron-datadriven.github.io/eos-roadmap/modules.json"
+
+// moduleDirectoryTTL evita descargar modules.json en cada envío; el sync lo
+// regenera a lo sumo unas cuantas veces por hora.
+const moduleDirectoryTTL = 5 * time.Minute
+
+// defaultProjectAreaField es el nombre del campo de área en el Project.
+const defaultProjectAreaField = "Area"
+
+var (
+	modulesURL       = envOrDefault("MODULES_URL", defaultModulesURL)
+	projectAreaField = envOrDefault("PROJECT_AREA_FIELD", defaultProjectAreaField)
+)
+
+// errModuleNotFound indica que el moduleId no corresponde a ningún módulo
+// publicado. Es un error de la solicitud, no del servicio.
+var errModuleNotFound = errors.New("módulo no encontrado")
+
+// moduleRef identifica el módulo relacionado con un envío.
+type moduleRef struct {
+	ID     string
+	Number int
+	Title  string
+}
+
+// moduleDirectory resuelve moduleId contra modules.json con un caché corto.
+type moduleDirectory struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+	modules   map[string]moduleRef
+}
+
+func newModuleDirectory(url string) *moduleDirectory {
+	return &moduleDirectory{
+		url:    url,
+		ttl:    moduleDirectoryTTL,
+		client: &http.Client{Timeout: 5 * time.Second, Transport: &outboundLoggingTransport{}},
+	}
+}
+
+// Resolve devuelve el módulo publicado con ese ID. Solo aceptamos módulos que
+// aparecen en modules.json: así un cliente no puede usar moduleId para
+// enlazar el issue con cualquier número arbitrario del repositorio.
+func (d *moduleDirectory) Resolve(ctx context.Context, id string) (*moduleRef, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.modules == nil || time.Since(d.fetchedAt) > d.ttl {
+		modules, err := d.fetch(ctx)
+		if err != nil {
+			// Con un directorio viejo seguimos resolviendo; solo fallamos si
+			// nunca logramos cargarlo.
+			if d.modules == nil {
+				return nil, err
+			}
+		} else {
+			d.modules = modules
+			d.fetchedAt = time.Now()
+		}
+	}
+
+	ref, ok := d.modules[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errModuleNotFound, id)
+	}
+	return &ref, nil
+}
+
+func (d *moduleDirectory) fetch(ctx context.Context) (map[string]moduleRef, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("descargar %s: %w", d.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("descargar %s: estado %d", d.url, resp.StatusCode)
+	}
+
+	var published []struct {
+		ID     string `json:"id"`
+		Nombre string `json:"nombre"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&published); err != nil {
+		return nil, fmt.Errorf("interpretar %s: %w", d.url, err)
+	}
+
+	modules := make(map[string]moduleRef, len(published))
+	for _, m := range published {
+		number, err := strconv.Atoi(m.ID)
+		if err != nil || number <= 0 {
+			continue
+		}
+		modules[m.ID] = moduleRef{ID: m.ID, Number: number, Title: m.Nombre}
+	}
+	return modules, nil
+}
+
+// resolveRelatedModule traduce moduleId en el módulo publicado. Un ID
+// desconocido es un error de la solicitud; si modules.json no está disponible
+// creamos el issue sin enlace, porque perder el reporte sería peor que perder
+// la referencia.
+func resolveRelatedModule(ctx context.Context, moduleID string) (*moduleRef, *submissionError) {
+	moduleID = strings.TrimSpace(moduleID)
+	if moduleID == "" {
+		return nil, nil
+	}
+	resolver := loadServiceDeps().ModuleResolver
+	if resolver == nil {
+		return nil, nil
+	}
+	ref, err := resolver(ctx, moduleID)
+	switch {
+	case errors.Is(err, errModuleNotFound):
+		return nil, &submissionError{Status: http.StatusBadRequest, Code: "invalid_module", Message: "El módulo indicado no existe en el roadmap", Cause: err}
+	case err != nil:
+		if logger := loggerFromContext(ctx); logger != nil {
+			logger.log(ctx, "module_lookup", severityError, fmt.Sprintf("no se pudo resolver el módulo %q, se crea el issue sin enlace: %v", moduleID, err))
+		}
+		return nil, nil
+	default:
+		return ref, nil
+	}
+}
+
+// copyModuleArea replica el campo de área del módulo relacionado en el item
+// del proyecto recién creado. Si el módulo no tiene área no hace nada: no
+// queremos inventar una clasificación.
+func copyModuleArea(ctx context.Context, issueNodeID string, moduleNumber int) error {
+	gqlClient := newGraphQLClient(ctx)
+
+	var moduleQuery struct {
+		Repository struct {
+			Issue struct {
+				ProjectItems struct {
+					Nodes []struct {
+						Project struct {
+							ID githubv4.ID
+						}
+						Area struct {
+							Single struct {
+								Name githubv4.String
+							} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+						} `graphql:"area: fieldValueByName(name: $areaField)"`
+					}
+				} `graphql:"projectItems(first: 20)"`
+			} `graphql:"issue(number: $number)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	vars := map[string]interface{}{
+		"owner":     githubv4.String(githubRepoOwner),
+		"name":      githubv4.String(githubRepoName),
+		"number":    githubv4.Int(moduleNumber),
+		"areaField": githubv4.String(projectAreaField),
+	}
+	if err := gqlClient.Query(ctx, &moduleQuery, vars); err != nil {
+		return fmt.Errorf("error al consultar el área del módulo #%d: %w", moduleNumber, err)
+	}
+
+	area := ""
+	for _, node := range moduleQuery.Repository.Issue.ProjectItems.Nodes {
+		if fmt.Sprint(node.Project.ID) == projectID {
+			area = strings.TrimSpace(string(node.Area.Single.Name))
+			break
+		}
+	}
+	if area == "" {
+		return nil
+	}
+
+	itemID, err := findProjectItemID(ctx, gqlClient, issueNodeID)
+	if err != nil {
+		return err
+	}
+	return setProjectSingleSelectField(ctx, gqlClient, itemID, projectAreaField, area)
+}
+
+// findProjectItemID busca el item del issue en el Project de su destino
+// (ver issueTargetFromContext).
+func findProjectItemID(ctx context.Context, gqlClient *githubv4.Client, issueNodeID string) (githubv4.ID, error) {
+	var issueQuery struct {
+		Node struct {
+			Issue struct {
+				ProjectItems struct {
+					Nodes []struct {
+						ID      githubv4.ID
+						Project struct {
+							ID githubv4.ID
+						}
+					}
+				} `graphql:"projectItems(first: 20)"`
+			} `graphql:"... on Issue"`
+		} `graphql:"node(id: $id)"`
+	}
+	if err := gqlClient.Query(ctx, &issueQuery, map[string]interface{}{"id": githubv4.ID(issueNodeID)}); err != nil {
+		return nil, fmt.Errorf("error al consultar los items del issue: %w", err)
+	}
+	target := issueTargetFromContext(ctx)
+	for _, node := range issueQuery.Node.Issue.ProjectItems.Nodes {
+		if fmt.Sprint(node.Project.ID) == target.ProjectID {
+			return node.ID, nil
+		}
+	}
+	return nil, errors.New("el issue no tiene item en el proyecto configurado")
+}
+
+// envOrDefault lee una variable de entorno y usa el valor por defecto si
+// llega vacía, evitando configuraciones a medias por un espacio accidental.
+func envOrDefault(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestModuleDirectoryResolve(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "caído", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`[{"id":"323","nombre":"Program Manager"},{"id":"sin-numero","nombre":"x"}]`))
+	}))
+	defer server.Close()
+
+	dir := newModuleDirectory(server.URL)
+	ref, err := dir.Resolve(context.Background(), "323")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if ref.Number != 323 || ref.Title != "Program Manager" {
+		t.Fatalf("módulo inesperado: %+v", ref)
+	}
+	if _, err := dir.Resolve(context.Background(), "sin-numero"); !errors.Is(err, errModuleNotFound) {
+		t.Fatalf("un ID no numérico no debe resolverse, got %v", err)
+	}
+
+	// Con el caché vencido y el servidor caído seguimos usando la última copia.
+	failing.Store(true)
+	dir.ttl = 0
+	if _, err := dir.Resolve(context.Background(), "323"); err != nil {
+		t.Fatalf("con un directorio previo no debería fallar: %v", err)
+	}
+}
+
+func TestPrepareSubmissionEnlazaModulo(t *testing.T) {
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.ModuleResolver = func(_ context.Context, id string) (*moduleRef, error) {
+			if id == "42" {
+				return &moduleRef{ID: "42", Number: 42}, nil
+			}
+			if id == "caido" {
+				return nil, errors.New("sin red")
+			}
+			return nil, errModuleNotFound
+		}
+	})
+
+	req := issueRequest{TemplateID: "blank", Title: "Falla", Fields: map[string]string{"descripcion": "x"}, ModuleID: "42", Consent: validConsent()}
+	prepared, subErr := prepareSubmission(context.Background(), req)
+	if subErr != nil {
+		t.Fatalf("prepareSubmission: %v", subErr)
+	}
+	if !strings.HasSuffix(prepared.Body, "Relacionado con #42") || prepared.Related == nil {
+		t.Fatalf("falta la referencia al módulo:\n%s", prepared.Body)
+	}
+
+	req.ModuleID = "999"
+	if _, subErr := prepareSubmission(context.Background(), req); subErr == nil || subErr.Code != "invalid_module" {
+		t.Fatalf("un módulo desconocido debe rechazarse, got %+v", subErr)
+	}
+
+	req.ModuleID = "caido"
+	prepared, subErr = prepareSubmission(context.Background(), req)
+	if subErr != nil || prepared.Related != nil {
+		t.Fatalf("sin directorio se crea el issue sin enlace, got (%+v, %+v)", prepared, subErr)
+	}
+}
+
+func TestSubmitPreparedCopiaAreaDelModulo(t *testing.T) {
+	var linkedNode string
+	var linkedModule int
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			return &githubIssueResponse{Number: 9, HTMLURL: "https://example.com/issues/9", NodeID: "node-9"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+		deps.ModuleAreaLinker = func(_ context.Context, nodeID string, module int) error {
+			linkedNode, linkedModule = nodeID, module
+			return errors.New("campo Area inexistente")
+		}
+	})
+
+	prepared := &preparedSubmission{TemplateID: "blank", Template: templates["blank"], Title: "x", Body: "y", Related: &moduleRef{ID: "7", Number: 7}}
+	resp, subErr := submitPrepared(context.Background(), prepared)
+	if subErr != nil || resp.Error != nil {
+		t.Fatalf("un fallo al copiar el área no debe afectar la respuesta, got (%+v, %+v)", resp, subErr)
+	}
+	if linkedNode != "node-9" || linkedModule != 7 {
+		t.Fatalf("ModuleAreaLinker recibió (%q, %d)", linkedNode, linkedModule)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxOutboundSnippetBytes limita cuánto del cuerpo de cada solicitud o
+// respuesta guardamos en el log. Un fragmento corto basta para entender un
+// "estado inesperado 422" sin inundar el backend con issues completos.
+const maxOutboundSnippetBytes = 512
+
+// redactedValue sustituye cualquier dato sensible detectado en los cuerpos que
+// registramos. Usar siempre el mismo marcador permite buscarlo en los logs.
+const redactedValue = "[REDACTED]"
+
+// verboseOutboundLogging activa la captura de fragmentos de cuerpo. Por
+// defecto solo registramos método, ruta, estado y latencia para no guardar
+// datos de las personas usuarias salvo que la operadora lo pida.
+var verboseOutboundLogging = parseBoolEnv(os.Getenv("LOG_OUTBOUND_BODIES"))
+
+// sensitiveKeys enumera las claves JSON cuyo valor nunca debe llegar al log,
+// aunque el modo detallado esté activo.
+var sensitiveKeys = map[string]struct{}{
+	"access_token":  {},
+	"assertion":     {},
+	"authorization": {},
+	"client_secret": {},
+	"password":      {},
+	"private_key":   {},
+	"secret":        {},
+	"token":         {},
+}
+
+var bearerRegex = regexp.MustCompile(`(?i)(bearer|token)\s+[A-Za-z0-9_\-\.=]+`)
+
+// outboundCall resume una llamada saliente hacia GitHub. Se adjunta a la
+// entrada de log para poder filtrar por ruta o estado sin parsear mensajes.
+type outboundCall struct {
+	Method         string `json:"method"`
+	Host           string `json:"host"`
+	Path           string `json:"path"`
+	Status         int    `json:"status,omitempty"`
+	DurationMillis int64  `json:"durationMillis"`
+	RequestBody    string `json:"requestBody,omitempty"`
+	ResponseBody   string `json:"responseBody,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// outboundLoggingTransport envuelve al transporte HTTP para registrar cada
+// solicitud hacia GitHub junto con su respuesta. Si base es nil usamos
+// http.DefaultTransport en el momento de la llamada, lo que mantiene intacta
+// la posibilidad de reemplazarlo en pruebas.
+type outboundLoggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *outboundLoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	call := outboundCall{
+		Method: req.Method,
+		Host:   req.URL.Host,
+		Path:   req.URL.Path,
+	}
+
+	if verboseOutboundLogging && req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		call.RequestBody = redactSnippet(body)
+	}
+
+	startedAt := time.Now()
+	resp, err := base.RoundTrip(req)
+	call.DurationMillis = time.Since(startedAt).Milliseconds()
+
+	if err != nil {
+		call.Error = err.Error()
+		logOutboundCall(req, call)
+		return nil, err
+	}
+
+	call.Status = resp.StatusCode
+	if verboseOutboundLogging && resp.Body != nil {
+		snippet, readErr := io.ReadAll(io.LimitReader(resp.Body, maxOutboundSnippetBytes))
+		if readErr == nil {
+			// Reconstruimos el cuerpo para que el llamador lo lea completo,
+			// como si nunca lo hubiéramos tocado.
+			resp.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(snippet), resp.Body), resp.Body}
+			call.ResponseBody = redactSnippet(snippet)
+		}
+	}
+
+	logOutboundCall(req, call)
+	return resp, nil
+}
+
+// logOutboundCall envía la llamada al logger de la petición en curso. Sin
+// logger (por ejemplo, en tareas de arranque) usamos el log estándar para no
+// perder la traza.
+func logOutboundCall(req *http.Request, call outboundCall) {
+	severity := severityInfo
+	if call.Error != "" || call.Status >= http.StatusBadRequest {
+		severity = severityError
+	}
+	message := fmt.Sprintf("llamada a GitHub %s %s", call.Method, call.Path)
+
+	ctx := req.Context()
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.logWithEntry(ctx, "outbound", severity, message, logEntry{Outbound: &call})
+		return
+	}
+
+	payload, err := json.Marshal(call)
+	if err != nil {
+		log.Printf("%s: %v", message, err)
+		return
+	}
+	log.Printf("outbound: %s", payload)
+}
+
+// redactSnippet oculta valores sensibles y recorta el cuerpo. Intentamos
+// primero interpretarlo como JSON para reemplazar claves conocidas; si no es
+// JSON aplicamos una expresión regular sobre encabezados tipo "Bearer xyz".
+func redactSnippet(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	var decoded any
+	if err := json.Unmarshal(trimmed, &decoded); err == nil {
+		if redacted, err := json.Marshal(redactValue(decoded)); err == nil {
+			return truncateBytes(string(redacted), maxOutboundSnippetBytes)
+		}
+	}
+
+	cleaned := bearerRegex.ReplaceAllString(string(trimmed), "$1 "+redactedValue)
+	return truncateBytes(cleaned, maxOutboundSnippetBytes)
+}
+
+func redactValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, inner := range typed {
+			if _, sensitive := sensitiveKeys[strings.ToLower(key)]; sensitive {
+				typed[key] = redactedValue
+				continue
+			}
+			typed[key] = redactValue(inner)
+		}
+		return typed
+	case []any:
+		for i, inner := range typed {
+			typed[i] = redactValue(inner)
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+func truncateBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "") + "…"
+}
+
+// parseBoolEnv interpreta los valores habituales de "verdadero" en variables
+// de entorno para que "1", "true" o "sí" activen la opción por igual.
+func parseBoolEnv(raw string) bool {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "1", "true", "yes", "on", "si", "sí":
+		return true
+	default:
+		return false
+	}
+}
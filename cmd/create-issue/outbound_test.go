@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOutboundLoggingTransportRegistraLlamada(t *testing.T) {
+	previousVerbose := verboseOutboundLogging
+	verboseOutboundLogging = true
+	t.Cleanup(func() { verboseOutboundLogging = previousVerbose })
+
+	backend := &memoryLogBackend{}
+	req := httptest.NewRequest(http.MethodPost, "http://service.local/", nil)
+	logger := newRequestLogger(context.Background(), backend, req)
+	ctx := logger.Attach(context.Background())
+
+	transport := &outboundLoggingTransport{base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusUnprocessableEntity,
+			Body:       io.NopCloser(strings.NewReader(`{"message":"Validation Failed","token":"ghp_secreto"}`)),
+			Header:     make(http.Header),
+		}, nil
+	})}
+
+	outbound, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.github.com/repos/o/r/issues", strings.NewReader(`{"title":"x","password":"1234"}`))
+	if err != nil {
+		t.Fatalf("no se pudo crear la solicitud: %v", err)
+	}
+
+	resp, err := transport.RoundTrip(outbound)
+	if err != nil {
+		t.Fatalf("RoundTrip devolvió error: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("no se pudo leer la respuesta: %v", err)
+	}
+	if !strings.Contains(string(body), "ghp_secreto") {
+		t.Fatalf("el cuerpo entregado al llamador debe permanecer intacto: %s", body)
+	}
+
+	var found *outboundCall
+	for _, entry := range backend.Entries() {
+		if entry.Stage == "outbound" {
+			found = entry.Outbound
+			if entry.Severity != severityError {
+				t.Fatalf("severidad = %s, se esperaba %s para un 422", entry.Severity, severityError)
+			}
+		}
+	}
+	if found == nil {
+		t.Fatal("no se registró la llamada saliente")
+	}
+	if found.Method != http.MethodPost || found.Path != "/repos/o/r/issues" || found.Status != http.StatusUnprocessableEntity {
+		t.Fatalf("llamada registrada inesperada: %+v", found)
+	}
+	if strings.Contains(found.RequestBody, "1234") || strings.Contains(found.ResponseBody, "ghp_secreto") {
+		t.Fatalf("los cuerpos registrados no fueron redactados: %+v", found)
+	}
+	if !strings.Contains(found.ResponseBody, "Validation Failed") {
+		t.Fatalf("el fragmento de respuesta debería conservar el mensaje: %q", found.ResponseBody)
+	}
+}
+
+func TestOutboundLoggingTransportSinModoDetallado(t *testing.T) {
+	previousVerbose := verboseOutboundLogging
+	verboseOutboundLogging = false
+	t.Cleanup(func() { verboseOutboundLogging = previousVerbose })
+
+	backend := &memoryLogBackend{}
+	req := httptest.NewRequest(http.MethodPost, "http://service.local/", nil)
+	logger := newRequestLogger(context.Background(), backend, req)
+	ctx := logger.Attach(context.Background())
+
+	transport := &outboundLoggingTransport{base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(strings.NewReader(`{}`)), Header: make(http.Header)}, nil
+	})}
+
+	outbound, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.github.com/graphql", strings.NewReader(`{"query":"x"}`))
+	if _, err := transport.RoundTrip(outbound); err != nil {
+		t.Fatalf("RoundTrip devolvió error: %v", err)
+	}
+
+	for _, entry := range backend.Entries() {
+		if entry.Stage != "outbound" {
+			continue
+		}
+		if entry.Outbound.RequestBody != "" || entry.Outbound.ResponseBody != "" {
+			t.Fatalf("sin modo detallado no deben registrarse cuerpos: %+v", entry.Outbound)
+		}
+		return
+	}
+	t.Fatal("no se registró la llamada saliente")
+}
+
+func TestRedactSnippetTextoPlano(t *testing.T) {
+	got := redactSnippet([]byte("Authorization: Bearer abc.def-123"))
+	if strings.Contains(got, "abc.def-123") {
+		t.Fatalf("el token no fue redactado: %q", got)
+	}
+}
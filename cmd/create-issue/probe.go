@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// probePath recibe la sonda sintética desde Cloud Scheduler.
+const probePath = "/probe"
+
+// probeTemplateID usa la plantilla en blanco: su Tipo ("Blank Issue") nunca
+// se publica en el roadmap, así que la sonda no aparece en la página ni
+// siquiera durante los segundos que vive.
+const probeTemplateID = "blank"
+
+// probeTitlePrefix marca los issues de la sonda para reconocerlos si la
+// limpieza falla y hay que borrarlos a mano.
+const probeTitlePrefix = "[sonda] "
+
+// probeVerifyAttempts y probeVerifyDelay dan margen a GitHub para reflejar
+// el item en el Project; agregarlo y leerlo no siempre es inmediato.
+const probeVerifyAttempts = 3
+
+var probeVerifyDelay = 2 * time.Second
+
+// probeIssue es lo que la sonda lee de GitHub para verificar el issue.
+type probeIssue struct {
+	NodeID      string
+	InProject   bool
+	ProjectTipo string
+}
+
+// probeBackend agrupa lo que la sonda necesita además del flujo normal de
+// envío. Es intercambiable para que las pruebas no toquen GitHub.
+type probeBackend struct {
+	Inspect func(ctx context.Context, issueURL string) (*probeIssue, error)
+	Cleanup func(ctx context.Context, issueNodeID string) error
+	// Alert avisa de una sonda fallida; nil deja solo el log.
+	Alert func(ctx context.Context, report probeReport) error
+}
+
+type probeStage struct {
+	Name           string `json:"name"`
+	OK             bool   `json:"ok"`
+	DurationMillis int64  `json:"durationMillis"`
+	Error          string `json:"error,omitempty"`
+}
+
+// probeReport es el resultado de una corrida de la sonda.
+type probeReport struct {
+	StartedAt string       `json:"startedAt"`
+	OK        bool         `json:"ok"`
+	IssueURL  string       `json:"issueUrl,omitempty"`
+	Stages    []probeStage `json:"stages"`
+}
+
+func (r *probeReport) stage(name string, fn func() error) bool {
+	startedAt := time.Now()
+	err := fn()
+	entry := probeStage{Name: name, OK: err == nil, DurationMillis: time.Since(startedAt).Milliseconds()}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.Stages = append(r.Stages, entry)
+	return err == nil
+}
+
+// runProbe envía un issue canario por el mismo camino que un envío real
+// (validación, consentimiento, creación y alta en el Project), verifica que
+// quedó en el tablero con el Tipo correcto y lo cierra y borra. La limpieza
+// corre aunque la verificación falle para no dejar basura en el repositorio.
+func runProbe(ctx context.Context, backend *probeBackend) probeReport {
+	now := time.Now()
+	report := probeReport{StartedAt: now.UTC().Format(time.RFC3339), Stages: []probeStage{}}
+
+	req := issueRequest{
+		TemplateID: probeTemplateID,
+		Title:      probeTitlePrefix + now.UTC().Format(time.RFC3339),
+		Fields:     map[string]string{"descripcion": "Issue generado por la sonda sintética; se borra automáticamente."},
+		Consent:    &consentRecord{PolicyVersion: privacyPolicyVersion, AcceptedAt: now},
+	}
+	submitted := report.stage("submit", func() error {
+		prepared, subErr := prepareSubmission(ctx, req)
+		if subErr != nil {
+			return subErr
+		}
+		resp, subErr := submitPrepared(ctx, prepared)
+		if subErr != nil {
+			return subErr
+		}
+		report.IssueURL = resp.IssueURL
+		if resp.Error != nil {
+			return fmt.Errorf("%s: %s", resp.Error.Code, resp.Error.Message)
+		}
+		return nil
+	})
+
+	var issue *probeIssue
+	if report.IssueURL != "" {
+		report.stage("verify", func() error {
+			var err error
+			for attempt := 1; attempt <= probeVerifyAttempts; attempt++ {
+				if attempt > 1 {
+					time.Sleep(probeVerifyDelay)
+				}
+				issue, err = backend.Inspect(ctx, report.IssueURL)
+				if err != nil {
+					continue
+				}
+				want := templateTypeToFieldValue(probeTemplateID)
+				switch {
+				case !issue.InProject:
+					err = errors.New("el issue no aparece en el Project")
+				case issue.ProjectTipo != want:
+					err = fmt.Errorf("Tipo en el Project = %q, se esperaba %q", issue.ProjectTipo, want)
+				default:
+					return nil
+				}
+			}
+			return err
+		})
+
+		report.stage("cleanup", func() error {
+			if issue == nil || issue.NodeID == "" {
+				return fmt.Errorf("no se pudo identificar %s para borrarlo", report.IssueURL)
+			}
+			return backend.Cleanup(ctx, issue.NodeID)
+		})
+	}
+
+	report.OK = submitted
+	for _, stage := range report.Stages {
+		report.OK = report.OK && stage.OK
+	}
+	recordProbe(ctx, backend, report)
+	return report
+}
+
+// recordProbe deja la corrida en el log (stage probe_ok o probe_failed, este
+// último con severidad ERROR para que una alerta de Cloud Logging lo tome) y
+// avisa por el canal configurado si falló.
+func recordProbe(ctx context.Context, backend *probeBackend, report probeReport) {
+	var failed []string
+	for _, stage := range report.Stages {
+		if !stage.OK {
+			failed = append(failed, fmt.Sprintf("%s: %s", stage.Name, stage.Error))
+		}
+	}
+	stage, severity, message := "probe_ok", severityInfo, "sonda sintética correcta"
+	if !report.OK {
+		stage, severity = "probe_failed", severityError
+		message = "sonda sintética fallida: " + strings.Join(failed, "; ")
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.log(ctx, stage, severity, message)
+	} else {
+		log.Print(message)
+	}
+
+	if report.OK || backend.Alert == nil {
+		return
+	}
+	if err := backend.Alert(ctx, report); err != nil {
+		logErrorWithFallback(ctx, "probe_alert_error", "no se pudo enviar la alerta de la sonda", err)
+	}
+}
+
+// newProbeBackendFromEnv arma la sonda contra GitHub. PROBE_ALERT_WEBHOOK es
+// opcional: recibe un POST JSON con {"text": ...}, el formato que aceptan los
+// webhooks entrantes de Slack y Google Chat.
+func newProbeBackendFromEnv(getenv func(string) string) *probeBackend {
+	backend := &probeBackend{Inspect: inspectProbeIssue, Cleanup: deleteProbeIssue}
+	if webhook := strings.TrimSpace(getenv("PROBE_ALERT_WEBHOOK")); webhook != "" {
+		backend.Alert = func(ctx context.Context, report probeReport) error {
+			return postProbeAlert(ctx, webhook, report)
+		}
+	}
+	return backend
+}
+
+func postProbeAlert(ctx context.Context, webhook string, report probeReport) error {
+	var lines []string
+	for _, stage := range report.Stages {
+		if !stage.OK {
+			lines = append(lines, fmt.Sprintf("• %s: %s", stage.Name, stage.Error))
+		}
+	}
+	text := fmt.Sprintf("Falló la sonda de create-issue (%s)\n%s", report.StartedAt, strings.Join(lines, "\n"))
+	if report.IssueURL != "" {
+		text += "\nIssue: " + report.IssueURL
+	}
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("el webhook respondió %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// inspectProbeIssue lee el issue y sus items de Project para confirmar que
+// quedó en el tablero configurado con el Tipo esperado.
+func inspectProbeIssue(ctx context.Context, issueURL string) (*probeIssue, error) {
+	parsed, err := url.Parse(issueURL)
+	if err != nil {
+		return nil, fmt.Errorf("URL de issue inválida %q: %w", issueURL, err)
+	}
+	var q struct {
+		Resource struct {
+			Issue struct {
+				ID           githubv4.ID
+				ProjectItems struct {
+					Nodes []struct {
+						Project struct {
+							ID githubv4.ID
+						}
+						Tipo struct {
+							Single struct {
+								Name string
+							} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+						} `graphql:"fieldValueByName(name: \"Tipo\")"`
+					}
+				} `graphql:"projectItems(first: 10)"`
+			} `graphql:"... on Issue"`
+		} `graphql:"resource(url: $url)"`
+	}
+	if err := newGraphQLClient(ctx).Query(ctx, &q, map[string]interface{}{"url": githubv4.URI{URL: parsed}}); err != nil {
+		return nil, fmt.Errorf("error al consultar %s: %w", issueURL, err)
+	}
+	issue := &probeIssue{NodeID: fmt.Sprint(q.Resource.Issue.ID)}
+	if q.Resource.Issue.ID == nil {
+		issue.NodeID = ""
+	}
+	for _, item := range q.Resource.Issue.ProjectItems.Nodes {
+		// La sonda sigue el destino de su plantilla, igual que un envío real.
+		if fmt.Sprint(item.Project.ID) == targetForTemplate(probeTemplateID).ProjectID {
+			issue.InProject = true
+			issue.ProjectTipo = item.Tipo.Single.Name
+		}
+	}
+	return issue, nil
+}
+
+// deleteProbeIssue cierra el issue y luego lo borra. Cerrarlo primero
+// garantiza que, si el token no puede borrar, al menos no queda abierto.
+func deleteProbeIssue(ctx context.Context, issueNodeID string) error {
+	client := newGraphQLClient(ctx)
+	reason := githubv4.IssueClosedStateReasonNotPlanned
+	var closeMutation struct {
+		CloseIssue struct {
+			Issue struct {
+				ID githubv4.ID
+			}
+		} `graphql:"closeIssue(input: $input)"`
+	}
+	if err := client.Mutate(ctx, &closeMutation, githubv4.CloseIssueInput{IssueID: githubv4.ID(issueNodeID), StateReason: &reason}, nil); err != nil {
+		return fmt.Errorf("error al cerrar el issue de la sonda: %w", err)
+	}
+	var deleteMutation struct {
+		DeleteIssue struct {
+			ClientMutationID string
+		} `graphql:"deleteIssue(input: $input)"`
+	}
+	if err := client.Mutate(ctx, &deleteMutation, githubv4.DeleteIssueInput{IssueID: githubv4.ID(issueNodeID)}, nil); err != nil {
+		return fmt.Errorf("issue cerrado pero no se pudo borrar: %w", err)
+	}
+	return nil
+}
+
+// handleProbe corre la sonda a pedido de Cloud Scheduler. Exige el secreto
+// PROBE_SECRET en X-Probe-Token: cada llamada crea un issue real, así que no
+// puede quedar abierta a cualquiera. Responde 503 si falló para que el
+// scheduler también marque el intento como fallido.
+func handleProbe(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	secret := strings.TrimSpace(loadServiceDeps().ProbeSecret)
+	backend := loadServiceDeps().Probe
+	if secret == "" || backend == nil {
+		writeError(ctx, w, http.StatusNotFound, "not_found", "Ruta no encontrada", nil)
+		return
+	}
+	if !hmac.Equal([]byte(r.Header.Get("X-Probe-Token")), []byte(secret)) {
+		writeError(ctx, w, http.StatusNotFound, "not_found", "Ruta no encontrada", nil)
+		return
+	}
+
+	report := runProbe(ctx, backend)
+	status := http.StatusOK
+	if !report.OK {
+		status = http.StatusServiceUnavailable
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.RecordStatus(status)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logErrorWithFallback(ctx, "write_response_error", "error al escribir respuesta", err)
+	}
+}
+
+// runProbeLoop es el scheduler interno para despliegues sin Cloud Scheduler:
+// corre la sonda cada interval hasta que el contexto se cancela.
+func runProbeLoop(ctx context.Context, interval time.Duration, backend *probeBackend, logs logBackend) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			logger := &requestLogger{backend: logs, requestID: generateRequestID(), method: "PROBE", path: probePath, templateID: probeTemplateID, startedAt: time.Now().UTC()}
+			probeCtx := logger.Attach(ctx)
+			runProbe(probeCtx, backend)
+			logger.Finish(probeCtx)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func useProbe(t *testing.T, backend *probeBackend) {
+	t.Helper()
+	previousDelay := probeVerifyDelay
+	probeVerifyDelay = 0
+	t.Cleanup(func() { probeVerifyDelay = previousDelay })
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(_ context.Context, title string, _ []string, _ string) (*githubIssueResponse, error) {
+			if !strings.HasPrefix(title, probeTitlePrefix) {
+				t.Errorf("el título de la sonda debe llevar el prefijo, llegó %q", title)
+			}
+			return &githubIssueResponse{Number: 7, HTMLURL: "https://github.com/o/r/issues/7", NodeID: "I_7"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+		deps.Probe = backend
+		deps.ProbeSecret = "secreto"
+	})
+}
+
+func TestRunProbeVerificaYBorra(t *testing.T) {
+	var inspections int
+	var cleaned string
+	useProbe(t, &probeBackend{
+		Inspect: func(context.Context, string) (*probeIssue, error) {
+			inspections++
+			if inspections == 1 {
+				return &probeIssue{NodeID: "I_7"}, nil
+			}
+			return &probeIssue{NodeID: "I_7", InProject: true, ProjectTipo: "Blank Issue"}, nil
+		},
+		Cleanup: func(_ context.Context, nodeID string) error { cleaned = nodeID; return nil },
+		Alert: func(context.Context, probeReport) error {
+			t.Fatal("una sonda correcta no debe alertar")
+			return nil
+		},
+	})
+
+	report := runProbe(context.Background(), loadServiceDeps().Probe)
+	if !report.OK || len(report.Stages) != 3 {
+		t.Fatalf("reporte inesperado: %+v", report)
+	}
+	if inspections != 2 {
+		t.Fatalf("la verificación debe reintentar hasta ver el item en el Project, intentos = %d", inspections)
+	}
+	if cleaned != "I_7" {
+		t.Fatalf("la sonda debe borrar su issue, borró %q", cleaned)
+	}
+}
+
+func TestRunProbeLimpiaYAlertaSiFallaLaVerificacion(t *testing.T) {
+	var cleaned bool
+	var alerted probeReport
+	useProbe(t, &probeBackend{
+		Inspect: func(context.Context, string) (*probeIssue, error) {
+			return &probeIssue{NodeID: "I_7", InProject: true, ProjectTipo: "Bug"}, nil
+		},
+		Cleanup: func(context.Context, string) error { cleaned = true; return nil },
+		Alert:   func(_ context.Context, report probeReport) error { alerted = report; return nil },
+	})
+
+	report := runProbe(context.Background(), loadServiceDeps().Probe)
+	if report.OK || !cleaned {
+		t.Fatalf("la sonda debe fallar y aun así limpiar: ok=%v limpiado=%v", report.OK, cleaned)
+	}
+	if alerted.IssueURL != "https://github.com/o/r/issues/7" || alerted.Stages[1].OK {
+		t.Fatalf("la alerta no describe la falla: %+v", alerted)
+	}
+}
+
+func TestHandleProbeExigeToken(t *testing.T) {
+	useProbe(t, &probeBackend{
+		Inspect: func(context.Context, string) (*probeIssue, error) {
+			return nil, errors.New("sin acceso")
+		},
+		Cleanup: func(context.Context, string) error { return nil },
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "http://service.local"+probePath, nil)
+	req.Header.Set("X-Probe-Token", "otro")
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("sin token válido la sonda debe responder 404, llegó %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "http://service.local"+probePath, nil)
+	req.Header.Set("X-Probe-Token", "secreto")
+	rr = httptest.NewRecorder()
+	handleRequest(rr, req)
+	if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), `"ok":false`) {
+		t.Fatalf("una sonda fallida debe responder 503 con el reporte, llegó %d: %s", rr.Code, rr.Body.String())
+	}
+}
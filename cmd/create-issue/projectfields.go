@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// labelProjectFields son los campos del Project que las plantillas ya
+// declaran como etiqueta ("Tipo: Bug", "Status: Ideas"). La etiqueta manda
+// para que el tablero muestre lo mismo que el issue.
+var labelProjectFields = []string{"Tipo", "Status"}
+
+// projectFieldValue es una opción a asignar en un campo de selección única.
+type projectFieldValue struct {
+	Field string
+	Value string
+}
+
+// parseProjectFields lee PROJECT_FIELDS con la forma
+// "bug.Area=Plataforma,feature.Status=Ideas". No valida las plantillas: el
+// catálogo cambia con .github/ISSUE_TEMPLATE y loadTemplateSettings solo
+// avisa de las que todavía no conoce.
+func parseProjectFields(raw string) (map[string]map[string]string, error) {
+	byTemplate := map[string]map[string]string{}
+	if strings.TrimSpace(raw) == "" {
+		return byTemplate, nil
+	}
+	for _, entry := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		templateID, field, hasField := strings.Cut(strings.TrimSpace(key), ".")
+		templateID, field, value = strings.TrimSpace(templateID), strings.TrimSpace(field), strings.TrimSpace(value)
+		if !ok || !hasField || templateID == "" || field == "" || value == "" {
+			return nil, fmt.Errorf("PROJECT_FIELDS: entrada inválida %q (se espera plantilla.Campo=Valor)", entry)
+		}
+		for _, canonical := range labelProjectFields {
+			if strings.EqualFold(field, canonical) {
+				field = canonical
+			}
+		}
+		if byTemplate[templateID] == nil {
+			byTemplate[templateID] = map[string]string{}
+		}
+		byTemplate[templateID][field] = value
+	}
+	return byTemplate, nil
+}
+
+// labelFieldValue busca una etiqueta "Campo: valor" sin distinguir
+// mayúsculas ni espacios alrededor de los dos puntos ("Status :En
+// planeación" también cuenta).
+func labelFieldValue(labels []string, field string) string {
+	for _, label := range labels {
+		prefix, value, ok := strings.Cut(label, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(prefix), field) {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// projectFieldValues decide qué campos del Project llenar para un envío.
+// Tipo y Status salen de la etiqueta, luego de PROJECT_FIELDS y, solo para
+// Tipo, del mapeo por plantilla; el resto de PROJECT_FIELDS se agrega en
+// orden alfabético para que las mutaciones sean predecibles.
+func projectFieldValues(templateID string, labels []string) []projectFieldValue {
+	configured := loadServiceConfig().ProjectFields[templateID]
+	var values []projectFieldValue
+	for _, field := range labelProjectFields {
+		value := labelFieldValue(labels, field)
+		if value == "" {
+			value = configured[field]
+		}
+		if value == "" && field == "Tipo" {
+			value = templateTypeToFieldValue(templateID)
+		}
+		if value != "" {
+			values = append(values, projectFieldValue{Field: field, Value: value})
+		}
+	}
+
+	extra := make([]string, 0, len(configured))
+	for field := range configured {
+		if !isLabelProjectField(field) {
+			extra = append(extra, field)
+		}
+	}
+	sort.Strings(extra)
+	for _, field := range extra {
+		values = append(values, projectFieldValue{Field: field, Value: configured[field]})
+	}
+	return values
+}
+
+func isLabelProjectField(field string) bool {
+	for _, candidate := range labelProjectFields {
+		if strings.EqualFold(candidate, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProjectFields(t *testing.T) {
+	got, err := parseProjectFields(" bug.Area=Plataforma, bug.status = Triage ,feature.Area=Producto")
+	if err != nil {
+		t.Fatalf("parseProjectFields: %v", err)
+	}
+	want := map[string]map[string]string{
+		"bug":     {"Area": "Plataforma", "Status": "Triage"},
+		"feature": {"Area": "Producto"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseProjectFields = %v, want %v", got, want)
+	}
+
+	for raw, wantErr := range map[string]string{
+		"bug=Plataforma": "entrada inválida",
+		"bug.Area=":      "entrada inválida",
+	} {
+		if _, err := parseProjectFields(raw); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("parseProjectFields(%q) = %v, se esperaba %q", raw, err, wantErr)
+		}
+	}
+
+	// Una plantilla que todavía no está en el catálogo no impide arrancar:
+	// puede llegar con la próxima recarga de los formularios.
+	if got, err := parseProjectFields("nueva.Area=Web"); err != nil || got["nueva"]["Area"] != "Web" {
+		t.Fatalf("una plantilla nueva se acepta: %v / %v", got, err)
+	}
+}
+
+func TestProjectFieldValuesEtiquetaYConfiguracion(t *testing.T) {
+	cfg := *loadServiceConfig()
+	cfg.ProjectFields = map[string]map[string]string{
+		"bug":   {"Status": "Triage", "Area": "Plataforma", "Prioridad": "Alta"},
+		"blank": {"Status": "Backlog"},
+	}
+	useServiceConfig(t, &cfg)
+
+	// Las etiquetas de la plantilla mandan, incluso con espacios raros.
+	got := projectFieldValues("bug", templates["bug"].Labels)
+	want := []projectFieldValue{
+		{Field: "Tipo", Value: "Bug"},
+		{Field: "Status", Value: "En planeación"},
+		{Field: "Area", Value: "Plataforma"},
+		{Field: "Prioridad", Value: "Alta"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("bug = %+v, want %+v", got, want)
+	}
+
+	// Sin etiqueta de Status se usa PROJECT_FIELDS y Tipo cae al mapeo.
+	got = projectFieldValues("blank", nil)
+	want = []projectFieldValue{{Field: "Tipo", Value: "Blank Issue"}, {Field: "Status", Value: "Backlog"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("blank = %+v, want %+v", got, want)
+	}
+
+	if got := projectFieldValues("pregunta", nil); len(got) != 0 {
+		t.Fatalf("una plantilla sin mapeo ni etiquetas no llena campos: %+v", got)
+	}
+}
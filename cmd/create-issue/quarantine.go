@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quarantinePrefix agrupa la revisión humana de envíos retenidos:
+// GET /admin/quarantine, POST /admin/quarantine/{id}/approve y
+// POST /admin/quarantine/{id}/reject.
+const quarantinePrefix = "/admin/quarantine"
+
+// maxQuarantined acota la memoria que puede ocupar la cuarentena si alguien
+// inunda el formulario: pasado el límite respondemos 503 en lugar de crear
+// los issues sin revisar.
+const maxQuarantined = 500
+
+// Estados de un envío en cuarentena.
+const (
+	quarantinePending  = "pendiente"
+	quarantineApproved = "aprobado"
+	quarantineRejected = "rechazado"
+)
+
+// quarantinedSubmission es un envío retenido. Request guarda la solicitud
+// tal como se validó (con la recepción del consentimiento ya fijada) para
+// que aprobarla cree el mismo issue que se habría creado al recibirla.
+type quarantinedSubmission struct {
+	ID         string       `json:"id"`
+	ReceivedAt time.Time    `json:"receivedAt"`
+	Origin     string       `json:"origin,omitempty"`
+	RequestID  string       `json:"requestId,omitempty"`
+	Request    issueRequest `json:"request"`
+	Score      int          `json:"score"`
+	Reasons    []string     `json:"reasons,omitempty"`
+	Status     string       `json:"status"`
+	ResolvedAt *time.Time   `json:"resolvedAt,omitempty"`
+	Reason     string       `json:"reason,omitempty"`
+	IssueURL   string       `json:"issueUrl,omitempty"`
+}
+
+var (
+	errQuarantineFull     = errors.New("cuarentena llena")
+	errQuarantineNotFound = errors.New("envío en cuarentena no encontrado")
+	errQuarantineResolved = errors.New("el envío ya fue resuelto")
+)
+
+// quarantineStore guarda los envíos retenidos hasta que alguien los resuelve.
+type quarantineStore interface {
+	Put(ctx context.Context, item quarantinedSubmission) error
+	List(ctx context.Context, includeResolved bool) ([]quarantinedSubmission, error)
+	// Claim reserva un pendiente mientras se crea su issue, para que dos
+	// aprobaciones simultáneas no lo dupliquen; Release lo libera si la
+	// creación falla y se puede reintentar.
+	Claim(ctx context.Context, id string) (quarantinedSubmission, error)
+	Release(ctx context.Context, id string)
+	// Resolve marca el envío pendiente como aprobado o rechazado; falla con
+	// errQuarantineResolved si otra persona se adelantó.
+	Resolve(ctx context.Context, id string, resolve func(*quarantinedSubmission)) (quarantinedSubmission, error)
+}
+
+// memoryQuarantine vive en memoria como las sesiones: un reinicio pierde los
+// pendientes, que quedan en el log con su ID para recuperarlos a mano.
+type memoryQuarantine struct {
+	limit int
+
+	mu      sync.Mutex
+	items   map[string]quarantinedSubmission
+	claimed map[string]bool
+}
+
+func newMemoryQuarantine(limit int) *memoryQuarantine {
+	return &memoryQuarantine{limit: limit, items: map[string]quarantinedSubmission{}, claimed: map[string]bool{}}
+}
+
+func (m *memoryQuarantine) Put(_ context.Context, item quarantinedSubmission) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.items) >= m.limit {
+		// Los resueltos solo sirven de historial; ceden su lugar primero.
+		for id, existing := range m.items {
+			if existing.Status != quarantinePending {
+				delete(m.items, id)
+			}
+		}
+	}
+	if len(m.items) >= m.limit {
+		return errQuarantineFull
+	}
+	m.items[item.ID] = item
+	return nil
+}
+
+func (m *memoryQuarantine) List(_ context.Context, includeResolved bool) ([]quarantinedSubmission, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]quarantinedSubmission, 0, len(m.items))
+	for _, item := range m.items {
+		if includeResolved || item.Status == quarantinePending {
+			out = append(out, item)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ReceivedAt.Before(out[j].ReceivedAt) })
+	return out, nil
+}
+
+func (m *memoryQuarantine) Claim(_ context.Context, id string) (quarantinedSubmission, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[id]
+	switch {
+	case !ok:
+		return quarantinedSubmission{}, errQuarantineNotFound
+	case item.Status != quarantinePending || m.claimed[id]:
+		return quarantinedSubmission{}, errQuarantineResolved
+	}
+	m.claimed[id] = true
+	return item, nil
+}
+
+func (m *memoryQuarantine) Release(_ context.Context, id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.claimed, id)
+}
+
+func (m *memoryQuarantine) Resolve(_ context.Context, id string, resolve func(*quarantinedSubmission)) (quarantinedSubmission, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[id]
+	if !ok {
+		return quarantinedSubmission{}, errQuarantineNotFound
+	}
+	if item.Status != quarantinePending {
+		return quarantinedSubmission{}, errQuarantineResolved
+	}
+	resolve(&item)
+	m.items[id] = item
+	delete(m.claimed, id)
+	return item, nil
+}
+
+// screenSubmission guarda en cuarentena el envío que el filtro de abuso
+// marcó como dudoso y responde 202. Devuelve handled=true cuando ya escribió
+// la respuesta.
+func screenSubmission(ctx context.Context, w http.ResponseWriter, req issueRequest, prepared *preparedSubmission, verdict screenVerdict) (handled, ok bool) {
+	deps := loadServiceDeps()
+	if !verdict.Quarantine || deps.Quarantine == nil {
+		return false, false
+	}
+
+	req.Consent = prepared.Consent
+	item := quarantinedSubmission{
+		ID:         generateRequestID(),
+		ReceivedAt: time.Now().UTC(),
+		Request:    req,
+		Score:      verdict.Score,
+		Reasons:    verdict.Reasons,
+		Status:     quarantinePending,
+	}
+	logger := loggerFromContext(ctx)
+	if logger != nil {
+		item.RequestID = logger.ID()
+		item.Origin = logger.origin
+	}
+	if err := deps.Quarantine.Put(ctx, item); err != nil {
+		writeError(ctx, w, http.StatusServiceUnavailable, "quarantine_full", "No se pudo recibir la solicitud", err)
+		return true, false
+	}
+	if logger != nil {
+		logger.log(ctx, "quarantine", severityInfo, fmt.Sprintf("envío %s retenido para revisión (puntaje %d: %s)", item.ID, item.Score, strings.Join(item.Reasons, "; ")))
+	}
+	writeResponse(ctx, w, http.StatusAccepted, issueResponse{SubmissionID: item.ID, PendingReview: true})
+	return true, true
+}
+
+// requireQuarantineReviewer apaga la cuarentena si nadie puede revisarla:
+// sin ADMIN_TOKEN /admin/quarantine no existe y lo retenido se perdería en
+// el próximo reinicio. Los envíos dudosos se crean directo y los que
+// alcanzan SPAM_REJECT_SCORE se siguen descartando. Devuelve si la apagó.
+func requireQuarantineReviewer(deps *serviceDeps) bool {
+	if deps.Quarantine == nil || strings.TrimSpace(deps.AdminToken) != "" {
+		return false
+	}
+	deps.Quarantine = nil
+	return true
+}
+
+// handleQuarantine atiende la revisión humana. Sin ADMIN_TOKEN la ruta no
+// existe, igual que /probe sin PROBE_SECRET.
+func handleQuarantine(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	deps := loadServiceDeps()
+	if !authorizeAdmin(ctx, w, r, deps.Quarantine != nil) {
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, quarantinePrefix), "/")
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			writeError(ctx, w, http.StatusMethodNotAllowed, "method_not_allowed", "método no permitido", nil)
+			return
+		}
+		items, err := deps.Quarantine.List(ctx, r.URL.Query().Get("estado") == "todos")
+		if err != nil {
+			writeError(ctx, w, http.StatusInternalServerError, "internal_error", "No se pudo leer la cuarentena", err)
+			return
+		}
+		writeAdminJSON(ctx, w, http.StatusOK, map[string]any{"items": items})
+		return
+	}
+
+	id, action, found := strings.Cut(rest, "/")
+	if !found || id == "" || (action != "approve" && action != "reject") {
+		writeError(ctx, w, http.StatusNotFound, "not_found", "Ruta no encontrada", nil)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(ctx, w, http.StatusMethodNotAllowed, "method_not_allowed", "método no permitido", nil)
+		return
+	}
+	if action == "approve" {
+		approveQuarantined(ctx, w, deps.Quarantine, id)
+		return
+	}
+	rejectQuarantined(ctx, w, r, deps.Quarantine, id)
+}
+
+// approveQuarantined crea el issue por el mismo camino que un envío directo.
+func approveQuarantined(ctx context.Context, w http.ResponseWriter, store quarantineStore, id string) {
+	item, err := store.Claim(ctx, id)
+	if err != nil {
+		writeQuarantineStoreError(ctx, w, err)
+		return
+	}
+	defer store.Release(ctx, id)
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.SetTemplate(item.Request.TemplateID)
+	}
+
+	prepared, subErr := prepareSubmission(ctx, item.Request)
+	if subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return
+	}
+	resp, subErr := submitPrepared(ctx, prepared)
+	if subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return
+	}
+
+	resolved, err := store.Resolve(ctx, id, func(q *quarantinedSubmission) {
+		now := time.Now().UTC()
+		q.Status = quarantineApproved
+		q.ResolvedAt = &now
+		q.IssueURL = resp.IssueURL
+	})
+	if err != nil {
+		// El issue ya existe; solo falló anotar la resolución.
+		logErrorWithFallback(ctx, "quarantine_resolve_error", "no se pudo marcar el envío como aprobado", err)
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.log(ctx, "quarantine", severityInfo, fmt.Sprintf("envío %s aprobado: %s", id, resp.IssueURL))
+	}
+	if err == nil {
+		item = resolved
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, item)
+}
+
+// rejectQuarantined descarta el envío y deja el motivo en la cuarentena y en
+// el log, que es el registro de auditoría.
+func rejectQuarantined(ctx context.Context, w http.ResponseWriter, r *http.Request, store quarantineStore, id string) {
+	var payload struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&payload); err != nil || strings.TrimSpace(payload.Reason) == "" {
+		writeError(ctx, w, http.StatusBadRequest, "invalid_request", "Indica el motivo del rechazo en \"reason\"", err)
+		return
+	}
+	reason := strings.TrimSpace(payload.Reason)
+
+	// Reservar primero evita rechazar un envío cuya aprobación está en curso.
+	if _, err := store.Claim(ctx, id); err != nil {
+		writeQuarantineStoreError(ctx, w, err)
+		return
+	}
+	defer store.Release(ctx, id)
+	item, err := store.Resolve(ctx, id, func(q *quarantinedSubmission) {
+		now := time.Now().UTC()
+		q.Status = quarantineRejected
+		q.ResolvedAt = &now
+		q.Reason = reason
+	})
+	if err != nil {
+		writeQuarantineStoreError(ctx, w, err)
+		return
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.SetTemplate(item.Request.TemplateID)
+		logger.log(ctx, "quarantine", severityInfo, fmt.Sprintf("envío %s rechazado: %s", id, reason))
+	}
+	writeAdminJSON(ctx, w, http.StatusOK, item)
+}
+
+func writeQuarantineStoreError(ctx context.Context, w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errQuarantineNotFound):
+		writeError(ctx, w, http.StatusNotFound, "quarantine_not_found", "Envío en cuarentena no encontrado", err)
+	case errors.Is(err, errQuarantineResolved):
+		writeError(ctx, w, http.StatusConflict, "quarantine_resolved", "El envío ya fue resuelto", err)
+	default:
+		writeError(ctx, w, http.StatusInternalServerError, "internal_error", "No se pudo leer la cuarentena", err)
+	}
+}
+
+// authorizeAdmin exige ADMIN_TOKEN como "Authorization: Bearer". Sin token
+// configurado, o con la función desactivada (enabled=false), la ruta no
+// existe. Devuelve false si ya respondió.
+func authorizeAdmin(ctx context.Context, w http.ResponseWriter, r *http.Request, enabled bool) bool {
+	token := strings.TrimSpace(loadServiceDeps().AdminToken)
+	if token == "" || !enabled {
+		writeError(ctx, w, http.StatusNotFound, "not_found", "Ruta no encontrada", nil)
+		return false
+	}
+	given := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if !hmac.Equal([]byte(given), []byte(token)) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(ctx, w, http.StatusUnauthorized, "unauthorized", "Credenciales de administración inválidas", nil)
+		return false
+	}
+	return true
+}
+
+func writeAdminJSON(ctx context.Context, w http.ResponseWriter, status int, value any) {
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.RecordStatus(status)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logErrorWithFallback(ctx, "write_response_error", "error al escribir respuesta", err)
+	}
+}
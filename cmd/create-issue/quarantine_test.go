@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func useQuarantine(t *testing.T, created *[]string) *memoryQuarantine {
+	t.Helper()
+	store := newMemoryQuarantine(10)
+	scorer, err := newSpamScorerFromEnv(func(key string) string {
+		if key == "QUARANTINE_KEYWORDS" {
+			return "casino, préstamo"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("newSpamScorerFromEnv: %v", err)
+	}
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Screener = scorer.Screen
+		deps.Quarantine = store
+		deps.AdminToken = "admin"
+		deps.IssueCreator = func(_ context.Context, title string, _ []string, _ string) (*githubIssueResponse, error) {
+			*created = append(*created, title)
+			return &githubIssueResponse{Number: 9, HTMLURL: "https://github.com/o/r/issues/9", NodeID: "I_9"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+	return store
+}
+
+func postSubmission(t *testing.T, title string) issueResponse {
+	t.Helper()
+	body := fmt.Sprintf(`{"templateId":"blank","title":%q,"fields":{"descripcion":"y"},%s}`, title, consentJSON())
+	req := httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	var resp issueResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("respuesta ilegible (%d): %s", rr.Code, rr.Body.String())
+	}
+	return resp
+}
+
+func adminRequest(method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "http://service.local"+path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	return rr
+}
+
+func TestCuarentenaRetieneYApruebaCreaElIssue(t *testing.T) {
+	var created []string
+	useQuarantine(t, &created)
+
+	resp := postSubmission(t, "Préstamo inmediato")
+	if !resp.PendingReview || resp.SubmissionID == "" || len(created) != 0 {
+		t.Fatalf("el envío debía quedar en cuarentena sin crear issue: %+v, creados %v", resp, created)
+	}
+	if normal := postSubmission(t, "Falla el login"); normal.IssueURL == "" || len(created) != 1 {
+		t.Fatalf("un envío normal debe crearse directo: %+v", normal)
+	}
+
+	rr := adminRequest(http.MethodGet, quarantinePrefix, "admin", "")
+	var list struct {
+		Items []quarantinedSubmission `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("listado inválido (%d): %s", rr.Code, rr.Body.String())
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != resp.SubmissionID || list.Items[0].Reasons[0] != "keywords: término préstamo" {
+		t.Fatalf("listado inesperado: %+v", list.Items)
+	}
+
+	rr = adminRequest(http.MethodPost, quarantinePrefix+"/"+resp.SubmissionID+"/approve", "admin", "")
+	if rr.Code != http.StatusOK || len(created) != 2 || created[1] != "Préstamo inmediato" {
+		t.Fatalf("aprobar debe crear el issue (%d): %s, creados %v", rr.Code, rr.Body.String(), created)
+	}
+	var approved quarantinedSubmission
+	if err := json.Unmarshal(rr.Body.Bytes(), &approved); err != nil || approved.Status != quarantineApproved || approved.IssueURL == "" {
+		t.Fatalf("resolución inesperada: %+v (%v)", approved, err)
+	}
+
+	rr = adminRequest(http.MethodPost, quarantinePrefix+"/"+resp.SubmissionID+"/approve", "admin", "")
+	if rr.Code != http.StatusConflict || len(created) != 2 {
+		t.Fatalf("aprobar dos veces no debe duplicar el issue, llegó %d", rr.Code)
+	}
+}
+
+func TestCuarentenaSinAdminTokenCreaDirecto(t *testing.T) {
+	var created []string
+	useQuarantine(t, &created)
+	deps := *loadServiceDeps()
+	deps.AdminToken = ""
+	if !requireQuarantineReviewer(&deps) || deps.Quarantine != nil {
+		t.Fatal("sin ADMIN_TOKEN la cuarentena debe apagarse")
+	}
+	storeServiceDeps(&deps)
+
+	resp := postSubmission(t, "Préstamo inmediato")
+	if resp.PendingReview || resp.IssueURL == "" || len(created) != 1 {
+		t.Fatalf("sin quien revise, un envío dudoso se crea directo: %+v, creados %v", resp, created)
+	}
+
+	deps.AdminToken = "admin"
+	deps.Quarantine = newMemoryQuarantine(1)
+	if requireQuarantineReviewer(&deps) || deps.Quarantine == nil {
+		t.Fatal("con ADMIN_TOKEN la cuarentena se conserva")
+	}
+}
+
+func TestCuarentenaRechazoGuardaElMotivo(t *testing.T) {
+	var created []string
+	store := useQuarantine(t, &created)
+	resp := postSubmission(t, "Casino gratis")
+
+	if rr := adminRequest(http.MethodPost, quarantinePrefix+"/"+resp.SubmissionID+"/reject", "admin", `{}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("rechazar sin motivo debe fallar, llegó %d", rr.Code)
+	}
+	rr := adminRequest(http.MethodPost, quarantinePrefix+"/"+resp.SubmissionID+"/reject", "admin", `{"reason":"spam"}`)
+	if rr.Code != http.StatusOK || len(created) != 0 {
+		t.Fatalf("rechazo inesperado (%d): %s", rr.Code, rr.Body.String())
+	}
+	items, _ := store.List(context.Background(), true)
+	if len(items) != 1 || items[0].Status != quarantineRejected || items[0].Reason != "spam" || items[0].ResolvedAt == nil {
+		t.Fatalf("el rechazo no quedó registrado: %+v", items)
+	}
+	if pending, _ := store.List(context.Background(), false); len(pending) != 0 {
+		t.Fatalf("el listado por defecto solo muestra pendientes: %+v", pending)
+	}
+}
+
+func TestCuarentenaExigeToken(t *testing.T) {
+	var created []string
+	useQuarantine(t, &created)
+
+	if rr := adminRequest(http.MethodGet, quarantinePrefix, "", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("sin token debe responder 401, llegó %d", rr.Code)
+	}
+	if rr := adminRequest(http.MethodGet, quarantinePrefix, "otro", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("con token equivocado debe responder 401, llegó %d", rr.Code)
+	}
+	if rr := adminRequest(http.MethodPost, quarantinePrefix+"/nada/approve", "admin", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("un ID desconocido debe responder 404, llegó %d", rr.Code)
+	}
+
+	useServiceDeps(t, func(deps *serviceDeps) { deps.AdminToken = "" })
+	if rr := adminRequest(http.MethodGet, quarantinePrefix, "", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("sin ADMIN_TOKEN la ruta no debe existir, llegó %d", rr.Code)
+	}
+}
+
+func TestMemoryQuarantineLimite(t *testing.T) {
+	store := newMemoryQuarantine(1)
+	ctx := context.Background()
+	if err := store.Put(ctx, quarantinedSubmission{ID: "a", Status: quarantinePending}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Put(ctx, quarantinedSubmission{ID: "b", Status: quarantinePending}); err != errQuarantineFull {
+		t.Fatalf("con pendientes al límite debe fallar, llegó %v", err)
+	}
+	if _, err := store.Resolve(ctx, "a", func(q *quarantinedSubmission) { q.Status = quarantineRejected }); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if err := store.Put(ctx, quarantinedSubmission{ID: "b", Status: quarantinePending}); err != nil {
+		t.Fatalf("los resueltos deben ceder su lugar: %v", err)
+	}
+}
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxSubmissionAttempts limita cuántas veces reintentamos un envío encolado
+// antes de descartarlo. Evita que un issue imposible de crear bloquee la cola
+// para siempre.
+const maxSubmissionAttempts = 5
+
+// Espera antes de reintentar un envío que falló: se duplica en cada intento
+// hasta el máximo que admite el plazo de confirmación de Pub/Sub, para no
+// martillar a GitHub mientras está caído.
+const (
+	submissionRetryBaseDelay = 10 * time.Second
+	submissionRetryMaxDelay  = 10 * time.Minute
+)
+
+// Atributos del mensaje de Pub/Sub con los que llevamos la cuenta de
+// intentos cuando la suscripción no tiene política de mensajes no
+// entregables (sin ella Pub/Sub no informa deliveryAttempt).
+const (
+	pubsubAttemptsAttribute  = "attempts"
+	pubsubNotBeforeAttribute = "notBefore"
+)
+
+// pubsubScope es el permiso que pedimos al firmar un JWT con credenciales
+// locales para publicar y consumir mensajes de Pub/Sub.
+const pubsubScope = "https://www.googleapis.com/auth/pubsub"
+
+const pubsubEndpoint = "https://pubsub.googleapis.com/v1"
+
+// pubsubEmptyPullDelay es la pausa tras un pull sin mensajes. Pub/Sub puede
+// responder vacío al instante aunque no haya nada que entregar; sin pausa el
+// worker gastaría CPU y cuota de la API en un bucle.
+const pubsubEmptyPullDelay = time.Second
+
+// submissionJob es lo que viaja por la cola: la solicitud original más los
+// datos necesarios para correlacionarla con el log de la petición HTTP.
+type submissionJob struct {
+	ID            string             `json:"id"`
+	RequestID     string             `json:"requestId,omitempty"`
+	Origin        string             `json:"origin,omitempty"`
+	ClientIP      string             `json:"clientIp,omitempty"`
+	TemplateID    string             `json:"templateId"`
+	Title         string             `json:"title"`
+	Fields        map[string]string  `json:"fields,omitempty"`
+	Client        *clientInfo        `json:"client,omitempty"`
+	ModuleID      string             `json:"moduleId,omitempty"`
+	Consent       *consentRecord     `json:"consent,omitempty"`
+	DuplicateOf   int                `json:"duplicateOf,omitempty"`
+	Accessibility *accessibilityInfo `json:"accessibility,omitempty"`
+	Attachments   []attachmentInput  `json:"attachments,omitempty"`
+	EnqueuedAt    time.Time          `json:"enqueuedAt"`
+	Attempts      int                `json:"attempts"`
+}
+
+func (j submissionJob) request() issueRequest {
+	return issueRequest{TemplateID: j.TemplateID, Title: j.Title, Fields: j.Fields, Client: j.Client, ModuleID: j.ModuleID, Consent: j.Consent, DuplicateOf: j.DuplicateOf, Accessibility: j.Accessibility, Attachments: j.Attachments}
+}
+
+// submissionRetryDelay es la espera antes del intento attempt+1.
+func submissionRetryDelay(attempt int) time.Duration {
+	delay := submissionRetryBaseDelay
+	for i := 0; i < attempt && delay < submissionRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > submissionRetryMaxDelay {
+		delay = submissionRetryMaxDelay
+	}
+	return delay
+}
+
+// queuedSubmission entrega un trabajo junto con las funciones para
+// confirmarlo o devolverlo a la cola. Cada backend decide cómo implementar
+// ambas operaciones; Nack siempre espera submissionRetryDelay antes de que
+// el trabajo vuelva a entregarse.
+type queuedSubmission struct {
+	Job  submissionJob
+	Ack  func(ctx context.Context) error
+	Nack func(ctx context.Context) error
+}
+
+// submissionQueue abstrae el almacenamiento de la cola para que el nivel de
+// fiabilidad se elija por entorno (memoria en desarrollo, Pub/Sub en
+// producción) sin tocar el código del handler ni del worker.
+type submissionQueue interface {
+	Enqueue(ctx context.Context, job submissionJob) error
+	Receive(ctx context.Context) (*queuedSubmission, error)
+	Close() error
+}
+
+// newSubmissionQueue construye la cola indicada por SUBMISSION_QUEUE: memory
+// o pubsub. Una cadena vacía significa "sin cola" y devuelve nil sin error.
+// Cassandra no está implementada (depende del paquete contracts, que no
+// forma parte de este repositorio) y se rechaza como cualquier valor
+// desconocido, en lugar de caer a memoria y perder envíos al reiniciar.
+func newSubmissionQueue(kind string, getenv func(string) string) (submissionQueue, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "none", "sync":
+		return nil, nil
+	case "memory":
+		return newMemorySubmissionQueue(100), nil
+	case "pubsub":
+		return newPubSubSubmissionQueue(
+			strings.TrimSpace(getenv("PUBSUB_PROJECT_ID")),
+			strings.TrimSpace(getenv("PUBSUB_TOPIC")),
+			strings.TrimSpace(getenv("PUBSUB_SUBSCRIPTION")),
+		)
+	default:
+		return nil, fmt.Errorf("SUBMISSION_QUEUE desconocida: %q", kind)
+	}
+}
+
+// enqueueSubmission guarda la solicitud en la cola y responde 202 con el
+// identificador del envío, que la interfaz puede mostrar a la persona usuaria,
+// y el aviso de incidente si lo hay. Devuelve false si no se pudo encolar.
+func enqueueSubmission(ctx context.Context, w http.ResponseWriter, queue submissionQueue, req issueRequest, incident *incidentNotice) bool {
+	job := submissionJob{
+		ID:            generateRequestID(),
+		TemplateID:    req.TemplateID,
+		Title:         strings.TrimSpace(req.Title),
+		Fields:        req.Fields,
+		Client:        req.Client,
+		ModuleID:      req.ModuleID,
+		Consent:       req.Consent,
+		DuplicateOf:   req.DuplicateOf,
+		Accessibility: req.Accessibility,
+		Attachments:   req.Attachments,
+		EnqueuedAt:    time.Now().UTC(),
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		job.RequestID = logger.ID()
+		job.Origin = logger.origin
+		job.ClientIP = logger.clientIP
+	}
+
+	if err := queue.Enqueue(ctx, job); err != nil {
+		writeError(ctx, w, http.StatusServiceUnavailable, "queue_unavailable", "No se pudo encolar la solicitud", err)
+		return false
+	}
+
+	writeResponse(ctx, w, http.StatusAccepted, issueResponse{SubmissionID: job.ID, Incident: incident})
+	return true
+}
+
+// runSubmissionWorker consume la cola hasta que el contexto se cancela. Los
+// rechazos de validación se confirman (reintentar no los arreglaría) y los
+// fallos de GitHub se devuelven a la cola para otro intento.
+func runSubmissionWorker(ctx context.Context, queue submissionQueue, backend logBackend) {
+	for {
+		item, err := queue.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("cola de envíos: error al recibir: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		processQueuedSubmission(ctx, item, backend)
+	}
+}
+
+func processQueuedSubmission(ctx context.Context, item *queuedSubmission, backend logBackend) {
+	logger := newJobLogger(ctx, backend, item.Job)
+	jobCtx := withFeatureFlags(logger.Attach(ctx), loadServiceConfig().Flags, logger.ID(), item.Job.Origin)
+	defer logger.Finish(jobCtx)
+
+	settle := func(ack bool) {
+		settleFn := item.Nack
+		if ack {
+			settleFn = item.Ack
+		}
+		if err := settleFn(jobCtx); err != nil {
+			logger.LogError(jobCtx, "queue_settle_error", "no se pudo confirmar el trabajo en la cola", err)
+		}
+	}
+
+	prepared, subErr := prepareSubmission(jobCtx, item.Job.request())
+	if subErr == nil {
+		var resp issueResponse
+		resp, subErr = submitPrepared(jobCtx, prepared)
+		if subErr == nil {
+			logger.RecordStatus(http.StatusOK)
+			logger.log(jobCtx, "queued_issue_created", severityInfo, fmt.Sprintf("issue creado desde la cola: %s", resp.IssueURL))
+			settle(true)
+			return
+		}
+	}
+
+	logger.RecordStatus(subErr.Status)
+	logger.LogError(jobCtx, string(subErr.Code), subErr.Message, subErr.Cause)
+	if !subErr.retryable() || item.Job.Attempts+1 >= maxSubmissionAttempts {
+		settle(true)
+		return
+	}
+	settle(false)
+}
+
+// newJobLogger reutiliza el requestLogger para los trabajos encolados. El
+// identificador es el de la petición original, de modo que una búsqueda por
+// debugId muestra tanto la recepción como el procesamiento diferido.
+func newJobLogger(ctx context.Context, backend logBackend, job submissionJob) *requestLogger {
+	requestID := job.RequestID
+	if requestID == "" {
+		requestID = job.ID
+	}
+	logger := &requestLogger{
+		backend:    backend,
+		requestID:  requestID,
+		method:     "QUEUE",
+		path:       "submission/" + job.ID,
+		origin:     job.Origin,
+		clientIP:   job.ClientIP,
+		templateID: job.TemplateID,
+		startedAt:  time.Now().UTC(),
+	}
+	logger.log(ctx, "start", severityInfo, fmt.Sprintf("procesando envío encolado (intento %d)", job.Attempts+1))
+	return logger
+}
+
+// memorySubmissionQueue es la cola para desarrollo: rápida y sin
+// dependencias, pero pierde los trabajos pendientes si el proceso termina.
+type memorySubmissionQueue struct {
+	items chan submissionJob
+	// retryDelay es submissionRetryDelay salvo en las pruebas.
+	retryDelay func(attempt int) time.Duration
+	// retrying cuenta los trabajos devueltos que esperan su reintento.
+	retrying atomic.Int64
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newMemorySubmissionQueue(capacity int) *memorySubmissionQueue {
+	return &memorySubmissionQueue{
+		items:      make(chan submissionJob, capacity),
+		retryDelay: submissionRetryDelay,
+		done:       make(chan struct{}),
+	}
+}
+
+func (m *memorySubmissionQueue) Enqueue(ctx context.Context, job submissionJob) error {
+	select {
+	case <-m.done:
+		return errors.New("cola cerrada")
+	default:
+	}
+	select {
+	case m.items <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return errors.New("cola llena")
+	}
+}
+
+// Depth cuenta los trabajos en espera, también los que aguardan un
+// reintento; el descarte por carga lo vigila.
+func (m *memorySubmissionQueue) Depth() int { return len(m.items) + int(m.retrying.Load()) }
+
+// requeue devuelve el trabajo a la cola pasada la espera. El envío bloquea en
+// lugar de fallar con la cola llena: un trabajo ya aceptado no se pierde por
+// un pico de envíos nuevos.
+func (m *memorySubmissionQueue) requeue(job submissionJob, delay time.Duration) {
+	m.retrying.Add(1)
+	go func() {
+		defer m.retrying.Add(-1)
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-m.done:
+			return
+		}
+		select {
+		case m.items <- job:
+		case <-m.done:
+		}
+	}()
+}
+
+func (m *memorySubmissionQueue) Receive(ctx context.Context) (*queuedSubmission, error) {
+	select {
+	case job := <-m.items:
+		return &queuedSubmission{
+			Job: job,
+			Ack: func(context.Context) error { return nil },
+			Nack: func(context.Context) error {
+				delay := m.retryDelay(job.Attempts)
+				job.Attempts++
+				m.requeue(job, delay)
+				return nil
+			},
+		}, nil
+	case <-m.done:
+		return nil, errors.New("cola cerrada")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *memorySubmissionQueue) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	return nil
+}
+
+// pubsubSubmissionQueue publica y consume trabajos mediante la API REST de
+// Pub/Sub, reutilizando la autenticación manual que ya usamos para Cloud
+// Logging en lugar de añadir el SDK completo.
+type pubsubSubmissionQueue struct {
+	topic        string
+	subscription string
+	endpoint     string
+	client       *http.Client
+	tokens       *googleTokenCache
+	// emptyPullDelay es pubsubEmptyPullDelay; las pruebas lo acortan.
+	emptyPullDelay time.Duration
+}
+
+func newPubSubSubmissionQueue(projectID, topic, subscription string) (*pubsubSubmissionQueue, error) {
+	if projectID == "" || topic == "" || subscription == "" {
+		return nil, errors.New("SUBMISSION_QUEUE=pubsub requiere PUBSUB_PROJECT_ID, PUBSUB_TOPIC y PUBSUB_SUBSCRIPTION")
+	}
+	return &pubsubSubmissionQueue{
+		topic:          fmt.Sprintf("projects/%s/topics/%s", projectID, url.PathEscape(topic)),
+		subscription:   fmt.Sprintf("projects/%s/subscriptions/%s", projectID, url.PathEscape(subscription)),
+		endpoint:       pubsubEndpoint,
+		client:         &http.Client{Timeout: 70 * time.Second},
+		tokens:         &googleTokenCache{scope: pubsubScope},
+		emptyPullDelay: pubsubEmptyPullDelay,
+	}, nil
+}
+
+func (p *pubsubSubmissionQueue) Enqueue(ctx context.Context, job submissionJob) error {
+	return p.publish(ctx, job, map[string]string{"templateId": job.TemplateID})
+}
+
+func (p *pubsubSubmissionQueue) publish(ctx context.Context, job submissionJob, attributes map[string]string) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("no se pudo serializar el trabajo: %w", err)
+	}
+	payload := map[string]any{
+		"messages": []map[string]any{{
+			"data":       base64.StdEncoding.EncodeToString(data),
+			"attributes": attributes,
+		}},
+	}
+	return p.call(ctx, p.topic+":publish", payload, nil)
+}
+
+// delay devuelve el mensaje a la suscripción pasado el plazo indicado.
+func (p *pubsubSubmissionQueue) delay(ctx context.Context, ackID string, delay time.Duration) error {
+	seconds := int(delay / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	if limit := int(submissionRetryMaxDelay / time.Second); seconds > limit {
+		seconds = limit
+	}
+	return p.call(ctx, p.subscription+":modifyAckDeadline", map[string]any{"ackIds": []string{ackID}, "ackDeadlineSeconds": seconds}, nil)
+}
+
+func (p *pubsubSubmissionQueue) ack(ctx context.Context, ackID string) error {
+	return p.call(ctx, p.subscription+":acknowledge", map[string]any{"ackIds": []string{ackID}}, nil)
+}
+
+func (p *pubsubSubmissionQueue) Receive(ctx context.Context) (*queuedSubmission, error) {
+	for {
+		var pulled struct {
+			ReceivedMessages []struct {
+				AckID           string `json:"ackId"`
+				DeliveryAttempt int    `json:"deliveryAttempt"`
+				Message         struct {
+					Data       string            `json:"data"`
+					Attributes map[string]string `json:"attributes"`
+				} `json:"message"`
+			} `json:"receivedMessages"`
+		}
+		if err := p.call(ctx, p.subscription+":pull", map[string]any{"maxMessages": 1}, &pulled); err != nil {
+			return nil, err
+		}
+		if len(pulled.ReceivedMessages) == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(p.emptyPullDelay):
+			}
+			continue
+		}
+
+		received := pulled.ReceivedMessages[0]
+		ackID := received.AckID
+		raw, err := base64.StdEncoding.DecodeString(received.Message.Data)
+		var job submissionJob
+		if err == nil {
+			err = json.Unmarshal(raw, &job)
+		}
+		if err != nil {
+			// Un mensaje ilegible nunca podrá procesarse; lo confirmamos para
+			// que no vuelva una y otra vez.
+			log.Printf("cola pubsub: mensaje inválido descartado: %v", err)
+			_ = p.ack(ctx, ackID)
+			continue
+		}
+
+		attributes := received.Message.Attributes
+		// Un reintento republicado todavía en espera vuelve a la suscripción
+		// hasta que se cumpla su plazo.
+		if notBefore, err := time.Parse(time.RFC3339, attributes[pubsubNotBeforeAttribute]); err == nil {
+			if wait := time.Until(notBefore); wait > 0 {
+				if err := p.delay(ctx, ackID, wait); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		countedByPubSub := received.DeliveryAttempt > 0
+		if countedByPubSub {
+			job.Attempts = received.DeliveryAttempt - 1
+		} else if attempts, err := strconv.Atoi(attributes[pubsubAttemptsAttribute]); err == nil {
+			job.Attempts = attempts
+		}
+
+		return &queuedSubmission{
+			Job: job,
+			Ack: func(ctx context.Context) error { return p.ack(ctx, ackID) },
+			Nack: func(ctx context.Context) error {
+				delay := submissionRetryDelay(job.Attempts)
+				if countedByPubSub {
+					// Con política de mensajes no entregables Pub/Sub lleva
+					// la cuenta; basta con alargar el plazo de confirmación.
+					return p.delay(ctx, ackID, delay)
+				}
+				// Sin ella republicamos el trabajo con el intento en los
+				// atributos y confirmamos el original. Si publicar falla,
+				// el original vuelve pasado el plazo sin perder el envío.
+				retry := job
+				retry.Attempts++
+				err := p.publish(ctx, retry, map[string]string{
+					"templateId":             job.TemplateID,
+					pubsubAttemptsAttribute:  strconv.Itoa(retry.Attempts),
+					pubsubNotBeforeAttribute: time.Now().Add(delay).UTC().Format(time.RFC3339),
+				})
+				if err != nil {
+					return errors.Join(err, p.delay(ctx, ackID, delay))
+				}
+				return p.ack(ctx, ackID)
+			},
+		}, nil
+	}
+}
+
+func (p *pubsubSubmissionQueue) Close() error { return nil }
+
+func (p *pubsubSubmissionQueue) call(ctx context.Context, resource string, payload any, out any) error {
+	token, err := p.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener token para Pub/Sub: %w", err)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/"+resource, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error al llamar a Pub/Sub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("Pub/Sub devolvió %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewSubmissionQueueSelecciona(t *testing.T) {
+	getenv := func(string) string { return "" }
+
+	queue, err := newSubmissionQueue("", getenv)
+	if err != nil || queue != nil {
+		t.Fatalf("sin configuración se esperaba camino síncrono, got %v, %v", queue, err)
+	}
+
+	queue, err = newSubmissionQueue("memory", getenv)
+	if err != nil {
+		t.Fatalf("memory devolvió error: %v", err)
+	}
+	if _, ok := queue.(*memorySubmissionQueue); !ok {
+		t.Fatalf("se esperaba memorySubmissionQueue, got %T", queue)
+	}
+
+	if _, err := newSubmissionQueue("pubsub", getenv); err == nil {
+		t.Fatal("pubsub sin variables debería fallar")
+	}
+	if _, err := newSubmissionQueue("cassandra", getenv); err == nil {
+		t.Fatal("cassandra no está implementada y debe rechazarse")
+	}
+	if _, err := newSubmissionQueue("kafka", getenv); err == nil {
+		t.Fatal("un backend desconocido debería fallar")
+	}
+}
+
+func TestHandlePostEncolaCuandoHayCola(t *testing.T) {
+	queue := newMemorySubmissionQueue(1)
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.SubmissionQueue = queue
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			t.Fatal("el camino asíncrono no debe crear el issue dentro de la petición")
+			return nil, nil
+		}
+	})
+
+	body := strings.NewReader(`{"templateId":"blank","title":"Encolado","fields":{"descripcion":"x"},` + consentJSON() + `}`)
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	rr := httptest.NewRecorder()
+	handlePost(context.Background(), rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, se esperaba %d", rr.Code, http.StatusAccepted)
+	}
+	var payload issueResponse
+	if err := json.NewDecoder(rr.Body).Decode(&payload); err != nil {
+		t.Fatalf("no se pudo leer la respuesta: %v", err)
+	}
+	if payload.SubmissionID == "" {
+		t.Fatal("se esperaba submissionId en la respuesta")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	item, err := queue.Receive(ctx)
+	if err != nil {
+		t.Fatalf("no se recibió el trabajo: %v", err)
+	}
+	if item.Job.ID != payload.SubmissionID || item.Job.Title != "Encolado" {
+		t.Fatalf("trabajo inesperado: %+v", item.Job)
+	}
+}
+
+func TestHandlePostNoEncolaSolicitudesInvalidas(t *testing.T) {
+	queue := newMemorySubmissionQueue(1)
+	useServiceDeps(t, func(deps *serviceDeps) { deps.SubmissionQueue = queue })
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"templateId":"bug","title":"x","fields":{}}`))
+	rr := httptest.NewRecorder()
+	handlePost(context.Background(), rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, se esperaba %d", rr.Code, http.StatusBadRequest)
+	}
+	if len(queue.items) != 0 {
+		t.Fatal("una solicitud inválida no debe llegar a la cola")
+	}
+}
+
+func TestProcessQueuedSubmissionReintentaErroresDeGitHub(t *testing.T) {
+	calls := 0
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("github caído")
+			}
+			return &githubIssueResponse{Number: 3, HTMLURL: "https://example.com/issues/3", NodeID: "node-3"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	queue := newMemorySubmissionQueue(2)
+	queue.retryDelay = func(int) time.Duration { return 0 }
+	job := submissionJob{ID: "job-1", TemplateID: "blank", Title: "Reintento", Fields: map[string]string{"descripcion": "x"}, Consent: validConsent()}
+	if err := queue.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	backend := &memoryLogBackend{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		item, err := queue.Receive(ctx)
+		if err != nil {
+			t.Fatalf("Receive %d: %v", i, err)
+		}
+		processQueuedSubmission(ctx, item, backend)
+	}
+
+	if calls != 2 {
+		t.Fatalf("issueCreator llamado %d veces, se esperaban 2", calls)
+	}
+	if queue.Depth() != 0 {
+		t.Fatal("el trabajo exitoso no debe volver a la cola")
+	}
+}
+
+func TestSubmissionRetryDelayCrece(t *testing.T) {
+	if got := submissionRetryDelay(0); got != submissionRetryBaseDelay {
+		t.Fatalf("primer reintento: %v", got)
+	}
+	if got := submissionRetryDelay(2); got != 4*submissionRetryBaseDelay {
+		t.Fatalf("tercer reintento: %v", got)
+	}
+	if got := submissionRetryDelay(30); got != submissionRetryMaxDelay {
+		t.Fatalf("la espera debe tener tope: %v", got)
+	}
+}
+
+func TestMemoryNackEsperaYNoPierdeConLaColaLlena(t *testing.T) {
+	queue := newMemorySubmissionQueue(1)
+	defer queue.Close()
+	var delays []int
+	queue.retryDelay = func(attempt int) time.Duration {
+		delays = append(delays, attempt)
+		return time.Hour
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := queue.Enqueue(ctx, submissionJob{ID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	item, err := queue.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := item.Nack(ctx); err != nil {
+		t.Fatalf("Nack: %v", err)
+	}
+	if len(delays) != 1 || delays[0] != 0 || queue.Depth() != 1 || len(queue.items) != 0 {
+		t.Fatalf("el trabajo debe esperar su reintento fuera del búfer: delays=%v depth=%d", delays, queue.Depth())
+	}
+	// La cola se llena con un envío nuevo; el reintento espera su lugar.
+	if err := queue.Enqueue(ctx, submissionJob{ID: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	queue.requeue(submissionJob{ID: "c", Attempts: 1}, 0)
+	for _, want := range []string{"b", "c"} {
+		item, err := queue.Receive(ctx)
+		if err != nil || item.Job.ID != want {
+			t.Fatalf("se esperaba %s: %+v / %v", want, item, err)
+		}
+	}
+}
+
+func TestPubSubNackSinDeliveryAttemptRepublica(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		published []map[string]any
+		calls     []string
+		notBefore string
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		resource := r.URL.Path[strings.LastIndex(r.URL.Path, ":")+1:]
+		calls = append(calls, resource)
+		switch resource {
+		case "pull":
+			attributes := map[string]string{"attempts": "2"}
+			if notBefore != "" {
+				attributes["notBefore"] = notBefore
+			}
+			data, _ := json.Marshal(submissionJob{ID: "job", TemplateID: "blank"})
+			_ = json.NewEncoder(w).Encode(map[string]any{"receivedMessages": []map[string]any{{
+				"ackId":   "ack-1",
+				"message": map[string]any{"data": data, "attributes": attributes},
+			}}})
+		case "publish":
+			published = append(published, body)
+			_, _ = w.Write([]byte(`{}`))
+		case "modifyAckDeadline":
+			// Basta con ver que se pospuso; cortamos el bucle de Receive.
+			cancel()
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	queue, err := newPubSubSubmissionQueue("p", "t", "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue.endpoint = server.URL
+	queue.client = server.Client()
+	queue.tokens = &googleTokenCache{token: "token", expiry: time.Now().Add(time.Hour)}
+
+	item, err := queue.Receive(ctx)
+	if err != nil || item.Job.Attempts != 2 {
+		t.Fatalf("los intentos salen de los atributos: %+v / %v", item, err)
+	}
+	if err := item.Nack(ctx); err != nil {
+		t.Fatalf("Nack: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(calls, ","); got != "pull,publish,acknowledge" {
+		t.Fatalf("sin deliveryAttempt se republica y se confirma el original: %s", got)
+	}
+	message := published[0]["messages"].([]any)[0].(map[string]any)
+	attributes := message["attributes"].(map[string]any)
+	if attributes["attempts"] != "3" || attributes["notBefore"] == nil {
+		t.Fatalf("el reintento lleva la cuenta y la espera en los atributos: %v", attributes)
+	}
+
+	// Un reintento todavía en espera vuelve a la suscripción sin entregarse.
+	calls = nil
+	notBefore = time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	mu.Unlock()
+	_, err = queue.Receive(ctx)
+	mu.Lock()
+	if err == nil {
+		t.Fatal("un mensaje en espera no debe entregarse")
+	}
+	if len(calls) < 2 || calls[1] != "modifyAckDeadline" {
+		t.Fatalf("el mensaje en espera debe posponerse: %v", calls)
+	}
+}
+
+func TestPubSubPausaTrasPullVacio(t *testing.T) {
+	var pulls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pulls.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	queue, err := newPubSubSubmissionQueue("p", "t", "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue.endpoint = server.URL
+	queue.client = server.Client()
+	queue.tokens = &googleTokenCache{token: "token", expiry: time.Now().Add(time.Hour)}
+	queue.emptyPullDelay = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	if _, err := queue.Receive(ctx); err == nil {
+		t.Fatal("sin mensajes Receive debe terminar al cancelar el contexto")
+	}
+	if got := pulls.Load(); got < 2 || got > 4 {
+		t.Fatalf("pulls = %d; tras un pull vacío debe esperar antes del siguiente", got)
+	}
+}
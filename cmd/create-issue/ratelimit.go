@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Límites por defecto, en envíos por minuto. Por IP alcanza para reportar
+// varias cosas seguidas o recorrer un formulario por pasos; por origen
+// protege de un sitio comprometido que reparte el spam entre muchas IP.
+const (
+	defaultRateLimitRPM       = 10
+	defaultOriginRateLimitRPM = 120
+)
+
+// defaultRateLimitProxyHops es cuántos proxies de confianza agregan una
+// entrada a X-Forwarded-For. Cloud Run agrega una: la última es la IP real
+// del cliente y lo anterior lo pudo escribir cualquiera.
+const defaultRateLimitProxyHops = 1
+
+// maxRateLimitBuckets es a partir de cuántas claves barremos las cubetas
+// llenas, que se comportan igual que una cubeta nueva.
+const maxRateLimitBuckets = 10000
+
+// rateLimiter reparte cubetas de tokens por IP y por origen. Cada cubeta
+// admite hasta rpm envíos de golpe y se recarga a rpm por minuto. Vive en
+// memoria: con varias réplicas el límite efectivo se multiplica, lo que es
+// aceptable para frenar a un cliente abusivo.
+type rateLimiter struct {
+	ipRPM     int
+	originRPM int
+	proxyHops int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	updated  time.Time
+}
+
+// newRateLimiterFromEnv lee RATE_LIMIT_RPM (por IP), RATE_LIMIT_ORIGIN_RPM y
+// RATE_LIMIT_PROXY_HOPS. Un límite en 0 lo desactiva; si ambos lo están
+// devuelve nil.
+func newRateLimiterFromEnv(getenv func(string) string) (*rateLimiter, error) {
+	readInt := func(key string, fallback int) (int, error) {
+		raw := strings.TrimSpace(getenv(key))
+		if raw == "" {
+			return fallback, nil
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("%s inválido: %q", key, raw)
+		}
+		return value, nil
+	}
+	ipRPM, err := readInt("RATE_LIMIT_RPM", defaultRateLimitRPM)
+	if err != nil {
+		return nil, err
+	}
+	originRPM, err := readInt("RATE_LIMIT_ORIGIN_RPM", defaultOriginRateLimitRPM)
+	if err != nil {
+		return nil, err
+	}
+	hops, err := proxyHopsFromEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+	if ipRPM == 0 && originRPM == 0 {
+		return nil, nil
+	}
+	return newRateLimiter(ipRPM, originRPM, hops), nil
+}
+
+func newRateLimiter(ipRPM, originRPM, proxyHops int) *rateLimiter {
+	return &rateLimiter{ipRPM: ipRPM, originRPM: originRPM, proxyHops: proxyHops, buckets: map[string]*tokenBucket{}}
+}
+
+// Allow consume un token de la cubeta de la IP y otro de la del origen. Si
+// alguna está vacía no consume ninguno y devuelve cuánto esperar.
+func (l *rateLimiter) Allow(ip, origin string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.buckets) >= maxRateLimitBuckets {
+		l.sweepLocked(now)
+	}
+
+	var buckets []*tokenBucket
+	if l.ipRPM > 0 && ip != "" {
+		buckets = append(buckets, l.bucketLocked("ip:"+ip, l.ipRPM, now))
+	}
+	if l.originRPM > 0 && origin != "" {
+		buckets = append(buckets, l.bucketLocked("origin:"+origin, l.originRPM, now))
+	}
+	var wait time.Duration
+	for _, bucket := range buckets {
+		if bucket.tokens < 1 {
+			perToken := time.Minute / time.Duration(bucket.capacity)
+			if missing := time.Duration((1 - bucket.tokens) * float64(perToken)); missing > wait {
+				wait = missing
+			}
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, bucket := range buckets {
+		bucket.tokens--
+	}
+	return true, 0
+}
+
+func (l *rateLimiter) bucketLocked(key string, rpm int, now time.Time) *tokenBucket {
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(rpm), capacity: float64(rpm), updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.refill(now)
+	return bucket
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed.Minutes()*b.capacity)
+		b.updated = now
+	}
+}
+
+func (l *rateLimiter) sweepLocked(now time.Time) {
+	for key, bucket := range l.buckets {
+		bucket.refill(now)
+		if bucket.tokens >= bucket.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// proxyHopsFromEnv lee RATE_LIMIT_PROXY_HOPS. Lo usan el límite de envíos
+// y todo lo que necesita la IP del cliente aunque el límite esté apagado.
+func proxyHopsFromEnv(getenv func(string) string) (int, error) {
+	raw := strings.TrimSpace(getenv("RATE_LIMIT_PROXY_HOPS"))
+	if raw == "" {
+		return defaultRateLimitProxyHops, nil
+	}
+	hops, err := strconv.Atoi(raw)
+	if err != nil || hops < 0 {
+		return 0, fmt.Errorf("RATE_LIMIT_PROXY_HOPS inválido: %q", raw)
+	}
+	return hops, nil
+}
+
+func (l *rateLimiter) clientIP(r *http.Request) string {
+	return clientIPBehindProxies(r, l.proxyHops)
+}
+
+// requestClientIP resuelve la IP del cliente con ProxyHops, esté o no activo
+// el límite de envíos.
+func requestClientIP(r *http.Request) string {
+	return clientIPBehindProxies(r, loadServiceDeps().ProxyHops)
+}
+
+// clientIPBehindProxies toma la IP que agregó el último proxy de confianza.
+// Sin proxies (o si el encabezado trae menos entradas) usa la conexión
+// directa.
+func clientIPBehindProxies(r *http.Request, proxyHops int) string {
+	if proxyHops > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, part := range strings.Split(header, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					hops = append(hops, part)
+				}
+			}
+		}
+		if len(hops) >= proxyHops {
+			return hops[len(hops)-proxyHops]
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// checkRateLimit rechaza con 429 y Retry-After si la IP o el origen agotaron
+// su cupo, como checkCooldown.
+func checkRateLimit(w http.ResponseWriter, r *http.Request) *submissionError {
+	limiter := loadServiceDeps().RateLimiter
+	if limiter == nil {
+		return nil
+	}
+	allowed, wait := limiter.Allow(limiter.clientIP(r), strings.TrimSpace(r.Header.Get("Origin")), time.Now())
+	if allowed {
+		return nil
+	}
+	seconds := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	return &submissionError{
+		Status:  http.StatusTooManyRequests,
+		Code:    "rate_limited",
+		Message: fmt.Sprintf("Demasiados envíos seguidos; intenta de nuevo en %d segundos", seconds),
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterCubetaPorIPYPorOrigen(t *testing.T) {
+	limiter := newRateLimiter(2, 3, 1)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("198.51.100.1", "https://sitio.example", now); !ok {
+			t.Fatalf("el envío %d entra en la ráfaga", i+1)
+		}
+	}
+	ok, wait := limiter.Allow("198.51.100.1", "https://sitio.example", now)
+	if ok || wait != 30*time.Second {
+		t.Fatalf("la IP agotó su cupo: ok=%v espera=%s", ok, wait)
+	}
+
+	// Otra IP del mismo origen usa el último token del origen.
+	if ok, _ := limiter.Allow("198.51.100.2", "https://sitio.example", now); !ok {
+		t.Fatal("el origen todavía tenía un token")
+	}
+	if ok, _ := limiter.Allow("198.51.100.3", "https://sitio.example", now); ok {
+		t.Fatal("el origen agotó su cupo aunque la IP sea nueva")
+	}
+	if ok, _ := limiter.Allow("198.51.100.3", "", now); !ok {
+		t.Fatal("el rechazo por origen no debe consumir el token de la IP")
+	}
+
+	if ok, _ := limiter.Allow("198.51.100.1", "", now.Add(30*time.Second)); !ok {
+		t.Fatal("pasado medio minuto la IP recupera un token")
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	req.Header.Add("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+	if got := newRateLimiter(1, 0, 1).clientIP(req); got != "198.51.100.7" {
+		t.Fatalf("con un proxy se usa la última entrada, got %q", got)
+	}
+	if got := newRateLimiter(1, 0, 2).clientIP(req); got != "203.0.113.9" {
+		t.Fatalf("con dos proxies se usa la penúltima, got %q", got)
+	}
+	if got := newRateLimiter(1, 0, 0).clientIP(req); got != "10.0.0.1" {
+		t.Fatalf("sin proxies se ignora el encabezado, got %q", got)
+	}
+	if got := newRateLimiter(1, 0, 3).clientIP(req); got != "10.0.0.1" {
+		t.Fatalf("con menos entradas que proxies se usa la conexión, got %q", got)
+	}
+}
+
+func TestHandleRequestLimitaPorIP(t *testing.T) {
+	created := 0
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.RateLimiter = newRateLimiter(1, 0, 0)
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			created++
+			return &githubIssueResponse{Number: created, HTMLURL: "https://example.com/issues/1", NodeID: "node"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	if resp := postSubmission(t, "primero"); resp.IssueURL == "" {
+		t.Fatalf("el primer envío debe crearse: %+v", resp)
+	}
+	body := `{"templateId":"blank","title":"segundo","fields":{"descripcion":"y"},` + consentJSON() + `}`
+	rr := httptest.NewRecorder()
+	handleRequest(rr, httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body)))
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" || !strings.Contains(rr.Body.String(), `"rate_limited"`) || created != 1 {
+		t.Fatalf("se esperaba 429 rate_limited: %d %v %s", rr.Code, rr.Header(), rr.Body.String())
+	}
+}
+
+func TestNewRateLimiterFromEnv(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(key string) string { return values[key] }
+	}
+	limiter, err := newRateLimiterFromEnv(env(nil))
+	if err != nil || limiter.ipRPM != defaultRateLimitRPM || limiter.originRPM != defaultOriginRateLimitRPM || limiter.proxyHops != 1 {
+		t.Fatalf("valores por defecto: %+v / %v", limiter, err)
+	}
+	if limiter, err := newRateLimiterFromEnv(env(map[string]string{"RATE_LIMIT_RPM": "0", "RATE_LIMIT_ORIGIN_RPM": "0"})); err != nil || limiter != nil {
+		t.Fatalf("ambos en 0 lo desactivan: %+v / %v", limiter, err)
+	}
+	if _, err := newRateLimiterFromEnv(env(map[string]string{"RATE_LIMIT_RPM": "muchos"})); err == nil {
+		t.Fatal("un valor no numérico es un error de arranque")
+	}
+}
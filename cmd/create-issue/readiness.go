@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthPath y readinessPath son las rutas que consulta la plataforma antes
+// de mandar tráfico. Se atienden antes del logger: llegan cada pocos
+// segundos y llenarían el log de solicitudes sin información.
+const (
+	healthPath    = "/healthz"
+	readinessPath = "/readyz"
+)
+
+// readinessTTL es cuánto reutilizamos el resultado de las verificaciones. Un
+// despliegue sondea cada pocos segundos por instancia y no queremos sumar
+// una llamada a GitHub y a Cloud Logging por cada sondeo.
+const readinessTTL = 30 * time.Second
+
+// readinessCheckTimeout acota cada verificación para que /readyz responda
+// antes de que la plataforma dé el sondeo por perdido.
+const readinessCheckTimeout = 5 * time.Second
+
+// readinessCheck es una dependencia que el servicio necesita para atender
+// envíos.
+type readinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type readinessResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// readinessReport es la respuesta de /readyz.
+type readinessReport struct {
+	Status    string            `json:"status"`
+	CheckedAt string            `json:"checkedAt"`
+	Checks    []readinessResult `json:"checks"`
+}
+
+// readinessProbe corre las verificaciones y guarda el resultado readinessTTL.
+// Los sondeos concurrentes esperan la misma corrida en lugar de repetirla.
+type readinessProbe struct {
+	checks []readinessCheck
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	report    readinessReport
+}
+
+func newReadinessProbe(ttl time.Duration, checks ...readinessCheck) *readinessProbe {
+	return &readinessProbe{checks: checks, ttl: ttl, now: time.Now}
+}
+
+// defaultReadinessChecks verifica el token de GitHub y, si los logs van a
+// Cloud Logging, que se pueda escribir en él.
+func defaultReadinessChecks() []readinessCheck {
+	return []readinessCheck{
+		{Name: "github", Check: checkGitHubToken},
+		{Name: "cloud_logging", Check: checkLogBackend},
+	}
+}
+
+func (p *readinessProbe) Report(ctx context.Context) readinessReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if !p.checkedAt.IsZero() && now.Sub(p.checkedAt) < p.ttl {
+		return p.report
+	}
+
+	report := readinessReport{Status: "ok", CheckedAt: now.UTC().Format(time.RFC3339), Checks: []readinessResult{}}
+	for _, check := range p.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+		err := check.Check(checkCtx)
+		cancel()
+		result := readinessResult{Name: check.Name, OK: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+			report.Status = "unavailable"
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	p.report, p.checkedAt = report, now
+	return report
+}
+
+// checkGitHubToken consulta /rate_limit, que no consume cuota, con el mismo
+// transporte que los envíos: un token revocado o vencido responde 401.
+func checkGitHubToken(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/rate_limit", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", userAgent)
+	client := &http.Client{Transport: &githubAuthTransport{base: &outboundLoggingTransport{}}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub no responde: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub respondió %d al validar el token", resp.StatusCode)
+	}
+	return nil
+}
+
+// logBackendPinger lo implementan los backends de logs remotos. Los locales
+// (stdout, noop) siempre están disponibles.
+type logBackendPinger interface {
+	Ping(ctx context.Context) error
+}
+
+func checkLogBackend(ctx context.Context) error {
+	pinger, ok := loadServiceDeps().LogBackend.(logBackendPinger)
+	if !ok {
+		return nil
+	}
+	return pinger.Ping(ctx)
+}
+
+// Ping escribe una entrada con dryRun: Cloud Logging valida el token, los
+// permisos y el nombre del log sin guardar nada.
+func (c *cloudLoggingBackend) Ping(ctx context.Context) error {
+	token, err := c.ensureToken(ctx)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener token para logging: %w", err)
+	}
+	body, err := json.Marshal(map[string]any{
+		"logName":  c.logName,
+		"resource": map[string]any{"type": "global"},
+		"entries":  []map[string]any{{"textPayload": "readiness"}},
+		"dryRun":   true,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loggingEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error al llamar a Cloud Logging: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("Cloud Logging devolvió %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+	return nil
+}
+
+// handleHealth atiende /healthz y /readyz. /healthz solo confirma que el
+// proceso responde; /readyz responde 503 mientras alguna dependencia falle
+// para que el despliegue no le mande tráfico.
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	status, payload := http.StatusOK, any(map[string]string{"status": "ok"})
+	if r.URL.Path == readinessPath {
+		report := readinessReport{Status: "ok", Checks: []readinessResult{}}
+		if probe := loadServiceDeps().Readiness; probe != nil {
+			report = probe.Report(r.Context())
+		}
+		if report.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		payload = report
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadinessProbeGuardaElResultado(t *testing.T) {
+	calls := 0
+	var failing error
+	probe := newReadinessProbe(readinessTTL,
+		readinessCheck{Name: "github", Check: func(context.Context) error { calls++; return failing }},
+		readinessCheck{Name: "cloud_logging", Check: func(context.Context) error { return nil }},
+	)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	probe.now = func() time.Time { return now }
+
+	if report := probe.Report(context.Background()); report.Status != "ok" || len(report.Checks) != 2 {
+		t.Fatalf("reporte inesperado: %+v", report)
+	}
+	failing = errors.New("GitHub respondió 401 al validar el token")
+	if report := probe.Report(context.Background()); report.Status != "ok" || calls != 1 {
+		t.Fatalf("dentro del TTL se reutiliza el resultado, got %+v tras %d llamadas", report, calls)
+	}
+
+	now = now.Add(readinessTTL)
+	report := probe.Report(context.Background())
+	if report.Status != "unavailable" || calls != 2 || report.Checks[0].OK || report.Checks[0].Error == "" || !report.Checks[1].OK {
+		t.Fatalf("se esperaba github caído, got %+v", report)
+	}
+}
+
+func TestHandleRequestHealthYReadiness(t *testing.T) {
+	logs := &memoryLogBackend{}
+	ready := false
+	probe := newReadinessProbe(0, readinessCheck{Name: "github", Check: func(context.Context) error {
+		if !ready {
+			return errors.New("sin token")
+		}
+		return nil
+	}})
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = logs
+		deps.Readiness = probe
+	})
+
+	get := func(path string) (*httptest.ResponseRecorder, readinessReport) {
+		rr := httptest.NewRecorder()
+		handleRequest(rr, httptest.NewRequest(http.MethodGet, "http://service.local"+path, nil))
+		var report readinessReport
+		_ = json.Unmarshal(rr.Body.Bytes(), &report)
+		return rr, report
+	}
+
+	if rr, report := get(healthPath); rr.Code != http.StatusOK || report.Status != "ok" {
+		t.Fatalf("/healthz = %d %s", rr.Code, rr.Body.String())
+	}
+	if rr, report := get(readinessPath); rr.Code != http.StatusServiceUnavailable || report.Checks[0].Error != "sin token" {
+		t.Fatalf("/readyz sin token = %d %s", rr.Code, rr.Body.String())
+	}
+	ready = true
+	if rr, _ := get(readinessPath); rr.Code != http.StatusOK || rr.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("/readyz listo = %d %v", rr.Code, rr.Header())
+	}
+	if len(logs.entries) != 0 {
+		t.Fatalf("los sondeos de salud no deben registrarse: %+v", logs.entries)
+	}
+
+	rr := httptest.NewRecorder()
+	handleRequest(rr, httptest.NewRequest(http.MethodPost, "http://service.local"+healthPath, nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST /healthz = %d", rr.Code)
+	}
+}
+
+func TestCheckLogBackendSinPingerEstaListo(t *testing.T) {
+	useServiceDeps(t, func(deps *serviceDeps) { deps.LogBackend = &stdoutLogBackend{} })
+	if err := checkLogBackend(context.Background()); err != nil {
+		t.Fatalf("stdout no depende de la red: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// issueTarget es el repositorio y el Project donde termina un envío. Por
+// defecto son GITHUB_REPO y GITHUB_PROJECT_ID; TEMPLATE_TARGETS los cambia
+// por plantilla.
+type issueTarget struct {
+	Owner     string
+	Repo      string
+	ProjectID string
+}
+
+func defaultIssueTarget() issueTarget {
+	return issueTarget{Owner: githubRepoOwner, Repo: githubRepoName, ProjectID: projectID}
+}
+
+// targetForTemplate devuelve el destino de la plantilla o el por defecto.
+func targetForTemplate(templateID string) issueTarget {
+	if target, ok := loadServiceConfig().TemplateTargets[templateID]; ok {
+		return target
+	}
+	return defaultIssueTarget()
+}
+
+// issueReference arma la referencia a un issue del repositorio por defecto
+// (los módulos del roadmap) desde un issue creado en target: "#N" si es el
+// mismo repositorio y "dueño/nombre#N" si no, para que GitHub la enlace bien.
+func (t issueTarget) issueReference(number int) string {
+	if strings.EqualFold(t.Owner, githubRepoOwner) && strings.EqualFold(t.Repo, githubRepoName) {
+		return fmt.Sprintf("#%d", number)
+	}
+	return fmt.Sprintf("%s/%s#%d", githubRepoOwner, githubRepoName, number)
+}
+
+// parseTemplateTargets lee TEMPLATE_TARGETS con la forma
+// "feature=org/ideas,bug=org/app@PVT_x,blank=@PVT_y": repositorio, Project o
+// ambos por plantilla. Lo que no se indica se hereda de base. Como
+// PROJECT_FIELDS, no valida las plantillas contra el catálogo.
+func parseTemplateTargets(raw string, base issueTarget) (map[string]issueTarget, error) {
+	targets := map[string]issueTarget{}
+	if strings.TrimSpace(raw) == "" {
+		return targets, nil
+	}
+	for _, entry := range strings.Split(raw, ",") {
+		templateID, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		templateID, value = strings.TrimSpace(templateID), strings.TrimSpace(value)
+		if !ok || templateID == "" || value == "" {
+			return nil, fmt.Errorf("TEMPLATE_TARGETS: entrada inválida %q (se espera plantilla=dueño/repo@proyecto)", entry)
+		}
+		if _, repeated := targets[templateID]; repeated {
+			return nil, fmt.Errorf("TEMPLATE_TARGETS: la plantilla %q aparece dos veces", templateID)
+		}
+
+		target := base
+		repo, project, hasProject := strings.Cut(value, "@")
+		if repo = strings.TrimSpace(repo); repo != "" {
+			owner, name, ok := strings.Cut(repo, "/")
+			if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+				return nil, fmt.Errorf("TEMPLATE_TARGETS: repositorio inválido %q para %s (usa dueño/nombre)", repo, templateID)
+			}
+			target.Owner, target.Repo = owner, name
+		}
+		if hasProject {
+			if project = strings.TrimSpace(project); project == "" {
+				return nil, fmt.Errorf("TEMPLATE_TARGETS: falta el ID del Project después de @ para %s", templateID)
+			}
+			target.ProjectID = project
+		}
+		targets[templateID] = target
+	}
+	return targets, nil
+}
+
+type issueTargetKey struct{}
+
+// withIssueTarget fija el destino para las llamadas a GitHub del envío
+// (crear, agregar al Project, comentar). Viaja en el contexto para no
+// cambiar la firma de IssueCreator y ProjectAdder.
+func withIssueTarget(ctx context.Context, target issueTarget) context.Context {
+	return context.WithValue(ctx, issueTargetKey{}, target)
+}
+
+// issueTargetFromContext devuelve el destino del envío o el por defecto.
+func issueTargetFromContext(ctx context.Context) issueTarget {
+	if target, ok := ctx.Value(issueTargetKey{}).(issueTarget); ok {
+		return target
+	}
+	return defaultIssueTarget()
+}
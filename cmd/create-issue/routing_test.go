@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplateTargets(t *testing.T) {
+	base := issueTarget{Owner: "org", Repo: "roadmap", ProjectID: "PVT_base"}
+	got, err := parseTemplateTargets(" feature=org/ideas , bug = org/app@PVT_bugs,blank=@PVT_otro", base)
+	if err != nil {
+		t.Fatalf("parseTemplateTargets: %v", err)
+	}
+	want := map[string]issueTarget{
+		"feature": {Owner: "org", Repo: "ideas", ProjectID: "PVT_base"},
+		"bug":     {Owner: "org", Repo: "app", ProjectID: "PVT_bugs"},
+		"blank":   {Owner: "org", Repo: "roadmap", ProjectID: "PVT_otro"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("destinos = %+v, se esperaba %+v", got, want)
+	}
+
+	for _, raw := range []string{"feature", "feature=sin-barra", "feature=org/a/b", "feature=org/x@", "feature=org/a,feature=org/b"} {
+		if _, err := parseTemplateTargets(raw, base); err == nil {
+			t.Errorf("%q debe ser un error de arranque", raw)
+		}
+	}
+	if got, err := parseTemplateTargets("nueva=org/ideas", base); err != nil || got["nueva"].Repo != "ideas" {
+		t.Fatalf("una plantilla que aún no está en el catálogo se acepta: %+v / %v", got, err)
+	}
+}
+
+func useTemplateTargets(t *testing.T, targets map[string]issueTarget) {
+	t.Helper()
+	cfg := *loadServiceConfig()
+	cfg.TemplateTargets = targets
+	useServiceConfig(t, &cfg)
+}
+
+func TestSubmitPreparedUsaElDestinoDeLaPlantilla(t *testing.T) {
+	useTemplateTargets(t, map[string]issueTarget{"feature": {Owner: "org", Repo: "ideas", ProjectID: "PVT_ideas"}})
+
+	var created, added issueTarget
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(ctx context.Context, _ string, _ []string, _ string) (*githubIssueResponse, error) {
+			created = issueTargetFromContext(ctx)
+			return &githubIssueResponse{Number: 3, HTMLURL: "https://github.com/org/ideas/issues/3", NodeID: "node"}, nil
+		}
+		deps.ProjectAdder = func(ctx context.Context, _ string, _ string, _ []string) error {
+			added = issueTargetFromContext(ctx)
+			return nil
+		}
+	})
+
+	if _, subErr := submitPrepared(context.Background(), &preparedSubmission{TemplateID: "feature", Template: templates["feature"], Title: "x", Body: "y"}); subErr != nil {
+		t.Fatal(subErr)
+	}
+	if want := targetForTemplate("feature"); created != want || added != want || want.Repo != "ideas" {
+		t.Fatalf("el issue debe crearse y agregarse en el destino de la plantilla: %+v / %+v", created, added)
+	}
+
+	if _, subErr := submitPrepared(context.Background(), &preparedSubmission{TemplateID: "blank", Template: templates["blank"], Title: "x", Body: "y"}); subErr != nil {
+		t.Fatal(subErr)
+	}
+	if created != defaultIssueTarget() {
+		t.Fatalf("una plantilla sin destino usa el por defecto: %+v", created)
+	}
+}
+
+func TestModuloRelacionadoDesdeOtroRepositorio(t *testing.T) {
+	useTemplateTargets(t, map[string]issueTarget{"blank": {Owner: "org", Repo: "ideas", ProjectID: projectID}})
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.ModuleResolver = func(context.Context, string) (*moduleRef, error) { return &moduleRef{ID: "42", Number: 42}, nil }
+	})
+
+	req := issueRequest{TemplateID: "blank", Title: "x", Fields: map[string]string{"descripcion": "y"}, ModuleID: "42", Consent: validConsent()}
+	prepared, subErr := prepareSubmission(context.Background(), req)
+	if subErr != nil {
+		t.Fatal(subErr)
+	}
+	if want := "Relacionado con " + githubRepoOwner + "/" + githubRepoName + "#42"; !strings.Contains(prepared.Body, want) {
+		t.Fatalf("la referencia al módulo debe incluir el repositorio del roadmap:\n%s", prepared.Body)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionPrefix es la ruta de las sesiones de envío por pasos.
+const sessionPrefix = "/sessions"
+
+// sessionTTL es cuánto vive una sesión desde su última actualización. Es
+// corto a propósito: el borrador guarda datos personales que no queremos
+// retener si la persona abandona el formulario.
+const sessionTTL = 30 * time.Minute
+
+// maxSessionBytes limita lo acumulado en una sesión. Coincide con el máximo
+// que GitHub acepta en el cuerpo de un issue, así un borrador nunca crece más
+// allá de lo que se podría enviar.
+const maxSessionBytes = 65536
+
+// maxMemorySessions evita que alguien agote la memoria abriendo sesiones sin
+// terminarlas.
+const maxMemorySessions = 1000
+
+var (
+	errSessionNotFound = errors.New("sesión no encontrada")
+	errSessionExpired  = errors.New("sesión vencida")
+	errSessionCapacity = errors.New("demasiadas sesiones abiertas")
+)
+
+// submissionSession es el borrador de un formulario largo que se completa en
+// varios POST y termina en un solo issue.
+type submissionSession struct {
+	Token      string
+	TemplateID string
+	Title      string
+	Fields     map[string]string
+	ModuleID   string
+	UpdatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// sessionStore abstrae dónde viven los borradores, igual que submissionQueue
+// con la cola: memoria para una sola instancia y, cuando exista, SessionDAO
+// para compartirlos entre réplicas.
+type sessionStore interface {
+	Create(ctx context.Context, s *submissionSession) error
+	Get(ctx context.Context, token string) (*submissionSession, error)
+	Save(ctx context.Context, s *submissionSession) error
+	Delete(ctx context.Context, token string) error
+}
+
+// newSessionStore construye el almacén indicado por SESSION_STORE.
+func newSessionStore(kind string) (sessionStore, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "memory":
+		return newMemorySessionStore(maxMemorySessions), nil
+	case "contracts", "sessiondao":
+		// SessionDAO vive en el paquete contracts, que todavía no forma parte
+		// de este repositorio. Fallamos al arrancar en lugar de caer a memoria
+		// y perder borradores entre réplicas sin que nadie lo note.
+		return nil, errors.New("SESSION_STORE=contracts requiere el paquete contracts (SessionDAO), no disponible en esta compilación")
+	default:
+		return nil, fmt.Errorf("SESSION_STORE desconocido: %q", kind)
+	}
+}
+
+// memorySessionStore guarda los borradores en memoria. Se pierden al
+// reiniciar, algo aceptable para sesiones de media hora.
+type memorySessionStore struct {
+	limit int
+
+	mu       sync.Mutex
+	sessions map[string]submissionSession
+}
+
+func newMemorySessionStore(limit int) *memorySessionStore {
+	return &memorySessionStore{limit: limit, sessions: map[string]submissionSession{}}
+}
+
+func (m *memorySessionStore) Create(_ context.Context, s *submissionSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reapLocked(time.Now())
+	if len(m.sessions) >= m.limit {
+		return errSessionCapacity
+	}
+	m.sessions[s.Token] = cloneSession(*s)
+	return nil
+}
+
+func (m *memorySessionStore) Get(_ context.Context, token string) (*submissionSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[token]
+	if !ok {
+		return nil, errSessionNotFound
+	}
+	if !time.Now().Before(s.ExpiresAt) {
+		delete(m.sessions, token)
+		return nil, errSessionExpired
+	}
+	clone := cloneSession(s)
+	return &clone, nil
+}
+
+func (m *memorySessionStore) Save(_ context.Context, s *submissionSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[s.Token]; !ok {
+		return errSessionNotFound
+	}
+	m.sessions[s.Token] = cloneSession(*s)
+	return nil
+}
+
+func (m *memorySessionStore) Delete(_ context.Context, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, token)
+	return nil
+}
+
+// reapLocked descarta las sesiones vencidas. Se llama al crear para que el
+// límite cuente solo borradores vivos.
+func (m *memorySessionStore) reapLocked(now time.Time) {
+	for token, s := range m.sessions {
+		if !now.Before(s.ExpiresAt) {
+			delete(m.sessions, token)
+		}
+	}
+}
+
+func cloneSession(s submissionSession) submissionSession {
+	fields := make(map[string]string, len(s.Fields))
+	for k, v := range s.Fields {
+		fields[k] = v
+	}
+	s.Fields = fields
+	return s
+}
+
+// newSessionToken genera un token imposible de adivinar: es la única
+// credencial para retomar el borrador.
+func newSessionToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// mergeSessionInput aplica un paso del formulario al borrador. Solo se
+// aceptan campos de la plantilla elegida: un ID desconocido suele ser un
+// cliente desactualizado y es mejor avisar que perder el dato en silencio.
+func mergeSessionInput(s *submissionSession, req issueRequest) *submissionError {
+	tmpl, ok := lookupTemplate(s.TemplateID)
+	if !ok {
+		return &submissionError{Status: http.StatusBadRequest, Code: "invalid_template", Message: "Plantilla no válida"}
+	}
+	if req.TemplateID != "" && req.TemplateID != s.TemplateID {
+		return &submissionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "La plantilla no se puede cambiar a mitad de la sesión"}
+	}
+	known := map[string]struct{}{}
+	for _, field := range tmpl.Body {
+		if field.Type != fieldTypeMarkdown {
+			known[field.ID] = struct{}{}
+		}
+	}
+	for id, value := range req.Fields {
+		if _, ok := known[id]; !ok {
+			return &submissionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf("El campo %q no pertenece a la plantilla", id)}
+		}
+		s.Fields[id] = value
+	}
+	if title := strings.TrimSpace(req.Title); title != "" {
+		s.Title = title
+	}
+	if moduleID := strings.TrimSpace(req.ModuleID); moduleID != "" {
+		s.ModuleID = moduleID
+	}
+
+	size := len(s.Title)
+	for _, value := range s.Fields {
+		size += len(value)
+	}
+	if size > maxSessionBytes {
+		return &submissionError{Status: http.StatusRequestEntityTooLarge, Code: "payload_too_large", Message: fmt.Sprintf("La sesión supera el límite de %d bytes", maxSessionBytes)}
+	}
+	return nil
+}
+
+func sessionResponse(s *submissionSession) issueResponse {
+	return issueResponse{SessionToken: s.Token, ExpiresAt: s.ExpiresAt.UTC().Format(time.RFC3339)}
+}
+
+func writeSessionStoreError(ctx context.Context, w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errSessionNotFound):
+		writeError(ctx, w, http.StatusNotFound, "session_not_found", "La sesión no existe", err)
+	case errors.Is(err, errSessionExpired):
+		writeError(ctx, w, http.StatusGone, "session_expired", "La sesión venció; vuelve a empezar el formulario", err)
+	case errors.Is(err, errSessionCapacity):
+		writeError(ctx, w, http.StatusServiceUnavailable, "session_capacity", "Hay demasiadas sesiones abiertas; intenta más tarde", err)
+	default:
+		writeError(ctx, w, http.StatusServiceUnavailable, "session_store_error", "No se pudo acceder a la sesión", err)
+	}
+}
+
+// handleSessionPost atiende el flujo por pasos:
+//
+//	POST /sessions                 abre un borrador con templateId (y lo que ya tenga)
+//	POST /sessions/{token}         agrega campos al borrador
+//	POST /sessions/{token}/submit  lo envía como un issue normal
+//
+// Cada paso renueva el vencimiento, así que un token sirve para retomar el
+// formulario mientras no pasen sessionTTL sin actividad.
+func handleSessionPost(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	store := loadServiceDeps().Sessions
+	if store == nil {
+		writeError(ctx, w, http.StatusNotFound, "sessions_disabled", "Las sesiones por pasos no están habilitadas", nil)
+		return
+	}
+
+	var req issueRequest
+	if !decodeRequestBody(ctx, w, r, &req) {
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, sessionPrefix), "/")
+	token, action, _ := strings.Cut(rest, "/")
+	now := time.Now()
+
+	if token == "" {
+		if _, ok := lookupTemplate(req.TemplateID); !ok {
+			writeSubmissionError(ctx, w, &submissionError{Status: http.StatusBadRequest, Code: "invalid_template", Message: "Plantilla no válida"})
+			return
+		}
+		newToken, err := newSessionToken()
+		if err != nil {
+			writeError(ctx, w, http.StatusInternalServerError, "session_store_error", "No se pudo abrir la sesión", err)
+			return
+		}
+		session := &submissionSession{Token: newToken, TemplateID: req.TemplateID, Fields: map[string]string{}, UpdatedAt: now, ExpiresAt: now.Add(sessionTTL)}
+		if subErr := mergeSessionInput(session, req); subErr != nil {
+			writeSubmissionError(ctx, w, subErr)
+			return
+		}
+		if err := store.Create(ctx, session); err != nil {
+			writeSessionStoreError(ctx, w, err)
+			return
+		}
+		writeResponse(ctx, w, http.StatusCreated, sessionResponse(session))
+		return
+	}
+
+	session, err := store.Get(ctx, token)
+	if err != nil {
+		writeSessionStoreError(ctx, w, err)
+		return
+	}
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.SetTemplate(session.TemplateID)
+	}
+	if subErr := mergeSessionInput(session, req); subErr != nil {
+		writeSubmissionError(ctx, w, subErr)
+		return
+	}
+	session.UpdatedAt = now
+	session.ExpiresAt = now.Add(sessionTTL)
+
+	switch action {
+	case "":
+		if err := store.Save(ctx, session); err != nil {
+			writeSessionStoreError(ctx, w, err)
+			return
+		}
+		writeResponse(ctx, w, http.StatusOK, sessionResponse(session))
+	case "submit":
+		final := issueRequest{
+			TemplateID: session.TemplateID,
+			Title:      session.Title,
+			Fields:     session.Fields,
+			Client:     req.Client,
+			ModuleID:   session.ModuleID,
+			Consent:    req.Consent,
+			// El aviso de duplicado se muestra al final, junto con el envío.
+			DuplicateOf:   req.DuplicateOf,
+			Accessibility: req.Accessibility,
+			RequestID:     req.RequestID,
+			Honeypot:      req.Honeypot,
+			CaptchaToken:  req.CaptchaToken,
+			Attachments:   req.Attachments,
+			Force:         req.Force,
+		}
+		if !submitIssueRequest(ctx, w, r, final) {
+			// Conservamos el borrador con lo último recibido para que la
+			// persona corrija el error sin volver a llenar todo.
+			if err := store.Save(ctx, session); err != nil {
+				logErrorWithFallback(ctx, "session_store_error", "no se pudo guardar la sesión tras un envío fallido", err)
+			}
+			return
+		}
+		if err := store.Delete(ctx, session.Token); err != nil {
+			logErrorWithFallback(ctx, "session_store_error", "no se pudo borrar la sesión enviada", err)
+		}
+	default:
+		writeError(ctx, w, http.StatusNotFound, "not_found", "Ruta no encontrada", nil)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postSession(t *testing.T, path, body string) (int, issueResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	var resp issueResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("respuesta inválida de %s: %v", path, err)
+	}
+	return rr.Code, resp
+}
+
+func TestSessionFlujoPorPasos(t *testing.T) {
+	store := newMemorySessionStore(10)
+	var gotTitle, gotBody string
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.Sessions = store
+		deps.IssueCreator = func(_ context.Context, title string, _ []string, body string) (*githubIssueResponse, error) {
+			gotTitle, gotBody = title, body
+			return &githubIssueResponse{Number: 11, HTMLURL: "https://example.com/issues/11", NodeID: "node-11"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	status, resp := postSession(t, "/sessions", `{"templateId":"feature","title":"Exportar a CSV"}`)
+	if status != http.StatusCreated || resp.SessionToken == "" || resp.ExpiresAt == "" {
+		t.Fatalf("abrir sesión: %d %+v", status, resp)
+	}
+	token := resp.SessionToken
+
+	if status, resp = postSession(t, "/sessions/"+token, `{"fields":{"descripcion":"Como analista quiero exportar"}}`); status != http.StatusOK {
+		t.Fatalf("paso 1: %d %+v", status, resp)
+	}
+	if status, resp = postSession(t, "/sessions/"+token, `{"fields":{"inventado":"x"}}`); status != http.StatusBadRequest {
+		t.Fatalf("un campo ajeno a la plantilla debe rechazarse, llegó %d", status)
+	}
+
+	// Sin consentimiento el envío falla pero el borrador sigue disponible.
+	if status, _ = postSession(t, "/sessions/"+token+"/submit", `{"fields":{"criterio":"Dado un filtro, se descarga CSV"}}`); status != http.StatusBadRequest {
+		t.Fatalf("sin consentimiento se esperaba 400, llegó %d", status)
+	}
+	if status, resp = postSession(t, "/sessions/"+token+"/submit", `{`+consentJSON()+`}`); status != http.StatusOK {
+		t.Fatalf("envío final: %d %+v", status, resp)
+	}
+	if resp.IssueURL != "https://example.com/issues/11" {
+		t.Fatalf("issueUrl = %q", resp.IssueURL)
+	}
+	if gotTitle != "Exportar a CSV" || !strings.Contains(gotBody, "Como analista quiero exportar") || !strings.Contains(gotBody, "se descarga CSV") {
+		t.Fatalf("el issue no reúne los pasos: %q / %q", gotTitle, gotBody)
+	}
+
+	if status, _ = postSession(t, "/sessions/"+token, `{}`); status != http.StatusNotFound {
+		t.Fatalf("la sesión enviada debe borrarse, llegó %d", status)
+	}
+}
+
+func TestSessionVencida(t *testing.T) {
+	store := newMemorySessionStore(10)
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	useServiceDeps(t, func(deps *serviceDeps) { deps.Sessions = store })
+
+	past := time.Now().Add(-time.Hour)
+	if err := store.Create(context.Background(), &submissionSession{Token: "viejo", TemplateID: "blank", Fields: map[string]string{}, UpdatedAt: past, ExpiresAt: past.Add(sessionTTL)}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if status, resp := postSession(t, "/sessions/viejo", `{}`); status != http.StatusGone || resp.Error == nil || resp.Error.Code != "session_expired" {
+		t.Fatalf("se esperaba 410 session_expired, llegó %d %+v", status, resp.Error)
+	}
+}
+
+func TestMemorySessionStoreLimite(t *testing.T) {
+	store := newMemorySessionStore(1)
+	future := time.Now().Add(time.Minute)
+	if err := store.Create(context.Background(), &submissionSession{Token: "a", ExpiresAt: future}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := store.Create(context.Background(), &submissionSession{Token: "b", ExpiresAt: future}); err != errSessionCapacity {
+		t.Fatalf("se esperaba errSessionCapacity, llegó %v", err)
+	}
+}
+
+func TestNewSessionStoreContractsNoDisponible(t *testing.T) {
+	if _, err := newSessionStore("contracts"); err == nil {
+		t.Fatal("SESSION_STORE=contracts debe fallar mientras no exista el paquete")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// shortLinkPrefix es la ruta bajo la que el servicio resuelve enlaces cortos.
+const shortLinkPrefix = "/i/"
+
+// shortLinkSignatureBytes recorta la firma HMAC: 16 bytes bastan para que
+// adivinar un token válido sea impracticable y mantienen el enlace corto.
+const shortLinkSignatureBytes = 16
+
+var errInvalidShortLink = errors.New("enlace corto inválido")
+
+// shortLinker emite y resuelve enlaces /i/{token}. El token firma el node ID
+// del issue en lugar de su URL: el node ID no cambia si el issue se transfiere
+// a otro repositorio, así que el mismo enlace sigue llevando al lugar correcto.
+type shortLinker struct {
+	secret  []byte
+	baseURL string
+	resolve func(ctx context.Context, issueNodeID string) (string, error)
+}
+
+// newShortLinkerFromEnv devuelve nil si falta SHORT_LINK_SECRET o
+// SHORT_LINK_BASE_URL: sin ambos no podemos emitir enlaces verificables, y
+// preferimos no emitirlos a emitir enlaces que luego no resuelvan.
+func newShortLinkerFromEnv(getenv func(string) string) *shortLinker {
+	secret := strings.TrimSpace(getenv("SHORT_LINK_SECRET"))
+	baseURL := strings.TrimRight(strings.TrimSpace(getenv("SHORT_LINK_BASE_URL")), "/")
+	if secret == "" || baseURL == "" {
+		return nil
+	}
+	return &shortLinker{secret: []byte(secret), baseURL: baseURL, resolve: resolveIssueURL}
+}
+
+func (s *shortLinker) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:shortLinkSignatureBytes])
+}
+
+// Token firma el node ID del issue.
+func (s *shortLinker) Token(issueNodeID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(issueNodeID)) + "." + s.sign(issueNodeID)
+}
+
+// URL arma el enlace público completo para un issue.
+func (s *shortLinker) URL(issueNodeID string) string {
+	return s.baseURL + shortLinkPrefix + s.Token(issueNodeID)
+}
+
+// Verify devuelve el node ID firmado en el token. La comparación de la firma
+// es de tiempo constante para no filtrar pistas sobre el secreto.
+func (s *shortLinker) Verify(token string) (string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || encoded == "" || signature == "" {
+		return "", errInvalidShortLink
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) == 0 {
+		return "", errInvalidShortLink
+	}
+	nodeID := string(raw)
+	if !hmac.Equal([]byte(signature), []byte(s.sign(nodeID))) {
+		return "", errInvalidShortLink
+	}
+	return nodeID, nil
+}
+
+// resolveIssueURL consulta la URL actual del issue a partir de su node ID.
+func resolveIssueURL(ctx context.Context, issueNodeID string) (string, error) {
+	var q struct {
+		Node struct {
+			Issue struct {
+				URL githubv4.URI
+			} `graphql:"... on Issue"`
+		} `graphql:"node(id: $id)"`
+	}
+	if err := newGraphQLClient(ctx).Query(ctx, &q, map[string]interface{}{"id": githubv4.ID(issueNodeID)}); err != nil {
+		return "", fmt.Errorf("error al consultar el issue %s: %w", issueNodeID, err)
+	}
+	if q.Node.Issue.URL.URL == nil {
+		return "", fmt.Errorf("el nodo %s no es un issue", issueNodeID)
+	}
+	return q.Node.Issue.URL.String(), nil
+}
+
+// issueShortURL emite el enlace corto del issue recién creado y lo deja en el
+// log para poder cruzarlo después con los clics.
+func issueShortURL(ctx context.Context, links *shortLinker, issue *githubIssueResponse) string {
+	if links == nil || issue.NodeID == "" {
+		return ""
+	}
+	shortURL := links.URL(issue.NodeID)
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.log(ctx, "short_link", severityInfo, fmt.Sprintf("issue #%d: enlace corto %s", issue.Number, shortURL))
+	}
+	return shortURL
+}
+
+// handleShortLink redirige /i/{token} a la URL vigente del issue. Cada clic
+// queda en el log (stage short_link_click) para medir cuánta gente abre el
+// issue desde la pantalla de confirmación.
+func handleShortLink(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(ctx, w, http.StatusMethodNotAllowed, "method_not_allowed", "método no permitido", nil)
+		return
+	}
+	links := loadServiceDeps().ShortLinks
+	if links == nil {
+		writeError(ctx, w, http.StatusNotFound, "short_link_disabled", "Los enlaces cortos no están habilitados", nil)
+		return
+	}
+
+	nodeID, err := links.Verify(strings.TrimPrefix(r.URL.Path, shortLinkPrefix))
+	if err != nil {
+		writeError(ctx, w, http.StatusNotFound, "invalid_short_link", "Enlace no válido", err)
+		return
+	}
+	target, err := links.resolve(ctx, nodeID)
+	if err != nil {
+		writeError(ctx, w, http.StatusBadGateway, "short_link_unresolved", "No se pudo localizar el issue", err)
+		return
+	}
+
+	if logger := loggerFromContext(ctx); logger != nil {
+		logger.log(ctx, "short_link_click", severityInfo, fmt.Sprintf("enlace corto hacia %s", target))
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testShortLinker(resolve func(context.Context, string) (string, error)) *shortLinker {
+	return &shortLinker{secret: []byte("secreto"), baseURL: "https://svc.example", resolve: resolve}
+}
+
+func TestShortLinkerVerify(t *testing.T) {
+	links := testShortLinker(nil)
+	token := links.Token("I_kwDOAbc123")
+
+	nodeID, err := links.Verify(token)
+	if err != nil || nodeID != "I_kwDOAbc123" {
+		t.Fatalf("Verify(%q) = %q, %v", token, nodeID, err)
+	}
+
+	encoded, _, _ := strings.Cut(token, ".")
+	other := testShortLinker(nil)
+	other.secret = []byte("otro")
+	for _, bad := range []string{"", "sinfirma", encoded + ".", encoded + ".AAAAAAAAAAAAAAAAAAAAAA", other.Token("I_kwDOAbc123")} {
+		if _, err := links.Verify(bad); !errors.Is(err, errInvalidShortLink) {
+			t.Errorf("Verify(%q) debía rechazarse, llegó %v", bad, err)
+		}
+	}
+}
+
+func TestNewShortLinkerFromEnvRequiereSecretoYBase(t *testing.T) {
+	env := map[string]string{"SHORT_LINK_SECRET": "s"}
+	if newShortLinkerFromEnv(func(k string) string { return env[k] }) != nil {
+		t.Fatal("sin SHORT_LINK_BASE_URL los enlaces deben quedar desactivados")
+	}
+	env["SHORT_LINK_BASE_URL"] = "https://svc.example/"
+	links := newShortLinkerFromEnv(func(k string) string { return env[k] })
+	if links == nil || !strings.HasPrefix(links.URL("x"), "https://svc.example/i/") {
+		t.Fatalf("enlace mal formado: %+v", links)
+	}
+}
+
+func TestHandleShortLinkRedirige(t *testing.T) {
+	backend := &memoryLogBackend{}
+	links := testShortLinker(func(_ context.Context, nodeID string) (string, error) {
+		if nodeID != "node-9" {
+			t.Fatalf("nodeID inesperado %q", nodeID)
+		}
+		// El issue se transfirió: la URL ya no es la original.
+		return "https://github.com/otra-org/otro-repo/issues/3", nil
+	})
+	useServiceConfig(t, &serviceConfig{AllowedOrigin: "https://solo.example"})
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = backend
+		deps.ShortLinks = links
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/i/"+links.Token("node-9"), nil)
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "https://github.com/otra-org/otro-repo/issues/3" {
+		t.Fatalf("redirección inesperada: %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+	clicked := false
+	for _, entry := range backend.Entries() {
+		clicked = clicked || entry.Stage == "short_link_click"
+	}
+	if !clicked {
+		t.Fatal("el clic debe quedar en el log")
+	}
+
+	rr = httptest.NewRecorder()
+	handleRequest(rr, httptest.NewRequest(http.MethodGet, "/i/"+links.Token("node-9")+"x", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("un token alterado debe responder 404, llegó %d", rr.Code)
+	}
+}
+
+func TestSubmitPreparedDevuelveEnlaceCorto(t *testing.T) {
+	links := testShortLinker(nil)
+	useServiceConfig(t, &serviceConfig{AllowAnyOrigin: true})
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.ShortLinks = links
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			return &githubIssueResponse{Number: 9, HTMLURL: "https://example.com/issues/9", NodeID: "node-9"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+
+	body := `{"templateId":"blank","title":"Corto","fields":{"descripcion":"x"},` + consentJSON() + `}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handleRequest(rr, req)
+
+	var resp issueResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("respuesta inválida: %v", err)
+	}
+	if resp.ShortURL != links.URL("node-9") {
+		t.Fatalf("shortUrl = %q, se esperaba %q", resp.ShortURL, links.URL("node-9"))
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// CreateIssueInput replica el input de la mutación createIssue con
+// projectV2Ids, que la versión de githubv4 que usamos todavía no trae. La
+// librería toma el nombre del tipo GraphQL del nombre del tipo Go, por eso
+// se llama igual.
+type CreateIssueInput struct {
+	RepositoryID githubv4.ID     `json:"repositoryId"`
+	Title        githubv4.String `json:"title"`
+	Body         githubv4.String `json:"body,omitempty"`
+	LabelIDs     []githubv4.ID   `json:"labelIds,omitempty"`
+	ProjectV2IDs []githubv4.ID   `json:"projectV2Ids,omitempty"`
+}
+
+// repositoryIDs son los IDs de GraphQL que la mutación necesita en lugar de
+// nombres.
+type repositoryIDs struct {
+	ID     githubv4.ID
+	Labels map[string]githubv4.ID
+}
+
+// singleCallCreator crea el issue con una sola mutación GraphQL que además
+// lo agrega al Project del destino: el issue nunca queda fuera del tablero
+// aunque falle lo que sigue. Los IDs del repositorio y de sus etiquetas se
+// consultan una vez por repositorio y se guardan.
+type singleCallCreator struct {
+	client func(ctx context.Context) *githubv4.Client
+
+	mu    sync.Mutex
+	repos map[string]*repositoryIDs
+}
+
+func newSingleCallCreator() *singleCallCreator {
+	return &singleCallCreator{client: newGraphQLClient, repos: map[string]*repositoryIDs{}}
+}
+
+// Create tiene la firma de IssueCreator. Si alguna etiqueta no existe en el
+// repositorio cae al camino REST, que la crea; GraphQL solo acepta IDs.
+func (c *singleCallCreator) Create(ctx context.Context, title string, labels []string, body string) (*githubIssueResponse, error) {
+	target := issueTargetFromContext(ctx)
+	ids, err := c.repository(ctx, target, false)
+	if err != nil {
+		return nil, err
+	}
+	labelIDs, missing := ids.labelIDs(labels)
+	if len(missing) > 0 {
+		// Una etiqueta nueva en la plantilla: releemos una vez por si se
+		// creó después de guardar los IDs.
+		if ids, err = c.repository(ctx, target, true); err != nil {
+			return nil, err
+		}
+		if labelIDs, missing = ids.labelIDs(labels); len(missing) > 0 {
+			log.Printf("creación en una llamada: %s/%s no tiene las etiquetas %q, se usa REST", target.Owner, target.Repo, missing)
+			return createIssue(ctx, title, labels, body)
+		}
+	}
+
+	input := CreateIssueInput{
+		RepositoryID: ids.ID,
+		Title:        githubv4.String(title),
+		Body:         githubv4.String(body),
+		LabelIDs:     labelIDs,
+		ProjectV2IDs: []githubv4.ID{githubv4.ID(target.ProjectID)},
+	}
+	var mutation struct {
+		CreateIssue struct {
+			Issue struct {
+				ID     githubv4.ID
+				Number int
+				URL    string
+			}
+		} `graphql:"createIssue(input: $input)"`
+	}
+	if err := c.client(ctx).Mutate(ctx, &mutation, input, nil); err != nil {
+		return nil, fmt.Errorf("error al crear issue por GraphQL: %w", err)
+	}
+	issue := mutation.CreateIssue.Issue
+	nodeID, _ := issue.ID.(string)
+	if nodeID == "" {
+		return nil, errors.New("respuesta de createIssue sin id")
+	}
+	return &githubIssueResponse{Number: issue.Number, HTMLURL: issue.URL, NodeID: nodeID}, nil
+}
+
+func (ids *repositoryIDs) labelIDs(labels []string) (found []githubv4.ID, missing []string) {
+	for _, label := range labels {
+		if id, ok := ids.Labels[strings.ToLower(label)]; ok {
+			found = append(found, id)
+		} else {
+			missing = append(missing, label)
+		}
+	}
+	return found, missing
+}
+
+// repository devuelve los IDs guardados del destino o los consulta si no
+// están o si refresh lo pide.
+func (c *singleCallCreator) repository(ctx context.Context, target issueTarget, refresh bool) (*repositoryIDs, error) {
+	key := strings.ToLower(target.Owner + "/" + target.Repo)
+	c.mu.Lock()
+	ids, ok := c.repos[key]
+	c.mu.Unlock()
+	if ok && !refresh {
+		return ids, nil
+	}
+
+	var query struct {
+		Repository struct {
+			ID     githubv4.ID
+			Labels struct {
+				Nodes []struct {
+					ID   githubv4.ID
+					Name string
+				}
+			} `graphql:"labels(first: 100)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	variables := map[string]interface{}{"owner": githubv4.String(target.Owner), "name": githubv4.String(target.Repo)}
+	if err := c.client(ctx).Query(ctx, &query, variables); err != nil {
+		return nil, fmt.Errorf("no se pudo leer el repositorio %s/%s: %w", target.Owner, target.Repo, err)
+	}
+	ids = &repositoryIDs{ID: query.Repository.ID, Labels: map[string]githubv4.ID{}}
+	for _, label := range query.Repository.Labels.Nodes {
+		ids.Labels[strings.ToLower(label.Name)] = label.ID
+	}
+
+	c.mu.Lock()
+	c.repos[key] = ids
+	c.mu.Unlock()
+	return ids, nil
+}
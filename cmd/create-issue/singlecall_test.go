@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func TestSingleCallCreatorAgregaAlProjectEnLaMutacion(t *testing.T) {
+	var queries int
+	var input map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if strings.HasPrefix(req.Query, "mutation") {
+			if !strings.Contains(req.Query, "$input:CreateIssueInput!") {
+				t.Errorf("la mutación debe declarar CreateIssueInput: %s", req.Query)
+			}
+			input = req.Variables["input"].(map[string]any)
+			_, _ = w.Write([]byte(`{"data":{"createIssue":{"issue":{"id":"I_1","number":5,"url":"https://github.com/org/app/issues/5"}}}}`))
+			return
+		}
+		queries++
+		_, _ = w.Write([]byte(`{"data":{"repository":{"id":"R_1","labels":{"nodes":[{"id":"L_bug","name":"Tipo: Bug"}]}}}}`))
+	}))
+	defer server.Close()
+
+	creator := newSingleCallCreator()
+	creator.client = func(context.Context) *githubv4.Client {
+		return githubv4.NewEnterpriseClient(server.URL, server.Client())
+	}
+	ctx := withIssueTarget(context.Background(), issueTarget{Owner: "org", Repo: "app", ProjectID: "PVT_1"})
+
+	for i := 0; i < 2; i++ {
+		issue, err := creator.Create(ctx, "t", []string{"tipo: bug"}, "cuerpo")
+		if err != nil || issue.Number != 5 || issue.NodeID != "I_1" || issue.HTMLURL != "https://github.com/org/app/issues/5" {
+			t.Fatalf("Create: %+v / %v", issue, err)
+		}
+	}
+	if queries != 1 {
+		t.Fatalf("los IDs del repositorio se consultan una vez: %d", queries)
+	}
+	if input["repositoryId"] != "R_1" || input["projectV2Ids"].([]any)[0] != "PVT_1" || input["labelIds"].([]any)[0] != "L_bug" {
+		t.Fatalf("input inesperado: %v", input)
+	}
+}
+
+func TestSubmitPreparedRespetaLaBanderaDeUnaLlamada(t *testing.T) {
+	var used string
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			used = "rest"
+			return &githubIssueResponse{Number: 1, HTMLURL: "https://example.com/issues/1", NodeID: "node"}, nil
+		}
+		deps.SingleCallCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			used = "graphql"
+			return &githubIssueResponse{Number: 1, HTMLURL: "https://example.com/issues/1", NodeID: "node"}, nil
+		}
+		deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+	})
+	prepared := &preparedSubmission{TemplateID: "blank", Template: templates["blank"], Title: "x", Body: "y"}
+
+	if _, subErr := submitPrepared(context.Background(), prepared); subErr != nil || used != "graphql" {
+		t.Fatalf("sin regla la bandera queda activa: %q / %+v", used, subErr)
+	}
+	flags, err := parseFeatureFlags(`{"graphql_single_call": {"percent": 0}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := withFeatureFlags(context.Background(), flags, "req", "")
+	if _, subErr := submitPrepared(ctx, prepared); subErr != nil || used != "rest" {
+		t.Fatalf("con la bandera apagada se usa REST: %q / %+v", used, subErr)
+	}
+}
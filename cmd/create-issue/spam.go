@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// honeypotField es el campo oculto del formulario. Una persona no lo ve y lo
+// deja vacío; los bots que llenan todo lo completan.
+const honeypotField = "website"
+
+// defaultSpamWebhookTimeout acota la espera del servicio de moderación para
+// que no frene los envíos legítimos.
+const defaultSpamWebhookTimeout = 3 * time.Second
+
+// spamFinding es lo que detecta un filtro. Reject descarta el envío sin
+// revisión; el resto suma un punto al puntaje que decide la cuarentena.
+type spamFinding struct {
+	Reject bool
+	Reason string
+}
+
+// spamFilter es un paso del filtro de abuso. Un filtro nuevo solo implementa
+// la interfaz y se agrega en newSpamScorerFromEnv.
+type spamFilter interface {
+	Name() string
+	Check(ctx context.Context, req issueRequest, p *preparedSubmission) ([]spamFinding, error)
+}
+
+// screenVerdict es la opinión del filtro sobre un envío ya validado: Reject
+// lo descarta y Quarantine lo retiene para revisión humana. Score y Reasons
+// quedan en la cuarentena para que quien revisa sepa por qué se retuvo.
+type screenVerdict struct {
+	Reject     bool
+	Quarantine bool
+	Score      int
+	Reasons    []string
+}
+
+// submissionScreener puntúa un envío válido.
+type submissionScreener func(ctx context.Context, req issueRequest, p *preparedSubmission) screenVerdict
+
+// spamScorer corre los filtros y suma sus hallazgos. Es el único lugar que
+// decide entre crear, retener o descartar un envío.
+type spamScorer struct {
+	filters []spamFilter
+	// rejectScore descarta en lugar de retener a partir de ese puntaje; 0
+	// deja que solo los hallazgos marcados como Reject descarten.
+	rejectScore int
+}
+
+// newSpamScorerFromEnv arma el filtro con el honeypot, que siempre está
+// activo y descarta, más QUARANTINE_KEYWORDS (lista separada por comas),
+// QUARANTINE_MAX_LINKS y SPAM_WEBHOOK_URL si están definidas. Términos y
+// enlaces suman puntaje y retienen; SPAM_REJECT_SCORE fija el puntaje desde
+// el cual se descarta sin revisión.
+func newSpamScorerFromEnv(getenv func(string) string) (*spamScorer, error) {
+	scorer := &spamScorer{filters: []spamFilter{honeypotFilter{}}}
+
+	if words := parseBlockedWords(getenv("QUARANTINE_KEYWORDS")); len(words) > 0 {
+		scorer.filters = append(scorer.filters, keywordFilter{words: words})
+	}
+	if raw := strings.TrimSpace(getenv("QUARANTINE_MAX_LINKS")); raw != "" {
+		maxLinks, err := strconv.Atoi(raw)
+		if err != nil || maxLinks < 0 {
+			return nil, fmt.Errorf("QUARANTINE_MAX_LINKS inválido: %q", raw)
+		}
+		scorer.filters = append(scorer.filters, linkCountFilter{max: maxLinks})
+	}
+	if raw := strings.TrimSpace(getenv("SPAM_REJECT_SCORE")); raw != "" {
+		score, err := strconv.Atoi(raw)
+		if err != nil || score <= 0 {
+			return nil, fmt.Errorf("SPAM_REJECT_SCORE inválido: %q", raw)
+		}
+		scorer.rejectScore = score
+	}
+	if endpoint := strings.TrimSpace(getenv("SPAM_WEBHOOK_URL")); endpoint != "" {
+		timeout := defaultSpamWebhookTimeout
+		if raw := strings.TrimSpace(getenv("SPAM_WEBHOOK_TIMEOUT")); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("SPAM_WEBHOOK_TIMEOUT inválido: %q", raw)
+			}
+			timeout = parsed
+		}
+		scorer.filters = append(scorer.filters, &moderationWebhook{
+			endpoint: endpoint,
+			secret:   strings.TrimSpace(getenv("SPAM_WEBHOOK_SECRET")),
+			client:   &http.Client{Timeout: timeout, Transport: &outboundLoggingTransport{}},
+		})
+	}
+	return scorer, nil
+}
+
+func parseBlockedWords(raw string) []string {
+	var words []string
+	for _, word := range strings.Split(raw, ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// Screen corre todos los filtros y arma el veredicto. Un hallazgo que
+// descarta corta el recorrido: no hace falta consultar el webhook para un
+// bot que completó el honeypot. Si un filtro falla (el webhook no responde)
+// se registra y se sigue: preferimos dejar pasar un envío a perder los
+// legítimos por una dependencia caída.
+func (s *spamScorer) Screen(ctx context.Context, req issueRequest, p *preparedSubmission) screenVerdict {
+	var verdict screenVerdict
+	for _, f := range s.filters {
+		findings, err := f.Check(ctx, req, p)
+		if err != nil {
+			logErrorWithFallback(ctx, "spam_filter_error", fmt.Sprintf("el filtro %s falló; el envío sigue", f.Name()), err)
+			continue
+		}
+		for _, finding := range findings {
+			verdict.Reasons = append(verdict.Reasons, f.Name()+": "+finding.Reason)
+			if finding.Reject {
+				verdict.Reject = true
+				return verdict
+			}
+			verdict.Score++
+		}
+	}
+	if s.rejectScore > 0 && verdict.Score >= s.rejectScore {
+		verdict.Reject = true
+		return verdict
+	}
+	verdict.Quarantine = verdict.Score > 0
+	return verdict
+}
+
+// rejectSpam puntúa el envío una sola vez. Devuelve un error spam_rejected
+// si se descarta; el motivo queda en el log pero no en la respuesta, para
+// no enseñarle al bot qué cambiar. Si no, el veredicto sigue hasta
+// screenSubmission, que decide la cuarentena.
+func rejectSpam(ctx context.Context, req issueRequest, p *preparedSubmission) (screenVerdict, *submissionError) {
+	screener := loadServiceDeps().Screener
+	if screener == nil {
+		return screenVerdict{}, nil
+	}
+	verdict := screener(ctx, req, p)
+	if !verdict.Reject {
+		return verdict, nil
+	}
+	return verdict, &submissionError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "spam_rejected",
+		Message: "El envío fue rechazado por el filtro de abuso",
+		Cause:   errors.New(strings.Join(verdict.Reasons, "; ")),
+	}
+}
+
+type honeypotFilter struct{}
+
+func (honeypotFilter) Name() string { return "honeypot" }
+
+func (honeypotFilter) Check(_ context.Context, req issueRequest, _ *preparedSubmission) ([]spamFinding, error) {
+	if strings.TrimSpace(req.Honeypot) != "" {
+		return []spamFinding{{Reject: true, Reason: "campo oculto completado"}}, nil
+	}
+	return nil, nil
+}
+
+// keywordFilter suma un punto por cada término de la lista que aparece en el
+// título o el cuerpo.
+type keywordFilter struct {
+	words []string
+}
+
+func (keywordFilter) Name() string { return "keywords" }
+
+func (f keywordFilter) Check(_ context.Context, _ issueRequest, p *preparedSubmission) ([]spamFinding, error) {
+	var findings []spamFinding
+	text := strings.ToLower(p.Title + "\n" + p.Body)
+	for _, word := range f.words {
+		if strings.Contains(text, word) {
+			findings = append(findings, spamFinding{Reason: "término " + word})
+		}
+	}
+	return findings, nil
+}
+
+type linkCountFilter struct {
+	max int
+}
+
+func (linkCountFilter) Name() string { return "links" }
+
+func (f linkCountFilter) Check(_ context.Context, _ issueRequest, p *preparedSubmission) ([]spamFinding, error) {
+	text := strings.ToLower(p.Title + "\n" + p.Body)
+	if links := strings.Count(text, "http://") + strings.Count(text, "https://"); links > f.max {
+		return []spamFinding{{Reason: fmt.Sprintf("%d enlaces (máximo %d)", links, f.max)}}, nil
+	}
+	return nil, nil
+}
+
+// moderationWebhook consulta un servicio externo de moderación. Recibe
+// plantilla, título, cuerpo y origen, y responde {"reject": true, "reason":
+// "..."} para descartar el envío o {"quarantine": true, "reason": "..."}
+// para retenerlo.
+type moderationWebhook struct {
+	endpoint string
+	secret   string
+	client   *http.Client
+}
+
+func (*moderationWebhook) Name() string { return "webhook" }
+
+func (m *moderationWebhook) Check(ctx context.Context, _ issueRequest, p *preparedSubmission) ([]spamFinding, error) {
+	payload := map[string]string{"templateId": p.TemplateID, "title": p.Title, "body": p.Body}
+	if logger := loggerFromContext(ctx); logger != nil {
+		payload["origin"] = logger.origin
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", userAgent)
+	if m.secret != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+m.secret)
+	}
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("el webhook de moderación no responde: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("el webhook de moderación devolvió %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	var verdict struct {
+		Reject     bool   `json:"reject"`
+		Quarantine bool   `json:"quarantine"`
+		Reason     string `json:"reason"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&verdict); err != nil {
+		return nil, fmt.Errorf("respuesta ilegible del webhook de moderación: %w", err)
+	}
+	if !verdict.Reject && !verdict.Quarantine {
+		return nil, nil
+	}
+	reason := strings.TrimSpace(verdict.Reason)
+	if reason == "" {
+		reason = "sin motivo"
+	}
+	return []spamFinding{{Reject: verdict.Reject, Reason: reason}}, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSpamScorerVeredictos(t *testing.T) {
+	moderation := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secreto" {
+			t.Errorf("el webhook debe recibir el secreto: %v", r.Header)
+		}
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		switch {
+		case strings.Contains(payload["body"], "estafa"):
+			_, _ = w.Write([]byte(`{"reject":true,"reason":"fraude"}`))
+		case strings.Contains(payload["body"], "dudoso"):
+			_, _ = w.Write([]byte(`{"quarantine":true,"reason":"revisar"}`))
+		default:
+			_, _ = w.Write([]byte(`{"reject":false}`))
+		}
+	}))
+	defer moderation.Close()
+
+	scorer, err := newSpamScorerFromEnv(func(key string) string {
+		return map[string]string{
+			"QUARANTINE_KEYWORDS":  "Casino, viagra",
+			"QUARANTINE_MAX_LINKS": "1",
+			"SPAM_REJECT_SCORE":    "3",
+			"SPAM_WEBHOOK_URL":     moderation.URL,
+			"SPAM_WEBHOOK_SECRET":  "secreto",
+		}[key]
+	})
+	if err != nil {
+		t.Fatalf("newSpamScorerFromEnv: %v", err)
+	}
+
+	cases := []struct {
+		name           string
+		req            issueRequest
+		body           string
+		wantReject     bool
+		wantQuarantine bool
+		wantScore      int
+	}{
+		{"legítimo", issueRequest{}, "Falla el login en https://app.example", false, false, 0},
+		{"honeypot", issueRequest{Honeypot: "https://spam.example"}, "texto", true, false, 0},
+		{"término", issueRequest{}, "Gana en el CASINO", false, true, 1},
+		{"enlaces", issueRequest{}, "https://a.example https://b.example", false, true, 1},
+		{"puntaje", issueRequest{}, "casino y viagra en https://a.example https://b.example", true, false, 3},
+		{"webhook rechaza", issueRequest{}, "una estafa", true, false, 0},
+		{"webhook retiene", issueRequest{}, "algo dudoso", false, true, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &preparedSubmission{TemplateID: "blank", Title: "t", Body: tc.body}
+			verdict := scorer.Screen(context.Background(), tc.req, p)
+			if verdict.Reject != tc.wantReject || verdict.Quarantine != tc.wantQuarantine || verdict.Score != tc.wantScore {
+				t.Fatalf("veredicto inesperado: %+v", verdict)
+			}
+			if (verdict.Reject || verdict.Quarantine) && len(verdict.Reasons) == 0 {
+				t.Fatal("el veredicto debe explicar el motivo")
+			}
+		})
+	}
+}
+
+func TestSpamScorerWebhookCaidoDejaPasar(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	scorer, err := newSpamScorerFromEnv(func(key string) string {
+		if key == "SPAM_WEBHOOK_URL" {
+			return down.URL
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := &memoryLogBackend{}
+	useServiceDeps(t, func(deps *serviceDeps) { deps.LogBackend = logs })
+	if verdict := scorer.Screen(context.Background(), issueRequest{}, &preparedSubmission{Body: "x"}); verdict.Reject || verdict.Quarantine {
+		t.Fatalf("un webhook caído no debe rechazar ni retener: %+v", verdict)
+	}
+}
+
+func TestSpamRechazadoNoLlegaAGitHub(t *testing.T) {
+	logs := &memoryLogBackend{}
+	useServiceDeps(t, func(deps *serviceDeps) {
+		deps.LogBackend = logs
+		deps.Screener = (&spamScorer{filters: []spamFilter{honeypotFilter{}}}).Screen
+		deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+			t.Fatal("un envío rechazado no debe llegar a GitHub")
+			return nil, nil
+		}
+	})
+
+	body := `{"templateId":"blank","title":"oferta","fields":{"descripcion":"y"},"website":"https://spam.example",` + consentJSON() + `}`
+	rr := httptest.NewRecorder()
+	handleRequest(rr, httptest.NewRequest(http.MethodPost, "http://service.local/", strings.NewReader(body)))
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), `"spam_rejected"`) || strings.Contains(rr.Body.String(), "honeypot") {
+		t.Fatalf("se esperaba 422 spam_rejected sin el motivo: %d %s", rr.Code, rr.Body.String())
+	}
+	logged := false
+	for _, entry := range logs.entries {
+		if entry.ErrorCode == "spam_rejected" && strings.Contains(entry.Message, "honeypot") {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Fatalf("el rechazo debe quedar en el log con su motivo: %+v", logs.entries)
+	}
+}
+
+func TestNewSpamScorerFromEnvValida(t *testing.T) {
+	for key, value := range map[string]string{"QUARANTINE_MAX_LINKS": "-1", "SPAM_REJECT_SCORE": "0", "SPAM_WEBHOOK_TIMEOUT": "rápido"} {
+		_, err := newSpamScorerFromEnv(func(k string) string {
+			if k == "SPAM_WEBHOOK_URL" {
+				return "https://moderacion.example"
+			}
+			if k == key {
+				return value
+			}
+			return ""
+		})
+		if err == nil {
+			t.Fatalf("%s=%q debe ser un error de arranque", key, value)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"eos-roadmap-tools/internal/errcodes"
+	"eos-roadmap-tools/internal/issueform"
+)
+
+// defaultTemplatesRefresh es cada cuánto releemos los formularios del
+// repositorio. Un formulario nuevo aparece en el sitio como mucho en ese
+// tiempo, sin redesplegar.
+const defaultTemplatesRefresh = 15 * time.Minute
+
+// templateFormMaxBytes acota cada formulario descargado; los actuales
+// ocupan unos pocos KB.
+const templateFormMaxBytes = 256 << 10
+
+// excludedTemplateIDs son formularios del repositorio que no se ofrecen en el
+// sitio público. Las épicas las crea sync-modules, no los visitantes.
+var excludedTemplateIDs = parseTemplateIDList(envOrDefault("TEMPLATES_EXCLUDE", "epica"))
+
+// currentTemplates es el catálogo que atienden los envíos. Arranca con el
+// compilado en templates y lo reemplaza cada recarga de los formularios.
+var currentTemplates atomic.Pointer[map[string]issueTemplate]
+
+func init() {
+	currentTemplates.Store(&templates)
+}
+
+// lookupTemplate busca una plantilla en el catálogo vigente. La validación
+// de EXTERNAL_TRACKERS sigue usando templates: solo conoce lo compilado.
+func lookupTemplate(id string) (issueTemplate, bool) {
+	tmpl, ok := (*currentTemplates.Load())[id]
+	return tmpl, ok
+}
+
+// warnUnknownTemplates avisa de las plantillas configuradas en setting que el
+// catálogo vigente no conoce, sin rechazarlas: su configuración se aplica en
+// cuanto la plantilla aparece en el repositorio.
+func warnUnknownTemplates[V any](setting string, configured map[string]V) {
+	ids := make([]string, 0, len(configured))
+	for id := range configured {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, ok := lookupTemplate(id); !ok {
+			log.Printf("%s: la plantilla %q no está en el catálogo vigente; se aplicará cuando aparezca", setting, id)
+		}
+	}
+}
+
+// storeTemplates publica un catálogo para los envíos y para /templates.
+func storeTemplates(catalog map[string]issueTemplate, now time.Time) error {
+	if err := publishTemplateCatalog(catalog, now); err != nil {
+		return err
+	}
+	currentTemplates.Store(&catalog)
+	return nil
+}
+
+func parseTemplateIDList(raw string) map[string]bool {
+	ids := map[string]bool{}
+	for _, id := range strings.Split(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// templateFormSource devuelve el contenido de cada formulario por nombre de
+// archivo.
+type templateFormSource func(ctx context.Context) (map[string][]byte, error)
+
+// fetchRepoForms lee .github/ISSUE_TEMPLATE del repositorio configurado con
+// la API de contenidos y el mismo token que los envíos.
+func fetchRepoForms(ctx context.Context) (map[string][]byte, error) {
+	client := &http.Client{Timeout: 15 * time.Second, Transport: &githubAuthTransport{base: &outboundLoggingTransport{}}}
+	base := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", githubRepoOwner, githubRepoName, defaultFormsDir)
+
+	var entries []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	if err := getRepoContents(ctx, client, base, "application/vnd.github+json", func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(&entries)
+	}); err != nil {
+		return nil, fmt.Errorf("listar %s: %w", defaultFormsDir, err)
+	}
+
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name)
+		if entry.Type != "file" || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		if err := getRepoContents(ctx, client, base+"/"+entry.Name, "application/vnd.github.raw+json", func(body io.Reader) error {
+			data, err := io.ReadAll(io.LimitReader(body, templateFormMaxBytes))
+			if err != nil {
+				return err
+			}
+			files[entry.Name] = data
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("leer %s: %w", entry.Name, err)
+		}
+	}
+	return files, nil
+}
+
+func getRepoContents(ctx context.Context, client *http.Client, url, accept string, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &errcodes.GitHubError{Status: resp.StatusCode}
+	}
+	return read(resp.Body)
+}
+
+// buildRepoCatalog combina el catálogo compilado con los formularios del
+// repositorio; si ambos tienen la misma plantilla gana el formulario, que es
+// lo que ve GitHub. Un formulario que no se puede convertir se reporta y se
+// omite sin tumbar al resto.
+func buildRepoCatalog(base map[string]issueTemplate, files map[string][]byte) (map[string]issueTemplate, []error) {
+	idsByFile := make(map[string]string, len(formFileNames))
+	for id, name := range formFileNames {
+		idsByFile[name] = id
+	}
+
+	catalog := make(map[string]issueTemplate, len(base)+len(files))
+	for id, tmpl := range base {
+		catalog[id] = tmpl
+	}
+	var problems []error
+	for _, name := range sortedFormNames(files) {
+		id, known := idsByFile[name]
+		if !known {
+			id = strings.TrimSuffix(name, path.Ext(name))
+		}
+		// config.yml configura el selector de GitHub, no es un formulario.
+		if id == "config" || excludedTemplateIDs[id] {
+			continue
+		}
+		form, err := issueform.Parse(files[name])
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		tmpl, err := formToTemplate(id, form)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		catalog[id] = tmpl
+	}
+	return catalog, problems
+}
+
+func sortedFormNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateLoader recarga el catálogo desde el repositorio. Los formularios
+// omitidos se registran solo cuando cambian, para no repetir el mismo aviso
+// en cada recarga.
+type templateLoader struct {
+	source templateFormSource
+	now    func() time.Time
+
+	mu           sync.Mutex
+	lastProblems string
+}
+
+func newTemplateLoader(source templateFormSource) *templateLoader {
+	return &templateLoader{source: source, now: time.Now}
+}
+
+// Refresh publica el catálogo combinado. Si GitHub no responde se conserva
+// el último catálogo publicado.
+func (l *templateLoader) Refresh(ctx context.Context) error {
+	files, err := l.source(ctx)
+	if err != nil {
+		return fmt.Errorf("no se pudieron leer los formularios del repositorio: %w", err)
+	}
+	catalog, problems := buildRepoCatalog(templates, files)
+	if err := storeTemplates(catalog, l.now()); err != nil {
+		return err
+	}
+
+	messages := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		messages = append(messages, problem.Error())
+	}
+	summary := strings.Join(messages, "; ")
+	l.mu.Lock()
+	changed := summary != l.lastProblems
+	l.lastProblems = summary
+	l.mu.Unlock()
+	if changed && summary != "" {
+		log.Printf("template_refresh: formularios omitidos: %s", summary)
+	}
+	return nil
+}
+
+func runTemplateRefreshLoop(ctx context.Context, interval time.Duration, loader *templateLoader) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := loader.Refresh(ctx); err != nil {
+				logErrorWithFallback(ctx, "template_refresh_error", "error al recargar las plantillas", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// repoForms lee los formularios reales del repositorio, como los devolvería
+// la API de contenidos.
+func repoForms(t *testing.T) map[string][]byte {
+	t.Helper()
+	dir := filepath.Join("..", "..", defaultFormsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("leer %s: %v", dir, err)
+	}
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = data
+	}
+	return files
+}
+
+const pruebaForm = `name: Pregunta
+description: Dudas sobre el roadmap
+title: "[PREGUNTA] "
+labels: ["Tipo: Pregunta"]
+body:
+  - type: textarea
+    id: question
+    attributes:
+      label: Pregunta
+    validations:
+      required: true
+`
+
+func TestBuildRepoCatalogAgregaFormulariosNuevos(t *testing.T) {
+	files := repoForms(t)
+	files["pregunta.yml"] = []byte(pruebaForm)
+	files["roto.yml"] = []byte("name: [sin cerrar")
+
+	catalog, problems := buildRepoCatalog(templates, files)
+	for id := range templates {
+		if _, ok := catalog[id]; !ok {
+			t.Errorf("falta la plantilla compilada %q", id)
+		}
+	}
+	if tmpl, ok := catalog["pregunta"]; !ok || tmpl.Title != "[PREGUNTA] " || len(tmpl.Body) != 1 || !tmpl.Body[0].Required {
+		t.Fatalf("el formulario nuevo debe importarse: %+v", tmpl)
+	}
+	for _, id := range []string{"config", "epica", "module", "roto", "bug_report"} {
+		if _, ok := catalog[id]; ok {
+			t.Errorf("%q no debe estar en el catálogo", id)
+		}
+	}
+
+	joined := errors.Join(problems...)
+	if joined == nil || !strings.Contains(joined.Error(), "module.yml") || !strings.Contains(joined.Error(), "roto.yml") || strings.Contains(joined.Error(), "config.yml") {
+		t.Fatalf("se esperaban omitidos module.yml y roto.yml, got %v", joined)
+	}
+}
+
+func TestTemplateLoaderRefreshPublicaYConservaSiFalla(t *testing.T) {
+	originalCatalog := currentCatalog.Load()
+	t.Cleanup(func() {
+		currentTemplates.Store(&templates)
+		currentCatalog.Store(originalCatalog)
+	})
+
+	files := repoForms(t)
+	files["pregunta.yml"] = []byte(pruebaForm)
+	var failing error
+	loader := newTemplateLoader(func(context.Context) (map[string][]byte, error) { return files, failing })
+	loader.now = func() time.Time { return originalCatalog.LastModified.Add(time.Hour) }
+
+	if err := loader.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if _, ok := lookupTemplate("pregunta"); !ok {
+		t.Fatal("la plantilla nueva debe atender envíos tras la recarga")
+	}
+	published := currentCatalog.Load()
+	if published.ETag == originalCatalog.ETag || !strings.Contains(string(published.Body), `"id":"pregunta"`) {
+		t.Fatalf("/templates debe publicar la plantilla nueva: %s", published.Body)
+	}
+	if _, subErr := prepareSubmission(context.Background(), issueRequest{TemplateID: "pregunta", Title: "¿Cuándo sale?", Fields: map[string]string{"question": "¿Fecha?"}, Consent: validConsent()}); subErr != nil {
+		t.Fatalf("prepareSubmission con la plantilla nueva: %+v", subErr)
+	}
+
+	failing = errors.New("GitHub respondió 502")
+	if err := loader.Refresh(context.Background()); err == nil {
+		t.Fatal("se esperaba el error de GitHub")
+	}
+	if _, ok := lookupTemplate("pregunta"); !ok || currentCatalog.Load() != published {
+		t.Fatal("si GitHub falla se conserva el último catálogo publicado")
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errNoGitHubToken = errors.New("no hay tokens de GitHub disponibles")
+
+// pooledToken es una credencial del pool con lo último que GitHub informó de
+// su cuota. remaining < 0 significa que todavía no lo sabemos. Si app no es
+// nil, el valor se pide a la GitHub App en cada uso en lugar de value.
+type pooledToken struct {
+	value     string
+	app       *githubAppSource
+	label     string
+	remaining int
+	resetAt   time.Time
+	revoked   bool
+}
+
+// tokenPool reparte las llamadas a GitHub entre varios tokens. Si uno se
+// revoca o agota su cuota, las siguientes llamadas usan otro, así el
+// formulario público sigue funcionando aunque una credencial se invalide.
+type tokenPool struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	tokens []*pooledToken
+	next   int
+}
+
+// newTokenPoolFromEnv arma el pool con GITHUB_TOKEN y los tokens adicionales
+// de GITHUB_TOKENS (separados por comas). Los duplicados se ignoran para que
+// repetir el token principal en la lista no cuente doble.
+func newTokenPoolFromEnv(getenv func(string) string) *tokenPool {
+	values := []string{getenv("GITHUB_TOKEN")}
+	values = append(values, strings.Split(getenv("GITHUB_TOKENS"), ",")...)
+	return newTokenPool(values, time.Now)
+}
+
+func newTokenPool(values []string, now func() time.Time) *tokenPool {
+	pool := &tokenPool{now: now}
+	seen := map[string]struct{}{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, dup := seen[value]; dup {
+			continue
+		}
+		seen[value] = struct{}{}
+		// La etiqueta identifica el token en los logs sin exponer su valor.
+		label := fmt.Sprintf("token#%d", len(pool.tokens)+1)
+		pool.tokens = append(pool.tokens, &pooledToken{value: value, label: label, remaining: -1})
+	}
+	return pool
+}
+
+// addApp suma al pool los tokens de instalación de una GitHub App.
+func (p *tokenPool) addApp(app *githubAppSource) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	label := fmt.Sprintf("app#%s", app.installationID)
+	p.tokens = append(p.tokens, &pooledToken{app: app, label: label, remaining: -1})
+}
+
+// resolve devuelve el valor a enviar en Authorization.
+func (t *pooledToken) resolve(ctx context.Context) (string, error) {
+	if t.app == nil {
+		return t.value, nil
+	}
+	return t.app.Token(ctx)
+}
+
+// Len devuelve cuántos tokens se configuraron, incluidos los revocados.
+func (p *tokenPool) Len() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.tokens)
+}
+
+func (t *pooledToken) usable(now time.Time) bool {
+	if t.revoked {
+		return false
+	}
+	return t.remaining != 0 || !now.Before(t.resetAt)
+}
+
+// acquire elige el siguiente token utilizable en orden rotativo, saltando los
+// que ya se probaron en esta llamada.
+func (p *tokenPool) acquire(tried map[int]bool) (int, *pooledToken, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for i := 0; i < len(p.tokens); i++ {
+		idx := (p.next + i) % len(p.tokens)
+		if tried[idx] || !p.tokens[idx].usable(now) {
+			continue
+		}
+		p.next = (idx + 1) % len(p.tokens)
+		token := *p.tokens[idx]
+		return idx, &token, nil
+	}
+	return -1, nil, errNoGitHubToken
+}
+
+// observe registra la cuota que informa la respuesta e indica si conviene
+// repetir la llamada con otro token: 401 significa token revocado y un 403 o
+// 429 con la cuota en cero significa token agotado hasta el reset.
+func (p *tokenPool) observe(idx int, resp *http.Response) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token := p.tokens[idx]
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		token.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		token.resetAt = time.Unix(reset, 0)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		if token.app != nil {
+			// Un token de instalación rechazado se reemplaza por otro; la
+			// App sigue siendo válida.
+			token.app.invalidate()
+			log.Printf("GitHub rechazó el token de %s (401); se pedirá uno nuevo", token.label)
+			return true
+		}
+		token.revoked = true
+		log.Printf("GitHub rechazó %s (401); se retira del pool", token.label)
+		return true
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			// Límite secundario: GitHub indica cuánto esperar aunque la cuota
+			// principal no esté en cero.
+			token.remaining = 0
+			token.resetAt = p.now().Add(time.Duration(retryAfter) * time.Second)
+		}
+		if token.remaining == 0 {
+			log.Printf("%s agotó su cuota hasta %s", token.label, token.resetAt.UTC().Format(time.RFC3339))
+			return true
+		}
+	}
+	return false
+}
+
+// hasUsable indica si queda algún token sin probar para reintentar.
+func (p *tokenPool) hasUsable(tried map[int]bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for idx, token := range p.tokens {
+		if !tried[idx] && token.usable(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// githubAuthTransport firma cada llamada a GitHub con un token del pool y,
+// si GitHub lo rechaza por revocado o agotado, repite la misma solicitud con
+// el siguiente. Sin pool configurado usa githubToken tal cual.
+type githubAuthTransport struct {
+	base http.RoundTripper
+}
+
+func (t *githubAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	pool := loadServiceDeps().GitHubTokens
+	if pool.Len() == 0 {
+		attempt := req.Clone(req.Context())
+		attempt.Header.Set("Authorization", "Bearer "+githubToken)
+		return base.RoundTrip(attempt)
+	}
+
+	tried := map[int]bool{}
+	for {
+		idx, token, err := pool.acquire(tried)
+		if err != nil {
+			return nil, err
+		}
+		value, err := token.resolve(req.Context())
+		if err != nil {
+			// Si la App no entrega token seguimos con el resto del pool.
+			log.Printf("no se pudo obtener token de %s: %v", token.label, err)
+			tried[idx] = true
+			if pool.hasUsable(tried) {
+				continue
+			}
+			return nil, err
+		}
+		attempt := req.Clone(req.Context())
+		if len(tried) > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		attempt.Header.Set("Authorization", "Bearer "+value)
+
+		resp, err := base.RoundTrip(attempt)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.observe(idx, resp) {
+			return resp, nil
+		}
+		tried[idx] = true
+		// Sin otro token, o sin forma de reenviar el cuerpo, devolvemos la
+		// respuesta de GitHub para que el llamador vea el error real.
+		canReplay := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if !canReplay || !pool.hasUsable(tried) {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if logger := loggerFromContext(req.Context()); logger != nil {
+			logger.log(req.Context(), "github_token_failover", severityError, fmt.Sprintf("se reintenta %s %s con otro token tras %s", req.Method, req.URL.Path, token.label))
+		}
+	}
+}
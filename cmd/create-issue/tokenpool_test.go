@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenPoolRotaYSaltaAgotados(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	pool := newTokenPool([]string{"a", "b", "a", " ", "c"}, func() time.Time { return now })
+	if pool.Len() != 3 {
+		t.Fatalf("Len() = %d; los duplicados y vacíos no deben contar", pool.Len())
+	}
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		_, token, err := pool.acquire(nil)
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		order = append(order, token.value)
+	}
+	if strings.Join(order, ",") != "a,b,c,a" {
+		t.Fatalf("orden de rotación = %v", order)
+	}
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Minute).Unix(), 10))
+	if !pool.observe(1, &http.Response{StatusCode: http.StatusForbidden, Header: header}) {
+		t.Fatal("un 403 con la cuota en cero debe pedir failover")
+	}
+	if pool.observe(2, &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}) {
+		t.Fatal("un 403 sin cuota agotada es un error de permisos, no de token")
+	}
+	for i := 0; i < 3; i++ {
+		if _, token, _ := pool.acquire(nil); token.value == "b" {
+			t.Fatal("el token agotado no debe usarse antes del reset")
+		}
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !pool.hasUsable(map[int]bool{0: true, 2: true}) {
+		t.Fatal("pasado el reset el token debe volver al pool")
+	}
+}
+
+func TestGitHubAuthTransportHaceFailoverConElMismoCuerpo(t *testing.T) {
+	pool := newTokenPool([]string{"revocado", "bueno"}, time.Now)
+	useServiceDeps(t, func(d *serviceDeps) { d.GitHubTokens = pool })
+
+	type call struct{ auth, body string }
+	var calls []call
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		calls = append(calls, call{req.Header.Get("Authorization"), string(body)})
+		status := http.StatusCreated
+		if req.Header.Get("Authorization") == "Bearer revocado" {
+			status = http.StatusUnauthorized
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	client := &http.Client{Transport: &githubAuthTransport{base: base}}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post("https://api.github.com/repos/o/r/issues", "application/json", bytes.NewReader([]byte(`{"title":"x"}`)))
+		if err != nil {
+			t.Fatalf("Post: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("status = %d; el failover debía completar la llamada", resp.StatusCode)
+		}
+	}
+
+	want := []call{
+		{"Bearer revocado", `{"title":"x"}`},
+		{"Bearer bueno", `{"title":"x"}`},
+		{"Bearer bueno", `{"title":"x"}`},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("llamadas = %+v; se esperaban %+v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("llamada %d = %+v; se esperaba %+v", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestGitHubAuthTransportDevuelveErrorSiTodosFallan(t *testing.T) {
+	useServiceDeps(t, func(d *serviceDeps) { d.GitHubTokens = newTokenPool([]string{"x"}, time.Now) })
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+	client := &http.Client{Transport: &githubAuthTransport{base: base}}
+
+	resp, err := client.Get("https://api.github.com/")
+	if err != nil {
+		t.Fatalf("el primer 401 debe llegar al llamador: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if _, err := client.Get("https://api.github.com/"); err == nil || !strings.Contains(err.Error(), errNoGitHubToken.Error()) {
+		t.Fatalf("sin tokens vivos se esperaba errNoGitHubToken, llegó %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"eos-roadmap-tools/internal/errcodes"
+)
+
+// externalIssue es lo que se replica en un tracker externo después de crear
+// el issue en GitHub.
+type externalIssue struct {
+	TemplateID string
+	Number     int
+	Title      string
+	Body       string
+	Labels     []string
+	GitHubURL  string
+}
+
+// externalRef identifica la copia en el tracker externo.
+type externalRef struct {
+	Tracker string
+	Key     string
+	URL     string
+}
+
+// externalTracker es un conector hacia otro sistema de seguimiento. Los
+// conectores nuevos solo implementan esta interfaz y se registran en
+// newExternalTracker.
+type externalTracker interface {
+	Name() string
+	Mirror(ctx context.Context, issue externalIssue) (*externalRef, error)
+}
+
+// trackerRouter decide a qué tracker se replica cada plantilla. Una plantilla
+// sin entrada solo queda en GitHub.
+type trackerRouter struct {
+	byTemplate map[string]externalTracker
+}
+
+func (r *trackerRouter) For(templateID string) externalTracker {
+	if r == nil {
+		return nil
+	}
+	return r.byTemplate[templateID]
+}
+
+// warnUnknownTemplates avisa de las plantillas de EXTERNAL_TRACKERS que el
+// catálogo vigente no conoce. Se llama después de recargar los formularios.
+func (r *trackerRouter) warnUnknownTemplates() {
+	if r != nil {
+		warnUnknownTemplates("EXTERNAL_TRACKERS", r.byTemplate)
+	}
+}
+
+// newTrackerRouterFromEnv lee EXTERNAL_TRACKERS con la forma
+// "bug=jira,feature=linear". Sin la variable devuelve nil. Un tracker
+// desconocido o sin credenciales es un error: preferimos no arrancar a
+// descubrir en producción que los bugs no llegan a Jira. Una plantilla que el
+// catálogo no conoce solo se avisa, como en el resto de la configuración por
+// plantilla, porque puede llegar con la próxima recarga de los formularios.
+func newTrackerRouterFromEnv(getenv func(string) string) (*trackerRouter, error) {
+	raw := strings.TrimSpace(getenv("EXTERNAL_TRACKERS"))
+	if raw == "" {
+		return nil, nil
+	}
+	router := &trackerRouter{byTemplate: map[string]externalTracker{}}
+	built := map[string]externalTracker{}
+	for _, entry := range strings.Split(raw, ",") {
+		templateID, name, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		templateID, name = strings.TrimSpace(templateID), strings.ToLower(strings.TrimSpace(name))
+		if !ok || templateID == "" || name == "" {
+			return nil, fmt.Errorf("EXTERNAL_TRACKERS: entrada inválida %q (se espera plantilla=tracker)", entry)
+		}
+		tracker, exists := built[name]
+		if !exists {
+			var err error
+			if tracker, err = newExternalTracker(name, getenv); err != nil {
+				return nil, err
+			}
+			built[name] = tracker
+		}
+		router.byTemplate[templateID] = tracker
+	}
+	return router, nil
+}
+
+func newExternalTracker(name string, getenv func(string) string) (externalTracker, error) {
+	env := func(key string) string { return strings.TrimSpace(getenv(key)) }
+	switch name {
+	case "jira":
+		tracker := &jiraTracker{
+			baseURL:   strings.TrimRight(env("JIRA_BASE_URL"), "/"),
+			email:     env("JIRA_EMAIL"),
+			token:     env("JIRA_API_TOKEN"),
+			project:   env("JIRA_PROJECT_KEY"),
+			issueType: env("JIRA_ISSUE_TYPE"),
+			client:    &http.Client{Timeout: 10 * time.Second, Transport: &outboundLoggingTransport{}},
+		}
+		if tracker.issueType == "" {
+			tracker.issueType = "Task"
+		}
+		if tracker.baseURL == "" || tracker.email == "" || tracker.token == "" || tracker.project == "" {
+			return nil, errors.New("el tracker jira requiere JIRA_BASE_URL, JIRA_EMAIL, JIRA_API_TOKEN y JIRA_PROJECT_KEY")
+		}
+		return tracker, nil
+	case "linear":
+		tracker := &linearTracker{
+			endpoint: linearEndpoint,
+			apiKey:   env("LINEAR_API_KEY"),
+			teamID:   env("LINEAR_TEAM_ID"),
+			client:   &http.Client{Timeout: 10 * time.Second, Transport: &outboundLoggingTransport{}},
+		}
+		if tracker.apiKey == "" || tracker.teamID == "" {
+			return nil, errors.New("el tracker linear requiere LINEAR_API_KEY y LINEAR_TEAM_ID")
+		}
+		return tracker, nil
+	default:
+		return nil, fmt.Errorf("EXTERNAL_TRACKERS: tracker desconocido %q (se admiten jira y linear)", name)
+	}
+}
+
+// mirrorDescription es la descripción común: el cuerpo del issue con el
+// enlace de vuelta a GitHub al inicio, que es la fuente de verdad.
+func mirrorDescription(issue externalIssue) string {
+	return fmt.Sprintf("Reflejo de %s\n\n%s", issue.GitHubURL, issue.Body)
+}
+
+// jiraTracker crea issues con la API REST v2 de Jira, que acepta la
+// descripción como texto plano (la v3 exige Atlassian Document Format).
+type jiraTracker struct {
+	baseURL   string
+	email     string
+	token     string
+	project   string
+	issueType string
+	client    *http.Client
+}
+
+func (j *jiraTracker) Name() string { return "jira" }
+
+func (j *jiraTracker) Mirror(ctx context.Context, issue externalIssue) (*externalRef, error) {
+	// Jira no admite espacios en las etiquetas.
+	labels := make([]string, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		labels = append(labels, strings.Join(strings.Fields(label), "-"))
+	}
+	sort.Strings(labels)
+	payload := map[string]any{
+		"fields": map[string]any{
+			"project":     map[string]string{"key": j.project},
+			"issuetype":   map[string]string{"name": j.issueType},
+			"summary":     issue.Title,
+			"description": mirrorDescription(issue),
+			"labels":      labels,
+		},
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := postTrackerJSON(ctx, j.client, j.baseURL+"/rest/api/2/issue", payload, func(req *http.Request) {
+		req.SetBasicAuth(j.email, j.token)
+	}, &created); err != nil {
+		return nil, err
+	}
+	if created.Key == "" {
+		return nil, errors.New("jira respondió sin clave de issue")
+	}
+	return &externalRef{Tracker: j.Name(), Key: created.Key, URL: j.baseURL + "/browse/" + created.Key}, nil
+}
+
+const linearEndpoint = "https://api.linear.app/graphql"
+
+// linearTracker crea issues con la API GraphQL de Linear.
+type linearTracker struct {
+	endpoint string
+	apiKey   string
+	teamID   string
+	client   *http.Client
+}
+
+func (l *linearTracker) Name() string { return "linear" }
+
+func (l *linearTracker) Mirror(ctx context.Context, issue externalIssue) (*externalRef, error) {
+	payload := map[string]any{
+		"query": `mutation($input: IssueCreateInput!) { issueCreate(input: $input) { success issue { identifier url } } }`,
+		"variables": map[string]any{
+			"input": map[string]string{
+				"teamId":      l.teamID,
+				"title":       issue.Title,
+				"description": mirrorDescription(issue),
+			},
+		},
+	}
+	var resp struct {
+		Data struct {
+			IssueCreate struct {
+				Success bool `json:"success"`
+				Issue   struct {
+					Identifier string `json:"identifier"`
+					URL        string `json:"url"`
+				} `json:"issue"`
+			} `json:"issueCreate"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	// Las API keys personales de Linear van sin el prefijo Bearer.
+	if err := postTrackerJSON(ctx, l.client, l.endpoint, payload, func(req *http.Request) {
+		req.Header.Set("Authorization", l.apiKey)
+	}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("linear: %s", resp.Errors[0].Message)
+	}
+	created := resp.Data.IssueCreate
+	if !created.Success || created.Issue.Identifier == "" {
+		return nil, errors.New("linear no confirmó la creación del issue")
+	}
+	return &externalRef{Tracker: l.Name(), Key: created.Issue.Identifier, URL: created.Issue.URL}, nil
+}
+
+func postTrackerJSON(ctx context.Context, client *http.Client, url string, payload any, auth func(*http.Request), out any) error {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	auth(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("respondió %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// externalRefLine es la referencia cruzada que se agrega al cuerpo del issue.
+func externalRefLine(ref *externalRef) string {
+	return fmt.Sprintf("_Reflejado en %s: [%s](%s)_", ref.Tracker, ref.Key, ref.URL)
+}
+
+// mirrorToExternalTracker replica el issue en el tracker de su plantilla y
+// anota la referencia en el cuerpo del issue. Es un complemento como el área
+// del módulo: si falla, el issue en GitHub ya existe y solo queda registro
+// para replicarlo a mano. El log con stage=external_tracker es la constancia
+// de auditoría del cruce.
+func mirrorToExternalTracker(ctx context.Context, deps *serviceDeps, p *preparedSubmission, issue *githubIssueResponse) {
+	tracker := deps.ExternalTrackers.For(p.TemplateID)
+	// La sonda sintética borra su issue enseguida; no dejamos copias huérfanas
+	// en el tracker externo.
+	if tracker == nil || strings.HasPrefix(p.Title, probeTitlePrefix) || !flagEnabled(ctx, flagExternalTracker) {
+		return
+	}
+	logger := loggerFromContext(ctx)
+	ref, err := tracker.Mirror(ctx, externalIssue{
+		TemplateID: p.TemplateID,
+		Number:     issue.Number,
+		Title:      p.Title,
+		Body:       p.Body,
+		Labels:     p.issueLabels(),
+		GitHubURL:  issue.HTMLURL,
+	})
+	if err != nil {
+		if logger != nil {
+			logger.log(ctx, "external_tracker", severityError, fmt.Sprintf("issue #%d: no se pudo reflejar en %s: %v", issue.Number, tracker.Name(), err))
+		}
+		return
+	}
+	message := fmt.Sprintf("issue #%d reflejado en %s como %s (%s)", issue.Number, ref.Tracker, ref.Key, ref.URL)
+	severity := severityInfo
+	if deps.IssueBodyUpdater != nil {
+		if err := deps.IssueBodyUpdater(ctx, issue.Number, p.Body+"\n\n"+externalRefLine(ref)); err != nil {
+			severity = severityError
+			message += fmt.Sprintf("; no se pudo anotar la referencia en el issue: %v", err)
+		}
+	}
+	if logger != nil {
+		logger.log(ctx, "external_tracker", severity, message)
+	}
+}
+
+// updateIssueBody reemplaza el cuerpo de un issue en el repositorio del
+// destino del envío.
+func updateIssueBody(ctx context.Context, number int, body string) error {
+	buf, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return err
+	}
+	target := issueTargetFromContext(ctx)
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d", target.Owner, target.Repo, number)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	client := &http.Client{Timeout: 15 * time.Second, Transport: &githubAuthTransport{base: &outboundLoggingTransport{}}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &errcodes.GitHubError{Status: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewTrackerRouterFromEnv(t *testing.T) {
+	env := map[string]string{
+		"EXTERNAL_TRACKERS": "bug=jira, feature=linear",
+		"JIRA_BASE_URL":     "https://acme.atlassian.net/",
+		"JIRA_EMAIL":        "bot@acme.test",
+		"JIRA_API_TOKEN":    "t",
+		"JIRA_PROJECT_KEY":  "EOS",
+		"LINEAR_API_KEY":    "lin_api",
+		"LINEAR_TEAM_ID":    "team",
+	}
+	getenv := func(k string) string { return env[k] }
+	router, err := newTrackerRouterFromEnv(getenv)
+	if err != nil {
+		t.Fatalf("newTrackerRouterFromEnv: %v", err)
+	}
+	if router.For("bug").Name() != "jira" || router.For("feature").Name() != "linear" || router.For("blank") != nil {
+		t.Fatalf("ruteo inesperado: %+v", router.byTemplate)
+	}
+
+	// Una plantilla que todavía no está en el catálogo no impide el arranque:
+	// puede llegar con la próxima recarga de los formularios.
+	env["EXTERNAL_TRACKERS"] = "soporte=jira"
+	if router, err = newTrackerRouterFromEnv(getenv); err != nil || router.For("soporte").Name() != "jira" {
+		t.Fatalf("plantilla aún desconocida: %+v, %v", router, err)
+	}
+
+	for name, value := range map[string]string{
+		"tracker desconocido": "bug=asana",
+		"entrada sin tracker": "bug",
+	} {
+		env["EXTERNAL_TRACKERS"] = value
+		if _, err := newTrackerRouterFromEnv(getenv); err == nil {
+			t.Errorf("%s: se esperaba error", name)
+		}
+	}
+
+	env["EXTERNAL_TRACKERS"] = "bug=linear"
+	env["LINEAR_TEAM_ID"] = ""
+	if _, err := newTrackerRouterFromEnv(getenv); err == nil {
+		t.Fatal("un tracker sin credenciales debe impedir el arranque")
+	}
+}
+
+func TestJiraTrackerMirror(t *testing.T) {
+	var got struct {
+		Fields struct {
+			Project     struct{ Key string } `json:"project"`
+			Summary     string               `json:"summary"`
+			Description string               `json:"description"`
+			Labels      []string             `json:"labels"`
+		} `json:"fields"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "bot@acme.test" || pass != "t" || r.URL.Path != "/rest/api/2/issue" {
+			http.Error(w, "no", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"key":"EOS-12"}`))
+	}))
+	defer server.Close()
+
+	tracker := &jiraTracker{baseURL: server.URL, email: "bot@acme.test", token: "t", project: "EOS", issueType: "Task", client: server.Client()}
+	ref, err := tracker.Mirror(context.Background(), externalIssue{
+		Title:     "Falla el lector",
+		Body:      "Detalle",
+		Labels:    []string{"Tipo: Bug"},
+		GitHubURL: "https://github.com/o/r/issues/3",
+	})
+	if err != nil {
+		t.Fatalf("Mirror: %v", err)
+	}
+	if ref.Key != "EOS-12" || ref.URL != server.URL+"/browse/EOS-12" {
+		t.Fatalf("referencia inesperada: %+v", ref)
+	}
+	if got.Fields.Project.Key != "EOS" || !strings.Contains(got.Fields.Description, "https://github.com/o/r/issues/3") || got.Fields.Labels[0] != "Tipo:-Bug" {
+		t.Fatalf("payload inesperado: %+v", got.Fields)
+	}
+}
+
+type fakeTracker struct {
+	ref *externalRef
+	err error
+}
+
+func (f fakeTracker) Name() string { return "jira" }
+
+func (f fakeTracker) Mirror(context.Context, externalIssue) (*externalRef, error) {
+	return f.ref, f.err
+}
+
+func TestSubmitPreparedAnotaLaReferenciaExterna(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		tracker     fakeTracker
+		wantUpdated bool
+	}{
+		{"reflejado", fakeTracker{ref: &externalRef{Tracker: "jira", Key: "EOS-1", URL: "https://acme.atlassian.net/browse/EOS-1"}}, true},
+		{"tracker caído", fakeTracker{err: errors.New("503")}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var updatedBody string
+			useServiceDeps(t, func(deps *serviceDeps) {
+				deps.IssueCreator = func(context.Context, string, []string, string) (*githubIssueResponse, error) {
+					return &githubIssueResponse{Number: 3, HTMLURL: "https://github.com/o/r/issues/3", NodeID: "I_3"}, nil
+				}
+				deps.ProjectAdder = func(context.Context, string, string, []string) error { return nil }
+				deps.ExternalTrackers = &trackerRouter{byTemplate: map[string]externalTracker{"blank": tc.tracker}}
+				deps.IssueBodyUpdater = func(_ context.Context, number int, body string) error {
+					updatedBody = body
+					return nil
+				}
+			})
+
+			p := &preparedSubmission{TemplateID: "blank", Template: templates["blank"], Title: "t", Body: "cuerpo", Consent: validConsent()}
+			resp, subErr := submitPrepared(context.Background(), p)
+			if subErr != nil || resp.IssueURL == "" {
+				t.Fatalf("la réplica externa no debe afectar el envío: %+v, %v", resp, subErr)
+			}
+			if tc.wantUpdated != (updatedBody != "") {
+				t.Fatalf("cuerpo actualizado = %q", updatedBody)
+			}
+			if tc.wantUpdated && (!strings.HasPrefix(updatedBody, "cuerpo\n\n") || !strings.Contains(updatedBody, "[EOS-1](https://acme.atlassian.net/browse/EOS-1)")) {
+				t.Fatalf("la referencia cruzada no quedó en el cuerpo: %q", updatedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// badgeIndexFile lista las áreas con insignia; el resto de archivos del
+// directorio son <slug>.json (resumen) y <slug>.shields.json (endpoint de
+// shields.io).
+const badgeIndexFile = "index.json"
+
+// areaProgress es el resumen de avance de un área. Es lo que una página de
+// equipo puede leer sin descargar modules.json completo.
+type areaProgress struct {
+	Area        string `json:"area"`
+	Slug        string `json:"slug"`
+	Completados int    `json:"completados"`
+	Total       int    `json:"total"`
+	Porcentaje  int    `json:"porcentaje"`
+}
+
+// shieldsBadge sigue el formato del endpoint de shields.io:
+// https://shields.io/badges/endpoint-badge
+type shieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// buildAreaProgress cuenta por área los módulos completados (isCompleted)
+// sobre el total. Los retirados del plan no cuentan: ya no forman parte del
+// trabajo comprometido.
+func buildAreaProgress(modules []ModuleOut) []areaProgress {
+	byArea := map[string]*areaProgress{}
+	for _, m := range modules {
+		if m.Retirado != "" {
+			continue
+		}
+		area := strings.TrimSpace(m.Area)
+		if area == "" {
+			area = areaSinAsignar
+		}
+		entry := byArea[area]
+		if entry == nil {
+			entry = &areaProgress{Area: area}
+			byArea[area] = entry
+		}
+		entry.Total++
+		if isCompleted(m) {
+			entry.Completados++
+		}
+	}
+
+	out := make([]areaProgress, 0, len(byArea))
+	for _, entry := range byArea {
+		entry.Porcentaje = entry.Completados * 100 / entry.Total
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Area < out[j].Area })
+
+	// Dos áreas pueden normalizar al mismo slug ("Logística" y "Logistica");
+	// la segunda lleva sufijo para no pisar la insignia de la primera. El
+	// slug del índice queda reservado de entrada, así un área "Index" no
+	// reemplaza badgeIndexFile.
+	used := map[string]int{strings.TrimSuffix(badgeIndexFile, ".json"): 1}
+	for i := range out {
+		slug := slugify(out[i].Area)
+		used[slug]++
+		if used[slug] > 1 {
+			slug = fmt.Sprintf("%s-%d", slug, used[slug])
+		}
+		out[i].Slug = slug
+	}
+	return out
+}
+
+func (p areaProgress) shields() shieldsBadge {
+	color := "lightgrey"
+	switch {
+	case p.Porcentaje == 100:
+		color = "brightgreen"
+	case p.Porcentaje >= 50:
+		color = "green"
+	case p.Porcentaje > 0:
+		color = "yellow"
+	}
+	return shieldsBadge{
+		SchemaVersion: 1,
+		Label:         p.Area,
+		Message:       fmt.Sprintf("%d/%d (%d%%)", p.Completados, p.Total, p.Porcentaje),
+		Color:         color,
+	}
+}
+
+// writeBadges prepara las insignias por área en dir y el borrado de las de
+// áreas que ya no existen. Solo reescribe los archivos cuyo contenido cambió para no
+// generar commits vacíos. Devuelve si cambió algún archivo.
+func writeBadges(out *outputStage, dir string, progress []areaProgress) (bool, error) {
+	files := map[string]any{badgeIndexFile: progress}
+	for _, p := range progress {
+		files[p.Slug+".json"] = p
+		files[p.Slug+".shields.json"] = p.shields()
+	}
+
+	changed := false
+	for name, value := range files {
+		content, err := marshalJSON(value)
+		if err != nil {
+			return changed, fmt.Errorf("preparar insignia %s: %w", name, err)
+		}
+		path := filepath.Join(dir, name)
+		differs, err := fileContentChanged(path, content)
+		if err != nil {
+			return changed, fmt.Errorf("comparar %s: %w", path, err)
+		}
+		if !differs {
+			continue
+		}
+		if err := out.write(path, content); err != nil {
+			return changed, fmt.Errorf("escribir %s: %w", path, err)
+		}
+		changed = true
+	}
+
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return changed, err
+	}
+	for _, path := range existing {
+		if _, keep := files[filepath.Base(path)]; keep {
+			continue
+		}
+		out.remove(path)
+		changed = true
+	}
+	return changed, nil
+}
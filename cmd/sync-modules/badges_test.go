@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildAreaProgress(t *testing.T) {
+	modules := []ModuleOut{
+		{Area: "Logística", Tipo: "feature", Estado: "Liberado"},
+		{Area: "Logística", Tipo: "feature", Estado: "En desarrollo"},
+		{Area: "Logística", Tipo: "bug", Estado: "Resuelto"},
+		{Area: "Logística", Tipo: "feature", Estado: "Archivado", Retirado: "2026-01-10"},
+		{Area: "Logistica", Tipo: "bug", Estado: "Reportado"},
+		{Tipo: "feature", Estado: "En pruebas"},
+	}
+	got := buildAreaProgress(modules)
+	if len(got) != 3 {
+		t.Fatalf("se esperaban 3 áreas, llegaron %+v", got)
+	}
+	if got[0].Slug != "logistica" || got[1].Slug != "logistica-2" {
+		t.Fatalf("slugs repetidos no se desambiguaron: %q, %q", got[0].Slug, got[1].Slug)
+	}
+	logistica := got[1]
+	if logistica.Area != "Logística" || logistica.Completados != 2 || logistica.Total != 3 || logistica.Porcentaje != 66 {
+		t.Fatalf("avance de Logística inesperado: %+v", logistica)
+	}
+	if got[2].Area != areaSinAsignar || got[2].Slug != "sin-area" {
+		t.Fatalf("los módulos sin área deben agruparse en %q: %+v", areaSinAsignar, got[2])
+	}
+	if badge := logistica.shields(); badge.Message != "2/3 (66%)" || badge.Color != "green" || badge.SchemaVersion != 1 {
+		t.Fatalf("insignia shields inesperada: %+v", badge)
+	}
+}
+
+func TestWriteBadgesBorraAreasObsoletas(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "compras.json")
+	if err := os.WriteFile(stale, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	progress := buildAreaProgress([]ModuleOut{{Area: "Ventas", Tipo: "feature", Estado: "Liberado"}})
+
+	changed, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return writeBadges(out, dir, progress) })
+	if err != nil || !changed {
+		t.Fatalf("writeBadges = %v, %v", changed, err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatal("la insignia de un área que ya no existe debe borrarse")
+	}
+	var badge shieldsBadge
+	raw, err := os.ReadFile(filepath.Join(dir, "ventas.shields.json"))
+	if err != nil || json.Unmarshal(raw, &badge) != nil || badge.Message != "1/1 (100%)" {
+		t.Fatalf("insignia de Ventas inesperada: %s, %v", raw, err)
+	}
+
+	if changed, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return writeBadges(out, dir, progress) }); err != nil || changed {
+		t.Fatalf("una segunda escritura sin cambios no debe tocar archivos: %v, %v", changed, err)
+	}
+}
+
+func TestWriteBadgesAreaIndexNoPisaElIndice(t *testing.T) {
+	dir := t.TempDir()
+	progress := buildAreaProgress([]ModuleOut{
+		{Area: "Index", Tipo: "feature", Estado: "Liberado"},
+		{Area: "Ventas", Tipo: "feature", Estado: "Reportado"},
+	})
+	if progress[0].Slug != "index-2" {
+		t.Fatalf("el slug del índice debe quedar reservado, llegó %q", progress[0].Slug)
+	}
+
+	if _, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return writeBadges(out, dir, progress) }); err != nil {
+		t.Fatalf("writeBadges: %v", err)
+	}
+	var index []areaProgress
+	raw, err := os.ReadFile(filepath.Join(dir, badgeIndexFile))
+	if err != nil || json.Unmarshal(raw, &index) != nil || len(index) != 2 {
+		t.Fatalf("%s debe listar las dos áreas: %s, %v", badgeIndexFile, raw, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index-2.shields.json")); err != nil {
+		t.Fatalf("la insignia del área Index debe usar el slug con sufijo: %v", err)
+	}
+}
+
+func TestLoadConfigRechazaBadgesJuntoALasSalidas(t *testing.T) {
+	env := map[string]string{"BADGES_DIR": "docs"}
+	if _, err := loadConfig(func(k string) string { return env[k] }); err == nil {
+		t.Fatal("BADGES_DIR no puede ser el directorio de modules.json")
+	}
+	env["BADGES_DIR"] = "docs/badges"
+	if _, err := loadConfig(func(k string) string { return env[k] }); err != nil {
+		t.Fatalf("docs/badges debe aceptarse: %v", err)
+	}
+}
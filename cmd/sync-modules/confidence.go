@@ -0,0 +1,67 @@
+package main
+
+// Niveles de confianza de la ETA, tomados del campo "Confidence" del
+// Project. Se publican en español como el resto de la vista pública.
+const (
+	confianzaAlta  = "Alta"
+	confianzaMedia = "Media"
+	confianzaBaja  = "Baja"
+)
+
+// confidenceAliases acepta las opciones en español o en inglés, porque el
+// campo se llama "Confidence" y es fácil que alguien cree las opciones así.
+var confidenceAliases = map[string]string{
+	"alta":   confianzaAlta,
+	"high":   confianzaAlta,
+	"media":  confianzaMedia,
+	"medium": confianzaMedia,
+	"baja":   confianzaBaja,
+	"low":    confianzaBaja,
+}
+
+// normalizeConfidence devuelve el nivel publicado; ok es false si el campo
+// trae una opción que no conocemos.
+func normalizeConfidence(raw string) (string, bool) {
+	key := normalizeText(raw)
+	if key == "" {
+		return "", true
+	}
+	level, ok := confidenceAliases[key]
+	return level, ok
+}
+
+// confidenceSummary cuenta las ETAs pendientes por nivel de confianza para
+// que la página diga qué tan firmes son las fechas en conjunto.
+type confidenceSummary struct {
+	Alta    int `json:"alta"`
+	Media   int `json:"media"`
+	Baja    int `json:"baja"`
+	SinDato int `json:"sinDato"`
+}
+
+// aggregateConfidence solo considera módulos con ETA que aún no terminan:
+// la confianza de una fecha ya cumplida no le dice nada a quien lee.
+func aggregateConfidence(modules []ModuleOut) *confidenceSummary {
+	var summary confidenceSummary
+	total := 0
+	for _, m := range modules {
+		if m.ETA == "" || m.Retirado != "" || isCompleted(m) {
+			continue
+		}
+		total++
+		switch m.Confianza {
+		case confianzaAlta:
+			summary.Alta++
+		case confianzaMedia:
+			summary.Media++
+		case confianzaBaja:
+			summary.Baja++
+		default:
+			summary.SinDato++
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	return &summary
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func confidenceItem(number int, eta, confidence string) Item {
+	it := epicItem(number, "", githubv4.IssueStateOpen, "bug")
+	it.Status.Typename = "ProjectV2ItemFieldSingleSelectValue"
+	it.Status.Single.Name = "Desarrollo"
+	if eta != "" {
+		parsed, _ := time.Parse("2006-01-02", eta)
+		it.ETA.DateVal.Date = GHFlexDate{Time: parsed, Raw: eta}
+	}
+	if confidence != "" {
+		it.Confianza.Typename = "ProjectV2ItemFieldSingleSelectValue"
+		it.Confianza.Single.Name = githubv4.String(confidence)
+	}
+	return it
+}
+
+func TestBuildModulesPublicaConfianzaDeLaETA(t *testing.T) {
+	report := newRunReport(time.Now)
+	modules := buildModules([]Item{
+		confidenceItem(1, "2026-12-01", "High"),
+		confidenceItem(2, "", "Baja"),
+		confidenceItem(3, "2026-12-15", "Quizás"),
+		confidenceItem(4, "2027-01-10", ""),
+	}, report)
+	if len(modules) != 4 {
+		t.Fatalf("se esperaban 4 módulos, llegaron %+v", modules)
+	}
+	if modules[0].Confianza != confianzaAlta {
+		t.Fatalf("High debe publicarse como %q, llegó %q", confianzaAlta, modules[0].Confianza)
+	}
+	if modules[1].Confianza != "" || modules[2].Confianza != "" {
+		t.Fatalf("sin ETA o con opción desconocida no se publica confianza: %+v", modules[1:3])
+	}
+	if len(report.Warnings) != 1 {
+		t.Fatalf("la opción desconocida debe advertirse una vez, llegó %v", report.Warnings)
+	}
+
+	summary := aggregateConfidence(modules)
+	if summary == nil || summary.Alta != 1 || summary.SinDato != 2 || summary.Baja != 0 {
+		t.Fatalf("resumen inesperado: %+v", summary)
+	}
+}
+
+func TestAggregateConfidenceIgnoraCompletadosYRetirados(t *testing.T) {
+	modules := []ModuleOut{
+		{Tipo: "feature", Estado: "Liberado", ETA: "2026-01-01", Confianza: confianzaBaja},
+		{Tipo: "feature", Estado: "En desarrollo", ETA: "2026-02-01", Confianza: confianzaMedia, Retirado: "2026-01-15"},
+		{Tipo: "feature", Estado: "En desarrollo"},
+	}
+	if summary := aggregateConfidence(modules); summary != nil {
+		t.Fatalf("sin ETAs pendientes no debe publicarse resumen, llegó %+v", summary)
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"eos-roadmap-tools/internal/issueform"
+	"github.com/shurcooL/githubv4"
+)
+
+// defaultFormsDir es donde viven los formularios que exporta create-issue
+// ("create-issue forms export") junto con los que se editan a mano.
+const defaultFormsDir = ".github/ISSUE_TEMPLATE"
+
+// formsChooserFile configura el selector de "New issue"; no es un formulario.
+const formsChooserFile = "config.yml"
+
+// expectedStatusOptions son las columnas de Status que publicPhase reconoce,
+// escritas como en el tablero. Si falta alguna, esa fase nunca se publica.
+var expectedStatusOptions = []string{"En planeación", "Prototipado", "Desarrollo", "Test", "Staging", "Deploy", "Archivado"}
+
+// requiredProjectFields son los campos de selección única sin los que el sync
+// no puede clasificar un item; optionalProjectFields solo enriquecen la vista.
+var (
+	requiredProjectFields = []string{"Status", "Tipo"}
+	optionalProjectFields = []string{"Area", "Prioridad", "Confidence", "Check Luis"}
+)
+
+// Severidades del reporte de consistencia. Solo los errores hacen fallar el
+// comando; los avisos e info piden una revisión humana.
+const (
+	findingError = "error"
+	findingAviso = "aviso"
+	findingInfo  = "info"
+)
+
+type consistencyFinding struct {
+	Severity string
+	Source   string
+	Message  string
+}
+
+type consistencyReport struct {
+	Findings []consistencyFinding
+}
+
+func (r *consistencyReport) add(severity, source, format string, args ...any) {
+	r.Findings = append(r.Findings, consistencyFinding{Severity: severity, Source: source, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r consistencyReport) errors() int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == findingError {
+			n++
+		}
+	}
+	return n
+}
+
+// projectFields son las opciones de cada campo de selección única del
+// Project, por nombre de campo.
+type projectFields map[string][]string
+
+// projectFieldsFetcher trae los campos del Project; es una variable de
+// función para probar sin GitHub, como statusUpdateFetcher.
+type projectFieldsFetcher func(ctx context.Context) (projectFields, error)
+
+type projectFieldsQuery struct {
+	RateLimit rateLimitInfo `graphql:"rateLimit"`
+	Org       struct {
+		Project struct {
+			Fields struct {
+				Nodes []struct {
+					Single struct {
+						Name    string
+						Options []struct{ Name string }
+					} `graphql:"... on ProjectV2SingleSelectField"`
+				}
+			} `graphql:"fields(first: 50)"`
+		} `graphql:"projectV2(number: $projectNumber)"`
+	} `graphql:"organization(login: $org)"`
+}
+
+func graphQLProjectFieldsFetcher(cli *githubv4.Client, cfg syncConfig) projectFieldsFetcher {
+	return func(ctx context.Context) (projectFields, error) {
+		var q projectFieldsQuery
+		vars := map[string]interface{}{
+			"org":           githubv4.String(cfg.Org),
+			"projectNumber": githubv4.Int(cfg.ProjectNum),
+		}
+		if err := cli.Query(ctx, &q, vars); err != nil {
+			return nil, classifyGraphQLError(err)
+		}
+		recordQueryCost(ctx, "project_fields", q.RateLimit)
+		fields := projectFields{}
+		for _, node := range q.Org.Project.Fields.Nodes {
+			if node.Single.Name == "" {
+				continue
+			}
+			options := make([]string, 0, len(node.Single.Options))
+			for _, option := range node.Single.Options {
+				options = append(options, option.Name)
+			}
+			fields[node.Single.Name] = options
+		}
+		return fields, nil
+	}
+}
+
+// formTipo devuelve el valor de la etiqueta "Tipo: X" del formulario, con la
+// misma regla que determineProjectTipoValue en create-issue: ese es el valor
+// que se busca, tal cual, entre las opciones del campo Tipo.
+func formTipo(labels []string) string {
+	for _, label := range labels {
+		prefix, value, ok := strings.Cut(label, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(prefix), "tipo") {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// publishedKind dice cómo clasifica el sync un Tipo: "bug", "feature",
+// "épica" o "" si los issues de ese Tipo no llegan al roadmap.
+func publishedKind(tipo string) string {
+	switch {
+	case isEpic(nil, tipo):
+		return "épica"
+	case isBug(nil, tipo):
+		return "bug"
+	case isFeature(nil, tipo):
+		return "feature"
+	default:
+		return ""
+	}
+}
+
+// loadForms lee los formularios de dir por nombre de archivo. Un formulario
+// que no se puede leer queda en el reporte en lugar de abortar la revisión.
+func loadForms(dir string, report *consistencyReport) (map[string]issueform.Form, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	forms := map[string]issueform.Form{}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if name == formsChooserFile {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		form, err := issueform.Parse(data)
+		if err != nil {
+			report.add(findingAviso, "formularios", "%s no se pudo interpretar: %v", name, err)
+			continue
+		}
+		forms[name] = form
+	}
+	if len(forms) == 0 {
+		return nil, fmt.Errorf("no hay formularios en %s", dir)
+	}
+	return forms, nil
+}
+
+// checkConsistency cruza los formularios de issue, los campos del Project y
+// la taxonomía del sync. Cada desajuste es una forma distinta de que un issue
+// creado correctamente no aparezca en el tablero o en el roadmap.
+func checkConsistency(forms map[string]issueform.Form, fields projectFields, tax taxonomy, taxErr error, report *consistencyReport) {
+	for _, name := range requiredProjectFields {
+		if _, ok := fields[name]; !ok {
+			report.add(findingError, "proyecto", "falta el campo de selección única %q; el sync no puede clasificar los items", name)
+		}
+	}
+	for _, name := range optionalProjectFields {
+		if _, ok := fields[name]; !ok {
+			report.add(findingAviso, "proyecto", "falta el campo de selección única %q; el roadmap se publica sin ese dato", name)
+		}
+	}
+
+	if statuses, ok := fields["Status"]; ok {
+		present := map[string]bool{}
+		for _, option := range statuses {
+			present[normalizeText(option)] = true
+			if _, known := publicPhase(option); known {
+				continue
+			}
+			if _, private := knownPrivateStatuses[normalizeText(option)]; !private {
+				report.add(findingError, "proyecto", "la opción de Status %q no tiene fase pública; sus items no se publican y cada corrida advierte", option)
+			}
+		}
+		for _, expected := range expectedStatusOptions {
+			if !present[normalizeText(expected)] {
+				report.add(findingAviso, "proyecto", "el Status no tiene la opción %q; la fase %q nunca se publica", expected, expected)
+			}
+		}
+	}
+
+	tipos, hasTipo := fields["Tipo"]
+	tipoOptions := map[string]bool{}
+	for _, option := range tipos {
+		tipoOptions[option] = true
+	}
+	usedTipos := map[string]bool{}
+	names := make([]string, 0, len(forms))
+	for name := range forms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tipo := formTipo(forms[name].Labels)
+		if tipo == "" {
+			report.add(findingAviso, "formularios", "%s no tiene etiqueta \"Tipo: …\"; sus issues llegan sin Tipo y no aparecen en el roadmap", name)
+			continue
+		}
+		usedTipos[tipo] = true
+		if hasTipo && !tipoOptions[tipo] {
+			report.add(findingError, "formularios", "%s usa el Tipo %q, que no es una opción del campo Tipo; create-issue no puede asignarlo (project_option_missing)", name, tipo)
+		}
+		if publishedKind(tipo) == "" {
+			report.add(findingInfo, "formularios", "%s (Tipo %q) no se publica en el roadmap", name, tipo)
+		}
+	}
+	for _, option := range tipos {
+		if !usedTipos[option] && publishedKind(option) != "" {
+			report.add(findingInfo, "proyecto", "el Tipo %q se publica como %s pero ningún formulario lo asigna", option, publishedKind(option))
+		}
+	}
+
+	for _, option := range fields["Confidence"] {
+		if _, ok := normalizeConfidence(option); !ok {
+			report.add(findingAviso, "proyecto", "la opción de Confidence %q no se reconoce; la ETA se publica sin confianza", option)
+		}
+	}
+
+	if taxErr != nil {
+		report.add(findingError, "taxonomía", "%v", taxErr)
+		return
+	}
+	published := map[string]bool{}
+	for _, estado := range publishedStatuses {
+		published[estado] = true
+	}
+	for _, display := range tax.Estados {
+		if !published[display.Estado] {
+			report.add(findingAviso, "taxonomía", "el estado %q tiene presentación pero el sync nunca lo publica", display.Estado)
+		}
+	}
+}
+
+// runConfigCheck atiende "sync-modules check-config": lee la misma
+// configuración que una corrida normal, consulta los campos del Project y
+// escribe un único reporte. Termina con 1 si hay errores.
+func runConfigCheck(args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check-config", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("forms", defaultFormsDir, "carpeta de los formularios de issue")
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+
+	cfg, err := loadConfig(getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "configuración inválida: %v\n", err)
+		return exitFailure
+	}
+	if cfg.Token == "" {
+		fmt.Fprintln(stderr, "GITHUB_TOKEN no está definido")
+		return exitAuthFailure
+	}
+	httpClient, err := cfg.Endpoint.httpClient(cfg.Token, os.ReadFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "configuración inválida: %v\n", err)
+		return exitFailure
+	}
+	cli := githubv4.NewEnterpriseClient(cfg.Endpoint.GraphQLURL, httpClient)
+	return checkConfig(context.Background(), cfg, *dir, graphQLProjectFieldsFetcher(cli, cfg), stdout, stderr)
+}
+
+func checkConfig(ctx context.Context, cfg syncConfig, dir string, fetchFields projectFieldsFetcher, stdout, stderr io.Writer) int {
+	var report consistencyReport
+	forms, err := loadForms(dir, &report)
+	if err != nil {
+		fmt.Fprintf(stderr, "no se pudieron leer los formularios: %v\n", err)
+		return exitFailure
+	}
+	fields, err := fetchFields(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "no se pudieron leer los campos del Project: %v\n", err)
+		code, _ := exitCodeFor(err, 0)
+		return code
+	}
+	tax, taxErr := loadTaxonomy(cfg.TaxonomyPath, os.ReadFile)
+	checkConsistency(forms, fields, tax, taxErr, &report)
+
+	fmt.Fprintf(stdout, "Consistencia: %d formularios, %d campos del Project %s/%d\n", len(forms), len(fields), cfg.Org, cfg.ProjectNum)
+	for _, f := range report.Findings {
+		fmt.Fprintf(stdout, "%-5s  %-11s  %s\n", f.Severity, f.Source, f.Message)
+	}
+	if n := report.errors(); n > 0 {
+		fmt.Fprintf(stdout, "%d errores\n", n)
+		return exitFailure
+	}
+	fmt.Fprintln(stdout, "Sin errores")
+	return exitSuccess
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"eos-roadmap-tools/internal/issueform"
+)
+
+func consistentFields() projectFields {
+	return projectFields{
+		"Status":     append([]string{"Backlog", "Ideas"}, expectedStatusOptions...),
+		"Tipo":       {"Bug", "Feature", "Change Request", "Blank Issue", "Épica"},
+		"Area":       {"Ventas"},
+		"Prioridad":  {"Alta"},
+		"Confidence": {"High", "Medium", "Low"},
+		"Check Luis": {"Aprobado"},
+	}
+}
+
+func findingsFor(report consistencyReport, severity string) []string {
+	var out []string
+	for _, f := range report.Findings {
+		if f.Severity == severity {
+			out = append(out, f.Source+": "+f.Message)
+		}
+	}
+	return out
+}
+
+func TestExpectedStatusOptionsTienenFasePublica(t *testing.T) {
+	for _, option := range expectedStatusOptions {
+		if _, ok := publicPhase(option); !ok {
+			t.Errorf("publicPhase no reconoce %q", option)
+		}
+	}
+}
+
+func TestCheckConsistencySinDesajustes(t *testing.T) {
+	forms := map[string]issueform.Form{
+		"bug_report.yml": {Labels: []string{"Tipo: Bug"}},
+		"feature.yml":    {Labels: []string{"Tipo: Feature"}},
+		"epica.yml":      {Labels: []string{"Tipo: Épica"}},
+	}
+	var report consistencyReport
+	checkConsistency(forms, consistentFields(), defaultTaxonomy(), nil, &report)
+	if got := findingsFor(report, findingError); len(got) != 0 {
+		t.Fatalf("no se esperaban errores: %v", got)
+	}
+	if got := findingsFor(report, findingAviso); len(got) != 0 {
+		t.Fatalf("no se esperaban avisos: %v", got)
+	}
+}
+
+func TestCheckConsistencyDetectaDesajustes(t *testing.T) {
+	fields := consistentFields()
+	fields["Status"] = []string{"En planeación", "Desarrollo", "QA", "Deploy"}
+	fields["Tipo"] = []string{"Bug", "Feature"}
+	fields["Confidence"] = []string{"Alta", "Quizás"}
+	delete(fields, "Area")
+	forms := map[string]issueform.Form{
+		"bug_report.yml":     {Labels: []string{"Tipo: Bug"}},
+		"change_request.yml": {Labels: []string{"Tipo: Change Request"}},
+		"module.yml":         {Labels: []string{"module"}},
+	}
+	tax := defaultTaxonomy()
+	tax.Estados = append(tax.Estados, statusDisplay{Estado: "Congelado", Color: "muted"})
+
+	var report consistencyReport
+	checkConsistency(forms, fields, tax, nil, &report)
+
+	errs := strings.Join(findingsFor(report, findingError), "\n")
+	for _, want := range []string{`Status "QA"`, `change_request.yml usa el Tipo "Change Request"`} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("falta el error %q en:\n%s", want, errs)
+		}
+	}
+	warnings := strings.Join(findingsFor(report, findingAviso), "\n")
+	for _, want := range []string{`"Area"`, `opción "Staging"`, `module.yml no tiene etiqueta`, `Confidence "Quizás"`, `estado "Congelado"`} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("falta el aviso %q en:\n%s", want, warnings)
+		}
+	}
+	info := strings.Join(findingsFor(report, findingInfo), "\n")
+	if !strings.Contains(info, `Tipo "Feature" se publica como feature pero ningún formulario`) || !strings.Contains(info, `change_request.yml (Tipo "Change Request") no se publica`) {
+		t.Errorf("info inesperada:\n%s", info)
+	}
+	if report.errors() != 2 {
+		t.Errorf("errores = %d; se esperaban 2", report.errors())
+	}
+}
+
+func TestCheckConfigLeeLosFormulariosDelRepositorio(t *testing.T) {
+	cfg := syncConfig{Org: "RON-DATADRIVEN", ProjectNum: 3}
+	fetch := func(context.Context) (projectFields, error) { return consistentFields(), nil }
+	var stdout, stderr bytes.Buffer
+	code := checkConfig(context.Background(), cfg, "../../"+defaultFormsDir, fetch, &stdout, &stderr)
+	if code != exitSuccess {
+		t.Fatalf("código = %d; stdout:\n%s\nstderr:\n%s", code, stdout.String(), stderr.String())
+	}
+	if strings.Contains(stdout.String(), "no se pudo interpretar") {
+		t.Fatalf("todos los formularios del repositorio deben poder leerse:\n%s", stdout.String())
+	}
+
+	fetch = func(context.Context) (projectFields, error) {
+		return nil, &authError{err: errors.New("bad credentials")}
+	}
+	if code := checkConfig(context.Background(), cfg, "../../"+defaultFormsDir, fetch, &stdout, &stderr); code != exitAuthFailure {
+		t.Fatalf("una falla de credenciales debe salir con %d, llegó %d", exitAuthFailure, code)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultRunsPerHour coincide con el cron del workflow (cada 5 minutos).
+const defaultRunsPerHour = 12
+
+// defaultHourlyPointBudget es la cuota GraphQL por hora de un PAT. El token
+// del sync la comparte con otras automatizaciones, así que conviene bajarla
+// con GRAPHQL_HOURLY_BUDGET si no es exclusivo.
+const defaultHourlyPointBudget = 5000
+
+// rateLimitInfo es el bloque rateLimit que agregamos a cada consulta para
+// saber cuánto costó sin pedirlo aparte.
+type rateLimitInfo struct {
+	Cost      int
+	Remaining int
+	Limit     int
+}
+
+// queryCost acumula el costo de una forma de consulta durante la corrida.
+type queryCost struct {
+	Consultas int `json:"consultas"`
+	Puntos    int `json:"puntos"`
+}
+
+// graphQLCost resume el costo GraphQL de la corrida en el reporte. Las
+// páginas se descargan en paralelo, por eso registra con un mutex.
+type graphQLCost struct {
+	mu sync.Mutex
+
+	Puntos          int                   `json:"puntos"`
+	PorConsulta     map[string]*queryCost `json:"porConsulta"`
+	Restante        *int                  `json:"restante,omitempty"`
+	CorridasPorHora int                   `json:"corridasPorHora"`
+	ProyeccionHora  int                   `json:"proyeccionHora"`
+	PresupuestoHora int                   `json:"presupuestoHora"`
+}
+
+func newGraphQLCost(runsPerHour, hourlyBudget int) *graphQLCost {
+	return &graphQLCost{
+		PorConsulta:     map[string]*queryCost{},
+		CorridasPorHora: runsPerHour,
+		PresupuestoHora: hourlyBudget,
+	}
+}
+
+// record suma el costo de una consulta. shape agrupa las consultas con la
+// misma forma (items, status_history, ...) aunque cambien sus variables.
+func (c *graphQLCost) record(shape string, rl rateLimitInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.PorConsulta[shape]
+	if entry == nil {
+		entry = &queryCost{}
+		c.PorConsulta[shape] = entry
+	}
+	entry.Consultas++
+	entry.Puntos += rl.Cost
+	c.Puntos += rl.Cost
+	if rl.Limit > 0 && (c.Restante == nil || rl.Remaining < *c.Restante) {
+		remaining := rl.Remaining
+		c.Restante = &remaining
+	}
+}
+
+// checkBudget proyecta el costo por hora con el de esta corrida y advierte si
+// supera el presupuesto. Así un cambio de configuración que encarece la
+// corrida (más historial, más enriquecimiento) se nota antes de agotar la
+// cuota y dejar el roadmap sin actualizar.
+func (c *graphQLCost) checkBudget(report *runReport) {
+	c.mu.Lock()
+	c.ProyeccionHora = c.Puntos * c.CorridasPorHora
+	projected, budget := c.ProyeccionHora, c.PresupuestoHora
+	shapes := make([]string, 0, len(c.PorConsulta))
+	for shape, cost := range c.PorConsulta {
+		shapes = append(shapes, fmt.Sprintf("%s=%d", shape, cost.Puntos))
+	}
+	c.mu.Unlock()
+
+	if budget > 0 && projected > budget {
+		sort.Strings(shapes)
+		report.warn("costo GraphQL proyectado de %d puntos/hora supera el presupuesto de %d (%s)", projected, budget, strings.Join(shapes, ", "))
+	}
+}
+
+type costMeterKey struct{}
+
+// withCostMeter adjunta el contador al contexto para que cada consulta lo
+// alimente sin cambiar la firma de los fetchers.
+func withCostMeter(ctx context.Context, cost *graphQLCost) context.Context {
+	return context.WithValue(ctx, costMeterKey{}, cost)
+}
+
+// recordQueryCost registra el rateLimit de una consulta en el contador del
+// contexto, si lo hay.
+func recordQueryCost(ctx context.Context, shape string, rl rateLimitInfo) {
+	cost, _ := ctx.Value(costMeterKey{}).(*graphQLCost)
+	cost.record(shape, rl)
+}
+
+// positiveIntEnv lee un entero positivo o devuelve fallback si está vacío.
+func positiveIntEnv(getenv func(string) string, key string, fallback int) (int, error) {
+	raw := strings.TrimSpace(getenv(key))
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("%s inválido: %q", key, raw)
+	}
+	return value, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGraphQLCostAcumulaPorForma(t *testing.T) {
+	cost := newGraphQLCost(12, 5000)
+	ctx := withCostMeter(context.Background(), cost)
+	recordQueryCost(ctx, "items", rateLimitInfo{Cost: 3, Remaining: 4990, Limit: 5000})
+	recordQueryCost(ctx, "items", rateLimitInfo{Cost: 3, Remaining: 4987, Limit: 5000})
+	recordQueryCost(ctx, "status_history", rateLimitInfo{Cost: 1, Remaining: 4986, Limit: 5000})
+
+	if cost.Puntos != 7 {
+		t.Fatalf("Puntos = %d, se esperaban 7", cost.Puntos)
+	}
+	if got := *cost.PorConsulta["items"]; got != (queryCost{Consultas: 2, Puntos: 6}) {
+		t.Fatalf("items = %+v", got)
+	}
+	if cost.Restante == nil || *cost.Restante != 4986 {
+		t.Fatalf("Restante = %v, se esperaba el mínimo observado", cost.Restante)
+	}
+
+	// Sin contador en el contexto la consulta no debe fallar.
+	recordQueryCost(context.Background(), "items", rateLimitInfo{Cost: 1})
+}
+
+func TestGraphQLCostAdvierteSobrePresupuesto(t *testing.T) {
+	report := newRunReport(time.Now)
+	cost := newGraphQLCost(12, 100)
+	cost.record("items", rateLimitInfo{Cost: 8})
+	cost.checkBudget(report)
+	if cost.ProyeccionHora != 96 || len(report.Warnings) != 0 {
+		t.Fatalf("96 puntos/hora caben en 100: proyección %d, advertencias %v", cost.ProyeccionHora, report.Warnings)
+	}
+
+	cost.record("status_history", rateLimitInfo{Cost: 1})
+	cost.checkBudget(report)
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "108 puntos/hora") || !strings.Contains(report.Warnings[0], "status_history=1") {
+		t.Fatalf("se esperaba una advertencia de presupuesto, llegaron %v", report.Warnings)
+	}
+}
+
+func TestLoadConfigValidaPresupuestoGraphQL(t *testing.T) {
+	env := map[string]string{"GRAPHQL_HOURLY_BUDGET": "cero"}
+	if _, err := loadConfig(func(k string) string { return env[k] }); err == nil || !strings.Contains(err.Error(), "GRAPHQL_HOURLY_BUDGET") {
+		t.Fatalf("se esperaba error por presupuesto inválido, llegó %v", err)
+	}
+	env = map[string]string{}
+	cfg, err := loadConfig(func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.RunsPerHour != defaultRunsPerHour || cfg.HourlyBudget != defaultHourlyPointBudget {
+		t.Fatalf("valores por defecto inesperados: %+v", cfg)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Endpoints de github.com. En GitHub Actions las variables GITHUB_API_URL y
+// GITHUB_GRAPHQL_URL ya vienen definidas, también en runners de GitHub
+// Enterprise Server, así que el mismo workflow sirve en ambos.
+const (
+	defaultRESTURL    = "https://api.github.com"
+	defaultGraphQLURL = "https://api.github.com/graphql"
+)
+
+// githubEndpoint describe contra qué instancia de GitHub corre el sync.
+type githubEndpoint struct {
+	RESTURL    string
+	GraphQLURL string
+	// CABundle es un PEM con la CA interna de la instancia; se suma a las
+	// del sistema.
+	CABundle string
+}
+
+// loadEndpoint lee GITHUB_API_URL y GITHUB_GRAPHQL_URL. Si solo se define la
+// URL REST de un GHES (https://host/api/v3), la de GraphQL se deduce
+// (https://host/api/graphql), que es lo que casi siempre se olvida.
+func loadEndpoint(getenv func(string) string) (githubEndpoint, error) {
+	ep := githubEndpoint{
+		RESTURL:    strings.TrimRight(strings.TrimSpace(getenv("GITHUB_API_URL")), "/"),
+		GraphQLURL: strings.TrimSpace(getenv("GITHUB_GRAPHQL_URL")),
+		CABundle:   strings.TrimSpace(getenv("GITHUB_CA_BUNDLE")),
+	}
+	if ep.RESTURL == "" {
+		ep.RESTURL = defaultRESTURL
+	}
+	if ep.GraphQLURL == "" {
+		ep.GraphQLURL = defaultGraphQLURL
+		if base, ok := strings.CutSuffix(ep.RESTURL, "/api/v3"); ok {
+			ep.GraphQLURL = base + "/api/graphql"
+		}
+	}
+	for name, raw := range map[string]string{"GITHUB_API_URL": ep.RESTURL, "GITHUB_GRAPHQL_URL": ep.GraphQLURL} {
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Host == "" {
+			return ep, fmt.Errorf("%s inválido: %q", name, raw)
+		}
+		// Poka-yoke: el token viaja en cada consulta; nunca en texto plano.
+		if parsed.Scheme != "https" {
+			return ep, fmt.Errorf("%s debe usar https: %q", name, raw)
+		}
+	}
+	if ep.host(ep.RESTURL) != ep.host(ep.GraphQLURL) {
+		return ep, fmt.Errorf("GITHUB_API_URL (%s) y GITHUB_GRAPHQL_URL (%s) apuntan a hosts distintos", ep.RESTURL, ep.GraphQLURL)
+	}
+	return ep, nil
+}
+
+func (ep githubEndpoint) host(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// Enterprise indica si el sync corre contra una instancia distinta de
+// github.com.
+func (ep githubEndpoint) Enterprise() bool {
+	return ep.host(ep.GraphQLURL) != ep.host(defaultGraphQLURL)
+}
+
+// tokenFor elige el token según el host, con la misma convención que gh:
+// GH_ENTERPRISE_TOKEN para GHES si está definido y GITHUB_TOKEN en los demás
+// casos. Así un runner con tokens de ambas instancias no manda el de
+// github.com al servidor interno.
+func (ep githubEndpoint) tokenFor(getenv func(string) string) string {
+	if ep.Enterprise() {
+		if token := strings.TrimSpace(getenv("GH_ENTERPRISE_TOKEN")); token != "" {
+			return token
+		}
+	}
+	return getenv("GITHUB_TOKEN")
+}
+
+// httpClient arma el cliente autenticado. El token solo se agrega a pedidos
+// hacia el host configurado: si GitHub redirige a otro dominio (por ejemplo
+// un almacenamiento de archivos) el token no sale de la instancia.
+func (ep githubEndpoint) httpClient(token string, readFile func(string) ([]byte, error)) (*http.Client, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	if ep.CABundle != "" {
+		pem, err := readFile(ep.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer GITHUB_CA_BUNDLE: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("GITHUB_CA_BUNDLE %s no contiene certificados PEM", ep.CABundle)
+		}
+		base.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	transport := roundTripperWithToken{token: token, host: ep.host(ep.GraphQLURL), base: base}
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}, nil
+}
+
+type roundTripperWithToken struct {
+	token string
+	host  string
+	base  http.RoundTripper
+}
+
+func (rt roundTripperWithToken) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.EqualFold(req.URL.Host, rt.host) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+rt.token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+	return rt.base.RoundTrip(req)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadEndpoint(t *testing.T) {
+	cases := []struct {
+		name    string
+		env     map[string]string
+		graphQL string
+		wantErr bool
+	}{
+		{"github.com por defecto", nil, defaultGraphQLURL, false},
+		{"GHES deduce GraphQL", map[string]string{"GITHUB_API_URL": "https://ghe.local/api/v3/"}, "https://ghe.local/api/graphql", false},
+		{"GraphQL explícito", map[string]string{"GITHUB_API_URL": "https://ghe.local/api/v3", "GITHUB_GRAPHQL_URL": "https://ghe.local/graphql"}, "https://ghe.local/graphql", false},
+		{"sin https", map[string]string{"GITHUB_API_URL": "http://ghe.local/api/v3"}, "", true},
+		{"hosts distintos", map[string]string{"GITHUB_API_URL": "https://ghe.local/api/v3", "GITHUB_GRAPHQL_URL": "https://otro.local/api/graphql"}, "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ep, err := loadEndpoint(func(k string) string { return tc.env[k] })
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && ep.GraphQLURL != tc.graphQL {
+				t.Fatalf("GraphQLURL = %q, se esperaba %q", ep.GraphQLURL, tc.graphQL)
+			}
+		})
+	}
+}
+
+func TestTokenForEligePorHost(t *testing.T) {
+	env := map[string]string{"GITHUB_TOKEN": "publico", "GH_ENTERPRISE_TOKEN": "interno"}
+	getenv := func(k string) string { return env[k] }
+
+	dotcom, _ := loadEndpoint(func(string) string { return "" })
+	if got := dotcom.tokenFor(getenv); got != "publico" {
+		t.Fatalf("github.com debe usar GITHUB_TOKEN, usó %q", got)
+	}
+	ghes, _ := loadEndpoint(func(k string) string { return map[string]string{"GITHUB_API_URL": "https://ghe.local/api/v3"}[k] })
+	if got := ghes.tokenFor(getenv); got != "interno" {
+		t.Fatalf("GHES debe usar GH_ENTERPRISE_TOKEN, usó %q", got)
+	}
+}
+
+func TestRoundTripperSoloAutenticaElHostConfigurado(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	rt := roundTripperWithToken{token: "secreto", host: "ghe.local", base: http.DefaultTransport}
+	req := httptest.NewRequest(http.MethodGet, server.URL, nil)
+	req.RequestURI = ""
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+	if len(seen) != 1 || seen[0] != "" {
+		t.Fatalf("el token no debe salir hacia otro host, Authorization = %q", seen)
+	}
+}
+
+func TestHTTPClientRechazaCABundleInvalido(t *testing.T) {
+	ep := githubEndpoint{GraphQLURL: defaultGraphQLURL, CABundle: "ca.pem"}
+	_, err := ep.httpClient("t", func(string) ([]byte, error) { return []byte("no es pem"), nil })
+	if err == nil {
+		t.Fatal("un GITHUB_CA_BUNDLE sin certificados debe rechazarse")
+	}
+}
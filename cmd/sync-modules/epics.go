@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// defaultMaxEpicsPerRun limita cuántas épicas crea una corrida. Si algo sale
+// mal (por ejemplo, un Area renombrado en bloque) preferimos unas pocas
+// épicas de más que decenas.
+const defaultMaxEpicsPerRun = 3
+
+// epicTitlePrefix coincide con la plantilla .github/ISSUE_TEMPLATE/epica.yml.
+const epicTitlePrefix = "[EPIC] "
+
+// epicLabel es la etiqueta que la plantilla de épica asigna y que isEpic
+// reconoce.
+const epicLabel = "Tipo: Épica"
+
+// isEpic reconoce una épica por la etiqueta o el campo Tipo, igual que
+// isBug e isFeature.
+func isEpic(labels []string, projectTipo string) bool {
+	for _, value := range append([]string{projectTipo}, labels...) {
+		switch normalizeForType(value) {
+		case "epica", "epic":
+			return true
+		}
+	}
+	return false
+}
+
+// epicChild es un issue del tablero que se lista en el checklist de la épica.
+type epicChild struct {
+	Number int
+	Title  string
+	URL    string
+	Closed bool
+}
+
+// orphanArea es un área con issues en el tablero pero sin épica que la
+// agrupe.
+type orphanArea struct {
+	Area     string
+	Children []epicChild
+}
+
+// findOrphanAreas agrupa los items por Area y devuelve las áreas sin épica,
+// ordenadas para que la corrida sea determinista. Los items sin Area no
+// forman un área.
+func findOrphanAreas(items []Item) []orphanArea {
+	children := map[string][]epicChild{}
+	withEpic := map[string]bool{}
+	for _, it := range items {
+		iss := it.Content.Issue
+		area := strings.TrimSpace(singleName(it.Area.Typename, it.Area.Single.Name))
+		if iss.Number == 0 || area == "" {
+			continue
+		}
+		projectTipo := projectValueToString(it.Tipo.Typename, string(it.Tipo.Single.Name), string(it.Tipo.Text.Text))
+		if isEpic(labelNames(iss.Labels.Nodes), projectTipo) {
+			withEpic[area] = true
+			continue
+		}
+		children[area] = append(children[area], epicChild{
+			Number: iss.Number,
+			Title:  iss.Title,
+			URL:    iss.URL.String(),
+			Closed: iss.State == githubv4.IssueStateClosed,
+		})
+	}
+
+	var out []orphanArea
+	for area, list := range children {
+		if !withEpic[area] {
+			sort.Slice(list, func(i, j int) bool { return list[i].Number < list[j].Number })
+			out = append(out, orphanArea{Area: area, Children: list})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Area < out[j].Area })
+	return out
+}
+
+// epicBody arma el cuerpo de la épica con las mismas secciones que la
+// plantilla y un checklist de los issues del área. Usamos la URL completa
+// porque la épica puede vivir en otro repositorio que sus hijos.
+func epicBody(orphan orphanArea) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Objetivo\nAgrupar el trabajo del área %s en el roadmap.\n\n", orphan.Area)
+	b.WriteString("### Alcance (done criteria)\n")
+	for _, child := range orphan.Children {
+		mark := " "
+		if child.Closed {
+			mark = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s\n", mark, child.URL)
+	}
+	b.WriteString("\n_Épica creada automáticamente por sync-modules porque el área no tenía una en el tablero. Ajusta el objetivo y el alcance._\n")
+	return b.String()
+}
+
+// epicCreator crea la épica de un área y la agrega al tablero con ese Area.
+// Devuelve la URL del issue, o "" si ya existía una con el mismo título.
+type epicCreator func(ctx context.Context, orphan orphanArea) (string, error)
+
+// createOrphanEpics crea hasta max épicas para las áreas huérfanas. Una falla
+// solo genera advertencia: el roadmap se publica igual.
+func createOrphanEpics(ctx context.Context, items []Item, create epicCreator, max int, report *runReport) []string {
+	orphans := findOrphanAreas(items)
+	if len(orphans) > max {
+		report.warn("%d áreas sin épica; esta corrida solo crea %d (EPIC_MAX_PER_RUN)", len(orphans), max)
+		orphans = orphans[:max]
+	}
+	var created []string
+	for _, orphan := range orphans {
+		url, err := create(ctx, orphan)
+		if err != nil {
+			report.warn("no se pudo crear la épica del área %q: %v", orphan.Area, err)
+			continue
+		}
+		if url != "" {
+			created = append(created, url)
+		}
+	}
+	return created
+}
+
+type epicTargetQuery struct {
+	RateLimit  rateLimitInfo `graphql:"rateLimit"`
+	Repository struct {
+		ID    githubv4.ID
+		Label struct {
+			ID githubv4.ID
+		} `graphql:"label(name: $label)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+	Org struct {
+		Project struct {
+			ID    githubv4.ID
+			Field struct {
+				SingleSelect struct {
+					ID      githubv4.ID
+					Options []struct {
+						ID   string
+						Name string
+					}
+				} `graphql:"... on ProjectV2SingleSelectField"`
+			} `graphql:"field(name: \"Area\")"`
+		} `graphql:"projectV2(number: $projectNumber)"`
+	} `graphql:"organization(login: $org)"`
+	Search struct {
+		IssueCount int
+	} `graphql:"search(query: $search, type: ISSUE, first: 1)"`
+}
+
+// graphQLEpicCreator crea la épica en EPIC_REPO con el cliente del sync:
+// busca primero una épica abierta con el mismo título (por si una corrida
+// anterior la creó pero no alcanzó a agregarla al tablero), luego crea el
+// issue, lo agrega al Project y fija su Area.
+func graphQLEpicCreator(cli *githubv4.Client, cfg syncConfig) epicCreator {
+	owner, repo, _ := strings.Cut(cfg.EpicRepo, "/")
+	return func(ctx context.Context, orphan orphanArea) (string, error) {
+		title := epicTitlePrefix + orphan.Area
+		var target epicTargetQuery
+		vars := map[string]interface{}{
+			"owner":         githubv4.String(owner),
+			"repo":          githubv4.String(repo),
+			"label":         githubv4.String(epicLabel),
+			"org":           githubv4.String(cfg.Org),
+			"projectNumber": githubv4.Int(cfg.ProjectNum),
+			"search":        githubv4.String(fmt.Sprintf("repo:%s is:issue is:open in:title %q", cfg.EpicRepo, title)),
+		}
+		if err := cli.Query(ctx, &target, vars); err != nil {
+			return "", classifyGraphQLError(err)
+		}
+		recordQueryCost(ctx, "epic_target", target.RateLimit)
+		if target.Search.IssueCount > 0 {
+			return "", nil
+		}
+		areaField := target.Org.Project.Field.SingleSelect
+		var optionID string
+		for _, option := range areaField.Options {
+			if option.Name == orphan.Area {
+				optionID = option.ID
+			}
+		}
+		if optionID == "" {
+			return "", fmt.Errorf("el campo Area no tiene la opción %q", orphan.Area)
+		}
+
+		var createIssue struct {
+			CreateIssue struct {
+				Issue struct {
+					ID  githubv4.ID
+					URL githubv4.URI
+				}
+			} `graphql:"createIssue(input: $input)"`
+		}
+		input := githubv4.CreateIssueInput{
+			RepositoryID: target.Repository.ID,
+			Title:        githubv4.String(title),
+			Body:         githubv4.NewString(githubv4.String(epicBody(orphan))),
+		}
+		if target.Repository.Label.ID != nil {
+			input.LabelIDs = &[]githubv4.ID{target.Repository.Label.ID}
+		}
+		if err := cli.Mutate(ctx, &createIssue, input, nil); err != nil {
+			return "", classifyGraphQLError(err)
+		}
+		issue := createIssue.CreateIssue.Issue
+
+		var addItem struct {
+			AddProjectV2ItemByID struct {
+				Item struct {
+					ID githubv4.ID
+				}
+			} `graphql:"addProjectV2ItemById(input: $input)"`
+		}
+		addInput := githubv4.AddProjectV2ItemByIdInput{ProjectID: target.Org.Project.ID, ContentID: issue.ID}
+		if err := cli.Mutate(ctx, &addItem, addInput, nil); err != nil {
+			return issue.URL.String(), fmt.Errorf("épica %s creada pero no se agregó al tablero: %w", issue.URL.String(), classifyGraphQLError(err))
+		}
+
+		var setArea struct {
+			UpdateProjectV2ItemFieldValue struct {
+				ProjectV2Item struct {
+					ID githubv4.ID
+				}
+			} `graphql:"updateProjectV2ItemFieldValue(input: $input)"`
+		}
+		option := githubv4.String(optionID)
+		updateInput := githubv4.UpdateProjectV2ItemFieldValueInput{
+			ProjectID: target.Org.Project.ID,
+			ItemID:    addItem.AddProjectV2ItemByID.Item.ID,
+			FieldID:   areaField.ID,
+			Value:     githubv4.ProjectV2FieldValue{SingleSelectOptionID: &option},
+		}
+		if err := cli.Mutate(ctx, &setArea, updateInput, nil); err != nil {
+			return issue.URL.String(), fmt.Errorf("épica %s sin Area en el tablero: %w", issue.URL.String(), classifyGraphQLError(err))
+		}
+		return issue.URL.String(), nil
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func epicItem(number int, area string, state githubv4.IssueState, labels ...string) Item {
+	var it Item
+	it.Content.Issue.Number = number
+	it.Content.Issue.Title = fmt.Sprintf("Issue %d", number)
+	it.Content.Issue.State = state
+	it.Content.Issue.URL = githubv4.URI{URL: &url.URL{Scheme: "https", Host: "github.com", Path: fmt.Sprintf("/o/r/issues/%d", number)}}
+	for _, label := range labels {
+		it.Content.Issue.Labels.Nodes = append(it.Content.Issue.Labels.Nodes, labelNode{Name: label})
+	}
+	if area != "" {
+		it.Area.Typename = "ProjectV2ItemFieldSingleSelectValue"
+		it.Area.Single.Name = githubv4.String(area)
+	}
+	return it
+}
+
+func TestFindOrphanAreas(t *testing.T) {
+	items := []Item{
+		epicItem(3, "Ventas", githubv4.IssueStateOpen),
+		epicItem(1, "Ventas", githubv4.IssueStateClosed),
+		epicItem(2, "Compras", githubv4.IssueStateOpen),
+		epicItem(9, "Compras", githubv4.IssueStateOpen, "Tipo: Épica"),
+		epicItem(4, "", githubv4.IssueStateOpen),
+		epicItem(5, "Logística", githubv4.IssueStateOpen, "bug"),
+	}
+
+	got := findOrphanAreas(items)
+	if len(got) != 2 || got[0].Area != "Logística" || got[1].Area != "Ventas" {
+		t.Fatalf("áreas huérfanas inesperadas: %+v", got)
+	}
+	ventas := got[1]
+	if len(ventas.Children) != 2 || ventas.Children[0].Number != 1 || !ventas.Children[0].Closed {
+		t.Fatalf("hijos de Ventas inesperados: %+v", ventas.Children)
+	}
+
+	body := epicBody(ventas)
+	if !strings.Contains(body, "- [x] https://github.com/o/r/issues/1\n") || !strings.Contains(body, "- [ ] https://github.com/o/r/issues/3\n") {
+		t.Fatalf("el checklist no refleja los hijos:\n%s", body)
+	}
+}
+
+func TestCreateOrphanEpicsRespetaLimiteYAdvierte(t *testing.T) {
+	items := []Item{
+		epicItem(1, "A", githubv4.IssueStateOpen),
+		epicItem(2, "B", githubv4.IssueStateOpen),
+		epicItem(3, "C", githubv4.IssueStateOpen),
+	}
+	var asked []string
+	create := func(_ context.Context, orphan orphanArea) (string, error) {
+		asked = append(asked, orphan.Area)
+		if orphan.Area == "B" {
+			return "", errors.New("sin permisos")
+		}
+		return "https://github.com/o/r/issues/" + orphan.Area, nil
+	}
+
+	report := newRunReport(time.Now)
+	created := createOrphanEpics(context.Background(), items, create, 2, report)
+	if strings.Join(asked, ",") != "A,B" {
+		t.Fatalf("solo se deben intentar las primeras 2 áreas, se intentaron %v", asked)
+	}
+	if len(created) != 1 || created[0] != "https://github.com/o/r/issues/A" {
+		t.Fatalf("épicas creadas = %v", created)
+	}
+	if len(report.Warnings) != 2 {
+		t.Fatalf("se esperaban advertencias por el límite y por la falla, llegaron %v", report.Warnings)
+	}
+}
+
+func TestLoadConfigValidaEpicRepo(t *testing.T) {
+	env := map[string]string{"EPIC_REPO": "solo-nombre"}
+	if _, err := loadConfig(func(k string) string { return env[k] }); err == nil {
+		t.Fatal("EPIC_REPO sin owner debe rechazarse")
+	}
+	env["EPIC_REPO"] = "RON-DATADRIVEN/eos-roadmap"
+	cfg, err := loadConfig(func(k string) string { return env[k] })
+	if err != nil || cfg.MaxEpics != defaultMaxEpicsPerRun {
+		t.Fatalf("loadConfig = %+v, %v", cfg, err)
+	}
+}
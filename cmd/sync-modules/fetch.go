@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// defaultPageSize es el máximo que acepta GitHub para items de un Project.
+const defaultPageSize = 100
+
+// defaultFetchParallelism limita cuántas páginas pedimos a la vez. Cuatro
+// basta para reducir a la mitad el sync del tablero de ~900 items sin acercarse
+// a los límites secundarios de GitHub.
+const defaultFetchParallelism = 4
+
+// pageFetcher trae una página de items a partir de un cursor (nil para la
+// primera). Separarlo del cliente GraphQL permite probar el reparto de
+// páginas sin red.
+type pageFetcher func(ctx context.Context, after *githubv4.String) (page, error)
+
+// offsetCursor describe un cursor de ProjectV2, que GitHub codifica como la
+// posición del item en base64. Guardamos si venía con relleno para generar
+// cursores con el mismo formato.
+type offsetCursor struct {
+	offset int
+	padded bool
+}
+
+func decodeOffsetCursor(cursor githubv4.String) (offsetCursor, bool) {
+	raw := string(cursor)
+	for _, candidate := range []struct {
+		enc    *base64.Encoding
+		padded bool
+	}{{base64.RawStdEncoding, false}, {base64.StdEncoding, true}} {
+		decoded, err := candidate.enc.DecodeString(raw)
+		if err != nil {
+			continue
+		}
+		offset, err := strconv.Atoi(strings.TrimSpace(string(decoded)))
+		if err != nil || offset < 0 {
+			return offsetCursor{}, false
+		}
+		return offsetCursor{offset: offset, padded: candidate.padded}, true
+	}
+	return offsetCursor{}, false
+}
+
+func (c offsetCursor) at(offset int) githubv4.String {
+	enc := base64.RawStdEncoding
+	if c.padded {
+		enc = base64.StdEncoding
+	}
+	return githubv4.String(enc.EncodeToString([]byte(strconv.Itoa(offset))))
+}
+
+// fetchAllPages trae la primera página de forma secuencial para conocer el
+// total y el formato del cursor; después pide el resto en paralelo y las
+// reensambla en orden. Si el cursor no tiene el formato esperado o el tablero
+// cambió durante la descarga, terminamos de forma secuencial: la descarga
+// paralela es una optimización, nunca debe costar items.
+func fetchAllPages(ctx context.Context, fetch pageFetcher, pageSize, parallelism int) ([]Item, error) {
+	first, err := fetch(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	items := append([]Item(nil), first.Nodes...)
+	if !first.PageInfo.HasNextPage {
+		return items, nil
+	}
+
+	cursor, ok := decodeOffsetCursor(first.PageInfo.EndCursor)
+	remaining := first.TotalCount - len(first.Nodes)
+	if !ok || parallelism <= 1 || pageSize <= 0 || remaining <= 0 {
+		return fetchSequential(ctx, fetch, items, first.PageInfo.EndCursor)
+	}
+
+	pageCount := (remaining + pageSize - 1) / pageSize
+	pages, err := fetchPagesConcurrently(ctx, fetch, cursor, pageSize, pageCount, parallelism)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range pages {
+		isLast := i == len(pages)-1
+		if !isLast && (len(p.Nodes) != pageSize || !p.PageInfo.HasNextPage) {
+			log.Printf("el tablero cambió durante la descarga paralela; se continúa de forma secuencial")
+			return fetchSequential(ctx, fetch, items, first.PageInfo.EndCursor)
+		}
+	}
+	for _, p := range pages {
+		items = append(items, p.Nodes...)
+	}
+
+	// Si se agregaron items después de leer el total, la última página todavía
+	// anuncia más resultados: los traemos en orden.
+	last := pages[len(pages)-1]
+	if last.PageInfo.HasNextPage {
+		return fetchSequential(ctx, fetch, items, last.PageInfo.EndCursor)
+	}
+	return items, nil
+}
+
+func fetchPagesConcurrently(ctx context.Context, fetch pageFetcher, cursor offsetCursor, pageSize, pageCount, parallelism int) ([]page, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make([]page, pageCount)
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i := 0; i < pageCount; i++ {
+		after := cursor.at(cursor.offset + i*pageSize)
+		wg.Add(1)
+		go func(i int, after githubv4.String) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			p, err := fetch(ctx, &after)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			pages[i] = p
+		}(i, after)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return pages, nil
+}
+
+func fetchSequential(ctx context.Context, fetch pageFetcher, items []Item, after githubv4.String) ([]Item, error) {
+	for {
+		cursor := after
+		p, err := fetch(ctx, &cursor)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, p.Nodes...)
+		if !p.PageInfo.HasNextPage {
+			return items, nil
+		}
+		after = p.PageInfo.EndCursor
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// fakeBoard simula la paginación de ProjectV2: el cursor es la posición del
+// último item devuelto, codificada en base64.
+type fakeBoard struct {
+	total    int
+	pageSize int
+	cursor   func(int) string
+	delay    func(offset int) time.Duration
+	failAt   int
+
+	mu       sync.Mutex
+	calls    int
+	inFlight int32
+	maxPar   int32
+}
+
+func newFakeBoard(total, pageSize int) *fakeBoard {
+	return &fakeBoard{
+		total:    total,
+		pageSize: pageSize,
+		cursor:   func(n int) string { return base64.StdEncoding.EncodeToString([]byte(strconv.Itoa(n))) },
+		failAt:   -1,
+	}
+}
+
+func (b *fakeBoard) fetch(ctx context.Context, after *githubv4.String) (page, error) {
+	cur := atomic.AddInt32(&b.inFlight, 1)
+	defer atomic.AddInt32(&b.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&b.maxPar)
+		if cur <= max || atomic.CompareAndSwapInt32(&b.maxPar, max, cur) {
+			break
+		}
+	}
+	b.mu.Lock()
+	b.calls++
+	b.mu.Unlock()
+
+	start := 0
+	if after != nil {
+		offset, ok := decodeOffsetCursor(*after)
+		if !ok {
+			n := 0
+			for n < b.total && b.cursor(n) != string(*after) {
+				n++
+			}
+			offset.offset = n
+		}
+		start = offset.offset
+	}
+	if start == b.failAt {
+		return page{}, errors.New("falla simulada")
+	}
+	if b.delay != nil {
+		select {
+		case <-time.After(b.delay(start)):
+		case <-ctx.Done():
+			return page{}, ctx.Err()
+		}
+	}
+
+	var p page
+	p.TotalCount = b.total
+	end := start + b.pageSize
+	if end > b.total {
+		end = b.total
+	}
+	for n := start; n < end; n++ {
+		var item Item
+		item.Content.Issue.Number = n + 1
+		p.Nodes = append(p.Nodes, item)
+	}
+	p.PageInfo.HasNextPage = end < b.total
+	p.PageInfo.EndCursor = githubv4.String(b.cursor(end))
+	return p, nil
+}
+
+func assertSequentialNumbers(t *testing.T, items []Item, total int) {
+	t.Helper()
+	if len(items) != total {
+		t.Fatalf("se esperaban %d items, llegaron %d", total, len(items))
+	}
+	for i, item := range items {
+		if item.Content.Issue.Number != i+1 {
+			t.Fatalf("item %d fuera de orden: #%d", i, item.Content.Issue.Number)
+		}
+	}
+}
+
+func TestFetchAllPagesReensamblaEnOrden(t *testing.T) {
+	board := newFakeBoard(950, 100)
+	// Las primeras páginas tardan más para que terminen después que las últimas.
+	board.delay = func(offset int) time.Duration { return time.Duration(1000-offset) * time.Microsecond * 10 }
+
+	items, err := fetchAllPages(context.Background(), board.fetch, 100, 4)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	assertSequentialNumbers(t, items, 950)
+	if board.calls != 10 {
+		t.Fatalf("se esperaban 10 consultas, hubo %d", board.calls)
+	}
+	if board.maxPar < 2 || board.maxPar > 4 {
+		t.Fatalf("paralelismo fuera de rango: %d", board.maxPar)
+	}
+}
+
+func TestFetchAllPagesCursorOpacoEsSecuencial(t *testing.T) {
+	board := newFakeBoard(250, 100)
+	board.cursor = func(n int) string { return "Y3Vyc29yOnYy:" + strconv.Itoa(n) }
+
+	items, err := fetchAllPages(context.Background(), board.fetch, 100, 4)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	assertSequentialNumbers(t, items, 250)
+	if board.maxPar != 1 {
+		t.Fatalf("con cursor opaco no debe haber consultas en paralelo, hubo %d", board.maxPar)
+	}
+}
+
+func TestFetchAllPagesTotalCrecienteTraeLoNuevo(t *testing.T) {
+	board := newFakeBoard(250, 100)
+	first := true
+	fetch := func(ctx context.Context, after *githubv4.String) (page, error) {
+		p, err := board.fetch(ctx, after)
+		if first {
+			// El total se leyó antes de que llegaran 60 items nuevos.
+			first = false
+			board.mu.Lock()
+			board.total = 310
+			board.mu.Unlock()
+			p.TotalCount = 250
+		}
+		return p, err
+	}
+
+	items, err := fetchAllPages(context.Background(), fetch, 100, 1)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	assertSequentialNumbers(t, items, 310)
+
+	board = newFakeBoard(250, 100)
+	first = true
+	items, err = fetchAllPages(context.Background(), fetch, 100, 4)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	assertSequentialNumbers(t, items, 310)
+}
+
+func TestFetchAllPagesPropagaError(t *testing.T) {
+	board := newFakeBoard(500, 100)
+	board.failAt = 300
+
+	if _, err := fetchAllPages(context.Background(), board.fetch, 100, 4); err == nil {
+		t.Fatalf("se esperaba el error de la página fallida")
+	}
+}
+
+func TestLoadConfigParallelism(t *testing.T) {
+	env := map[string]string{"ORG": "acme", "PROJECT_NUMBER": "1", "SYNC_PR_TOKEN": "x"}
+	getenv := func(k string) string { return env[k] }
+
+	cfg, err := loadConfig(getenv)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if cfg.Parallelism != defaultFetchParallelism {
+		t.Fatalf("paralelismo por defecto = %d", cfg.Parallelism)
+	}
+
+	env["SYNC_PARALLELISM"] = "0"
+	if _, err := loadConfig(getenv); err == nil {
+		t.Fatalf("SYNC_PARALLELISM=0 debe rechazarse")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Prefijos de las claves de traducción. Son parte del contrato con el
+// frontend: cambiarlos rompe las traducciones ya escritas.
+const (
+	keyPrefixStatus     = "status."
+	keyPrefixPhase      = "phase."
+	keyPrefixType       = "type."
+	keyPrefixArea       = "area."
+	keyPrefixConfidence = "confidence."
+)
+
+// statusKeyPattern valida las claves de estado que vienen de TAXONOMY_PATH.
+var statusKeyPattern = regexp.MustCompile(`^status\.[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// slugify deriva un identificador estable (minúsculas, ASCII y guiones) de
+// un texto visible. Lo usan los nombres de las insignias y las claves de
+// traducción, así que cambiarlo renombra ambos.
+func slugify(text string) string {
+	val := strings.NewReplacer("ñ", "n", "ü", "u").Replace(normalizeText(text))
+	var b strings.Builder
+	dash := false
+	for _, r := range val {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		slug = "sin-nombre"
+	}
+	return slug
+}
+
+// moduleKeys son las claves estables de los campos tipo enumeración de un
+// módulo. El texto visible sigue en español en el propio módulo; el frontend
+// usa estas claves para traducir sin comparar etiquetas.
+type moduleKeys struct {
+	Fase      string `json:"fase,omitempty"`
+	Estado    string `json:"estado,omitempty"`
+	Tipo      string `json:"tipo,omitempty"`
+	Area      string `json:"area,omitempty"`
+	Confianza string `json:"confianza,omitempty"`
+}
+
+// statusKey devuelve la clave del estado: la de la taxonomía si la define o
+// una derivada del nombre.
+func (t taxonomy) statusKey(estado string) string {
+	for _, display := range t.Estados {
+		if display.Estado == estado && display.Clave != "" {
+			return display.Clave
+		}
+	}
+	return keyPrefixStatus + slugify(estado)
+}
+
+// validateKeys exige claves bien formadas y únicas, para que dos estados no
+// terminen con la misma traducción.
+func (t taxonomy) validateKeys() error {
+	seen := map[string]string{}
+	for _, display := range t.Estados {
+		key := t.statusKey(display.Estado)
+		if display.Clave != "" && !statusKeyPattern.MatchString(display.Clave) {
+			return fmt.Errorf("estado %q con clave inválida %q (se espera status.nombre-en-minusculas)", display.Estado, display.Clave)
+		}
+		if other, dup := seen[key]; dup {
+			return fmt.Errorf("los estados %q y %q comparten la clave %q", other, display.Estado, key)
+		}
+		seen[key] = display.Estado
+	}
+	return nil
+}
+
+// withMachineKeys agrega las claves a los módulos ya filtrados por
+// visibilidad: un campo oculto no debe reaparecer como clave.
+func withMachineKeys(modules []ModuleOut, tax taxonomy) []ModuleOut {
+	out := make([]ModuleOut, len(modules))
+	for i, m := range modules {
+		keys := moduleKeys{Estado: tax.statusKey(m.Estado)}
+		if m.Fase != "" {
+			keys.Fase = keyPrefixPhase + slugify(m.Fase)
+		}
+		if m.Tipo != "" {
+			keys.Tipo = keyPrefixType + m.Tipo
+		}
+		if strings.TrimSpace(m.Area) != "" {
+			keys.Area = keyPrefixArea + slugify(m.Area)
+		}
+		if m.Confianza != "" {
+			keys.Confianza = keyPrefixConfidence + slugify(m.Confianza)
+		}
+		m.Claves = &keys
+		out[i] = m
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithMachineKeys(t *testing.T) {
+	tax := defaultTaxonomy()
+	tax.Estados[0].Clave = "status.bug-reported"
+	modules := withMachineKeys([]ModuleOut{
+		{Fase: "Desarrollo", Estado: "En validación", Tipo: "feature", Area: "Logística y Ñandú", Confianza: confianzaMedia},
+		{Fase: "Reportados", Estado: "Reportado", Tipo: "bug"},
+	}, tax)
+
+	got := *modules[0].Claves
+	want := moduleKeys{Fase: "phase.desarrollo", Estado: "status.en-validacion", Tipo: "type.feature", Area: "area.logistica-y-nandu", Confianza: "confidence.media"}
+	if got != want {
+		t.Fatalf("claves = %+v, se esperaba %+v", got, want)
+	}
+	if modules[1].Claves.Estado != "status.bug-reported" || modules[1].Claves.Area != "" {
+		t.Fatalf("la clave de la taxonomía debe tener prioridad y un área vacía no lleva clave: %+v", modules[1].Claves)
+	}
+}
+
+func TestWithMachineKeysRespetaVisibilidad(t *testing.T) {
+	vis := visibility{Internos: []string{"area"}}
+	modules := withMachineKeys(publicModules([]ModuleOut{{Estado: "Liberado", Tipo: "feature", Area: "Ventas"}}, vis), defaultTaxonomy())
+	if modules[0].Claves.Area != "" {
+		t.Fatalf("un campo oculto no debe reaparecer como clave: %+v", modules[0].Claves)
+	}
+}
+
+func TestTaxonomyValidaClaves(t *testing.T) {
+	tax := defaultTaxonomy()
+	tax.Estados[0].Clave = "Status.Reportado"
+	if err := tax.validate(); err == nil || !strings.Contains(err.Error(), "clave inválida") {
+		t.Fatalf("una clave mal formada debe rechazarse: %v", err)
+	}
+	tax = defaultTaxonomy()
+	tax.Estados[0].Clave = "status.resuelto"
+	if err := tax.validate(); err == nil || !strings.Contains(err.Error(), "comparten la clave") {
+		t.Fatalf("dos estados con la misma clave deben rechazarse: %v", err)
+	}
+	for _, entry := range defaultTaxonomy().leyenda() {
+		if entry.Clave == "" {
+			t.Fatalf("la leyenda publicada debe llevar la clave de %q", entry.Estado)
+		}
+	}
+}
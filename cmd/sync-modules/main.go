@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -63,12 +65,14 @@ func toISO(d GHFlexDate) string { return d.ISODate() }
 type Item struct {
 	Content struct {
 		Issue struct {
-			Number int
-			Title  string
-			URL    githubv4.URI
-			Body   string
-			State  githubv4.IssueState
-			Labels struct {
+			Number    int
+			Title     string
+			URL       githubv4.URI
+			Body      string
+			State     githubv4.IssueState
+			CreatedAt GHFlexDate
+			ClosedAt  GHFlexDate
+			Labels    struct {
 				Nodes []labelNode
 			} `graphql:"labels(first: 20)"`
 			Assignees struct {
@@ -95,6 +99,21 @@ type Item struct {
 		} `graphql:"... on ProjectV2ItemFieldTextValue"`
 	} `graphql:"tipo: fieldValueByName(name:\"Tipo\")"`
 
+	Area struct {
+		Typename githubv4.String                `graphql:"__typename"`
+		Single   struct{ Name githubv4.String } `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	} `graphql:"area: fieldValueByName(name:\"Area\")"`
+
+	Prioridad struct {
+		Typename githubv4.String                `graphql:"__typename"`
+		Single   struct{ Name githubv4.String } `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	} `graphql:"prioridad: fieldValueByName(name:\"Prioridad\")"`
+
+	Confianza struct {
+		Typename githubv4.String                `graphql:"__typename"`
+		Single   struct{ Name githubv4.String } `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	} `graphql:"confianza: fieldValueByName(name:\"Confidence\")"`
+
 	Start struct {
 		Typename githubv4.String `graphql:"__typename"`
 		DateVal  struct {
@@ -111,22 +130,27 @@ type Item struct {
 }
 
 type page struct {
-	Nodes    []Item
-	PageInfo struct {
+	TotalCount int
+	Nodes      []Item
+	PageInfo   struct {
 		HasNextPage bool
 		EndCursor   githubv4.String
 	}
 }
 
 type Query struct {
-	Org struct {
+	RateLimit rateLimitInfo `graphql:"rateLimit"`
+	Org       struct {
 		Project struct {
 			Items page `graphql:"items(first: $first, after: $after)"`
 		} `graphql:"projectV2(number: $projectNumber)"`
 	} `graphql:"organization(login: $org)"`
 }
 
-type assigneeNode struct{ Login string }
+type assigneeNode struct {
+	Login string
+	Name  string
+}
 type labelNode struct{ Name string }
 
 type ModuleOut struct {
@@ -139,14 +163,43 @@ type ModuleOut struct {
 	Propietario string    `json:"propietario,omitempty"`
 	Inicio      string    `json:"inicio,omitempty"`
 	ETA         string    `json:"eta,omitempty"`
+	Confianza   string    `json:"confianza,omitempty"`
 	Enlaces     []LinkOut `json:"enlaces,omitempty"`
 	Tipo        string    `json:"tipo"`
+	Area        string    `json:"area,omitempty"`
+	Retirado    string    `json:"retirado,omitempty"`
+
+	// Claves lleva las claves de traducción de los campos anteriores.
+	Claves *moduleKeys `json:"claves,omitempty"`
+
+	// Ajustes lista los campos que vienen de OVERRIDES_PATH y no del issue.
+	Ajustes []string `json:"ajustes,omitempty"`
+
+	// Campos internos: defaultVisibility los quita de docs/modules.json.
+	Responsables string `json:"responsables,omitempty"`
+	Prioridad    string `json:"prioridad,omitempty"`
+
+	LeadTimeDays  *float64 `json:"leadTimeDays,omitempty"`
+	CycleTimeDays *float64 `json:"cycleTimeDays,omitempty"`
 }
 
 type MetadataOut struct {
-	GeneratedAt string `json:"generatedAt"`
-	Source      string `json:"source"`
-	ItemCount   int    `json:"itemCount"`
+	GeneratedAt string          `json:"generatedAt"`
+	Source      string          `json:"source"`
+	ItemCount   int             `json:"itemCount"`
+	Leyenda     []statusDisplay `json:"leyenda,omitempty"`
+	Metricas    []areaMetrics   `json:"metricas,omitempty"`
+
+	Confianza *confidenceSummary `json:"confianza,omitempty"`
+
+	Actualizacion *statusUpdateOut `json:"actualizacion,omitempty"`
+}
+
+// metadataExtras son los datos de modules-meta.json que no se derivan de los
+// módulos. Un cambio en cualquiera de ellos basta para reescribir la metadata.
+type metadataExtras struct {
+	Leyenda       []statusDisplay
+	Actualizacion *statusUpdateOut
 }
 
 type LinkOut struct {
@@ -343,6 +396,23 @@ func buildOwner(nodes []assigneeNode) string {
 	return strings.Join(owners, ", ")
 }
 
+// buildResponsables lista los nombres reales de las personas asignadas, o su
+// login si no configuraron nombre. Es un dato interno: no se publica salvo que
+// la configuración de visibilidad lo permita.
+func buildResponsables(nodes []assigneeNode) string {
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		name := strings.TrimSpace(n.Name)
+		if name == "" {
+			name = strings.TrimSpace(n.Login)
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
 func buildLinks(url string) []LinkOut {
 	url = strings.TrimSpace(url)
 	if url == "" {
@@ -363,111 +433,404 @@ func labelNames(nodes []labelNode) []string {
 
 func main() {
 	log.SetFlags(0)
-	org := os.Getenv("ORG")
-	if org == "" {
-		org = "RON-DATADRIVEN"
+	if len(os.Args) > 1 && os.Args[1] == "check-config" {
+		os.Exit(runConfigCheck(os.Args[2:], os.Getenv, os.Stdout, os.Stderr))
 	}
-	projectStr := os.Getenv("PROJECT_NUMBER")
+	os.Exit(run(os.Getenv, time.Now))
+}
+
+// syncConfig reúne la configuración leída del entorno. Tenerla en una sola
+// estructura permite validar todo antes de llamar a GitHub.
+type syncConfig struct {
+	Org          string
+	ProjectNum   int
+	OutPath      string
+	MetaOutPath  string
+	ReportPath   string
+	TaxonomyPath string
+	Token        string
+	Parallelism  int
+
+	// Endpoint apunta a github.com o a una instancia de GitHub Enterprise
+	// Server.
+	Endpoint githubEndpoint
+
+	VisibilityPath  string
+	InternalOutPath string
+
+	// OverridesPath apunta a las correcciones editoriales de las PM; vacío
+	// las desactiva.
+	OverridesPath string
+
+	// RisksOutPath recibe el registro de riesgos de las épicas; vacío lo
+	// desactiva. Es interno como InternalOutPath.
+	RisksOutPath string
+
+	// MiniOutPath recibe la versión compacta de la vista pública para
+	// widgets; vacío la desactiva.
+	MiniOutPath string
+
+	// QuartersOutPath recibe la proyección por trimestre; vacío la
+	// desactiva.
+	QuartersOutPath string
+
+	// BadgesDir recibe las insignias de avance por área; vacío las
+	// desactiva.
+	BadgesDir string
+
+	// RunsPerHour y HourlyBudget proyectan el costo GraphQL por hora.
+	RunsPerHour  int
+	HourlyBudget int
+
+	// EpicRepo ("owner/nombre") activa la creación de épicas para áreas sin
+	// una; vacío la desactiva.
+	EpicRepo string
+	MaxEpics int
+}
+
+func loadConfig(getenv func(string) string) (syncConfig, error) {
+	cfg := syncConfig{
+		Org:          getenv("ORG"),
+		OutPath:      getenv("OUTPUT"),
+		MetaOutPath:  getenv("META_OUTPUT"),
+		ReportPath:   strings.TrimSpace(getenv("RUN_REPORT")),
+		TaxonomyPath: strings.TrimSpace(getenv("TAXONOMY_PATH")),
+
+		VisibilityPath:  strings.TrimSpace(getenv("VISIBILITY_PATH")),
+		InternalOutPath: strings.TrimSpace(getenv("INTERNAL_OUTPUT")),
+		OverridesPath:   strings.TrimSpace(getenv("OVERRIDES_PATH")),
+		RisksOutPath:    strings.TrimSpace(getenv("RISKS_OUTPUT")),
+		MiniOutPath:     strings.TrimSpace(getenv("MINI_OUTPUT")),
+		QuartersOutPath: strings.TrimSpace(getenv("QUARTERS_OUTPUT")),
+		BadgesDir:       strings.TrimSpace(getenv("BADGES_DIR")),
+	}
+	if cfg.Org == "" {
+		cfg.Org = "RON-DATADRIVEN"
+	}
+	projectStr := getenv("PROJECT_NUMBER")
 	if projectStr == "" {
 		projectStr = "3"
 	}
 	projectNum, err := strconv.Atoi(projectStr)
 	if err != nil {
-		log.Fatalf("PROJECT_NUMBER inválido: %v", err)
+		return cfg, fmt.Errorf("PROJECT_NUMBER inválido: %v", err)
+	}
+	cfg.ProjectNum = projectNum
+	if cfg.Endpoint, err = loadEndpoint(getenv); err != nil {
+		return cfg, err
+	}
+	cfg.Token = cfg.Endpoint.tokenFor(getenv)
+	if cfg.Parallelism, err = positiveIntEnv(getenv, "SYNC_PARALLELISM", defaultFetchParallelism); err != nil {
+		return cfg, err
 	}
-	outPath := os.Getenv("OUTPUT")
-	if outPath == "" {
-		outPath = "docs/modules.json"
+	if cfg.RunsPerHour, err = positiveIntEnv(getenv, "SYNC_RUNS_PER_HOUR", defaultRunsPerHour); err != nil {
+		return cfg, err
 	}
-	metaOutPath := os.Getenv("META_OUTPUT")
-	if metaOutPath == "" {
-		metaOutPath = "docs/modules-meta.json"
+	if cfg.HourlyBudget, err = positiveIntEnv(getenv, "GRAPHQL_HOURLY_BUDGET", defaultHourlyPointBudget); err != nil {
+		return cfg, err
 	}
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("GITHUB_TOKEN no está definido")
+	cfg.EpicRepo = strings.TrimSpace(getenv("EPIC_REPO"))
+	if owner, repo, ok := strings.Cut(cfg.EpicRepo, "/"); cfg.EpicRepo != "" && (!ok || owner == "" || repo == "") {
+		return cfg, fmt.Errorf("EPIC_REPO inválido: %q (se espera owner/nombre)", cfg.EpicRepo)
+	}
+	if cfg.MaxEpics, err = positiveIntEnv(getenv, "EPIC_MAX_PER_RUN", defaultMaxEpicsPerRun); err != nil {
+		return cfg, err
+	}
+	if cfg.OutPath == "" {
+		cfg.OutPath = "docs/modules.json"
+	}
+	if cfg.MetaOutPath == "" {
+		cfg.MetaOutPath = "docs/modules-meta.json"
+	}
+	// La salida interna no puede caer junto a la pública: todo lo que está en
+	// ese directorio se publica en Pages.
+	if cfg.InternalOutPath != "" && filepath.Clean(dirOf(cfg.InternalOutPath)) == filepath.Clean(dirOf(cfg.OutPath)) {
+		return cfg, fmt.Errorf("INTERNAL_OUTPUT %s no puede estar en el mismo directorio público que %s", cfg.InternalOutPath, cfg.OutPath)
+	}
+	if cfg.RisksOutPath != "" && filepath.Clean(dirOf(cfg.RisksOutPath)) == filepath.Clean(dirOf(cfg.OutPath)) {
+		return cfg, fmt.Errorf("RISKS_OUTPUT %s no puede estar en el mismo directorio público que %s", cfg.RisksOutPath, cfg.OutPath)
+	}
+	// writeBadges borra los .json que no reconoce en BADGES_DIR; si
+	// compartiera directorio con las salidas, borraría modules.json.
+	if cfg.BadgesDir != "" {
+		badges := filepath.Clean(cfg.BadgesDir)
+		for _, out := range []string{cfg.OutPath, cfg.MetaOutPath, cfg.InternalOutPath, cfg.RisksOutPath, cfg.MiniOutPath, cfg.QuartersOutPath} {
+			if out != "" && filepath.Clean(dirOf(out)) == badges {
+				return cfg, fmt.Errorf("BADGES_DIR %s no puede contener %s; usa un directorio propio", cfg.BadgesDir, out)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// run ejecuta el sync completo y devuelve el código de salida. Siempre intenta
+// escribir el reporte, incluso si una fase falla, porque es justo en esos
+// casos cuando el scheduler más lo necesita.
+func run(getenv func(string) string, now func() time.Time) int {
+	report := newRunReport(now)
+	cfg, err := loadConfig(getenv)
+	if err != nil {
+		return finishRun(cfg, report, now, err)
+	}
+	tax, err := loadTaxonomy(cfg.TaxonomyPath, os.ReadFile)
+	if err != nil {
+		return finishRun(cfg, report, now, err)
+	}
+	vis, err := loadVisibility(cfg.VisibilityPath, os.ReadFile)
+	if err != nil {
+		return finishRun(cfg, report, now, err)
+	}
+	ov, err := loadOverrides(cfg.OverridesPath, os.ReadFile)
+	if err != nil {
+		return finishRun(cfg, report, now, err)
+	}
+	if cfg.Token == "" {
+		return finishRun(cfg, report, now, &authError{err: errors.New("GITHUB_TOKEN no está definido")})
+	}
+
+	httpClient, err := cfg.Endpoint.httpClient(cfg.Token, os.ReadFile)
+	if err != nil {
+		return finishRun(cfg, report, now, err)
+	}
+	cli := githubv4.NewEnterpriseClient(cfg.Endpoint.GraphQLURL, httpClient)
+	report.GraphQLCost = newGraphQLCost(cfg.RunsPerHour, cfg.HourlyBudget)
+	ctx := withCostMeter(context.Background(), report.GraphQLCost)
+
+	var items []Item
+	err = report.phase("fetch", now, func() error {
+		var fetchErr error
+		items, fetchErr = fetchProjectItems(ctx, cli, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		return finishRun(cfg, report, now, err)
+	}
+	report.ItemsProcessed = len(items)
+
+	if cfg.EpicRepo != "" {
+		_ = report.phase("epics", now, func() error {
+			report.EpicsCreated = createOrphanEpics(ctx, items, graphQLEpicCreator(cli, cfg), cfg.MaxEpics, report)
+			return nil
+		})
 	}
 
-	httpClient := &http.Client{Transport: roundTripperWithToken{token: token}, Timeout: 30 * time.Second}
-	cli := githubv4.NewClient(httpClient)
-	first := githubv4.Int(100)
-	var after *githubv4.String
 	var all []ModuleOut
+	_ = report.phase("build", now, func() error {
+		all = buildModules(items, report)
+		return nil
+	})
+	previous, previousErr := readPreviousModules(cfg.OutPath)
+	if previousErr != nil {
+		report.warn("no se pudo leer la corrida anterior: %v; no se detectan módulos retirados", previousErr)
+	}
+	_ = report.phase("metrics", now, func() error {
+		computeFlowMetrics(ctx, all, items, previous, graphQLStatusHistoryLookup(cli, cfg.ProjectNum), report)
+		return nil
+	})
+	_ = report.phase("retire", now, func() error {
+		if previousErr != nil {
+			return nil
+		}
+		today := now().UTC().Format("2006-01-02")
+		retired := retainRemovedModules(ctx, previous, boardIssueIDs(items), graphQLIssueStateLookup(cli), today, report)
+		report.ModulesRetired = len(retired)
+		all = append(all, retired...)
+		return nil
+	})
+	all = applyOverrides(all, ov, report)
+	report.ModulesPublished = len(all)
+
+	var update *statusUpdateOut
+	_ = report.phase("status_update", now, func() error {
+		var fetchErr error
+		update, fetchErr = graphQLStatusUpdateFetcher(cli, cfg)(ctx)
+		if fetchErr != nil {
+			report.warn("no se pudo consultar la actualización de estado del proyecto: %v; se conserva la publicada", fetchErr)
+			update = publishedStatusUpdate(cfg.MetaOutPath)
+		}
+		return nil
+	})
+	report.GraphQLCost.checkBudget(report)
+
+	var changed bool
+	err = report.phase("write", now, func() error {
+		out, stageErr := newOutputStage()
+		if stageErr != nil {
+			return stageErr
+		}
+		defer out.discard()
+		if cfg.InternalOutPath != "" {
+			if writeErr := writeInternalOutput(out, cfg.InternalOutPath, all); writeErr != nil {
+				return writeErr
+			}
+		}
+		if cfg.RisksOutPath != "" {
+			if writeErr := writeRiskRegister(out, cfg.RisksOutPath, buildRiskRegister(items, report)); writeErr != nil {
+				return writeErr
+			}
+		}
+		public := withMachineKeys(withoutHidden(publicModules(all, vis), ov), tax)
+		var mini []byte
+		if cfg.MiniOutPath != "" {
+			var prepErr error
+			if mini, prepErr = prepareMiniOutput(public); prepErr != nil {
+				return fmt.Errorf("preparar %s: %w", cfg.MiniOutPath, prepErr)
+			}
+		}
+		var writeErr error
+		changed, writeErr = writeOutputsIfModulesChanged(out, cfg.OutPath, cfg.MetaOutPath, public, metadataExtras{Leyenda: tax.leyenda(), Actualizacion: update}, now)
+		if writeErr != nil {
+			return writeErr
+		}
+		if mini != nil {
+			miniChanged, writeErr := writeMiniOutput(out, cfg.MiniOutPath, mini)
+			if writeErr != nil {
+				return writeErr
+			}
+			changed = changed || miniChanged
+		}
+		if cfg.QuartersOutPath != "" {
+			previousQuarters, readErr := readPreviousQuarters(cfg.QuartersOutPath)
+			if readErr != nil {
+				report.warn("no se pudo leer %s: %v; no se detectan arrastres", cfg.QuartersOutPath, readErr)
+			}
+			quartersChanged, writeErr := writeQuarters(out, cfg.QuartersOutPath, buildQuarters(public, previousQuarters, now()))
+			if writeErr != nil {
+				return writeErr
+			}
+			changed = changed || quartersChanged
+		}
+		if cfg.BadgesDir != "" {
+			badgesChanged, writeErr := writeBadges(out, cfg.BadgesDir, buildAreaProgress(public))
+			if writeErr != nil {
+				return writeErr
+			}
+			changed = changed || badgesChanged
+		}
+		// Nada llega a docs/ hasta que el conjunto completo es coherente.
+		if writeErr := out.validate(cfg); writeErr != nil {
+			return writeErr
+		}
+		return out.publish()
+	})
+	if err != nil {
+		return finishRun(cfg, report, now, err)
+	}
+	report.Changed = changed
+
+	if !changed {
+		log.Printf("OK: %s sin cambios; no se actualiza %s", cfg.OutPath, cfg.MetaOutPath)
+	} else {
+		log.Printf("OK: escrito %s y %s con %d elementos públicos", cfg.OutPath, cfg.MetaOutPath, len(all))
+	}
+	return finishRun(cfg, report, now, nil)
+}
 
-	for {
+// fetchProjectItems recorre todas las páginas de items del proyecto. Los
+// errores de GraphQL se envuelven para distinguir credenciales inválidas de
+// fallas del API en el código de salida.
+func fetchProjectItems(ctx context.Context, cli *githubv4.Client, cfg syncConfig) ([]Item, error) {
+	fetch := func(ctx context.Context, after *githubv4.String) (page, error) {
 		var q Query
 		vars := map[string]interface{}{
-			"org":           githubv4.String(org),
-			"projectNumber": githubv4.Int(projectNum),
-			"first":         first,
+			"org":           githubv4.String(cfg.Org),
+			"projectNumber": githubv4.Int(cfg.ProjectNum),
+			"first":         githubv4.Int(defaultPageSize),
 			"after":         after,
 		}
-		if err := cli.Query(context.Background(), &q, vars); err != nil {
-			log.Fatalf("GraphQL: %v", err)
+		if err := cli.Query(ctx, &q, vars); err != nil {
+			return page{}, classifyGraphQLError(err)
 		}
-		for _, it := range q.Org.Project.Items.Nodes {
-			iss := it.Content.Issue
-			if iss.Number == 0 {
-				continue
-			}
-			labels := labelNames(iss.Labels.Nodes)
-			projectTipo := projectValueToString(it.Tipo.Typename, string(it.Tipo.Single.Name), string(it.Tipo.Text.Text))
-			rawStatus := singleName(it.Status.Typename, it.Status.Single.Name)
-			checkLuis := singleName(it.CheckLuis.Typename, it.CheckLuis.Single.Name)
-			phase, phaseOK := publicPhase(rawStatus)
-			if !phaseOK {
-				continue
-			}
+		recordQueryCost(ctx, "items", q.RateLimit)
+		return q.Org.Project.Items, nil
+	}
+	return fetchAllPages(ctx, fetch, defaultPageSize, cfg.Parallelism)
+}
 
-			tipo := ""
-			estado := ""
-			porcentajeBase := 0
-			if isBug(labels, projectTipo) {
-				tipo = "bug"
-				estado, porcentajeBase = publicBugStatus(phase, iss.State)
-			} else if isFeature(labels, projectTipo) && isLuisApproved(checkLuis) {
-				if publicStatus, baseline, ok := publicFeatureStatus(phase); ok {
-					tipo = "feature"
-					estado = publicStatus
-					porcentajeBase = baseline
-				}
+// knownPrivateStatuses son estados del proyecto que existen a propósito fuera
+// de la vista pública. No generan advertencias; cualquier otro estado sin
+// mapeo sí, porque suele indicar que alguien renombró una columna del tablero.
+var knownPrivateStatuses = map[string]struct{}{
+	"":        {},
+	"ideas":   {},
+	"backlog": {},
+	"todo":    {},
+}
+
+// buildModules transforma los items del proyecto en módulos públicos y anota
+// en el reporte cualquier anomalía que no impide publicar.
+func buildModules(items []Item, report *runReport) []ModuleOut {
+	var all []ModuleOut
+	for _, it := range items {
+		iss := it.Content.Issue
+		if iss.Number == 0 {
+			continue
+		}
+		labels := labelNames(iss.Labels.Nodes)
+		projectTipo := projectValueToString(it.Tipo.Typename, string(it.Tipo.Single.Name), string(it.Tipo.Text.Text))
+		rawStatus := singleName(it.Status.Typename, it.Status.Single.Name)
+		checkLuis := singleName(it.CheckLuis.Typename, it.CheckLuis.Single.Name)
+		phase, phaseOK := publicPhase(rawStatus)
+		if !phaseOK {
+			if _, known := knownPrivateStatuses[normalizeText(rawStatus)]; !known {
+				report.warn("issue #%d con Status no reconocido: %q", iss.Number, rawStatus)
 			}
-			if tipo == "" {
-				continue
+			continue
+		}
+
+		tipo := ""
+		estado := ""
+		porcentajeBase := 0
+		if isBug(labels, projectTipo) {
+			tipo = "bug"
+			estado, porcentajeBase = publicBugStatus(phase, iss.State)
+		} else if isFeature(labels, projectTipo) && isLuisApproved(checkLuis) {
+			if publicStatus, baseline, ok := publicFeatureStatus(phase); ok {
+				tipo = "feature"
+				estado = publicStatus
+				porcentajeBase = baseline
 			}
+		}
+		if tipo == "" {
+			continue
+		}
 
-			all = append(all, ModuleOut{
-				ID:          strconv.Itoa(iss.Number),
-				Nombre:      iss.Title,
-				Descripcion: buildDescription(iss.Body, iss.Title),
-				Fase:        phase,
-				Estado:      estado,
-				Porcentaje:  calculatePercentage(iss.Body, porcentajeBase),
-				Propietario: buildOwner(iss.Assignees.Nodes),
-				Inicio:      toISO(it.Start.DateVal.Date),
-				ETA:         toISO(it.ETA.DateVal.Date),
-				Enlaces:     buildLinks(iss.URL.String()),
-				Tipo:        tipo,
-			})
+		eta := toISO(it.ETA.DateVal.Date)
+		confianza, confianzaOK := normalizeConfidence(singleName(it.Confianza.Typename, it.Confianza.Single.Name))
+		if !confianzaOK {
+			report.warn("issue #%d con Confidence no reconocido: %q", iss.Number, singleName(it.Confianza.Typename, it.Confianza.Single.Name))
 		}
-		if !q.Org.Project.Items.PageInfo.HasNextPage {
-			break
+		// La confianza califica la ETA; sin fecha no hay nada que calificar.
+		if eta == "" {
+			confianza = ""
 		}
-		after = &q.Org.Project.Items.PageInfo.EndCursor
-	}
 
-	changed, err := writeOutputsIfModulesChanged(outPath, metaOutPath, all, time.Now)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if !changed {
-		log.Printf("OK: %s sin cambios; no se actualiza %s", outPath, metaOutPath)
-		return
-	}
-	log.Printf("OK: escrito %s y %s con %d elementos públicos", outPath, metaOutPath, len(all))
+		all = append(all, ModuleOut{
+			ID:          strconv.Itoa(iss.Number),
+			Nombre:      iss.Title,
+			Descripcion: buildDescription(iss.Body, iss.Title),
+			Fase:        phase,
+			Estado:      estado,
+			Porcentaje:  calculatePercentage(iss.Body, porcentajeBase),
+			Propietario: buildOwner(iss.Assignees.Nodes),
+			Inicio:      toISO(it.Start.DateVal.Date),
+			ETA:         eta,
+			Confianza:   confianza,
+			Enlaces:     buildLinks(iss.URL.String()),
+			Tipo:        tipo,
+			Area:        strings.TrimSpace(singleName(it.Area.Typename, it.Area.Single.Name)),
+
+			Responsables: buildResponsables(iss.Assignees.Nodes),
+			Prioridad:    strings.TrimSpace(singleName(it.Prioridad.Typename, it.Prioridad.Single.Name)),
+		})
+	}
+	return all
 }
 
-func writeOutputsIfModulesChanged(outPath string, metaOutPath string, modules []ModuleOut, now func() time.Time) (bool, error) {
+// writeOutputsIfModulesChanged solo reescribe la metadata cuando cambian los
+// módulos, la leyenda o la actualización de estado; así generatedAt refleja
+// cambios reales de datos.
+func writeOutputsIfModulesChanged(out *outputStage, outPath string, metaOutPath string, modules []ModuleOut, extras metadataExtras, now func() time.Time) (bool, error) {
 	modulesJSON, err := marshalJSON(modules)
 	if err != nil {
 		return false, fmt.Errorf("preparar %s: %w", outPath, err)
@@ -476,35 +839,68 @@ func writeOutputsIfModulesChanged(outPath string, metaOutPath string, modules []
 	if err != nil {
 		return false, fmt.Errorf("comparar %s: %w", outPath, err)
 	}
-	if !changed {
+	if changed {
+		if err := out.write(outPath, modulesJSON); err != nil {
+			return false, fmt.Errorf("escribir %s: %w", outPath, err)
+		}
+	} else if !metadataExtrasChanged(metaOutPath, extras) {
 		return false, nil
 	}
-	if err := writeFile(outPath, modulesJSON); err != nil {
-		return false, fmt.Errorf("escribir %s: %w", outPath, err)
-	}
 
 	generatedAt := now().UTC().Format(time.RFC3339)
 	metadata := MetadataOut{
 		GeneratedAt: generatedAt,
 		Source:      defaultMetadataSource,
 		ItemCount:   len(modules),
+		Leyenda:     extras.Leyenda,
+		Metricas:    aggregateFlowMetrics(modules),
+		Confianza:   aggregateConfidence(modules),
+
+		Actualizacion: extras.Actualizacion,
 	}
 	metadataJSON, err := marshalJSON(metadata)
 	if err != nil {
 		return false, fmt.Errorf("preparar %s: %w", metaOutPath, err)
 	}
-	if err := writeFile(metaOutPath, metadataJSON); err != nil {
+	if err := out.write(metaOutPath, metadataJSON); err != nil {
 		return false, fmt.Errorf("escribir %s: %w", metaOutPath, err)
 	}
 	return true, nil
 }
 
-type roundTripperWithToken struct{ token string }
+// writeInternalOutput escribe los módulos con todos sus campos. No se
+// publica en Pages: el workflow la sube como artefacto, que solo ve quien
+// tiene acceso al repositorio.
+func writeInternalOutput(out *outputStage, path string, modules []ModuleOut) error {
+	content, err := marshalJSON(modules)
+	if err != nil {
+		return fmt.Errorf("preparar %s: %w", path, err)
+	}
+	if err := out.write(path, content); err != nil {
+		return fmt.Errorf("escribir %s: %w", path, err)
+	}
+	return nil
+}
 
-func (rt roundTripperWithToken) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Set("Authorization", "Bearer "+rt.token)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	return http.DefaultTransport.RoundTrip(req)
+// metadataExtrasChanged compara la leyenda y la actualización publicadas con
+// las actuales. Una metadata ilegible cuenta como cambio para que la
+// siguiente escritura la repare.
+func metadataExtrasChanged(metaOutPath string, extras metadataExtras) bool {
+	raw, err := os.ReadFile(metaOutPath)
+	if err != nil {
+		return len(extras.Leyenda) > 0 || extras.Actualizacion != nil
+	}
+	var current MetadataOut
+	if err := json.Unmarshal(raw, &current); err != nil {
+		return true
+	}
+	if !reflect.DeepEqual(current.Actualizacion, extras.Actualizacion) {
+		return true
+	}
+	if len(current.Leyenda) == 0 && len(extras.Leyenda) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(current.Leyenda, extras.Leyenda)
 }
 
 func dirOf(p string) string {
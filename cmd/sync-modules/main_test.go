@@ -288,7 +288,7 @@ func TestWriteOutputsIfModulesChangedSkipsMetadataWhenModulesUnchanged(t *testin
 		t.Fatalf("Chtimes metadata: %v", err)
 	}
 
-	changed, err := writeOutputsIfModulesChanged(modulesPath, metaPath, modules, func() time.Time {
+	changed, err := writeAndPublishOutputs(t, modulesPath, metaPath, modules, metadataExtras{}, func() time.Time {
 		return time.Date(2026, 6, 25, 12, 0, 0, 0, time.UTC)
 	})
 	if err != nil {
@@ -327,7 +327,7 @@ func TestWriteOutputsIfModulesChangedWritesMetadataWhenModulesChange(t *testing.
 
 	modules := []ModuleOut{{ID: "1", Nombre: "Test", Fase: "Test", Estado: "En atención", Porcentaje: 50, Tipo: "bug"}}
 	fixedTime := time.Date(2026, 6, 25, 12, 34, 56, 0, time.UTC)
-	changed, err := writeOutputsIfModulesChanged(modulesPath, metaPath, modules, func() time.Time {
+	changed, err := writeAndPublishOutputs(t, modulesPath, metaPath, modules, metadataExtras{}, func() time.Time {
 		return fixedTime
 	})
 	if err != nil {
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// areaSinAsignar agrupa en las métricas a los módulos sin campo Area.
+const areaSinAsignar = "Sin área"
+
+// statusChange es un cambio de Status del item en el Project, tomado del
+// timeline del issue.
+type statusChange struct {
+	At     time.Time
+	Status string
+}
+
+// statusHistoryLookup devuelve los cambios de Status de un issue en el
+// proyecto sincronizado. Es una variable de función para que las pruebas no
+// dependan de GitHub.
+type statusHistoryLookup func(ctx context.Context, issueURL string) ([]statusChange, error)
+
+type statusHistoryQuery struct {
+	RateLimit rateLimitInfo `graphql:"rateLimit"`
+	Resource  struct {
+		Issue struct {
+			TimelineItems struct {
+				Nodes []struct {
+					StatusChanged struct {
+						CreatedAt githubv4.DateTime
+						Status    string
+						Project   struct {
+							Number int
+						}
+					} `graphql:"... on ProjectV2ItemStatusChangedEvent"`
+				}
+			} `graphql:"timelineItems(first: 50, itemTypes: [PROJECT_V2_ITEM_STATUS_CHANGED_EVENT])"`
+		} `graphql:"... on Issue"`
+	} `graphql:"resource(url: $url)"`
+}
+
+// graphQLStatusHistoryLookup consulta el timeline del issue y se queda con
+// los cambios del proyecto indicado; un issue puede vivir en varios tableros.
+func graphQLStatusHistoryLookup(cli *githubv4.Client, projectNumber int) statusHistoryLookup {
+	return func(ctx context.Context, issueURL string) ([]statusChange, error) {
+		parsed, err := url.Parse(issueURL)
+		if err != nil {
+			return nil, fmt.Errorf("URL de issue inválida %q: %w", issueURL, err)
+		}
+		var q statusHistoryQuery
+		if err := cli.Query(ctx, &q, map[string]interface{}{"url": githubv4.URI{URL: parsed}}); err != nil {
+			return nil, classifyGraphQLError(err)
+		}
+		recordQueryCost(ctx, "status_history", q.RateLimit)
+		var changes []statusChange
+		for _, node := range q.Resource.Issue.TimelineItems.Nodes {
+			event := node.StatusChanged
+			if event.CreatedAt.IsZero() || event.Project.Number != projectNumber {
+				continue
+			}
+			changes = append(changes, statusChange{At: event.CreatedAt.Time, Status: event.Status})
+		}
+		return changes, nil
+	}
+}
+
+// isCompleted indica si el módulo ya terminó su flujo: funcionalidad
+// liberada o bug resuelto.
+func isCompleted(m ModuleOut) bool {
+	return (m.Tipo == "feature" && m.Estado == "Liberado") || (m.Tipo == "bug" && m.Estado == "Resuelto")
+}
+
+// isWorkPhase indica si un Status del tablero significa que alguien empezó a
+// trabajar en el módulo; marca el inicio del cycle time.
+func isWorkPhase(rawStatus string) bool {
+	switch phase, _ := publicPhase(rawStatus); phase {
+	case "Prototipado", "Desarrollo", "Test", "Staging", "Deploy":
+		return true
+	default:
+		return false
+	}
+}
+
+// computeFlowMetrics agrega leadTimeDays (creación → cierre) y cycleTimeDays
+// (inicio de trabajo → cierre) a los módulos completados. Si la corrida
+// anterior ya los calculó para el mismo módulo completado los reutilizamos,
+// así solo consultamos el historial de lo que se completó desde entonces.
+// Sin historial usamos el campo "Start date" como inicio; si tampoco existe,
+// el módulo queda sin cycle time en lugar de inventar uno.
+func computeFlowMetrics(ctx context.Context, modules []ModuleOut, items []Item, previous []ModuleOut, lookup statusHistoryLookup, report *runReport) {
+	byNumber := make(map[string]Item, len(items))
+	for _, it := range items {
+		if number := it.Content.Issue.Number; number != 0 {
+			byNumber[strconv.Itoa(number)] = it
+		}
+	}
+	prevByID := make(map[string]ModuleOut, len(previous))
+	for _, prev := range previous {
+		prevByID[prev.ID] = prev
+	}
+
+	var failed []string
+	for i := range modules {
+		m := &modules[i]
+		it, ok := byNumber[m.ID]
+		if !ok || !isCompleted(*m) {
+			continue
+		}
+		if prev, ok := prevByID[m.ID]; ok && prev.LeadTimeDays != nil && isCompleted(prev) {
+			m.LeadTimeDays, m.CycleTimeDays = prev.LeadTimeDays, prev.CycleTimeDays
+			continue
+		}
+
+		var history []statusChange
+		if lookup != nil && len(m.Enlaces) > 0 {
+			var err error
+			history, err = lookup(ctx, m.Enlaces[0].URL)
+			if err != nil {
+				failed = append(failed, m.ID)
+			}
+		}
+
+		iss := it.Content.Issue
+		completedAt := iss.ClosedAt.Time
+		if completedAt.IsZero() {
+			completedAt = lastEntryInto(history, "deploy")
+		}
+		if completedAt.IsZero() {
+			continue
+		}
+		m.LeadTimeDays = daysBetween(iss.CreatedAt.Time, completedAt)
+
+		startedAt := firstWorkChange(history)
+		if startedAt.IsZero() {
+			startedAt = it.Start.DateVal.Date.Time
+		}
+		m.CycleTimeDays = daysBetween(startedAt, completedAt)
+	}
+
+	if len(failed) > 0 {
+		report.warn("no se pudo consultar el historial de Status de %d módulos (%s); su cycle time usa Start date", len(failed), joinLimited(failed, 10))
+	}
+}
+
+func firstWorkChange(history []statusChange) time.Time {
+	var first time.Time
+	for _, change := range history {
+		if isWorkPhase(change.Status) && (first.IsZero() || change.At.Before(first)) {
+			first = change.At
+		}
+	}
+	return first
+}
+
+func lastEntryInto(history []statusChange, status string) time.Time {
+	var last time.Time
+	for _, change := range history {
+		if normalizeText(change.Status) == status && change.At.After(last) {
+			last = change.At
+		}
+	}
+	return last
+}
+
+// daysBetween devuelve los días transcurridos con un decimal, o nil si falta
+// alguna fecha o el orden es imposible (p. ej. un Start date posterior al
+// cierre).
+func daysBetween(from, to time.Time) *float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return nil
+	}
+	days := math.Round(to.Sub(from).Hours()/24*10) / 10
+	return &days
+}
+
+func joinLimited(ids []string, max int) string {
+	if len(ids) <= max {
+		return fmt.Sprint(ids)
+	}
+	return fmt.Sprintf("%v y %d más", ids[:max], len(ids)-max)
+}
+
+// areaMetrics resume el flujo de los módulos completados de un área para
+// las revisiones de mejora continua.
+type areaMetrics struct {
+	Area                  string   `json:"area"`
+	Completados           int      `json:"completados"`
+	LeadTimeDaysPromedio  *float64 `json:"leadTimeDaysPromedio,omitempty"`
+	LeadTimeDaysMediana   *float64 `json:"leadTimeDaysMediana,omitempty"`
+	CycleTimeDaysPromedio *float64 `json:"cycleTimeDaysPromedio,omitempty"`
+	CycleTimeDaysMediana  *float64 `json:"cycleTimeDaysMediana,omitempty"`
+}
+
+// aggregateFlowMetrics agrupa por área los tiempos de los módulos
+// completados. El orden es alfabético para que el JSON sea estable.
+func aggregateFlowMetrics(modules []ModuleOut) []areaMetrics {
+	type samples struct {
+		completed   int
+		lead, cycle []float64
+	}
+	byArea := map[string]*samples{}
+	for _, m := range modules {
+		if m.LeadTimeDays == nil {
+			continue
+		}
+		area := m.Area
+		if area == "" {
+			area = areaSinAsignar
+		}
+		s := byArea[area]
+		if s == nil {
+			s = &samples{}
+			byArea[area] = s
+		}
+		s.completed++
+		s.lead = append(s.lead, *m.LeadTimeDays)
+		if m.CycleTimeDays != nil {
+			s.cycle = append(s.cycle, *m.CycleTimeDays)
+		}
+	}
+
+	out := make([]areaMetrics, 0, len(byArea))
+	for area, s := range byArea {
+		out = append(out, areaMetrics{
+			Area:                  area,
+			Completados:           s.completed,
+			LeadTimeDaysPromedio:  mean(s.lead),
+			LeadTimeDaysMediana:   median(s.lead),
+			CycleTimeDaysPromedio: mean(s.cycle),
+			CycleTimeDaysMediana:  median(s.cycle),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Area < out[j].Area })
+	return out
+}
+
+func mean(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	avg := math.Round(sum/float64(len(values))*10) / 10
+	return &avg
+}
+
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	med := sorted[mid]
+	if len(sorted)%2 == 0 {
+		med = math.Round((sorted[mid-1]+sorted[mid])/2*10) / 10
+	}
+	return &med
+}
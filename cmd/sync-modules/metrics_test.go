@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func metricsItem(number int, created, closed, start string) Item {
+	var it Item
+	it.Content.Issue.Number = number
+	parse := func(raw string) GHFlexDate {
+		if raw == "" {
+			return GHFlexDate{}
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			t, _ = time.Parse("2006-01-02", raw)
+		}
+		return GHFlexDate{Time: t, Raw: raw}
+	}
+	it.Content.Issue.CreatedAt = parse(created)
+	it.Content.Issue.ClosedAt = parse(closed)
+	it.Start.DateVal.Date = parse(start)
+	return it
+}
+
+func floatOrNil(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func TestComputeFlowMetrics(t *testing.T) {
+	modules := []ModuleOut{
+		{ID: "1", Tipo: "feature", Estado: "Liberado", Area: "Ventas", Enlaces: buildLinks("https://github.com/o/r/issues/1")},
+		{ID: "2", Tipo: "bug", Estado: "Resuelto", Enlaces: buildLinks("https://github.com/o/r/issues/2")},
+		{ID: "3", Tipo: "feature", Estado: "En desarrollo", Enlaces: buildLinks("https://github.com/o/r/issues/3")},
+		{ID: "4", Tipo: "feature", Estado: "Liberado", Enlaces: buildLinks("https://github.com/o/r/issues/4")},
+		{ID: "5", Tipo: "feature", Estado: "Liberado", Enlaces: buildLinks("https://github.com/o/r/issues/5")},
+	}
+	items := []Item{
+		metricsItem(1, "2026-01-01T00:00:00Z", "2026-01-11T00:00:00Z", ""),
+		metricsItem(2, "2026-02-01T00:00:00Z", "2026-02-03T12:00:00Z", "2026-02-02"),
+		metricsItem(3, "2026-03-01T00:00:00Z", "", ""),
+		// Liberado con el issue abierto: el cierre sale de la entrada a Deploy.
+		metricsItem(4, "2026-04-01T00:00:00Z", "", ""),
+		metricsItem(5, "2026-05-01T00:00:00Z", "2026-05-20T00:00:00Z", ""),
+	}
+	previous := []ModuleOut{{ID: "5", Tipo: "feature", Estado: "Liberado", LeadTimeDays: ptrFloat(19), CycleTimeDays: ptrFloat(7)}}
+
+	histories := map[string][]statusChange{
+		"https://github.com/o/r/issues/1": {
+			{At: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Status: "En planeación"},
+			{At: time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC), Status: "Desarrollo"},
+			{At: time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC), Status: "Deploy"},
+		},
+		"https://github.com/o/r/issues/4": {
+			{At: time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC), Status: "Prototipado"},
+			{At: time.Date(2026, 4, 8, 0, 0, 0, 0, time.UTC), Status: "Deploy"},
+		},
+	}
+	var looked []string
+	lookup := func(_ context.Context, issueURL string) ([]statusChange, error) {
+		looked = append(looked, issueURL)
+		if issueURL == "https://github.com/o/r/issues/2" {
+			return nil, errors.New("sin historial")
+		}
+		return histories[issueURL], nil
+	}
+
+	report := newRunReport(time.Now)
+	computeFlowMetrics(context.Background(), modules, items, previous, lookup, report)
+
+	want := map[string][2]any{
+		"1": {10.0, 7.0},
+		"2": {2.5, 1.5}, // sin historial: el inicio es Start date
+		"3": {nil, nil},
+		"4": {7.0, 5.0},
+		"5": {19.0, 7.0}, // reutilizado de la corrida anterior
+	}
+	for _, m := range modules {
+		got := [2]any{floatOrNil(m.LeadTimeDays), floatOrNil(m.CycleTimeDays)}
+		if got != want[m.ID] {
+			t.Errorf("módulo %s: lead/cycle = %v, se esperaba %v", m.ID, got, want[m.ID])
+		}
+	}
+	if len(looked) != 3 {
+		t.Errorf("solo se debe consultar el historial de lo recién completado, hubo %d consultas", len(looked))
+	}
+	if len(report.Warnings) != 1 {
+		t.Errorf("se esperaba una advertencia agregada, llegaron %v", report.Warnings)
+	}
+}
+
+func TestAggregateFlowMetrics(t *testing.T) {
+	modules := []ModuleOut{
+		{ID: "1", Area: "Ventas", LeadTimeDays: ptrFloat(10), CycleTimeDays: ptrFloat(4)},
+		{ID: "2", Area: "Ventas", LeadTimeDays: ptrFloat(20), CycleTimeDays: ptrFloat(6)},
+		{ID: "3", Area: "Ventas", LeadTimeDays: ptrFloat(3)},
+		{ID: "4", LeadTimeDays: ptrFloat(1), CycleTimeDays: ptrFloat(1)},
+		{ID: "5", Area: "Ventas"},
+	}
+	got := aggregateFlowMetrics(modules)
+	if len(got) != 2 || got[0].Area != areaSinAsignar || got[1].Area != "Ventas" {
+		t.Fatalf("áreas inesperadas: %+v", got)
+	}
+	ventas := got[1]
+	if ventas.Completados != 3 {
+		t.Fatalf("completados = %d, se esperaban 3", ventas.Completados)
+	}
+	if *ventas.LeadTimeDaysPromedio != 11 || *ventas.LeadTimeDaysMediana != 10 {
+		t.Fatalf("lead time promedio/mediana = %v/%v", *ventas.LeadTimeDaysPromedio, *ventas.LeadTimeDaysMediana)
+	}
+	if *ventas.CycleTimeDaysPromedio != 5 || *ventas.CycleTimeDaysMediana != 5 {
+		t.Fatalf("cycle time promedio/mediana = %v/%v", *ventas.CycleTimeDaysPromedio, *ventas.CycleTimeDaysMediana)
+	}
+}
+
+func ptrFloat(v float64) *float64 { return &v }
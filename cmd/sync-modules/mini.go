@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// miniOutputBudget es el tamaño máximo de MINI_OUTPUT. Los widgets de estado
+// de otros sitios lo descargan en cada visita, así que preferimos fallar el
+// sync a publicar un archivo que deje de ser liviano.
+const miniOutputBudget = 64 << 10
+
+// miniModuleOut es lo único que necesita un widget: ni descripción, ni
+// fechas, ni enlaces.
+type miniModuleOut struct {
+	ID         string `json:"id"`
+	Nombre     string `json:"nombre"`
+	Estado     string `json:"estado"`
+	Porcentaje int    `json:"porcentaje"`
+	Area       string `json:"area,omitempty"`
+}
+
+// buildMiniModules reduce la vista pública a miniModuleOut. Los módulos
+// retirados se omiten: siguen en modules.json para el historial, pero un
+// widget de estado no debe mostrarlos.
+func buildMiniModules(public []ModuleOut) []miniModuleOut {
+	mini := make([]miniModuleOut, 0, len(public))
+	for _, m := range public {
+		if m.Retirado != "" {
+			continue
+		}
+		mini = append(mini, miniModuleOut{ID: m.ID, Nombre: m.Nombre, Estado: m.Estado, Porcentaje: m.Porcentaje, Area: m.Area})
+	}
+	return mini
+}
+
+// prepareMiniOutput serializa sin sangría y verifica el presupuesto. run lo
+// llama antes de escribir modules.json para no publicar uno sin el otro.
+func prepareMiniOutput(public []ModuleOut) ([]byte, error) {
+	content, err := json.Marshal(buildMiniModules(public))
+	if err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	content = append(content, '\n')
+	if len(content) > miniOutputBudget {
+		return nil, fmt.Errorf("ocupa %d bytes y el límite es %d", len(content), miniOutputBudget)
+	}
+	return content, nil
+}
+
+// writeMiniOutput prepara MINI_OUTPUT solo si cambió.
+func writeMiniOutput(out *outputStage, path string, content []byte) (bool, error) {
+	changed, err := fileContentChanged(path, content)
+	if err != nil {
+		return false, fmt.Errorf("comparar %s: %w", path, err)
+	}
+	if !changed {
+		return false, nil
+	}
+	if err := out.write(path, content); err != nil {
+		return false, fmt.Errorf("escribir %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeFileAtomic escribe en un temporal del mismo directorio y lo renombra
+// sobre path; el rename es atómico dentro de un mismo sistema de archivos.
+func writeFileAtomic(path string, content []byte) error {
+	if err := os.MkdirAll(dirOf(path), 0o755); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dirOf(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("temporal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("escribir: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cerrar: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("renombrar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrepareMiniOutputSoloCamposDelWidget(t *testing.T) {
+	public := []ModuleOut{
+		{ID: "1", Nombre: "Pagos", Descripcion: "larga", Estado: "En desarrollo", Porcentaje: 40, Area: "Ventas", ETA: "2026-12-01"},
+		{ID: "2", Nombre: "Viejo", Estado: "Retirado", Retirado: "2026-01-01"},
+	}
+	content, err := prepareMiniOutput(public)
+	if err != nil {
+		t.Fatalf("prepareMiniOutput: %v", err)
+	}
+	want := `[{"id":"1","nombre":"Pagos","estado":"En desarrollo","porcentaje":40,"area":"Ventas"}]` + "\n"
+	if string(content) != want {
+		t.Fatalf("contenido inesperado:\n%s", content)
+	}
+}
+
+// El presupuesto debe alcanzar con holgura para el roadmap actual y para uno
+// varias veces más grande; si esto falla, hay que recortar campos, no subir
+// el límite sin avisar a quienes embeben el widget.
+func TestMiniOutputCabeEnElPresupuesto(t *testing.T) {
+	raw, err := os.ReadFile("../../docs/modules.json")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var published []ModuleOut
+	if err := json.Unmarshal(raw, &published); err != nil {
+		t.Fatalf("docs/modules.json ilegible: %v", err)
+	}
+	if _, err := prepareMiniOutput(published); err != nil {
+		t.Fatalf("el roadmap publicado no cabe: %v", err)
+	}
+
+	large := make([]ModuleOut, 300)
+	for i := range large {
+		large[i] = ModuleOut{ID: fmt.Sprint(1000 + i), Nombre: strings.Repeat("m", 80), Estado: "En planeación", Porcentaje: 100, Area: "Operaciones y logística"}
+	}
+	if _, err := prepareMiniOutput(large); err != nil {
+		t.Fatalf("300 módulos deben caber: %v", err)
+	}
+
+	large = append(large, ModuleOut{ID: "x", Nombre: strings.Repeat("n", miniOutputBudget)})
+	if _, err := prepareMiniOutput(large); err == nil {
+		t.Fatal("se esperaba error al superar el presupuesto")
+	}
+}
+
+func TestWriteMiniOutputSoloSiCambia(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs", "modules-mini.json")
+	content, err := prepareMiniOutput([]ModuleOut{{ID: "1", Nombre: "Pagos", Estado: "QA", Porcentaje: 80}})
+	if err != nil {
+		t.Fatalf("prepareMiniOutput: %v", err)
+	}
+	if changed, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return writeMiniOutput(out, path, content) }); err != nil || !changed {
+		t.Fatalf("primera escritura: %v / %v", changed, err)
+	}
+	if changed, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return writeMiniOutput(out, path, content) }); err != nil || changed {
+		t.Fatalf("sin cambios no debe reescribirse: %v / %v", changed, err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("no deben quedar temporales: %v / %v", entries, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Fatalf("permisos inesperados: %v / %v", info, err)
+	}
+}
+
+func TestLoadConfigRechazaMiniEnBadges(t *testing.T) {
+	env := map[string]string{"MINI_OUTPUT": "docs/badges/mini.json", "BADGES_DIR": "docs/badges"}
+	if _, err := loadConfig(func(key string) string { return env[key] }); err == nil {
+		t.Fatal("MINI_OUTPUT dentro de BADGES_DIR debe rechazarse")
+	}
+}
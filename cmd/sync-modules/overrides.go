@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// overrides son correcciones editoriales que mantienen las PM en
+// OVERRIDES_PATH (el workflow usa docs/overrides.json), indexadas por el ID
+// del módulo. Sirven para cambios chicos que no justifican editar el issue.
+type overrides struct {
+	Modulos map[string]moduleOverride `json:"modulos"`
+}
+
+// moduleOverride reemplaza los campos presentes (un texto vacío borra el
+// valor), agrega enlaces a los del issue y puede ocultar el módulo de la
+// vista pública. Nota es para quien mantiene el archivo y no se publica.
+type moduleOverride struct {
+	Nombre      *string   `json:"nombre,omitempty"`
+	Descripcion *string   `json:"descripcion,omitempty"`
+	Propietario *string   `json:"propietario,omitempty"`
+	Inicio      *string   `json:"inicio,omitempty"`
+	ETA         *string   `json:"eta,omitempty"`
+	Area        *string   `json:"area,omitempty"`
+	Enlaces     []LinkOut `json:"enlaces,omitempty"`
+	Ocultar     bool      `json:"ocultar,omitempty"`
+	Nota        string    `json:"nota,omitempty"`
+}
+
+// loadOverrides lee OVERRIDES_PATH si está definido. Como VISIBILITY_PATH,
+// una clave desconocida detiene el sync: un typo como "descripción" no debe
+// ignorarse en silencio.
+func loadOverrides(path string, readFile func(string) ([]byte, error)) (overrides, error) {
+	if strings.TrimSpace(path) == "" {
+		return overrides{}, nil
+	}
+	raw, err := readFile(path)
+	if err != nil {
+		return overrides{}, fmt.Errorf("leer OVERRIDES_PATH %s: %w", path, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var ov overrides
+	if err := dec.Decode(&ov); err != nil {
+		return overrides{}, fmt.Errorf("interpretar OVERRIDES_PATH %s: %w", path, err)
+	}
+	if err := ov.validate(); err != nil {
+		return overrides{}, fmt.Errorf("OVERRIDES_PATH %s: %w", path, err)
+	}
+	return ov, nil
+}
+
+func (ov overrides) validate() error {
+	for id, o := range ov.Modulos {
+		if o.Nombre != nil && strings.TrimSpace(*o.Nombre) == "" {
+			return fmt.Errorf("módulo %s: nombre no puede quedar vacío", id)
+		}
+		for field, value := range map[string]*string{"inicio": o.Inicio, "eta": o.ETA} {
+			if value == nil || *value == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", *value); err != nil {
+				return fmt.Errorf("módulo %s: %s %q no tiene formato AAAA-MM-DD", id, field, *value)
+			}
+		}
+		for _, link := range o.Enlaces {
+			parsed, err := url.Parse(link.URL)
+			if strings.TrimSpace(link.Label) == "" || err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+				return fmt.Errorf("módulo %s: enlace inválido %+v (se espera label y una URL http/https)", id, link)
+			}
+		}
+	}
+	return nil
+}
+
+// applyOverrides aplica las correcciones a todos los módulos, incluida la
+// salida interna, y marca en Ajustes qué campos no vienen del issue. Un ID
+// que ya no existe solo genera una advertencia para que alguien limpie el
+// archivo.
+func applyOverrides(modules []ModuleOut, ov overrides, report *runReport) []ModuleOut {
+	if len(ov.Modulos) == 0 {
+		return modules
+	}
+	seen := map[string]bool{}
+	out := make([]ModuleOut, len(modules))
+	for i, m := range modules {
+		o, ok := ov.Modulos[m.ID]
+		if !ok {
+			out[i] = m
+			continue
+		}
+		seen[m.ID] = true
+		var ajustes []string
+		for _, field := range []struct {
+			name  string
+			value *string
+			dst   *string
+		}{
+			{"nombre", o.Nombre, &m.Nombre},
+			{"descripcion", o.Descripcion, &m.Descripcion},
+			{"propietario", o.Propietario, &m.Propietario},
+			{"inicio", o.Inicio, &m.Inicio},
+			{"eta", o.ETA, &m.ETA},
+			{"area", o.Area, &m.Area},
+		} {
+			if field.value != nil {
+				*field.dst = strings.TrimSpace(*field.value)
+				ajustes = append(ajustes, field.name)
+			}
+		}
+		if added := appendMissingLinks(&m, o.Enlaces); added {
+			ajustes = append(ajustes, "enlaces")
+		}
+		if o.Ocultar {
+			ajustes = append(ajustes, "ocultar")
+		}
+		sort.Strings(ajustes)
+		m.Ajustes = ajustes
+		out[i] = m
+	}
+	ids := make([]string, 0, len(ov.Modulos))
+	for id := range ov.Modulos {
+		if !seen[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		report.warn("OVERRIDES_PATH corrige el módulo %s, que ya no está en el tablero", id)
+	}
+	return out
+}
+
+// appendMissingLinks agrega los enlaces cuya URL todavía no aparece. Los
+// módulos retirados se copian de la corrida anterior con los enlaces ya
+// agregados, así que repetir la corrección no debe duplicarlos.
+func appendMissingLinks(m *ModuleOut, links []LinkOut) bool {
+	if len(links) == 0 {
+		return false
+	}
+	existing := map[string]bool{}
+	for _, link := range m.Enlaces {
+		existing[link.URL] = true
+	}
+	for _, link := range links {
+		if !existing[link.URL] {
+			m.Enlaces = append(m.Enlaces, link)
+			existing[link.URL] = true
+		}
+	}
+	return true
+}
+
+// withoutHidden quita de la vista pública los módulos con "ocultar": true.
+// Siguen en INTERNAL_OUTPUT.
+func withoutHidden(public []ModuleOut, ov overrides) []ModuleOut {
+	out := make([]ModuleOut, 0, len(public))
+	for _, m := range public {
+		if !ov.Modulos[m.ID].Ocultar {
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func overridesFrom(t *testing.T, raw string) overrides {
+	t.Helper()
+	ov, err := loadOverrides("docs/overrides.json", func(string) ([]byte, error) { return []byte(raw), nil })
+	if err != nil {
+		t.Fatalf("loadOverrides: %v", err)
+	}
+	return ov
+}
+
+func TestLoadOverridesValida(t *testing.T) {
+	if ov, err := loadOverrides("", nil); err != nil || len(ov.Modulos) != 0 {
+		t.Fatalf("sin OVERRIDES_PATH no hay correcciones: %+v / %v", ov, err)
+	}
+	read := func(raw string) func(string) ([]byte, error) {
+		return func(string) ([]byte, error) { return []byte(raw), nil }
+	}
+	for _, bad := range []string{
+		`{"modulos": {"1": {"descripción": "typo"}}}`,
+		`{"modulos": {"1": {"eta": "pronto"}}}`,
+		`{"modulos": {"1": {"nombre": " "}}}`,
+		`{"modulos": {"1": {"enlaces": [{"label": "Doc", "url": "javascript:alert(1)"}]}}}`,
+	} {
+		if _, err := loadOverrides("docs/overrides.json", read(bad)); err == nil {
+			t.Errorf("se esperaba error con %s", bad)
+		}
+	}
+	missing := func(string) ([]byte, error) { return nil, errors.New("no existe") }
+	if _, err := loadOverrides("docs/overrides.json", missing); err == nil {
+		t.Fatal("un OVERRIDES_PATH ilegible debe detener el sync")
+	}
+}
+
+func TestApplyOverridesMarcaProcedencia(t *testing.T) {
+	ov := overridesFrom(t, `{"modulos": {
+		"1": {"descripcion": "Texto corregido", "propietario": "", "enlaces": [{"label": "Manual", "url": "https://example.com/manual"}], "nota": "pedido por ventas"},
+		"2": {"ocultar": true},
+		"99": {"nombre": "Ya no existe"}
+	}}`)
+	modules := []ModuleOut{
+		{ID: "1", Nombre: "Pagos", Descripcion: "typo", Propietario: "ana", Enlaces: []LinkOut{{Label: "Issue", URL: "https://github.com/o/r/issues/1"}}},
+		{ID: "2", Nombre: "Interno"},
+		{ID: "3", Nombre: "Sin cambios"},
+	}
+	report := newRunReport(time.Now)
+	out := applyOverrides(modules, ov, report)
+
+	pagos := out[0]
+	if pagos.Descripcion != "Texto corregido" || pagos.Propietario != "" || len(pagos.Enlaces) != 2 {
+		t.Fatalf("corrección inesperada: %+v", pagos)
+	}
+	if !reflect.DeepEqual(pagos.Ajustes, []string{"descripcion", "enlaces", "propietario"}) {
+		t.Fatalf("ajustes = %v", pagos.Ajustes)
+	}
+	if out[2].Ajustes != nil || modules[0].Descripcion != "typo" {
+		t.Fatal("applyOverrides no debe tocar otros módulos ni la entrada")
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "99") {
+		t.Fatalf("un ID inexistente debe advertir: %v", report.Warnings)
+	}
+
+	// Un módulo retirado llega con los enlaces ya agregados en la corrida
+	// anterior; volver a aplicar no los duplica.
+	again := applyOverrides(out, ov, newRunReport(time.Now))
+	if len(again[0].Enlaces) != 2 {
+		t.Fatalf("enlaces duplicados: %+v", again[0].Enlaces)
+	}
+
+	public := withoutHidden(out, ov)
+	if len(public) != 2 || public[1].ID != "3" {
+		t.Fatalf("el módulo oculto debe salir de la vista pública: %+v", public)
+	}
+}
+
+func TestOverridesDelRepositorioSonValidas(t *testing.T) {
+	if _, err := loadOverrides("../../docs/overrides.json", os.ReadFile); err != nil {
+		t.Fatalf("docs/overrides.json: %v", err)
+	}
+}
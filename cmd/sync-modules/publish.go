@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// outputStage junta las salidas de una corrida en un directorio temporal y
+// solo las publica si el conjunto completo pasa la validación. Antes cada
+// archivo se escribía apenas estaba listo y un error a mitad de camino dejó
+// publicado un modules.json roto junto a una metadata válida.
+type outputStage struct {
+	dir    string
+	order  []string
+	staged map[string]string // destino -> temporal; "" borra el destino
+}
+
+func newOutputStage() (*outputStage, error) {
+	dir, err := os.MkdirTemp("", "sync-modules-*")
+	if err != nil {
+		return nil, fmt.Errorf("directorio temporal: %w", err)
+	}
+	return &outputStage{dir: dir, staged: map[string]string{}}, nil
+}
+
+// write prepara path con content. Escribir dos veces el mismo destino se
+// queda con la última versión.
+func (s *outputStage) write(path string, content []byte) error {
+	tmp := filepath.Join(s.dir, fmt.Sprintf("%03d-%s", len(s.order), filepath.Base(path)))
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return fmt.Errorf("escribir temporal: %w", err)
+	}
+	s.track(path, tmp)
+	return nil
+}
+
+// remove marca path para borrarlo al publicar.
+func (s *outputStage) remove(path string) {
+	s.track(path, "")
+}
+
+func (s *outputStage) track(path, tmp string) {
+	if _, ok := s.staged[path]; !ok {
+		s.order = append(s.order, path)
+	}
+	s.staged[path] = tmp
+}
+
+// read devuelve el contenido que tendrá path después de publicar: el
+// preparado si la corrida lo escribe y el publicado si no lo toca.
+func (s *outputStage) read(path string) ([]byte, error) {
+	tmp, ok := s.staged[path]
+	switch {
+	case !ok:
+		return os.ReadFile(path)
+	case tmp == "":
+		return nil, os.ErrNotExist
+	default:
+		return os.ReadFile(tmp)
+	}
+}
+
+// discard borra el directorio temporal. Se puede llamar después de publish.
+func (s *outputStage) discard() {
+	os.RemoveAll(s.dir)
+}
+
+// publish reemplaza cada destino con writeFileAtomic, así nadie lee un
+// archivo a medio escribir. El conjunto no se puede reemplazar en un solo
+// paso: si falla un destino se restauran los ya publicados y la página
+// queda como estaba.
+func (s *outputStage) publish() error {
+	type previous struct {
+		path    string
+		content []byte
+		existed bool
+	}
+	var done []previous
+	rollback := func(cause error) error {
+		errs := []error{cause}
+		for i := len(done) - 1; i >= 0; i-- {
+			p := done[i]
+			var err error
+			if p.existed {
+				err = writeFileAtomic(p.path, p.content)
+			} else {
+				err = os.Remove(p.path)
+			}
+			if err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("restaurar %s: %w", p.path, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, path := range s.order {
+		content, err := os.ReadFile(path)
+		existed := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return rollback(fmt.Errorf("leer %s: %w", path, err))
+		}
+		current := previous{path: path, content: content, existed: existed}
+		if tmp := s.staged[path]; tmp == "" {
+			err = os.Remove(path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else if content, err = os.ReadFile(tmp); err == nil {
+			err = writeFileAtomic(path, content)
+		}
+		if err != nil {
+			return rollback(fmt.Errorf("publicar %s: %w", path, err))
+		}
+		done = append(done, current)
+	}
+	return nil
+}
+
+// requiredModuleFields son los campos que docs/modules.schema.json exige en
+// cada módulo.
+var requiredModuleFields = []string{"id", "nombre", "fase", "estado", "porcentaje", "tipo"}
+
+// validate revisa las salidas como quedarían publicadas: que cada JSON se
+// pueda leer, que modules.json cumpla el esquema, que MINI_OUTPUT respete su
+// presupuesto y que la metadata, el mini y los trimestres hablen de los
+// mismos módulos. Devuelve todos los problemas juntos.
+func (s *outputStage) validate(cfg syncConfig) error {
+	var problems []error
+	for _, path := range s.order {
+		if s.staged[path] == "" || filepath.Ext(path) != ".json" {
+			continue
+		}
+		if content, err := s.read(path); err != nil || !json.Valid(content) {
+			problems = append(problems, fmt.Errorf("%s no es JSON válido", path))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("validar salidas: %w", errors.Join(problems...))
+	}
+
+	raw, err := s.read(cfg.OutPath)
+	if err != nil {
+		return fmt.Errorf("validar salidas: leer %s: %w", cfg.OutPath, err)
+	}
+	ids, err := validateModulesJSON(raw)
+	if ids == nil {
+		return fmt.Errorf("validar salidas: %s: %w", cfg.OutPath, err)
+	}
+	if err != nil {
+		problems = append(problems, fmt.Errorf("%s: %w", cfg.OutPath, err))
+	}
+
+	var meta MetadataOut
+	if found, err := s.decode(cfg.MetaOutPath, &meta); err != nil {
+		problems = append(problems, err)
+	} else if found && meta.ItemCount != len(ids) {
+		problems = append(problems, fmt.Errorf("%s declara %d módulos y %s tiene %d", cfg.MetaOutPath, meta.ItemCount, cfg.OutPath, len(ids)))
+	}
+
+	if cfg.MiniOutPath != "" {
+		var mini []miniModuleOut
+		if content, err := s.read(cfg.MiniOutPath); err == nil && len(content) > miniOutputBudget {
+			problems = append(problems, fmt.Errorf("%s ocupa %d bytes y el límite es %d", cfg.MiniOutPath, len(content), miniOutputBudget))
+		}
+		if found, err := s.decode(cfg.MiniOutPath, &mini); err != nil {
+			problems = append(problems, err)
+		} else if found {
+			for _, m := range mini {
+				problems = appendUnknownModule(problems, cfg.MiniOutPath, m.ID, ids)
+			}
+		}
+	}
+
+	if cfg.QuartersOutPath != "" {
+		var quarters quartersOut
+		if found, err := s.decode(cfg.QuartersOutPath, &quarters); err != nil {
+			problems = append(problems, err)
+		} else if found {
+			groups := [][]quarterModule{quarters.SinFecha}
+			for _, q := range quarters.Trimestres {
+				groups = append(groups, q.Planificados, q.EnCurso, q.Hechos)
+			}
+			for _, group := range groups {
+				for _, m := range group {
+					problems = appendUnknownModule(problems, cfg.QuartersOutPath, m.ID, ids)
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("validar salidas: %w", errors.Join(problems...))
+	}
+	return nil
+}
+
+// decode lee path como quedaría publicado. Un archivo que no existe no es
+// un error: la corrida puede no generarlo todavía.
+func (s *outputStage) decode(path string, out any) (bool, error) {
+	content, err := s.read(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("leer %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, out); err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	return true, nil
+}
+
+// validateModulesJSON verifica los campos obligatorios, que los IDs no se
+// repitan y el rango del porcentaje. Devuelve los IDs para las referencias
+// cruzadas.
+func validateModulesJSON(raw []byte) (map[string]bool, error) {
+	var modules []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &modules); err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(modules))
+	var problems []error
+	for i, m := range modules {
+		for _, field := range requiredModuleFields {
+			if _, ok := m[field]; !ok {
+				problems = append(problems, fmt.Errorf("módulo %d sin %q", i, field))
+			}
+		}
+		var id string
+		if json.Unmarshal(m["id"], &id) != nil || id == "" {
+			problems = append(problems, fmt.Errorf("módulo %d sin id", i))
+			continue
+		}
+		if ids[id] {
+			problems = append(problems, fmt.Errorf("id %q repetido", id))
+		}
+		ids[id] = true
+		var pct int
+		if json.Unmarshal(m["porcentaje"], &pct) != nil || pct < 0 || pct > 100 {
+			problems = append(problems, fmt.Errorf("módulo %q con porcentaje fuera de 0–100", id))
+		}
+	}
+	return ids, errors.Join(problems...)
+}
+
+func appendUnknownModule(problems []error, path, id string, ids map[string]bool) []error {
+	if ids[id] {
+		return problems
+	}
+	return append(problems, fmt.Errorf("%s menciona el módulo %q, que no está en modules.json", path, id))
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stageAndPublish corre write sobre un outputStage nuevo y publica lo que
+// haya preparado, como el paso "write" de run.
+func stageAndPublish(t *testing.T, write func(out *outputStage) (bool, error)) (bool, error) {
+	t.Helper()
+	out, err := newOutputStage()
+	if err != nil {
+		t.Fatalf("newOutputStage: %v", err)
+	}
+	defer out.discard()
+	changed, err := write(out)
+	if err != nil {
+		return changed, err
+	}
+	return changed, out.publish()
+}
+
+func writeAndPublishOutputs(t *testing.T, outPath, metaOutPath string, modules []ModuleOut, extras metadataExtras, now func() time.Time) (bool, error) {
+	t.Helper()
+	return stageAndPublish(t, func(out *outputStage) (bool, error) {
+		return writeOutputsIfModulesChanged(out, outPath, metaOutPath, modules, extras, now)
+	})
+}
+
+func TestOutputStageNoTocaDocsHastaPublicar(t *testing.T) {
+	dir := t.TempDir()
+	modulesPath := filepath.Join(dir, "modules.json")
+	stale := filepath.Join(dir, "badges", "viejo.json")
+	if err := os.MkdirAll(filepath.Dir(stale), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := newOutputStage()
+	if err != nil {
+		t.Fatalf("newOutputStage: %v", err)
+	}
+	if err := out.write(modulesPath, []byte("[]\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out.remove(stale)
+	if _, err := os.Stat(modulesPath); !os.IsNotExist(err) {
+		t.Fatal("write no debe tocar el destino antes de publicar")
+	}
+	if raw, err := out.read(modulesPath); err != nil || string(raw) != "[]\n" {
+		t.Fatalf("read debe ver lo preparado: %q / %v", raw, err)
+	}
+	if err := out.publish(); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	out.discard()
+	if raw, err := os.ReadFile(modulesPath); err != nil || string(raw) != "[]\n" {
+		t.Fatalf("modules.json = %q / %v", raw, err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatal("el borrado preparado debe aplicarse al publicar")
+	}
+	if _, err := os.Stat(out.dir); !os.IsNotExist(err) {
+		t.Fatal("discard debe borrar el directorio temporal")
+	}
+}
+
+func TestOutputStagePublishRestauraSiFallaUnDestino(t *testing.T) {
+	dir := t.TempDir()
+	modulesPath := filepath.Join(dir, "modules.json")
+	metaPath := filepath.Join(dir, "modules-meta.json")
+	newPath := filepath.Join(dir, "modules-mini.json")
+	if err := os.WriteFile(modulesPath, []byte("viejo"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Un directorio en lugar del archivo hace fallar el rename final.
+	blocked := filepath.Join(dir, "quarters.json")
+	if err := os.MkdirAll(filepath.Join(blocked, "ocupado"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := newOutputStage()
+	if err != nil {
+		t.Fatalf("newOutputStage: %v", err)
+	}
+	defer out.discard()
+	for _, path := range []string{modulesPath, metaPath, newPath, blocked} {
+		if err := out.write(path, []byte("nuevo")); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	if err := out.publish(); err == nil || !strings.Contains(err.Error(), "quarters.json") {
+		t.Fatalf("se esperaba el error de quarters.json, got %v", err)
+	}
+	if raw, _ := os.ReadFile(modulesPath); string(raw) != "viejo" {
+		t.Fatalf("modules.json debe volver a la versión anterior: %q", raw)
+	}
+	for _, path := range []string{metaPath, newPath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s no existía y debe borrarse al restaurar", path)
+		}
+	}
+}
+
+func TestOutputStageValidate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := syncConfig{
+		OutPath:         filepath.Join(dir, "modules.json"),
+		MetaOutPath:     filepath.Join(dir, "modules-meta.json"),
+		MiniOutPath:     filepath.Join(dir, "modules-mini.json"),
+		QuartersOutPath: filepath.Join(dir, "quarters.json"),
+	}
+	public := []ModuleOut{{ID: "1", Nombre: "Pagos", Fase: "Desarrollo", Estado: "En desarrollo", Porcentaje: 40, Tipo: "feature", ETA: "2026-11-01"}}
+	stage := func(modules []ModuleOut, mini []ModuleOut) *outputStage {
+		t.Helper()
+		out, err := newOutputStage()
+		if err != nil {
+			t.Fatalf("newOutputStage: %v", err)
+		}
+		t.Cleanup(out.discard)
+		if _, err := writeOutputsIfModulesChanged(out, cfg.OutPath, cfg.MetaOutPath, modules, metadataExtras{}, time.Now); err != nil {
+			t.Fatalf("writeOutputsIfModulesChanged: %v", err)
+		}
+		content, err := prepareMiniOutput(mini)
+		if err != nil {
+			t.Fatalf("prepareMiniOutput: %v", err)
+		}
+		if _, err := writeMiniOutput(out, cfg.MiniOutPath, content); err != nil {
+			t.Fatalf("writeMiniOutput: %v", err)
+		}
+		if _, err := writeQuarters(out, cfg.QuartersOutPath, buildQuarters(mini, quartersOut{}, time.Now())); err != nil {
+			t.Fatalf("writeQuarters: %v", err)
+		}
+		return out
+	}
+
+	if err := stage(public, public).validate(cfg); err != nil {
+		t.Fatalf("salidas coherentes: %v", err)
+	}
+
+	// El mini y los trimestres mencionan un módulo que modules.json no tiene.
+	extra := append(append([]ModuleOut{}, public...), ModuleOut{ID: "2", Nombre: "Fantasma", ETA: "2026-12-01"})
+	err := stage(public, extra).validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), `modules-mini.json menciona el módulo "2"`) || !strings.Contains(err.Error(), `quarters.json menciona el módulo "2"`) {
+		t.Fatalf("se esperaban referencias rotas, got %v", err)
+	}
+
+	broken := []ModuleOut{{ID: "1", Nombre: "Pagos", Porcentaje: 140}, {ID: "1", Nombre: "Copia"}}
+	err = stage(broken, nil).validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), `id "1" repetido`) || !strings.Contains(err.Error(), "fuera de 0–100") {
+		t.Fatalf("se esperaban errores de esquema, got %v", err)
+	}
+
+	out := stage(public, public)
+	if err := out.write(cfg.MetaOutPath, []byte("{roto")); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.validate(cfg); err == nil || !strings.Contains(err.Error(), "no es JSON válido") {
+		t.Fatalf("se esperaba JSON inválido, got %v", err)
+	}
+}
+
+func TestValidateModulesJSONCamposObligatorios(t *testing.T) {
+	ids, err := validateModulesJSON([]byte(`[{"id": "7", "nombre": "x", "fase": "Test", "estado": "QA", "porcentaje": 10}]`))
+	if err == nil || !strings.Contains(err.Error(), `sin "tipo"`) || !ids["7"] {
+		t.Fatalf("se esperaba el campo tipo faltante, got %v / %v", ids, err)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// quarterModule es un módulo dentro de la proyección trimestral. Vencido
+// marca lo que sigue abierto en un trimestre que ya terminó y
+// ArrastradoDesde, el trimestre en el que estaba antes de que moviera su ETA.
+type quarterModule struct {
+	ID              string `json:"id"`
+	Nombre          string `json:"nombre"`
+	Area            string `json:"area,omitempty"`
+	ETA             string `json:"eta,omitempty"`
+	Porcentaje      int    `json:"porcentaje"`
+	Vencido         bool   `json:"vencido,omitempty"`
+	ArrastradoDesde string `json:"arrastradoDesde,omitempty"`
+}
+
+// quarterOut agrupa los módulos de un trimestre por avance.
+type quarterOut struct {
+	Trimestre    string          `json:"trimestre"`
+	Inicio       string          `json:"inicio"`
+	Fin          string          `json:"fin"`
+	Planificados []quarterModule `json:"planificados"`
+	EnCurso      []quarterModule `json:"enCurso"`
+	Hechos       []quarterModule `json:"hechos"`
+}
+
+// quartersOut es el contenido de QUARTERS_OUTPUT.
+type quartersOut struct {
+	Actual     string          `json:"actual"`
+	Trimestres []quarterOut    `json:"trimestres"`
+	SinFecha   []quarterModule `json:"sinFecha"`
+}
+
+// quarterOf devuelve "2026-T3" para cualquier fecha de julio a septiembre
+// de 2026.
+func quarterOf(t time.Time) string {
+	return fmt.Sprintf("%d-T%d", t.Year(), (int(t.Month())-1)/3+1)
+}
+
+func quarterBounds(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), time.Month((int(t.Month())-1)/3*3+1), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 3, -1)
+}
+
+// moduleQuarterDate es la fecha que ubica al módulo: la ETA, que es el
+// compromiso, o el inicio si todavía no hay ETA.
+func moduleQuarterDate(m ModuleOut) (time.Time, bool) {
+	for _, raw := range []string{m.ETA, m.Inicio} {
+		if raw == "" {
+			continue
+		}
+		if t, err := time.Parse("2006-01-02", raw); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// buildQuarters proyecta la vista pública por trimestre. previous es la
+// proyección anterior: si un módulo abierto aparece ahora en un trimestre
+// posterior al que tenía, se marca como arrastrado desde ese trimestre, y la
+// marca se conserva mientras no vuelva a moverse.
+func buildQuarters(public []ModuleOut, previous quartersOut, now time.Time) quartersOut {
+	current := quarterOf(now.UTC())
+	type placement struct {
+		quarter string
+		from    string
+	}
+	before := map[string]placement{}
+	for _, q := range previous.Trimestres {
+		for _, group := range [][]quarterModule{q.Planificados, q.EnCurso, q.Hechos} {
+			for _, m := range group {
+				before[m.ID] = placement{quarter: q.Trimestre, from: m.ArrastradoDesde}
+			}
+		}
+	}
+
+	out := quartersOut{Actual: current, Trimestres: []quarterOut{}, SinFecha: []quarterModule{}}
+	byQuarter := map[string]*quarterOut{}
+	for _, m := range public {
+		if m.Retirado != "" || (m.Fase == "Archivado" && !isCompleted(m)) {
+			continue
+		}
+		entry := quarterModule{ID: m.ID, Nombre: m.Nombre, Area: m.Area, ETA: m.ETA, Porcentaje: m.Porcentaje}
+		date, ok := moduleQuarterDate(m)
+		if !ok {
+			out.SinFecha = append(out.SinFecha, entry)
+			continue
+		}
+		quarter := quarterOf(date)
+		bucket := byQuarter[quarter]
+		if bucket == nil {
+			start, end := quarterBounds(date)
+			bucket = &quarterOut{Trimestre: quarter, Inicio: start.Format("2006-01-02"), Fin: end.Format("2006-01-02"), Planificados: []quarterModule{}, EnCurso: []quarterModule{}, Hechos: []quarterModule{}}
+			byQuarter[quarter] = bucket
+		}
+		if isCompleted(m) {
+			bucket.Hechos = append(bucket.Hechos, entry)
+			continue
+		}
+		// "2026-T1" < "2026-T2" < "2027-T1": el orden de texto es el
+		// cronológico.
+		entry.Vencido = quarter < current
+		if prev, ok := before[m.ID]; ok {
+			switch {
+			case prev.quarter < quarter:
+				entry.ArrastradoDesde = prev.quarter
+			case prev.quarter == quarter:
+				entry.ArrastradoDesde = prev.from
+			}
+		}
+		if isWorkPhase(m.Fase) {
+			bucket.EnCurso = append(bucket.EnCurso, entry)
+		} else {
+			bucket.Planificados = append(bucket.Planificados, entry)
+		}
+	}
+
+	for _, bucket := range byQuarter {
+		for _, group := range [][]quarterModule{bucket.Planificados, bucket.EnCurso, bucket.Hechos} {
+			sortQuarterModules(group)
+		}
+		out.Trimestres = append(out.Trimestres, *bucket)
+	}
+	sort.Slice(out.Trimestres, func(i, j int) bool { return out.Trimestres[i].Trimestre < out.Trimestres[j].Trimestre })
+	sortQuarterModules(out.SinFecha)
+	return out
+}
+
+func sortQuarterModules(modules []quarterModule) {
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].ETA != modules[j].ETA {
+			return modules[i].ETA < modules[j].ETA
+		}
+		return modules[i].ID < modules[j].ID
+	})
+}
+
+// readPreviousQuarters carga la proyección publicada. Si no existe o no se
+// puede leer, no hay arrastres que detectar en esta corrida.
+func readPreviousQuarters(path string) (quartersOut, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return quartersOut{}, nil
+	}
+	if err != nil {
+		return quartersOut{}, err
+	}
+	var previous quartersOut
+	if err := json.Unmarshal(raw, &previous); err != nil {
+		return quartersOut{}, err
+	}
+	return previous, nil
+}
+
+// writeQuarters prepara QUARTERS_OUTPUT solo si cambió.
+func writeQuarters(out *outputStage, path string, quarters quartersOut) (bool, error) {
+	content, err := marshalJSON(quarters)
+	if err != nil {
+		return false, fmt.Errorf("preparar %s: %w", path, err)
+	}
+	changed, err := fileContentChanged(path, content)
+	if err != nil {
+		return false, fmt.Errorf("comparar %s: %w", path, err)
+	}
+	if !changed {
+		return false, nil
+	}
+	if err := out.write(path, content); err != nil {
+		return false, fmt.Errorf("escribir %s: %w", path, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuarterOf(t *testing.T) {
+	cases := map[string]string{"2026-01-01": "2026-T1", "2026-03-31": "2026-T1", "2026-07-15": "2026-T3", "2026-12-31": "2026-T4"}
+	for raw, want := range cases {
+		date, _ := time.Parse("2006-01-02", raw)
+		if got := quarterOf(date); got != want {
+			t.Errorf("quarterOf(%s) = %s; se esperaba %s", raw, got, want)
+		}
+	}
+	start, end := quarterBounds(time.Date(2026, 8, 20, 0, 0, 0, 0, time.UTC))
+	if start.Format("2006-01-02") != "2026-07-01" || end.Format("2006-01-02") != "2026-09-30" {
+		t.Fatalf("límites inesperados: %s – %s", start, end)
+	}
+}
+
+func TestBuildQuartersAgrupaYDetectaArrastres(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	public := []ModuleOut{
+		{ID: "1", Nombre: "Pagos", Tipo: "feature", Fase: "Deploy", Estado: "Liberado", Porcentaje: 100, ETA: "2026-08-01"},
+		{ID: "2", Nombre: "Reportes", Tipo: "feature", Fase: "Desarrollo", Estado: "En desarrollo", Porcentaje: 50, ETA: "2026-09-15"},
+		{ID: "3", Nombre: "Portal", Tipo: "feature", Fase: "Reportados", Estado: "Planificado", ETA: "2026-11-30"},
+		{ID: "4", Nombre: "Sin ETA", Tipo: "feature", Fase: "Test", Estado: "En pruebas", Inicio: "2027-01-10"},
+		{ID: "5", Nombre: "Idea", Tipo: "feature", Fase: "Reportados", Estado: "Planificado"},
+		{ID: "6", Nombre: "Viejo", Tipo: "feature", Fase: "Desarrollo", ETA: "2026-05-01", Retirado: "2026-06-01"},
+	}
+	previous := quartersOut{Trimestres: []quarterOut{{Trimestre: "2026-T3", Planificados: []quarterModule{{ID: "3"}}}}}
+
+	got := buildQuarters(public, previous, now)
+	if got.Actual != "2026-T4" || len(got.Trimestres) != 3 {
+		t.Fatalf("trimestres inesperados: %+v", got)
+	}
+	t3, t4, t1 := got.Trimestres[0], got.Trimestres[1], got.Trimestres[2]
+	if t3.Trimestre != "2026-T3" || len(t3.Hechos) != 1 || len(t3.EnCurso) != 1 || !t3.EnCurso[0].Vencido {
+		t.Fatalf("2026-T3 inesperado: %+v", t3)
+	}
+	if len(t4.Planificados) != 1 || t4.Planificados[0].ArrastradoDesde != "2026-T3" || t4.Planificados[0].Vencido {
+		t.Fatalf("el módulo 3 debe figurar arrastrado desde 2026-T3: %+v", t4)
+	}
+	if t1.Trimestre != "2027-T1" || len(t1.EnCurso) != 1 {
+		t.Fatalf("sin ETA se usa el inicio: %+v", t1)
+	}
+	if len(got.SinFecha) != 1 || got.SinFecha[0].ID != "5" {
+		t.Fatalf("sinFecha inesperado: %+v", got.SinFecha)
+	}
+
+	// La marca de arrastre se conserva mientras el módulo no vuelva a moverse.
+	again := buildQuarters(public, got, now)
+	if again.Trimestres[1].Planificados[0].ArrastradoDesde != "2026-T3" {
+		t.Fatalf("se perdió la marca de arrastre: %+v", again.Trimestres[1])
+	}
+}
+
+func TestWriteQuartersSoloSiCambia(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarters.json")
+	quarters := buildQuarters([]ModuleOut{{ID: "1", Nombre: "Pagos", Fase: "Reportados", ETA: "2026-11-01"}}, quartersOut{}, time.Now())
+	if changed, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return writeQuarters(out, path, quarters) }); err != nil || !changed {
+		t.Fatalf("primera escritura: %v / %v", changed, err)
+	}
+	if changed, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return writeQuarters(out, path, quarters) }); err != nil || changed {
+		t.Fatalf("sin cambios no debe reescribirse: %v / %v", changed, err)
+	}
+	previous, err := readPreviousQuarters(path)
+	if err != nil || len(previous.Trimestres) != 1 {
+		t.Fatalf("readPreviousQuarters: %+v / %v", previous, err)
+	}
+	if missing, err := readPreviousQuarters(filepath.Join(t.TempDir(), "no.json")); err != nil || len(missing.Trimestres) != 0 {
+		t.Fatalf("sin archivo previo no hay error: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Códigos de salida del sync. El scheduler los usa para distinguir una
+// corrida degradada (publicó, pero con advertencias) de una falla real y
+// alertar a la persona adecuada: credenciales para auth, GitHub para GraphQL.
+const (
+	exitSuccess        = 0
+	exitFailure        = 1
+	exitPartial        = 3
+	exitAuthFailure    = 4
+	exitGraphQLFailure = 5
+)
+
+// authError marca fallas de credenciales (token ausente, revocado o sin
+// permisos sobre el proyecto).
+type authError struct{ err error }
+
+func (e *authError) Error() string { return "autenticación: " + e.err.Error() }
+func (e *authError) Unwrap() error { return e.err }
+
+// graphQLError marca cualquier otra falla al consultar el API de GitHub.
+type graphQLError struct{ err error }
+
+func (e *graphQLError) Error() string { return "GraphQL: " + e.err.Error() }
+func (e *graphQLError) Unwrap() error { return e.err }
+
+// classifyGraphQLError decide si un error del cliente GraphQL se debe a las
+// credenciales. El cliente solo expone el texto de la respuesta, así que
+// buscamos las señales que GitHub devuelve en esos casos.
+func classifyGraphQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"401 unauthorized", "bad credentials", "403 forbidden", "resource not accessible"} {
+		if strings.Contains(msg, marker) {
+			return &authError{err: err}
+		}
+	}
+	return &graphQLError{err: err}
+}
+
+// phaseReport registra cuánto tardó cada fase de la corrida.
+type phaseReport struct {
+	Name           string `json:"name"`
+	DurationMillis int64  `json:"durationMillis"`
+	Error          string `json:"error,omitempty"`
+}
+
+// runReport es el reporte legible por máquinas que dejamos en RUN_REPORT al
+// terminar cada corrida.
+type runReport struct {
+	StartedAt        string        `json:"startedAt"`
+	FinishedAt       string        `json:"finishedAt"`
+	Outcome          string        `json:"outcome"`
+	ExitCode         int           `json:"exitCode"`
+	Phases           []phaseReport `json:"phases"`
+	ItemsProcessed   int           `json:"itemsProcessed"`
+	ModulesPublished int           `json:"modulesPublished"`
+	ModulesRetired   int           `json:"modulesRetired"`
+	Changed          bool          `json:"changed"`
+	Warnings         []string      `json:"warnings"`
+	Error            string        `json:"error,omitempty"`
+
+	GraphQLCost  *graphQLCost `json:"graphqlCost,omitempty"`
+	EpicsCreated []string     `json:"epicsCreated,omitempty"`
+}
+
+func newRunReport(now func() time.Time) *runReport {
+	return &runReport{
+		StartedAt: now().UTC().Format(time.RFC3339),
+		Phases:    []phaseReport{},
+		Warnings:  []string{},
+	}
+}
+
+// phase mide la duración de fn y la agrega al reporte con el nombre dado.
+func (r *runReport) phase(name string, now func() time.Time, fn func() error) error {
+	startedAt := now()
+	err := fn()
+	entry := phaseReport{Name: name, DurationMillis: now().Sub(startedAt).Milliseconds()}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.Phases = append(r.Phases, entry)
+	return err
+}
+
+// warn agrega una advertencia que no detiene la publicación pero convierte la
+// corrida en parcial.
+func (r *runReport) warn(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	r.Warnings = append(r.Warnings, message)
+	log.Printf("ADVERTENCIA: %s", message)
+}
+
+// exitCodeFor traduce el error final (o su ausencia) en el código de salida y
+// el resultado que se publica en el reporte.
+func exitCodeFor(err error, warnings int) (int, string) {
+	var authErr *authError
+	var gqlErr *graphQLError
+	switch {
+	case err == nil && warnings == 0:
+		return exitSuccess, "success"
+	case err == nil:
+		return exitPartial, "partial"
+	case errors.As(err, &authErr):
+		return exitAuthFailure, "auth_failure"
+	case errors.As(err, &gqlErr):
+		return exitGraphQLFailure, "graphql_failure"
+	default:
+		return exitFailure, "failure"
+	}
+}
+
+// finishRun cierra el reporte, lo escribe si se configuró RUN_REPORT y
+// devuelve el código de salida correspondiente.
+func finishRun(cfg syncConfig, report *runReport, now func() time.Time, err error) int {
+	code, outcome := exitCodeFor(err, len(report.Warnings))
+	report.FinishedAt = now().UTC().Format(time.RFC3339)
+	report.ExitCode = code
+	report.Outcome = outcome
+	if err != nil {
+		report.Error = err.Error()
+		log.Printf("ERROR: %v", err)
+	}
+
+	if cfg.ReportPath != "" {
+		reportJSON, marshalErr := marshalJSON(report)
+		if marshalErr == nil {
+			marshalErr = writeFile(cfg.ReportPath, reportJSON)
+		}
+		if marshalErr != nil {
+			log.Printf("no se pudo escribir el reporte %s: %v", cfg.ReportPath, marshalErr)
+		}
+	}
+	return code
+}
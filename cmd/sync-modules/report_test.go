@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	cases := []struct {
+		name        string
+		err         error
+		warnings    int
+		wantCode    int
+		wantOutcome string
+	}{
+		{"éxito", nil, 0, exitSuccess, "success"},
+		{"parcial", nil, 2, exitPartial, "partial"},
+		{"auth", classifyGraphQLError(errors.New("non-200 OK status code: 401 Unauthorized body: \"\"")), 0, exitAuthFailure, "auth_failure"},
+		{"graphql", classifyGraphQLError(errors.New("Could not resolve to a ProjectV2")), 0, exitGraphQLFailure, "graphql_failure"},
+		{"genérico", errors.New("disco lleno"), 1, exitFailure, "failure"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, outcome := exitCodeFor(tc.err, tc.warnings)
+			if code != tc.wantCode || outcome != tc.wantOutcome {
+				t.Fatalf("exitCodeFor = (%d, %q); want (%d, %q)", code, outcome, tc.wantCode, tc.wantOutcome)
+			}
+		})
+	}
+}
+
+func TestBuildModulesAdvierteStatusDesconocido(t *testing.T) {
+	var it Item
+	it.Content.Issue.Number = 42
+	it.Content.Issue.Title = "Bug renombrado"
+	it.Content.Issue.Labels.Nodes = []labelNode{{Name: "Tipo: Bug"}}
+	it.Status.Typename = "ProjectV2ItemFieldSingleSelectValue"
+	it.Status.Single.Name = githubv4.String("En revisión")
+
+	var ideas Item
+	ideas.Content.Issue.Number = 43
+	ideas.Status.Typename = "ProjectV2ItemFieldSingleSelectValue"
+	ideas.Status.Single.Name = githubv4.String("Ideas")
+
+	report := newRunReport(time.Now)
+	modules := buildModules([]Item{it, ideas}, report)
+
+	if len(modules) != 0 {
+		t.Fatalf("no se esperaban módulos públicos, got %d", len(modules))
+	}
+	if len(report.Warnings) != 1 {
+		t.Fatalf("se esperaba una advertencia (Ideas es privado a propósito), got %v", report.Warnings)
+	}
+}
+
+func TestRunSinTokenEscribeReporte(t *testing.T) {
+	dir := t.TempDir()
+	reportPath := filepath.Join(dir, "report.json")
+	env := map[string]string{
+		"OUTPUT":      filepath.Join(dir, "modules.json"),
+		"META_OUTPUT": filepath.Join(dir, "modules-meta.json"),
+		"RUN_REPORT":  reportPath,
+	}
+	fixed := time.Date(2026, 6, 25, 12, 0, 0, 0, time.UTC)
+
+	code := run(func(key string) string { return env[key] }, func() time.Time { return fixed })
+	if code != exitAuthFailure {
+		t.Fatalf("run sin token = %d; want %d", code, exitAuthFailure)
+	}
+
+	raw, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("ReadFile report: %v", err)
+	}
+	var report runReport
+	if err := json.Unmarshal(raw, &report); err != nil {
+		t.Fatalf("Unmarshal report: %v", err)
+	}
+	if report.Outcome != "auth_failure" || report.ExitCode != exitAuthFailure || report.Error == "" {
+		t.Fatalf("reporte inesperado: %+v", report)
+	}
+}
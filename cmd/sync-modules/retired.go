@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// estadoRetirado identifica a los módulos que salieron del tablero mientras su
+// issue seguía abierto. Los conservamos en modules.json con este estado para
+// que quien consulta el roadmap note el recorte en lugar de ver desaparecer
+// el trabajo sin explicación.
+const estadoRetirado = "Retirado del plan"
+
+// issueStateLookup consulta el estado actual de un issue a partir de su URL.
+// Es una variable de función para que las pruebas no dependan de GitHub.
+type issueStateLookup func(ctx context.Context, issueURL string) (githubv4.IssueState, error)
+
+type issueStateQuery struct {
+	RateLimit rateLimitInfo `graphql:"rateLimit"`
+	Resource  struct {
+		Issue struct {
+			State githubv4.IssueState
+		} `graphql:"... on Issue"`
+	} `graphql:"resource(url: $url)"`
+}
+
+// graphQLIssueStateLookup resuelve el estado con la consulta resource(url:),
+// que no requiere separar dueño, repositorio y número del enlace publicado.
+func graphQLIssueStateLookup(cli *githubv4.Client) issueStateLookup {
+	return func(ctx context.Context, issueURL string) (githubv4.IssueState, error) {
+		parsed, err := url.Parse(issueURL)
+		if err != nil {
+			return "", fmt.Errorf("URL de issue inválida %q: %w", issueURL, err)
+		}
+		var q issueStateQuery
+		if err := cli.Query(ctx, &q, map[string]interface{}{"url": githubv4.URI{URL: parsed}}); err != nil {
+			return "", classifyGraphQLError(err)
+		}
+		recordQueryCost(ctx, "issue_state", q.RateLimit)
+		if q.Resource.Issue.State == "" {
+			return "", fmt.Errorf("%s no corresponde a un issue", issueURL)
+		}
+		return q.Resource.Issue.State, nil
+	}
+}
+
+// readPreviousModules carga el modules.json de la corrida anterior, que es la
+// memoria entre ejecuciones: así sabemos qué módulos existían sin mantener un
+// archivo de estado adicional. La ausencia del archivo no es un error.
+func readPreviousModules(path string) ([]ModuleOut, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var modules []ModuleOut
+	if err := json.Unmarshal(raw, &modules); err != nil {
+		return nil, fmt.Errorf("interpretar %s: %w", path, err)
+	}
+	return modules, nil
+}
+
+// boardIssueIDs devuelve los números de issue presentes en el tablero,
+// públicos o no. Un módulo que solo pasó a una columna privada sigue en el
+// proyecto y no debe marcarse como retirado.
+func boardIssueIDs(items []Item) map[string]struct{} {
+	seen := make(map[string]struct{}, len(items))
+	for _, it := range items {
+		if number := it.Content.Issue.Number; number != 0 {
+			seen[strconv.Itoa(number)] = struct{}{}
+		}
+	}
+	return seen
+}
+
+// retainRemovedModules recorre los módulos publicados en la corrida anterior
+// que ya no aparecen en el tablero. Si su issue sigue abierto los devuelve con
+// estado "Retirado del plan" y la fecha en que detectamos la salida; si se
+// cerró, los deja caer. Ante la duda (sin enlace o error al consultar)
+// preferimos conservarlos: ocultar un recorte es peor que mostrarlo de más.
+func retainRemovedModules(ctx context.Context, previous []ModuleOut, seen map[string]struct{}, lookup issueStateLookup, today string, report *runReport) []ModuleOut {
+	var retained []ModuleOut
+	for _, prev := range previous {
+		if _, onBoard := seen[prev.ID]; onBoard {
+			continue
+		}
+
+		issueURL := ""
+		if len(prev.Enlaces) > 0 {
+			issueURL = prev.Enlaces[0].URL
+		}
+		if issueURL == "" {
+			report.warn("módulo %s salió del tablero y no tiene enlace para consultar su estado; se conserva como retirado", prev.ID)
+		} else {
+			state, err := lookup(ctx, issueURL)
+			if err != nil {
+				report.warn("no se pudo consultar el estado del issue %s: %v; se conserva como retirado", prev.ID, err)
+			} else if state != githubv4.IssueStateOpen {
+				continue
+			}
+		}
+
+		if prev.Estado != estadoRetirado || prev.Retirado == "" {
+			prev.Estado = estadoRetirado
+			prev.Retirado = today
+		}
+		retained = append(retained, prev)
+	}
+	return retained
+}
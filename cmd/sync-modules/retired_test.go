@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func TestRetainRemovedModules(t *testing.T) {
+	previous := []ModuleOut{
+		{ID: "1", Estado: "En desarrollo", Enlaces: buildLinks("https://github.com/o/r/issues/1")},
+		{ID: "2", Estado: "En pruebas", Enlaces: buildLinks("https://github.com/o/r/issues/2")},
+		{ID: "3", Estado: "Liberado", Enlaces: buildLinks("https://github.com/o/r/issues/3")},
+		{ID: "4", Estado: estadoRetirado, Retirado: "2026-01-05", Enlaces: buildLinks("https://github.com/o/r/issues/4")},
+		{ID: "5", Estado: "Reportado", Enlaces: buildLinks("https://github.com/o/r/issues/5")},
+	}
+	// El 1 sigue en el tablero (aunque quizá en una columna privada).
+	seen := map[string]struct{}{"1": {}}
+	states := map[string]githubv4.IssueState{
+		"https://github.com/o/r/issues/2": githubv4.IssueStateOpen,
+		"https://github.com/o/r/issues/3": githubv4.IssueStateClosed,
+		"https://github.com/o/r/issues/4": githubv4.IssueStateOpen,
+	}
+	lookup := func(_ context.Context, issueURL string) (githubv4.IssueState, error) {
+		if state, ok := states[issueURL]; ok {
+			return state, nil
+		}
+		return "", errors.New("sin respuesta")
+	}
+
+	report := newRunReport(time.Now)
+	got := retainRemovedModules(context.Background(), previous, seen, lookup, "2026-07-01", report)
+
+	want := map[string]string{"2": "2026-07-01", "4": "2026-01-05", "5": "2026-07-01"}
+	if len(got) != len(want) {
+		t.Fatalf("módulos retenidos = %+v; se esperaban %v", got, want)
+	}
+	for _, m := range got {
+		if m.Estado != estadoRetirado || m.Retirado != want[m.ID] {
+			t.Errorf("módulo %s = (%q, %q); want (%q, %q)", m.ID, m.Estado, m.Retirado, estadoRetirado, want[m.ID])
+		}
+	}
+	if len(report.Warnings) != 1 {
+		t.Fatalf("se esperaba una advertencia por la consulta fallida, got %v", report.Warnings)
+	}
+}
+
+func TestReadPreviousModulesSinArchivo(t *testing.T) {
+	modules, err := readPreviousModules(filepath.Join(t.TempDir(), "modules.json"))
+	if err != nil || modules != nil {
+		t.Fatalf("sin archivo previo se esperaba (nil, nil), got (%v, %v)", modules, err)
+	}
+}
+
+func TestReadPreviousModulesArchivoDanado(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "modules.json")
+	if err := os.WriteFile(path, []byte("{no es json"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := readPreviousModules(path); err == nil {
+		t.Fatal("un modules.json dañado debería reportarse")
+	}
+}
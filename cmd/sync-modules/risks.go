@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// Severidades del registro de riesgos. Usan los mismos niveles que la
+// confianza de la ETA para que el comité lea una sola escala.
+var riskSeverityAliases = map[string]string{
+	"alta":    confianzaAlta,
+	"high":    confianzaAlta,
+	"critica": confianzaAlta,
+	"media":   confianzaMedia,
+	"medium":  confianzaMedia,
+	"baja":    confianzaBaja,
+	"low":     confianzaBaja,
+}
+
+// riskOut es un riesgo declarado en la sección "Riesgos" de una épica.
+type riskOut struct {
+	ID         string `json:"id"`
+	Modulo     string `json:"modulo"`
+	Area       string `json:"area,omitempty"`
+	Riesgo     string `json:"riesgo"`
+	Severidad  string `json:"severidad,omitempty"`
+	Mitigacion string `json:"mitigacion,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+type riskSeverityCounts struct {
+	Alta    int `json:"alta"`
+	Media   int `json:"media"`
+	Baja    int `json:"baja"`
+	SinDato int `json:"sinDato"`
+}
+
+// riskRegister es el contenido de RISKS_OUTPUT.
+type riskRegister struct {
+	Riesgos      []riskOut          `json:"riesgos"`
+	PorSeveridad riskSeverityCounts `json:"porSeveridad"`
+}
+
+// riskSection devuelve las líneas bajo el primer encabezado que empieza con
+// "Riesgos" (el formulario de épica lo titula "Riesgos/Dependencias") hasta
+// el siguiente encabezado.
+func riskSection(body string) []string {
+	var lines []string
+	inSection := false
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r", ""), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			if inSection {
+				break
+			}
+			inSection = strings.HasPrefix(normalizeText(strings.TrimLeft(trimmed, "# ")), "riesgos")
+			continue
+		}
+		if inSection {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseRiskLine separa "[Alta] texto — Mitigación: plan" en sus partes. La
+// etiqueta de severidad va al inicio entre corchetes o paréntesis; ok es
+// false si trae una etiqueta que no reconocemos.
+func parseRiskLine(text string) (risk, severity, mitigation string, ok bool) {
+	text = strings.TrimSpace(text)
+	ok = true
+	if len(text) > 0 && (text[0] == '[' || text[0] == '(') {
+		closing := "]"
+		if text[0] == '(' {
+			closing = ")"
+		}
+		if end := strings.Index(text, closing); end > 0 {
+			tag := normalizeText(text[1:end])
+			if level, known := riskSeverityAliases[tag]; known {
+				severity = level
+			} else {
+				ok = false
+			}
+			text = strings.TrimSpace(text[end+1:])
+		}
+	}
+	lower := strings.ToLower(text)
+	for _, marker := range []string{"mitigación:", "mitigacion:"} {
+		if cut := strings.Index(lower, marker); cut >= 0 {
+			mitigation = strings.TrimSpace(text[cut+len(marker):])
+			text = strings.TrimRight(strings.TrimSpace(text[:cut]), " —–-;,.")
+			break
+		}
+	}
+	return strings.TrimSpace(text), severity, mitigation, ok
+}
+
+// extractRisks lee los riesgos de una épica. Cada viñeta es un riesgo y las
+// líneas con sangría que le siguen lo continúan; si la sección es texto libre
+// (como la deja el formulario), cada línea es un riesgo.
+func extractRisks(body string) (risks []riskOut, unknownTags []string) {
+	var current []string
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		risk, severity, mitigation, ok := parseRiskLine(strings.Join(current, " "))
+		current = nil
+		if !ok {
+			unknownTags = append(unknownTags, risk)
+		}
+		if risk == "" || normalizeText(risk) == "_no response_" {
+			return
+		}
+		risks = append(risks, riskOut{Riesgo: risk, Severidad: severity, Mitigacion: mitigation})
+	}
+	for _, line := range riskSection(body) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "+ "):
+			flush()
+			item := strings.TrimSpace(trimmed[2:])
+			for _, box := range []string{"[ ] ", "[x] ", "[X] "} {
+				item = strings.TrimPrefix(item, box)
+			}
+			current = []string{item}
+		case len(current) > 0 && line != trimmed:
+			current = append(current, trimmed)
+		default:
+			flush()
+			current = []string{trimmed}
+		}
+	}
+	flush()
+	return risks, unknownTags
+}
+
+// buildRiskRegister consolida los riesgos de las épicas abiertas del
+// tablero. Las cerradas ya no comprometen el plan.
+func buildRiskRegister(items []Item, report *runReport) riskRegister {
+	register := riskRegister{Riesgos: []riskOut{}}
+	for _, it := range items {
+		iss := it.Content.Issue
+		if iss.Number == 0 || iss.State == githubv4.IssueStateClosed {
+			continue
+		}
+		projectTipo := projectValueToString(it.Tipo.Typename, string(it.Tipo.Single.Name), string(it.Tipo.Text.Text))
+		if !isEpic(labelNames(iss.Labels.Nodes), projectTipo) {
+			continue
+		}
+		risks, unknownTags := extractRisks(iss.Body)
+		for _, risk := range unknownTags {
+			report.warn("épica #%d con severidad de riesgo no reconocida: %q", iss.Number, risk)
+		}
+		for _, risk := range risks {
+			risk.ID = strconv.Itoa(iss.Number)
+			risk.Modulo = strings.TrimSpace(strings.TrimPrefix(iss.Title, epicTitlePrefix))
+			risk.Area = strings.TrimSpace(singleName(it.Area.Typename, it.Area.Single.Name))
+			risk.URL = iss.URL.String()
+			register.Riesgos = append(register.Riesgos, risk)
+			switch risk.Severidad {
+			case confianzaAlta:
+				register.PorSeveridad.Alta++
+			case confianzaMedia:
+				register.PorSeveridad.Media++
+			case confianzaBaja:
+				register.PorSeveridad.Baja++
+			default:
+				register.PorSeveridad.SinDato++
+			}
+		}
+	}
+	severityOrder := map[string]int{confianzaAlta: 0, confianzaMedia: 1, confianzaBaja: 2, "": 3}
+	sort.SliceStable(register.Riesgos, func(i, j int) bool {
+		a, b := register.Riesgos[i], register.Riesgos[j]
+		if severityOrder[a.Severidad] != severityOrder[b.Severidad] {
+			return severityOrder[a.Severidad] < severityOrder[b.Severidad]
+		}
+		return a.Modulo < b.Modulo
+	})
+	return register
+}
+
+// writeRiskRegister escribe el registro completo. Como INTERNAL_OUTPUT, no
+// se publica: el workflow lo sube como artefacto.
+func writeRiskRegister(out *outputStage, path string, register riskRegister) error {
+	content, err := marshalJSON(register)
+	if err != nil {
+		return fmt.Errorf("preparar %s: %w", path, err)
+	}
+	if err := out.write(path, content); err != nil {
+		return fmt.Errorf("escribir %s: %w", path, err)
+	}
+	return nil
+}
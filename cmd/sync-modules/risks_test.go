@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func TestParseRiskLine(t *testing.T) {
+	cases := []struct {
+		line                       string
+		risk, severity, mitigation string
+		ok                         bool
+	}{
+		{"[Alta] Proveedor sin SLA — Mitigación: contrato de respaldo", "Proveedor sin SLA", confianzaAlta, "contrato de respaldo", true},
+		{"(low) Falta documentación", "Falta documentación", confianzaBaja, "", true},
+		{"Dependencia del equipo de datos; mitigacion: acordar fechas", "Dependencia del equipo de datos", "", "acordar fechas", true},
+		{"[Urgente] Servidor viejo", "Servidor viejo", "", "", false},
+	}
+	for _, tc := range cases {
+		risk, severity, mitigation, ok := parseRiskLine(tc.line)
+		if risk != tc.risk || severity != tc.severity || mitigation != tc.mitigation || ok != tc.ok {
+			t.Errorf("parseRiskLine(%q) = (%q, %q, %q, %v)", tc.line, risk, severity, mitigation, ok)
+		}
+	}
+}
+
+func TestExtractRisks(t *testing.T) {
+	body := "### Objetivo\nVender más\n\n### Riesgos/Dependencias\n\n" +
+		"- [Media] API de pagos inestable\n  en horas pico. Mitigación: reintentos\n" +
+		"- [ ] [Alta] Migración de datos\n" +
+		"\n### Entregables\n- No es un riesgo\n"
+	risks, unknown := extractRisks(body)
+	if len(unknown) != 0 || len(risks) != 2 {
+		t.Fatalf("riesgos = %+v, desconocidas = %v", risks, unknown)
+	}
+	if risks[0].Riesgo != "API de pagos inestable en horas pico" || risks[0].Mitigacion != "reintentos" || risks[0].Severidad != confianzaMedia {
+		t.Fatalf("la línea de continuación debe unirse al riesgo: %+v", risks[0])
+	}
+	if risks[1].Riesgo != "Migración de datos" || risks[1].Severidad != confianzaAlta {
+		t.Fatalf("riesgo inesperado: %+v", risks[1])
+	}
+
+	// Así queda la sección cuando se llena el formulario con texto libre.
+	free, _ := extractRisks("### Riesgos/Dependencias\n\nDepende del proveedor X\nFalta presupuesto\n")
+	if len(free) != 2 || free[1].Riesgo != "Falta presupuesto" {
+		t.Fatalf("texto libre: %+v", free)
+	}
+	if empty, _ := extractRisks("### Riesgos/Dependencias\n\n_No response_\n"); len(empty) != 0 {
+		t.Fatalf("una sección vacía no tiene riesgos: %+v", empty)
+	}
+}
+
+func TestBuildRiskRegister(t *testing.T) {
+	pagos := epicItem(7, "Ventas", githubv4.IssueStateOpen, "Tipo: Épica")
+	pagos.Content.Issue.Title = "[EPIC] Pagos"
+	pagos.Content.Issue.Body = "### Riesgos\n- [Baja] Cambio de pasarela\n- [Alta] Fraude\n- [Quizás] Clima\n"
+	cerrada := epicItem(8, "Compras", githubv4.IssueStateClosed, "Tipo: Épica")
+	cerrada.Content.Issue.Body = "### Riesgos\n- [Alta] Ya no aplica\n"
+	feature := epicItem(9, "Ventas", githubv4.IssueStateOpen, "Tipo: Feature")
+	feature.Content.Issue.Body = "### Riesgos\n- [Alta] No es épica\n"
+
+	report := newRunReport(time.Now)
+	register := buildRiskRegister([]Item{pagos, cerrada, feature}, report)
+	if len(register.Riesgos) != 3 || register.Riesgos[0].Riesgo != "Fraude" || register.Riesgos[0].Modulo != "Pagos" || register.Riesgos[0].Area != "Ventas" {
+		t.Fatalf("registro inesperado: %+v", register.Riesgos)
+	}
+	if register.PorSeveridad != (riskSeverityCounts{Alta: 1, Baja: 1, SinDato: 1}) {
+		t.Fatalf("conteo inesperado: %+v", register.PorSeveridad)
+	}
+	if len(report.Warnings) != 1 {
+		t.Fatalf("una severidad desconocida debe advertir: %v", report.Warnings)
+	}
+
+	path := filepath.Join(t.TempDir(), "risks.json")
+	if _, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return false, writeRiskRegister(out, path, register) }); err != nil {
+		t.Fatalf("writeRiskRegister: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var decoded riskRegister
+	if err := json.Unmarshal(raw, &decoded); err != nil || len(decoded.Riesgos) != 3 {
+		t.Fatalf("registro escrito ilegible: %v", err)
+	}
+}
+
+func TestLoadConfigRechazaRiesgosPublicos(t *testing.T) {
+	env := map[string]string{"RISKS_OUTPUT": "docs/risks.json"}
+	if _, err := loadConfig(func(key string) string { return env[key] }); err == nil {
+		t.Fatal("RISKS_OUTPUT junto a modules.json debe rechazarse")
+	}
+}
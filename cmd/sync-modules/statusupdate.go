@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// maxStatusUpdateRunes limita el texto publicado; la página muestra la
+// narrativa semanal, no un documento completo.
+const maxStatusUpdateRunes = 2000
+
+// statusUpdateOut es la última actualización de estado ("Status updates")
+// publicada en el Project, para mostrar la narrativa del PM junto a los datos.
+type statusUpdateOut struct {
+	Texto    string `json:"texto"`
+	Fecha    string `json:"fecha"`
+	Estado   string `json:"estado,omitempty"`
+	Inicio   string `json:"inicio,omitempty"`
+	Objetivo string `json:"objetivo,omitempty"`
+}
+
+// statusUpdateFetcher trae la última actualización del proyecto, o nil si
+// todavía no hay ninguna. Es una variable de función para probar sin GitHub.
+type statusUpdateFetcher func(ctx context.Context) (*statusUpdateOut, error)
+
+type statusUpdateQuery struct {
+	RateLimit rateLimitInfo `graphql:"rateLimit"`
+	Org       struct {
+		Project struct {
+			StatusUpdates struct {
+				Nodes []struct {
+					Body       string
+					CreatedAt  GHFlexDate
+					StartDate  GHFlexDate
+					TargetDate GHFlexDate
+					Status     string
+				}
+			} `graphql:"statusUpdates(first: 1, orderBy: {field: CREATED_AT, direction: DESC})"`
+		} `graphql:"projectV2(number: $projectNumber)"`
+	} `graphql:"organization(login: $org)"`
+}
+
+// statusUpdateLabels traduce el estado de la actualización al español de la
+// página. Un valor nuevo de GitHub se publica tal cual en lugar de perderse.
+var statusUpdateLabels = map[string]string{
+	"ON_TRACK":  "En curso",
+	"AT_RISK":   "En riesgo",
+	"OFF_TRACK": "Fuera de curso",
+	"COMPLETE":  "Completado",
+	"INACTIVE":  "Inactivo",
+}
+
+func graphQLStatusUpdateFetcher(cli *githubv4.Client, cfg syncConfig) statusUpdateFetcher {
+	return func(ctx context.Context) (*statusUpdateOut, error) {
+		var q statusUpdateQuery
+		vars := map[string]interface{}{
+			"org":           githubv4.String(cfg.Org),
+			"projectNumber": githubv4.Int(cfg.ProjectNum),
+		}
+		if err := cli.Query(ctx, &q, vars); err != nil {
+			return nil, classifyGraphQLError(err)
+		}
+		recordQueryCost(ctx, "status_update", q.RateLimit)
+		nodes := q.Org.Project.StatusUpdates.Nodes
+		if len(nodes) == 0 {
+			return nil, nil
+		}
+		latest := nodes[0]
+		estado := strings.TrimSpace(latest.Status)
+		if label, ok := statusUpdateLabels[estado]; ok {
+			estado = label
+		}
+		return &statusUpdateOut{
+			Texto:    truncateRunes(strings.TrimSpace(strings.ReplaceAll(latest.Body, "\r", "")), maxStatusUpdateRunes),
+			Fecha:    toISO(latest.CreatedAt),
+			Estado:   estado,
+			Inicio:   toISO(latest.StartDate),
+			Objetivo: toISO(latest.TargetDate),
+		}, nil
+	}
+}
+
+// publishedStatusUpdate lee la actualización que ya está en modules-meta.json.
+// Si GitHub falla la conservamos: es mejor mostrar la narrativa de la semana
+// pasada que hacerla desaparecer por un error pasajero.
+func publishedStatusUpdate(metaOutPath string) *statusUpdateOut {
+	raw, err := os.ReadFile(metaOutPath)
+	if err != nil {
+		return nil
+	}
+	var current MetadataOut
+	if err := json.Unmarshal(raw, &current); err != nil {
+		return nil
+	}
+	return current.Actualizacion
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteOutputsPublicaActualizacionDeEstado(t *testing.T) {
+	dir := t.TempDir()
+	modulesPath := filepath.Join(dir, "modules.json")
+	metaPath := filepath.Join(dir, "modules-meta.json")
+	if err := os.WriteFile(modulesPath, []byte("[]\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile modules: %v", err)
+	}
+	if err := os.WriteFile(metaPath, []byte("{\"generatedAt\":\"2026-01-01T00:00:00Z\"}\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile metadata: %v", err)
+	}
+	fixed := func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) }
+
+	if publishedStatusUpdate(metaPath) != nil {
+		t.Fatal("sin actualización publicada se esperaba nil")
+	}
+
+	update := &statusUpdateOut{Texto: "Semana 42: cerramos el módulo de pagos.", Fecha: "2026-10-16", Estado: "En curso"}
+	changed, err := writeAndPublishOutputs(t, modulesPath, metaPath, []ModuleOut{}, metadataExtras{Actualizacion: update}, fixed)
+	if err != nil || !changed {
+		t.Fatalf("una actualización nueva debe reescribir la metadata, got (%v, %v)", changed, err)
+	}
+	if got := publishedStatusUpdate(metaPath); got == nil || *got != *update {
+		t.Fatalf("actualización publicada = %+v, se esperaba %+v", got, update)
+	}
+
+	same := *update
+	changed, err = writeAndPublishOutputs(t, modulesPath, metaPath, []ModuleOut{}, metadataExtras{Actualizacion: &same}, fixed)
+	if err != nil || changed {
+		t.Fatalf("la misma actualización no debe reescribir la metadata, got (%v, %v)", changed, err)
+	}
+
+	newer := &statusUpdateOut{Texto: "Semana 43", Fecha: "2026-10-23", Estado: "En riesgo"}
+	changed, err = writeAndPublishOutputs(t, modulesPath, metaPath, []ModuleOut{}, metadataExtras{Actualizacion: newer}, fixed)
+	if err != nil || !changed {
+		t.Fatalf("una actualización distinta debe reescribir la metadata, got (%v, %v)", changed, err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// statusDisplay describe cómo debe mostrarse un estado público. El frontend
+// lo usa para la leyenda y el color de las tarjetas, de modo que agregar o
+// renombrar un estado se hace en un solo lugar.
+type statusDisplay struct {
+	Estado      string `json:"estado"`
+	Color       string `json:"color"`
+	Icono       string `json:"icono"`
+	Orden       int    `json:"orden"`
+	Descripcion string `json:"descripcion"`
+	// Clave es la clave de traducción; vacía en TAXONOMY_PATH se deriva del
+	// nombre del estado.
+	Clave string `json:"clave,omitempty"`
+}
+
+// taxonomy es la configuración de presentación de estados. Sin TAXONOMY_PATH
+// usamos defaultTaxonomy, que refleja los estados que hoy produce el sync.
+type taxonomy struct {
+	Estados []statusDisplay `json:"estados"`
+}
+
+// colorTokens son los únicos colores aceptados; coinciden con las variables
+// de docs/style.css para que un token mal escrito se detecte aquí y no como
+// una tarjeta sin color en la página.
+var colorTokens = map[string]struct{}{
+	"muted":  {},
+	"green":  {},
+	"blue":   {},
+	"yellow": {},
+	"red":    {},
+}
+
+// publishedStatuses enumera todos los estados que buildModules y
+// retainRemovedModules pueden escribir en modules.json.
+var publishedStatuses = []string{
+	"Reportado", "En atención", "Resuelto",
+	"En prototipo", "En desarrollo", "En pruebas", "En validación", "Liberado", "Archivado",
+	estadoRetirado,
+}
+
+func defaultTaxonomy() taxonomy {
+	return taxonomy{Estados: []statusDisplay{
+		{Estado: "Reportado", Color: "red", Icono: "⚠", Orden: 10, Descripcion: "Bug recibido, pendiente de atención"},
+		{Estado: "En atención", Color: "green", Icono: "⏳", Orden: 20, Descripcion: "Bug en corrección"},
+		{Estado: "Resuelto", Color: "blue", Icono: "✔", Orden: 30, Descripcion: "Bug corregido"},
+		{Estado: "En prototipo", Color: "muted", Icono: "✎", Orden: 40, Descripcion: "Explorando la solución"},
+		{Estado: "En desarrollo", Color: "green", Icono: "⚙", Orden: 50, Descripcion: "En construcción"},
+		{Estado: "En pruebas", Color: "yellow", Icono: "🧪", Orden: 60, Descripcion: "Verificando calidad"},
+		{Estado: "En validación", Color: "blue", Icono: "🔍", Orden: 70, Descripcion: "Validación previa a la liberación"},
+		{Estado: "Liberado", Color: "blue", Icono: "🚀", Orden: 80, Descripcion: "Disponible para usuarios"},
+		{Estado: "Archivado", Color: "muted", Icono: "🗄", Orden: 90, Descripcion: "Cerrado sin más cambios"},
+		{Estado: estadoRetirado, Color: "muted", Icono: "⊘", Orden: 100, Descripcion: "Salió del plan con el issue aún abierto"},
+	}}
+}
+
+// loadTaxonomy lee TAXONOMY_PATH si está definido. Validamos al arrancar que
+// cada estado publicado tenga presentación: un estado sin leyenda es justo el
+// desfase entre backend y frontend que la taxonomía busca evitar.
+func loadTaxonomy(path string, readFile func(string) ([]byte, error)) (taxonomy, error) {
+	if strings.TrimSpace(path) == "" {
+		return defaultTaxonomy(), nil
+	}
+	raw, err := readFile(path)
+	if err != nil {
+		return taxonomy{}, fmt.Errorf("leer TAXONOMY_PATH %s: %w", path, err)
+	}
+	var tax taxonomy
+	if err := json.Unmarshal(raw, &tax); err != nil {
+		return taxonomy{}, fmt.Errorf("interpretar TAXONOMY_PATH %s: %w", path, err)
+	}
+	if err := tax.validate(); err != nil {
+		return taxonomy{}, fmt.Errorf("TAXONOMY_PATH %s: %w", path, err)
+	}
+	return tax, nil
+}
+
+func (t taxonomy) validate() error {
+	seen := map[string]struct{}{}
+	for _, display := range t.Estados {
+		if strings.TrimSpace(display.Estado) == "" {
+			return fmt.Errorf("hay un estado sin nombre")
+		}
+		if _, dup := seen[display.Estado]; dup {
+			return fmt.Errorf("estado duplicado %q", display.Estado)
+		}
+		seen[display.Estado] = struct{}{}
+		if _, ok := colorTokens[display.Color]; !ok {
+			return fmt.Errorf("estado %q con color desconocido %q", display.Estado, display.Color)
+		}
+	}
+	for _, estado := range publishedStatuses {
+		if _, ok := seen[estado]; !ok {
+			return fmt.Errorf("falta la presentación del estado %q", estado)
+		}
+	}
+	return t.validateKeys()
+}
+
+// leyenda devuelve los estados ordenados para publicarlos en modules-meta.json.
+func (t taxonomy) leyenda() []statusDisplay {
+	out := append([]statusDisplay(nil), t.Estados...)
+	for i := range out {
+		out[i].Clave = t.statusKey(out[i].Estado)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Orden < out[j].Orden })
+	return out
+}
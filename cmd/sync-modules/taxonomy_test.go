@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultTaxonomyCubreEstadosPublicados(t *testing.T) {
+	if err := defaultTaxonomy().validate(); err != nil {
+		t.Fatalf("la taxonomía por defecto no es válida: %v", err)
+	}
+}
+
+func TestLoadTaxonomyValida(t *testing.T) {
+	cases := []struct {
+		name    string
+		mutate  func(*taxonomy)
+		wantErr string
+	}{
+		{"estado faltante", func(tax *taxonomy) { tax.Estados = tax.Estados[1:] }, "falta la presentación"},
+		{"color desconocido", func(tax *taxonomy) { tax.Estados[0].Color = "fucsia" }, "color desconocido"},
+		{"duplicado", func(tax *taxonomy) { tax.Estados = append(tax.Estados, tax.Estados[0]) }, "duplicado"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tax := defaultTaxonomy()
+			tc.mutate(&tax)
+			raw, err := json.Marshal(tax)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			_, err = loadTaxonomy("taxonomy.json", func(string) ([]byte, error) { return raw, nil })
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("loadTaxonomy() error = %v; want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLeyendaOrdenada(t *testing.T) {
+	tax := taxonomy{Estados: []statusDisplay{{Estado: "b", Orden: 2}, {Estado: "a", Orden: 1}}}
+	got := tax.leyenda()
+	if got[0].Estado != "a" || got[1].Estado != "b" {
+		t.Fatalf("leyenda() = %+v; se esperaba orden ascendente", got)
+	}
+	if tax.Estados[0].Estado != "b" {
+		t.Fatal("leyenda() no debe reordenar la taxonomía original")
+	}
+}
+
+func TestWriteOutputsReescribeMetadataSiCambiaLeyenda(t *testing.T) {
+	dir := t.TempDir()
+	modulesPath := filepath.Join(dir, "modules.json")
+	metaPath := filepath.Join(dir, "modules-meta.json")
+	if err := os.WriteFile(modulesPath, []byte("[]\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile modules: %v", err)
+	}
+	if err := os.WriteFile(metaPath, []byte("{\"generatedAt\":\"2026-01-01T00:00:00Z\"}\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile metadata: %v", err)
+	}
+
+	fixed := func() time.Time { return time.Date(2026, 6, 25, 12, 0, 0, 0, time.UTC) }
+	leyenda := defaultTaxonomy().leyenda()
+	changed, err := writeAndPublishOutputs(t, modulesPath, metaPath, []ModuleOut{}, metadataExtras{Leyenda: leyenda}, fixed)
+	if err != nil || !changed {
+		t.Fatalf("con leyenda nueva se esperaba reescribir la metadata, got (%v, %v)", changed, err)
+	}
+
+	changed, err = writeAndPublishOutputs(t, modulesPath, metaPath, []ModuleOut{}, metadataExtras{Leyenda: leyenda}, fixed)
+	if err != nil || changed {
+		t.Fatalf("sin cambios no debe reescribirse la metadata, got (%v, %v)", changed, err)
+	}
+}
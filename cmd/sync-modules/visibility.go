@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// visibility decide qué campos de ModuleOut son internos: se conservan en la
+// salida interna (INTERNAL_OUTPUT) y se borran de docs/modules.json, que es
+// público en Pages. Sin VISIBILITY_PATH usamos defaultVisibility.
+type visibility struct {
+	Internos []string `json:"internos"`
+}
+
+// defaultVisibility oculta lo que solo sirve al equipo: los nombres reales de
+// los responsables y la prioridad del tablero. propietario y enlaces siguen
+// públicos porque la página ya los muestra; un VISIBILITY_PATH puede
+// ocultarlos, por ejemplo si los issues viven en un repositorio privado.
+func defaultVisibility() visibility {
+	return visibility{Internos: []string{"responsables", "prioridad"}}
+}
+
+// hideableModuleFields mapea el nombre JSON de cada campo opcional de
+// ModuleOut a su índice. Solo los campos con omitempty se pueden ocultar: los
+// demás son obligatorios en docs/modules.schema.json y la página no funciona
+// sin ellos.
+var hideableModuleFields = func() map[string]int {
+	fields := map[string]int{}
+	t := reflect.TypeOf(ModuleOut{})
+	for i := 0; i < t.NumField(); i++ {
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && strings.Contains(opts, "omitempty") {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// loadVisibility lee VISIBILITY_PATH si está definido. Un campo desconocido
+// detiene el sync: un typo en la configuración no debe terminar publicando
+// justo el dato que se quería ocultar.
+func loadVisibility(path string, readFile func(string) ([]byte, error)) (visibility, error) {
+	if strings.TrimSpace(path) == "" {
+		return defaultVisibility(), nil
+	}
+	raw, err := readFile(path)
+	if err != nil {
+		return visibility{}, fmt.Errorf("leer VISIBILITY_PATH %s: %w", path, err)
+	}
+	var vis visibility
+	if err := json.Unmarshal(raw, &vis); err != nil {
+		return visibility{}, fmt.Errorf("interpretar VISIBILITY_PATH %s: %w", path, err)
+	}
+	if err := vis.validate(); err != nil {
+		return visibility{}, fmt.Errorf("VISIBILITY_PATH %s: %w", path, err)
+	}
+	return vis, nil
+}
+
+func (v visibility) validate() error {
+	for _, name := range v.Internos {
+		if _, ok := hideableModuleFields[name]; !ok {
+			allowed := make([]string, 0, len(hideableModuleFields))
+			for field := range hideableModuleFields {
+				allowed = append(allowed, field)
+			}
+			sort.Strings(allowed)
+			return fmt.Errorf("el campo %q no se puede ocultar; los válidos son %s", name, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// publicModules devuelve una copia de los módulos sin los campos internos.
+// No modifica la lista original, que es la que se escribe en la salida interna.
+func publicModules(modules []ModuleOut, vis visibility) []ModuleOut {
+	out := make([]ModuleOut, len(modules))
+	for i, m := range modules {
+		value := reflect.ValueOf(&m).Elem()
+		for _, name := range vis.Internos {
+			if idx, ok := hideableModuleFields[name]; ok {
+				field := value.Field(idx)
+				field.Set(reflect.Zero(field.Type()))
+			}
+		}
+		out[i] = m
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublicModulesOcultaCamposInternos(t *testing.T) {
+	modules := []ModuleOut{{
+		ID:           "7",
+		Nombre:       "Portal",
+		Propietario:  "ana",
+		Enlaces:      buildLinks("https://github.com/o/privado/issues/7"),
+		Responsables: "Ana Pérez",
+		Prioridad:    "Alta",
+	}}
+
+	public := publicModules(modules, defaultVisibility())
+	if public[0].Responsables != "" || public[0].Prioridad != "" {
+		t.Fatalf("la vista pública conserva campos internos: %+v", public[0])
+	}
+	if public[0].Propietario != "ana" || len(public[0].Enlaces) != 1 {
+		t.Fatalf("la visibilidad por defecto no debe ocultar propietario ni enlaces: %+v", public[0])
+	}
+	if modules[0].Responsables != "Ana Pérez" {
+		t.Fatal("publicModules no debe modificar la lista interna")
+	}
+
+	raw, err := json.Marshal(public[0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(raw), "responsables") || strings.Contains(string(raw), "prioridad") {
+		t.Fatalf("el JSON público incluye claves internas: %s", raw)
+	}
+
+	strict := publicModules(modules, visibility{Internos: []string{"enlaces", "propietario"}})
+	if strict[0].Propietario != "" || strict[0].Enlaces != nil {
+		t.Fatalf("la configuración debe poder ocultar propietario y enlaces: %+v", strict[0])
+	}
+}
+
+func TestLoadVisibilityRechazaCamposNoOcultables(t *testing.T) {
+	for _, field := range []string{"nombre", "estado", "prioridd"} {
+		raw := []byte(`{"internos": ["` + field + `"]}`)
+		_, err := loadVisibility("visibility.json", func(string) ([]byte, error) { return raw, nil })
+		if err == nil || !strings.Contains(err.Error(), "no se puede ocultar") {
+			t.Fatalf("loadVisibility(%q) error = %v; se esperaba rechazo", field, err)
+		}
+	}
+}
+
+func TestLoadConfigRechazaSalidaInternaPublica(t *testing.T) {
+	env := map[string]string{"OUTPUT": "docs/modules.json", "INTERNAL_OUTPUT": "docs/modules-internal.json"}
+	if _, err := loadConfig(func(k string) string { return env[k] }); err == nil {
+		t.Fatal("INTERNAL_OUTPUT dentro de docs/ debe rechazarse")
+	}
+	env["INTERNAL_OUTPUT"] = "modules-internal.json"
+	if _, err := loadConfig(func(k string) string { return env[k] }); err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+}
+
+func TestWriteInternalOutputConservaTodo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "internal", "modules-internal.json")
+	modules := []ModuleOut{{ID: "1", Responsables: "Ana Pérez", Prioridad: "Alta"}}
+	if _, err := stageAndPublish(t, func(out *outputStage) (bool, error) { return false, writeInternalOutput(out, path, modules) }); err != nil {
+		t.Fatalf("writeInternalOutput: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !strings.Contains(string(raw), `"responsables": "Ana Pérez"`) || !strings.Contains(string(raw), `"prioridad": "Alta"`) {
+		t.Fatalf("la salida interna perdió campos: %s", raw)
+	}
+}
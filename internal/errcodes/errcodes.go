@@ -0,0 +1,340 @@
+// Package errcodes define los códigos de error estables que el servicio
+// devuelve al cliente, con su mensaje y sugerencia en cada idioma, y cómo se
+// clasifica una falla de GitHub (REST o GraphQL) en uno de ellos.
+package errcodes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Code es un código de error del contrato con el cliente.
+type Code string
+
+// Códigos de error de GitHub. Reemplazan al genérico github_issue_error para
+// que el cliente sepa si reintentar, esperar o avisar a soporte.
+const (
+	GitHubAuth            Code = "github_auth_error"
+	GitHubForbidden       Code = "github_forbidden"
+	GitHubRateLimited     Code = "github_rate_limited"
+	GitHubNotFound        Code = "github_repo_not_found"
+	GitHubIssuesDisabled  Code = "github_issues_disabled"
+	GitHubRejectedContent Code = "github_rejected_content"
+	GitHubUnavailable     Code = "github_unavailable"
+	GitHubIssueError      Code = "github_issue_error"
+)
+
+// DefaultLocale es el idioma en que se escriben los mensajes en el código y
+// el de respaldo cuando el cliente no pide uno que tengamos.
+const DefaultLocale = "es"
+
+// hints es el catálogo de códigos que puede devolver el servicio, con la
+// sugerencia que acompaña al mensaje en cada idioma. Es la lista estable del
+// contrato: un código nuevo debe agregarse aquí en todos los idiomas (la
+// prueba del catálogo lo exige) para que nunca llegue al cliente un código
+// sin explicación.
+var hints = map[string]map[Code]string{
+	"es": {
+		"invalid_request":         "Revisa los campos marcados y vuelve a enviar.",
+		"invalid_template":        "Recarga la página para obtener las plantillas vigentes.",
+		"invalid_module":          "Recarga el roadmap; el módulo pudo haber cambiado.",
+		"payload_too_large":       "Acorta el texto o divide el reporte en varios issues.",
+		"unsupported_media_type":  "Envía el formulario como application/x-www-form-urlencoded o multipart/form-data.",
+		"consent_required":        "Marca la casilla del aviso de privacidad.",
+		"consent_outdated":        "Recarga la página y acepta el aviso de privacidad vigente.",
+		"cooldown_active":         "Espera el tiempo indicado en Retry-After antes de reintentar.",
+		"forbidden_origin":        "Este sitio no está autorizado para enviar reportes.",
+		"method_not_allowed":      "",
+		"not_found":               "",
+		"internal_error":          "Intenta de nuevo; si persiste, comparte el debugId con soporte.",
+		"overloaded":              "Espera el tiempo indicado en Retry-After antes de reintentar.",
+		"rate_limited":            "Espera el tiempo indicado en Retry-After antes de reintentar.",
+		"queue_unavailable":       "Intenta de nuevo en unos minutos.",
+		"sessions_disabled":       "Envía el formulario completo en una sola solicitud.",
+		"session_not_found":       "Vuelve a empezar el formulario.",
+		"session_expired":         "Vuelve a empezar el formulario.",
+		"session_capacity":        "Intenta de nuevo en unos minutos.",
+		"session_store_error":     "Intenta de nuevo en unos minutos.",
+		"invalid_attachment":      "Adjunta solo imágenes PNG, JPEG, GIF o WebP dentro del límite indicado.",
+		"possible_duplicates":     "Si tu reporte es uno de los issues de duplicates, coméntalo allí; si no, reenvía con force.",
+		"invalid_captcha":         "Completa de nuevo la verificación anti-bots y reenvía.",
+		"spam_rejected":           "Revisa que el reporte no tenga enlaces de más ni texto promocional.",
+		"quarantine_full":         "Intenta de nuevo más tarde.",
+		"quarantine_not_found":    "Revisa el ID en GET /admin/quarantine.",
+		"quarantine_resolved":     "Otra persona ya resolvió este envío.",
+		"unauthorized":            "Envía ADMIN_TOKEN como Authorization: Bearer.",
+		"short_link_disabled":     "",
+		"invalid_short_link":      "Revisa que el enlace esté completo.",
+		"short_link_unresolved":   "Intenta de nuevo en unos minutos.",
+		"github_project_error":    "El issue ya existe; no lo envíes de nuevo.",
+		"invalid_idempotency_key": "Genera la clave con crypto.randomUUID().",
+		"idempotency_key_reused":  "Genera una clave nueva para cada reporte distinto.",
+		"idempotency_in_progress": "Espera el tiempo indicado en Retry-After y repite con la misma clave.",
+		GitHubAuth:                "Es un problema de configuración del servicio; comparte el debugId con soporte.",
+		GitHubForbidden:           "Es un problema de permisos del servicio; comparte el debugId con soporte.",
+		GitHubRateLimited:         "GitHub limitó las solicitudes; intenta de nuevo en unos minutos.",
+		GitHubNotFound:            "El repositorio de destino no está disponible; comparte el debugId con soporte.",
+		GitHubIssuesDisabled:      "El repositorio de destino no acepta issues; comparte el debugId con soporte.",
+		GitHubRejectedContent:     "Revisa el título, la longitud del texto y los campos.",
+		GitHubUnavailable:         "GitHub no responde; intenta de nuevo en unos minutos.",
+		GitHubIssueError:          "Intenta de nuevo en unos minutos.",
+	},
+	"en": {
+		"invalid_request":         "Check the highlighted fields and submit again.",
+		"invalid_template":        "Reload the page to get the current templates.",
+		"invalid_module":          "Reload the roadmap; the module may have changed.",
+		"payload_too_large":       "Shorten the text or split the report into several issues.",
+		"unsupported_media_type":  "Send the form as application/x-www-form-urlencoded or multipart/form-data.",
+		"consent_required":        "Tick the privacy notice checkbox.",
+		"consent_outdated":        "Reload the page and accept the current privacy notice.",
+		"cooldown_active":         "Wait for the time given in Retry-After before retrying.",
+		"forbidden_origin":        "This site is not allowed to send reports.",
+		"method_not_allowed":      "",
+		"not_found":               "",
+		"internal_error":          "Try again; if it persists, share the debugId with support.",
+		"overloaded":              "Wait for the time given in Retry-After before retrying.",
+		"rate_limited":            "Wait for the time given in Retry-After before retrying.",
+		"queue_unavailable":       "Try again in a few minutes.",
+		"sessions_disabled":       "Send the whole form in a single request.",
+		"session_not_found":       "Start the form again.",
+		"session_expired":         "Start the form again.",
+		"session_capacity":        "Try again in a few minutes.",
+		"session_store_error":     "Try again in a few minutes.",
+		"invalid_attachment":      "Attach only PNG, JPEG, GIF or WebP images within the stated limit.",
+		"possible_duplicates":     "If your report is one of the issues in duplicates, comment there; otherwise resend with force.",
+		"invalid_captcha":         "Complete the anti-bot check again and resend.",
+		"spam_rejected":           "Make sure the report has no excess links or promotional text.",
+		"quarantine_full":         "Try again later.",
+		"quarantine_not_found":    "Check the ID in GET /admin/quarantine.",
+		"quarantine_resolved":     "Someone else already resolved this submission.",
+		"unauthorized":            "Send ADMIN_TOKEN as Authorization: Bearer.",
+		"short_link_disabled":     "",
+		"invalid_short_link":      "Make sure the link is complete.",
+		"short_link_unresolved":   "Try again in a few minutes.",
+		"github_project_error":    "The issue already exists; do not send it again.",
+		"invalid_idempotency_key": "Generate the key with crypto.randomUUID().",
+		"idempotency_key_reused":  "Generate a new key for each different report.",
+		"idempotency_in_progress": "Wait for the time given in Retry-After and repeat with the same key.",
+		GitHubAuth:                "This is a service configuration problem; share the debugId with support.",
+		GitHubForbidden:           "This is a service permissions problem; share the debugId with support.",
+		GitHubRateLimited:         "GitHub throttled the requests; try again in a few minutes.",
+		GitHubNotFound:            "The target repository is unavailable; share the debugId with support.",
+		GitHubIssuesDisabled:      "The target repository does not accept issues; share the debugId with support.",
+		GitHubRejectedContent:     "Check the title, the length of the text and the fields.",
+		GitHubUnavailable:         "GitHub is not responding; try again in a few minutes.",
+		GitHubIssueError:          "Try again in a few minutes.",
+	},
+}
+
+// messages reemplaza el mensaje de cada código fuera del español. En
+// español el mensaje lo escribe quien devuelve el error, con el detalle del
+// caso (el campo, los segundos de espera); en otro idioma el mensaje es fijo
+// por código y el detalle queda en el hint o en los encabezados.
+var messages = map[string]map[Code]string{
+	"en": {
+		"invalid_request":         "The request is not valid",
+		"invalid_template":        "The template does not exist",
+		"invalid_module":          "The roadmap module does not exist",
+		"payload_too_large":       "The request is too large",
+		"unsupported_media_type":  "Unsupported content type",
+		"consent_required":        "You must accept the privacy notice",
+		"consent_outdated":        "The accepted privacy notice is out of date",
+		"cooldown_active":         "GitHub recently rejected a similar submission",
+		"forbidden_origin":        "Origin not allowed",
+		"method_not_allowed":      "Method not allowed",
+		"not_found":               "Not found",
+		"internal_error":          "Internal error",
+		"overloaded":              "The service is overloaded",
+		"rate_limited":            "Too many submissions in a row",
+		"queue_unavailable":       "The request could not be queued",
+		"sessions_disabled":       "Step-by-step submissions are disabled",
+		"session_not_found":       "The session does not exist",
+		"session_expired":         "The session expired",
+		"session_capacity":        "Too many open sessions",
+		"session_store_error":     "The session could not be saved",
+		"invalid_attachment":      "The attachment is not valid",
+		"possible_duplicates":     "There are open issues that look like this report",
+		"invalid_captcha":         "The anti-bot check is missing or not valid",
+		"spam_rejected":           "The submission was rejected as spam",
+		"quarantine_full":         "The review queue is full",
+		"quarantine_not_found":    "The held submission does not exist",
+		"quarantine_resolved":     "The held submission was already resolved",
+		"unauthorized":            "Invalid or missing token",
+		"short_link_disabled":     "Short links are disabled",
+		"invalid_short_link":      "The link is not valid",
+		"short_link_unresolved":   "The link could not be resolved",
+		"github_project_error":    "The issue was created but could not be added to the Project",
+		"invalid_idempotency_key": "The Idempotency-Key is not valid",
+		"idempotency_key_reused":  "The Idempotency-Key was already used for different content",
+		"idempotency_in_progress": "A submission with this Idempotency-Key is still in progress",
+		GitHubAuth:                "The service could not authenticate with GitHub",
+		GitHubForbidden:           "The service is not allowed to create issues",
+		GitHubRateLimited:         "GitHub temporarily throttled the requests",
+		GitHubNotFound:            "The target repository was not found",
+		GitHubIssuesDisabled:      "Issues are disabled in the target repository",
+		GitHubRejectedContent:     "GitHub rejected the issue content",
+		GitHubUnavailable:         "GitHub is not available right now",
+		GitHubIssueError:          "The issue could not be created on GitHub",
+	},
+}
+
+// Known indica si el código está en el catálogo.
+func Known(code Code) bool {
+	_, ok := hints[DefaultLocale][code]
+	return ok
+}
+
+// Codes devuelve los códigos del catálogo en orden alfabético.
+func Codes() []Code {
+	codes := make([]Code, 0, len(hints[DefaultLocale]))
+	for code := range hints[DefaultLocale] {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// Locales devuelve los idiomas del catálogo en orden alfabético.
+func Locales() []string {
+	locales := make([]string, 0, len(hints))
+	for locale := range hints {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Hint devuelve la sugerencia del código en el idioma dado, con el español
+// como respaldo.
+func Hint(locale string, code Code) string {
+	if hint, ok := hints[locale][code]; ok {
+		return hint
+	}
+	return hints[DefaultLocale][code]
+}
+
+// Message devuelve el mensaje fijo del código en el idioma dado. En español
+// no hay: el mensaje lo escribe quien devuelve el error.
+func Message(locale string, code Code) (string, bool) {
+	message, ok := messages[locale][code]
+	return message, ok
+}
+
+// NegotiateLocale elige el idioma del catálogo con mayor peso en
+// Accept-Language. Ante un empate gana el que aparece antes; sin
+// coincidencias (o con "*") usa DefaultLocale.
+func NegotiateLocale(header string) string {
+	best, bestQ := DefaultLocale, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := hints[base]; ok && q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// Tipos de error que GitHub informa en el campo type de una respuesta
+// GraphQL.
+const (
+	GraphQLForbidden   = "FORBIDDEN"
+	GraphQLNotFound    = "NOT_FOUND"
+	GraphQLRateLimited = "RATE_LIMITED"
+)
+
+// GitHubError es una falla con respuesta de GitHub. En REST conserva el
+// estado HTTP; en GraphQL, donde la respuesta llega con 200, conserva el
+// tipo del primer error.
+type GitHubError struct {
+	Status int
+	// Type es el tipo del error GraphQL (FORBIDDEN, NOT_FOUND...); vacío en
+	// REST.
+	Type   string
+	Detail map[string]any
+	// RateLimited distingue un 403/429 por cuota de uno por permisos.
+	RateLimited bool
+}
+
+func (e *GitHubError) Error() string {
+	if e.Type != "" {
+		if message, ok := e.Detail["message"]; ok {
+			return fmt.Sprintf("error GraphQL %s: %v", e.Type, message)
+		}
+		return "error GraphQL " + e.Type
+	}
+	if e.Detail == nil {
+		return fmt.Sprintf("estado inesperado %d", e.Status)
+	}
+	return fmt.Sprintf("estado inesperado %d: %v", e.Status, e.Detail)
+}
+
+// ParseGraphQLErrors busca en el cuerpo de una respuesta GraphQL un error
+// con uno de los tipos que sabemos clasificar. Devuelve nil si no hay, para
+// que la librería siga reportando el resto como siempre.
+func ParseGraphQLErrors(body []byte) *GitHubError {
+	var resp struct {
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	for _, gqlErr := range resp.Errors {
+		switch gqlErr.Type {
+		case GraphQLForbidden, GraphQLNotFound, GraphQLRateLimited:
+			return &GitHubError{
+				Type:        gqlErr.Type,
+				Detail:      map[string]any{"message": gqlErr.Message},
+				RateLimited: gqlErr.Type == GraphQLRateLimited,
+			}
+		}
+	}
+	return nil
+}
+
+// Classify traduce la falla al crear el issue en un código estable. Sin
+// respuesta de GitHub (red, timeout) no sabemos más y usa GitHubIssueError.
+func Classify(err error) Code {
+	var ghErr *GitHubError
+	if !errors.As(err, &ghErr) {
+		return GitHubIssueError
+	}
+	switch {
+	case ghErr.RateLimited:
+		return GitHubRateLimited
+	case ghErr.Type == GraphQLForbidden:
+		return GitHubForbidden
+	case ghErr.Type == GraphQLNotFound:
+		return GitHubNotFound
+	case ghErr.Status == http.StatusUnauthorized:
+		return GitHubAuth
+	case ghErr.Status == http.StatusForbidden:
+		return GitHubForbidden
+	case ghErr.Status == http.StatusNotFound:
+		return GitHubNotFound
+	case ghErr.Status == http.StatusGone:
+		return GitHubIssuesDisabled
+	case ghErr.Status == http.StatusUnprocessableEntity:
+		return GitHubRejectedContent
+	case ghErr.Status >= http.StatusInternalServerError:
+		return GitHubUnavailable
+	default:
+		return GitHubIssueError
+	}
+}
@@ -0,0 +1,91 @@
+package errcodes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCatalogoCompletoEnCadaIdioma(t *testing.T) {
+	for _, locale := range Locales() {
+		if locale == DefaultLocale {
+			continue
+		}
+		for _, code := range Codes() {
+			if _, ok := hints[locale][code]; !ok {
+				t.Errorf("falta la sugerencia de %q en %s", code, locale)
+			}
+			if _, ok := Message(locale, code); !ok {
+				t.Errorf("falta el mensaje de %q en %s", code, locale)
+			}
+		}
+	}
+}
+
+func TestHintUsaElEspanolDeRespaldo(t *testing.T) {
+	if got := Hint("fr", GitHubIssueError); got != hints[DefaultLocale][GitHubIssueError] {
+		t.Fatalf("Hint(fr) = %q; se esperaba el hint en español", got)
+	}
+	if _, ok := Message(DefaultLocale, GitHubIssueError); ok {
+		t.Fatal("en español el mensaje lo escribe quien devuelve el error")
+	}
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	cases := map[string]string{
+		"":                          "es",
+		"en-US,en;q=0.9":            "en",
+		"fr-FR, en;q=0.5, es;q=0.8": "es",
+		"de, *;q=0.1":               "es",
+		"en;q=0, es-MX;q=0.2":       "es",
+		"pt-BR;q=rapido, EN;q=0.3":  "en",
+	}
+	for header, want := range cases {
+		if got := NegotiateLocale(header); got != want {
+			t.Errorf("NegotiateLocale(%q) = %q; se esperaba %q", header, got, want)
+		}
+	}
+}
+
+func TestParseGraphQLErrors(t *testing.T) {
+	got := ParseGraphQLErrors([]byte(`{"data":null,"errors":[{"message":"otro"},{"type":"NOT_FOUND","message":"Could not resolve to a Repository"}]}`))
+	if got == nil || got.Type != GraphQLNotFound || got.Detail["message"] != "Could not resolve to a Repository" {
+		t.Fatalf("ParseGraphQLErrors = %+v", got)
+	}
+	if got := ParseGraphQLErrors([]byte(`{"errors":[{"type":"RATE_LIMITED","message":"x"}]}`)); got == nil || !got.RateLimited {
+		t.Fatalf("RATE_LIMITED debe marcar la cuota: %+v", got)
+	}
+	for _, body := range []string{`{"data":{}}`, `{"errors":[{"type":"INTERNAL","message":"x"}]}`, `no es JSON`} {
+		if got := ParseGraphQLErrors([]byte(body)); got != nil {
+			t.Errorf("%s: no se esperaba error clasificado, llegó %+v", body, got)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		err  error
+		want Code
+	}{
+		{errors.New("connection reset"), GitHubIssueError},
+		{&GitHubError{Status: http.StatusUnauthorized}, GitHubAuth},
+		{&GitHubError{Status: http.StatusForbidden}, GitHubForbidden},
+		{&GitHubError{Status: http.StatusTooManyRequests, RateLimited: true}, GitHubRateLimited},
+		{&GitHubError{Status: http.StatusNotFound}, GitHubNotFound},
+		{&GitHubError{Status: http.StatusGone}, GitHubIssuesDisabled},
+		{&GitHubError{Status: http.StatusUnprocessableEntity}, GitHubRejectedContent},
+		{&GitHubError{Status: http.StatusBadGateway}, GitHubUnavailable},
+		{&GitHubError{Type: GraphQLForbidden}, GitHubForbidden},
+		{&GitHubError{Type: GraphQLNotFound}, GitHubNotFound},
+		{fmt.Errorf("envuelto: %w", &GitHubError{Type: GraphQLRateLimited, RateLimited: true}), GitHubRateLimited},
+	}
+	for _, tc := range cases {
+		if got := Classify(tc.err); got != tc.want {
+			t.Errorf("Classify(%v) = %s; se esperaba %s", tc.err, got, tc.want)
+		}
+		if !Known(tc.want) {
+			t.Errorf("el código %s no está en el catálogo", tc.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Package issueform lee y escribe los formularios de issue de GitHub
+// (.github/ISSUE_TEMPLATE/*.yml). La lectura usa un parser de YAML completo
+// porque el servicio carga en caliente lo que haya en el repositorio; la
+// escritura es propia para que el formato de salida sea estable.
+package issueform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tipos de elemento que GitHub acepta en el cuerpo de un formulario.
+const (
+	TypeMarkdown   = "markdown"
+	TypeInput      = "input"
+	TypeTextarea   = "textarea"
+	TypeDropdown   = "dropdown"
+	TypeCheckboxes = "checkboxes"
+)
+
+// Form representa un formulario de issue completo.
+type Form struct {
+	Name        string
+	Description string
+	Title       string
+	Labels      []string
+	Body        []Element
+}
+
+// Element es un campo del cuerpo del formulario. Options solo aplica a
+// dropdown y checkboxes.
+type Element struct {
+	Type        string
+	ID          string
+	Label       string
+	Description string
+	Placeholder string
+	Value       string
+	Options     []string
+	Required    bool
+}
+
+// yamlForm refleja el esquema de GitHub; las claves que no usamos
+// (assignees, projects, type) se ignoran.
+type yamlForm struct {
+	Name        string        `yaml:"name"`
+	Description string        `yaml:"description"`
+	Title       string        `yaml:"title"`
+	Labels      labelList     `yaml:"labels"`
+	Body        []yamlElement `yaml:"body"`
+}
+
+type yamlElement struct {
+	Type       string `yaml:"type"`
+	ID         string `yaml:"id"`
+	Attributes struct {
+		Label       string       `yaml:"label"`
+		Description string       `yaml:"description"`
+		Placeholder string       `yaml:"placeholder"`
+		Value       string       `yaml:"value"`
+		Options     []yamlOption `yaml:"options"`
+	} `yaml:"attributes"`
+	Validations struct {
+		Required bool `yaml:"required"`
+	} `yaml:"validations"`
+}
+
+// labelList acepta las dos formas que admite GitHub: una lista o un texto
+// separado por comas.
+type labelList []string
+
+func (l *labelList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = nil
+		for _, label := range strings.Split(node.Value, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				*l = append(*l, label)
+			}
+		}
+		return nil
+	}
+	var labels []string
+	if err := node.Decode(&labels); err != nil {
+		return err
+	}
+	*l = labels
+	return nil
+}
+
+// yamlOption acepta tanto las opciones de dropdown (texto) como las de
+// checkboxes (mapas con label).
+type yamlOption string
+
+func (o *yamlOption) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*o = yamlOption(node.Value)
+		return nil
+	}
+	var option struct {
+		Label string `yaml:"label"`
+	}
+	if err := node.Decode(&option); err != nil {
+		return err
+	}
+	*o = yamlOption(option.Label)
+	return nil
+}
+
+// Parse interpreta el contenido de un archivo de formulario. Los errores de
+// YAML indican la línea para que corregirlo no sea adivinanza.
+func Parse(data []byte) (Form, error) {
+	var raw yamlForm
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Form{}, fmt.Errorf("YAML inválido: %w", err)
+	}
+	if raw.Body == nil {
+		return Form{}, fmt.Errorf("el formulario debe definir body como lista")
+	}
+
+	form := Form{
+		Name:        raw.Name,
+		Description: raw.Description,
+		Title:       raw.Title,
+		Labels:      raw.Labels,
+	}
+	for i, element := range raw.Body {
+		if element.Type == "" {
+			return Form{}, fmt.Errorf("body[%d]: falta type", i)
+		}
+		parsed := Element{
+			Type:        element.Type,
+			ID:          element.ID,
+			Label:       element.Attributes.Label,
+			Description: element.Attributes.Description,
+			Placeholder: element.Attributes.Placeholder,
+			Value:       element.Attributes.Value,
+			Required:    element.Validations.Required,
+		}
+		for _, option := range element.Attributes.Options {
+			parsed.Options = append(parsed.Options, string(option))
+		}
+		form.Body = append(form.Body, parsed)
+	}
+	return form, nil
+}
+
+// Marshal escribe el formulario con un formato estable: mismas comillas y el
+// mismo orden de claves en cada corrida, para que regenerar sin cambios no
+// produzca diffs.
+func Marshal(form Form) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "name: %s\n", quote(form.Name))
+	if form.Description != "" {
+		fmt.Fprintf(&b, "description: %s\n", quote(form.Description))
+	}
+	if form.Title != "" {
+		fmt.Fprintf(&b, "title: %s\n", quote(form.Title))
+	}
+	if len(form.Labels) > 0 {
+		quoted := make([]string, len(form.Labels))
+		for i, label := range form.Labels {
+			quoted[i] = quote(label)
+		}
+		fmt.Fprintf(&b, "labels: [%s]\n", strings.Join(quoted, ", "))
+	}
+	b.WriteString("body:\n")
+	for i, element := range form.Body {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeElement(&b, element)
+	}
+	return []byte(b.String())
+}
+
+func writeElement(b *strings.Builder, element Element) {
+	fmt.Fprintf(b, "  - type: %s\n", element.Type)
+	if element.ID != "" {
+		fmt.Fprintf(b, "    id: %s\n", element.ID)
+	}
+	b.WriteString("    attributes:\n")
+	writeScalar(b, "      ", "label", element.Label)
+	writeScalar(b, "      ", "description", element.Description)
+	writeScalar(b, "      ", "placeholder", element.Placeholder)
+	writeScalar(b, "      ", "value", element.Value)
+	if len(element.Options) > 0 {
+		b.WriteString("      options:\n")
+		for _, option := range element.Options {
+			if element.Type == TypeCheckboxes {
+				fmt.Fprintf(b, "        - label: %s\n", quote(option))
+				continue
+			}
+			fmt.Fprintf(b, "        - %s\n", quote(option))
+		}
+	}
+	if element.Required {
+		b.WriteString("    validations:\n      required: true\n")
+	}
+}
+
+// writeScalar usa un bloque literal para textos de varias líneas; GitHub los
+// muestra tal cual y así el archivo sigue siendo legible. El bloque conserva
+// los espacios al final de cada línea (el "- " de una viñeta vacía) y los
+// saltos finales.
+func writeScalar(b *strings.Builder, indent, key, value string) {
+	if value == "" {
+		return
+	}
+	if !strings.Contains(value, "\n") || !blockSafe(value) {
+		fmt.Fprintf(b, "%s%s: %s\n", indent, key, quote(value))
+		return
+	}
+	// El indicador de bloque conserva exactamente los saltos finales: "|-"
+	// ninguno, "|" uno y "|+" todos los demás.
+	trimmed := strings.TrimRight(value, "\n")
+	header := "|-"
+	switch trailing := len(value) - len(trimmed); {
+	case trailing == 1:
+		header = "|"
+	case trailing > 1:
+		header = "|+"
+	}
+	lines := strings.Split(trimmed, "\n")
+	// Si la primera línea con texto empieza con espacios, la sangría no se
+	// puede deducir y hay que declararla.
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, " ") {
+			header = "|2" + header[1:]
+		}
+		break
+	}
+	fmt.Fprintf(b, "%s%s: %s\n", indent, key, header)
+	if strings.HasSuffix(header, "+") {
+		lines = append(lines, make([]string, len(value)-len(trimmed)-1)...)
+	}
+	for _, line := range lines {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(b, "%s  %s\n", indent, line)
+	}
+}
+
+// blockSafe indica si el texto cabe en un bloque literal: no admite
+// retornos de carro ni caracteres de control, que solo sobreviven entre
+// comillas.
+func blockSafe(value string) bool {
+	for _, r := range value {
+		if r == '\n' || r == '\t' {
+			continue
+		}
+		if !strconv.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func quote(value string) string { return strconv.Quote(value) }
@@ -0,0 +1,150 @@
+package issueform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleForm = `# comentario inicial
+name: "🐞 Bug"
+description: Reportar un defecto
+title: "fix: <resumen>"
+labels: ["Tipo: Bug", 'Status :En planeación']
+body:
+  - type: markdown
+    attributes:
+      value: |
+        Primera línea.
+
+        Segunda línea.
+  - type: input
+    id: summary
+    attributes:
+      label: Resumen
+      placeholder: "Error 500 al crear programa"
+    validations:
+      required: true
+
+  - type: dropdown
+    id: area
+    attributes:
+      label: Área
+      options:
+        - area:IAM
+        - area:Programas
+`
+
+func TestParse(t *testing.T) {
+	form, err := Parse([]byte(sampleForm))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := Form{
+		Name:        "🐞 Bug",
+		Description: "Reportar un defecto",
+		Title:       "fix: <resumen>",
+		Labels:      []string{"Tipo: Bug", "Status :En planeación"},
+		Body: []Element{
+			{Type: TypeMarkdown, Value: "Primera línea.\n\nSegunda línea.\n"},
+			{Type: TypeInput, ID: "summary", Label: "Resumen", Placeholder: "Error 500 al crear programa", Required: true},
+			{Type: TypeDropdown, ID: "area", Label: "Área", Options: []string{"area:IAM", "area:Programas"}},
+		},
+	}
+	if !reflect.DeepEqual(form, want) {
+		t.Fatalf("Parse() = %#v\nwant %#v", form, want)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	form := Form{
+		Name:   "Prueba",
+		Title:  `comillas "dobles" y 'simples'`,
+		Labels: []string{"a", "b, c"},
+		Body: []Element{
+			{Type: TypeTextarea, ID: "sin_salto", Label: "Uno", Value: "x\n-\n\ny"},
+			{Type: TypeTextarea, ID: "un_salto", Label: "Dos", Value: "x\n"},
+			{Type: TypeTextarea, ID: "varios", Label: "Tres", Value: "x\n\n\n", Required: true},
+			{Type: TypeCheckboxes, ID: "dor", Label: "DoR", Options: []string{"Listo"}},
+		},
+	}
+	got, err := Parse(Marshal(form))
+	if err != nil {
+		t.Fatalf("Parse(Marshal()): %v\n%s", err, Marshal(form))
+	}
+	if !reflect.DeepEqual(got, form) {
+		t.Fatalf("ida y vuelta = %#v\nwant %#v\n%s", got, form, Marshal(form))
+	}
+}
+
+func TestParseReportaLinea(t *testing.T) {
+	src := "name: x\nbody:\n  - type: markdown\n  attributes:\n    value: y\n"
+	_, err := Parse([]byte(src))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("se esperaba un error con la línea, got %v", err)
+	}
+}
+
+// TestParseYAMLCompleto cubre formas válidas para GitHub que un subconjunto
+// de YAML no entendería: bloques plegados, etiquetas separadas por comas,
+// mapas en línea, anclas y texto plano de varias líneas.
+func TestParseYAMLCompleto(t *testing.T) {
+	src := `name: Pedido
+labels: triage, area:IAM
+body:
+  - type: markdown
+    attributes:
+      value: >
+        Una línea
+        plegada.
+  - type: checkboxes
+    id: dor
+    attributes: {label: DoR, options: [{label: Listo, required: true}]}
+  - type: input
+    id: resumen
+    attributes: &campo
+      label: Resumen
+        en dos líneas
+    validations:
+      required: true
+  - type: input
+    id: copia
+    attributes: *campo
+`
+	form, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := Form{
+		Name:   "Pedido",
+		Labels: []string{"triage", "area:IAM"},
+		Body: []Element{
+			{Type: TypeMarkdown, Value: "Una línea plegada.\n"},
+			{Type: TypeCheckboxes, ID: "dor", Label: "DoR", Options: []string{"Listo"}},
+			{Type: TypeInput, ID: "resumen", Label: "Resumen en dos líneas", Required: true},
+			{Type: TypeInput, ID: "copia", Label: "Resumen en dos líneas"},
+		},
+	}
+	if !reflect.DeepEqual(form, want) {
+		t.Fatalf("Parse() = %#v\nwant %#v", form, want)
+	}
+}
+
+func TestMarshalConservaEspaciosYSaltos(t *testing.T) {
+	values := []string{
+		"**Contexto**\n- \n\n**Detalles**\n- \n",
+		"  sangría inicial\nsegunda",
+		"\n\nsaltos al inicio y al final\n\n",
+		"retorno\r\nde carro",
+	}
+	for _, value := range values {
+		form := Form{Name: "x", Body: []Element{{Type: TypeTextarea, ID: "t", Value: value}}}
+		got, err := Parse(Marshal(form))
+		if err != nil {
+			t.Fatalf("Parse(Marshal()): %v\n%s", err, Marshal(form))
+		}
+		if got.Body[0].Value != value {
+			t.Fatalf("valor %q volvió como %q\n%s", value, got.Body[0].Value, Marshal(form))
+		}
+	}
+}